@@ -37,6 +37,9 @@ headers checked as it queried for the previous (now incomplete) set of logs.`,
 func init() {
 	rootCmd.AddCommand(backfillEventsCmd)
 	backfillEventsCmd.Flags().Int64VarP(&endingBlockNumber, endingBlockNumberFlagName, "e", -1, "last block from which to back-fill events")
+	backfillEventsCmd.Flags().Int64Var(&logRangeSize, "log-range-size", 0, "max number of blocks to fetch logs for with each eth_getLogs query, defaults to 0 so logs are fetched per header")
+	backfillEventsCmd.Flags().BoolVar(&bloomFilter, "bloom-filter", false, "skip fetching logs for headers whose logsBloom has none of the watched logs")
+	backfillEventsCmd.Flags().StringVar(&metricsAddress, "metrics-address", "", "address to serve metrics such as the logsBloom prefilter skip rate on at /debug/vars, defaults to empty so metrics aren't served")
 	backfillEventsCmd.MarkFlagRequired(endingBlockNumberFlagName)
 }
 
@@ -65,6 +68,10 @@ func backFillEvents() error {
 		return fmt.Errorf("error creating checked headers repository %w for schema %s", repoErr, genConfig.Schema)
 	}
 	extractor := logs.NewLogExtractor(&db, blockChain, repo)
+	extractor.LogRangeSize = logRangeSize
+	extractor.BloomFilter = bloomFilter
+	extractor.BloomFilterStats.Publish("logsBloomFilter")
+	serveMetrics(metricsAddress)
 
 	for _, initializer := range ethEventInitializers {
 		transformer := initializer(&db)
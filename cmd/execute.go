@@ -24,8 +24,10 @@ import (
 	"github.com/makerdao/vulcanizedb/libraries/shared/factories/event"
 	"github.com/makerdao/vulcanizedb/libraries/shared/factories/storage"
 	"github.com/makerdao/vulcanizedb/libraries/shared/logs"
+	storage2 "github.com/makerdao/vulcanizedb/libraries/shared/storage"
 	"github.com/makerdao/vulcanizedb/libraries/shared/transformer"
 	"github.com/makerdao/vulcanizedb/libraries/shared/watcher"
+	"github.com/makerdao/vulcanizedb/pkg/datastore/postgres"
 	"github.com/makerdao/vulcanizedb/pkg/datastore/postgres/repositories"
 	"github.com/makerdao/vulcanizedb/pkg/fs"
 	"github.com/makerdao/vulcanizedb/utils"
@@ -34,7 +36,22 @@ import (
 )
 
 var (
+	bloomFilter              bool
+	finalizedOnly            bool
+	listenForInserts         bool
+	logRangeSize             int64
+	logWorkers               int
+	maxDiffAttempts          int
+	maxLogAttempts           int
+	metricsAddress           string
 	minTimeBetweenTransforms time.Duration
+	pruneArchiveDir          string
+	pruneBatchSize           int
+	pruneDepth               int64
+	pruneInterval            time.Duration
+	pruneStatuses            []string
+	reconcileReorgs          bool
+	storageDiffWorkers       int
 )
 
 // executeCmd represents the execute command
@@ -68,6 +85,21 @@ func init() {
 	executeCmd.Flags().Int64VarP(&newDiffBlockFromHeadOfChain, "new-diff-blocks-from-head", "d", -1, "number of blocks from head of chain to start reprocessing new diffs, defaults to -1 so all diffs are processsed")
 	executeCmd.Flags().Int64VarP(&unrecognizedDiffBlockFromHeadOfChain, "unrecognized-diff-blocks-from-head", "u", -1, "number of blocks from head of chain to start reprocessing unrecognized diffs, defaults to -1 so all diffs are processsed")
 	executeCmd.Flags().DurationVarP(&minTimeBetweenTransforms, "throttle-time", "t", time.Minute, "throttle transform queries to reduce the load on the database (defaults to 1 minute)")
+	executeCmd.Flags().BoolVar(&listenForInserts, "listen-for-inserts", false, "wake watchers on Postgres notifications of new headers, logs and diffs, falling back to polling at the throttle time/retry interval")
+	executeCmd.Flags().IntVarP(&storageDiffWorkers, "storage-workers", "w", 1, "max number of contracts whose storage diffs are transformed concurrently by each storage watcher")
+	executeCmd.Flags().BoolVar(&reconcileReorgs, "reconcile-reorgs", false, "replace stale headers with the node's canonical header and requeue or delete the affected storage diffs, instead of marking diffs outside the reorg window noncanonical")
+	executeCmd.Flags().IntVar(&maxDiffAttempts, "max-diff-attempts", 0, "number of failed transformer executions before a storage diff is marked failed, defaults to 0 so diffs are retried indefinitely")
+	executeCmd.Flags().IntVar(&maxLogAttempts, "max-log-attempts", logs.DefaultMaxLogAttempts, "number of failed transformer executions before a quarantined event log is no longer retried automatically, 0 retries indefinitely")
+	executeCmd.Flags().Int64Var(&logRangeSize, "log-range-size", 0, "max number of blocks to fetch logs for with each eth_getLogs query, defaults to 0 so logs are fetched per header")
+	executeCmd.Flags().IntVar(&logWorkers, "log-workers", 1, "number of headers the event watcher checks for logs at once")
+	executeCmd.Flags().BoolVar(&bloomFilter, "bloom-filter", false, "skip fetching logs for headers whose logsBloom has none of the watched logs")
+	executeCmd.Flags().StringVar(&metricsAddress, "metrics-address", "", "address to serve metrics such as the logsBloom prefilter skip rate on at /debug/vars, defaults to empty so metrics aren't served")
+	executeCmd.Flags().DurationVar(&pruneInterval, "prune-interval", 0, "interval between pruning old processed storage diffs in the background, defaults to 0 so diffs aren't pruned")
+	executeCmd.Flags().Int64Var(&pruneDepth, "prune-depth", 100000, "number of blocks below the latest header that storage diffs must be to be pruned")
+	executeCmd.Flags().StringSliceVar(&pruneStatuses, "prune-statuses", storage2.DefaultPruneStatuses, "statuses of storage diffs to prune")
+	executeCmd.Flags().StringVar(&pruneArchiveDir, "prune-archive-dir", "", "directory to write pruned storage diffs to, instead of the archive table")
+	executeCmd.Flags().IntVar(&pruneBatchSize, "prune-batch-size", 1000, "number of storage diffs to archive and delete per batch")
+	executeCmd.Flags().BoolVar(&finalizedOnly, "finalized-only", false, "only extract logs for, and transform logs and storage diffs from, headers that headerSync has marked finalized")
 }
 
 func executeTransformers() {
@@ -85,6 +117,18 @@ func executeTransformers() {
 	blockChain := getBlockChain()
 	db := utils.LoadPostgres(databaseConfig, blockChain.Node())
 	healthCheckFile := "/tmp/execute_health_check"
+	serveMetrics(metricsAddress)
+
+	var listener *postgres.Listener
+	if listenForInserts {
+		var listenErr error
+		listener, listenErr = postgres.NewListener(databaseConfig, postgres.HeadersInsertedChannel,
+			postgres.EventLogsInsertedChannel, postgres.StorageDiffInsertedChannel)
+		if listenErr != nil {
+			LogWithCommand.Fatalf("failed to listen for insert notifications: %s", listenErr.Error())
+		}
+		defer listener.Close()
+	}
 
 	// Execute over transformer sets returned by the exporter
 	// Use WaitGroup to wait on both goroutines
@@ -95,10 +139,21 @@ func executeTransformers() {
 			LogWithCommand.Fatalf("failed to create checked headers repository %s for schema %s", repoErr.Error(), genConfig.Schema)
 		}
 		extractor := logs.NewLogExtractor(&db, blockChain, repo)
+		extractor.LogRangeSize = logRangeSize
+		extractor.Workers = logWorkers
+		extractor.BloomFilter = bloomFilter
+		extractor.BloomFilterStats.Publish("logsBloomFilter")
+		extractor.FinalizedOnly = finalizedOnly
 		delegator := logs.NewLogDelegator(&db)
+		delegator.FinalizedOnly = finalizedOnly
+		delegator.MaxLogAttempts = maxLogAttempts
 		eventHealthCheckMessage := []byte("event watcher starting\n")
 		statusWriter := fs.NewStatusWriter(healthCheckFile, eventHealthCheckMessage)
 		ew := watcher.NewEventWatcher(&db, blockChain, extractor, delegator, maxUnexpectedErrors, retryInterval, statusWriter)
+		if listener != nil {
+			ew.ExtractorWakeups = listener.Wakeups(postgres.HeadersInsertedChannel)
+			ew.DelegatorWakeups = listener.Wakeups(postgres.EventLogsInsertedChannel)
+		}
 		addErr := ew.AddTransformers(ethEventInitializers)
 		if addErr != nil {
 			LogWithCommand.Fatalf("failed to add event transformer initializers to watcher: %s", addErr.Error())
@@ -108,9 +163,19 @@ func executeTransformers() {
 	}
 
 	if len(ethStorageInitializers) > 0 {
+		var reconciler storage2.IReorgReconciler
+		if reconcileReorgs {
+			reconciler = storage2.NewReorgReconciler(&db, blockChain)
+		}
+
 		newDiffStorageHealthCheckMessage := []byte("storage watcher for new diffs starting\n")
 		newDiffStatusWriter := fs.NewStatusWriter(healthCheckFile, newDiffStorageHealthCheckMessage)
-		newDiffStorageWatcher := watcher.NewStorageWatcher(&db, newDiffBlockFromHeadOfChain, newDiffStatusWriter, 0)
+		newDiffStorageWatcher := watcher.NewStorageWatcher(&db, newDiffBlockFromHeadOfChain, newDiffStatusWriter, newDiffThrottleTime())
+		newDiffStorageWatcher.DiffWorkers = storageDiffWorkers
+		newDiffStorageWatcher.MaxDiffAttempts = maxDiffAttempts
+		newDiffStorageWatcher.ReorgReconciler = reconciler
+		newDiffStorageWatcher.FinalizedOnly = finalizedOnly
+		wakeOnInsertedDiffs(&newDiffStorageWatcher, listener)
 		newDiffStorageWatcher.AddTransformers(ethStorageInitializers)
 		wg.Add(1)
 		go watchEthStorage(&newDiffStorageWatcher, &wg)
@@ -118,6 +183,10 @@ func executeTransformers() {
 		unrecognizedDiffStorageHealthCheckMessage := []byte("storage watcher for unrecognized diffs starting\n")
 		unrecognizedDiffStatusWriter := fs.NewStatusWriter(healthCheckFile, unrecognizedDiffStorageHealthCheckMessage)
 		unrecognizedDiffStorageWatcher := watcher.UnrecognizedStorageWatcher(&db, unrecognizedDiffBlockFromHeadOfChain, unrecognizedDiffStatusWriter, 0)
+		unrecognizedDiffStorageWatcher.DiffWorkers = storageDiffWorkers
+		unrecognizedDiffStorageWatcher.MaxDiffAttempts = maxDiffAttempts
+		unrecognizedDiffStorageWatcher.ReorgReconciler = reconciler
+		unrecognizedDiffStorageWatcher.FinalizedOnly = finalizedOnly
 		unrecognizedDiffStorageWatcher.AddTransformers(ethStorageInitializers)
 		wg.Add(1)
 		go watchEthStorage(&unrecognizedDiffStorageWatcher, &wg)
@@ -125,13 +194,39 @@ func executeTransformers() {
 		pendingDiffStorageHealthCheckMessage := []byte("storage watcher for pending diffs starting\n")
 		pendingDiffStatusWriter := fs.NewStatusWriter(healthCheckFile, pendingDiffStorageHealthCheckMessage)
 		pendingDiffStorageWatcher := watcher.PendingStorageWatcher(&db, newDiffBlockFromHeadOfChain, pendingDiffStatusWriter, minTimeBetweenTransforms)
+		pendingDiffStorageWatcher.DiffWorkers = storageDiffWorkers
+		pendingDiffStorageWatcher.MaxDiffAttempts = maxDiffAttempts
+		pendingDiffStorageWatcher.ReorgReconciler = reconciler
+		pendingDiffStorageWatcher.FinalizedOnly = finalizedOnly
+		wakeOnInsertedDiffs(&pendingDiffStorageWatcher, listener)
 		pendingDiffStorageWatcher.AddTransformers(ethStorageInitializers)
 		wg.Add(1)
 		go watchEthStorage(&pendingDiffStorageWatcher, &wg)
+
+		if pruneInterval > 0 {
+			pruner := newDiffPruner(&db, pruneArchiveDir, pruneDepth, pruneStatuses, pruneBatchSize)
+			go pruner.PruneContinuously(pruneInterval, nil)
+		}
 	}
 	wg.Wait()
 }
 
+// with no listener the new diff watcher polls continuously; with one it can wait for inserts between passes
+func newDiffThrottleTime() time.Duration {
+	if listenForInserts {
+		return minTimeBetweenTransforms
+	}
+	return 0
+}
+
+func wakeOnInsertedDiffs(w *watcher.StorageWatcher, listener *postgres.Listener) {
+	if listener == nil {
+		return
+	}
+	wakeups := listener.Wakeups(postgres.StorageDiffInsertedChannel)
+	w.Throttler = utils.NewWakeableThrottler(&utils.StandardTimer{}, wakeups).Throttle
+}
+
 type Exporter interface {
 	Export() ([]event.TransformerInitializer, []storage.TransformerInitializer, []transformer.ContractTransformerInitializer)
 }
@@ -2,11 +2,13 @@ package cmd
 
 import (
 	"fmt"
+	"path/filepath"
 
 	"github.com/ethereum/go-ethereum/eth/filters"
 	"github.com/makerdao/vulcanizedb/libraries/shared/storage"
 	"github.com/makerdao/vulcanizedb/libraries/shared/storage/fetcher"
 	"github.com/makerdao/vulcanizedb/libraries/shared/streamer"
+	"github.com/makerdao/vulcanizedb/pkg/datastore/postgres"
 	"github.com/makerdao/vulcanizedb/pkg/fs"
 	"github.com/makerdao/vulcanizedb/utils"
 	"github.com/sirupsen/logrus"
@@ -18,6 +20,8 @@ var (
 	storageDiffsPath       string
 	storageDiffsSourceFlag = "storageDiffs-source"
 	storageDiffsSource     string
+	checkpointFile         string
+	rejectFile             string
 )
 
 // extractDiffsCmd represents the extractDiffs command
@@ -25,7 +29,11 @@ var extractDiffsCmd = &cobra.Command{
 	Use:   "extractDiffs",
 	Short: "Extract storage diffs from a node and write them to postgres",
 	Long: fmt.Sprintf(`Run this command to reads storage diffs from either a CSV or JSON RPC subscription.
-Configure which with the %s flag. Received diffs are written to public.storage_diff.`, storageDiffsSourceFlag),
+Configure which with the %s flag. Received diffs are written to public.storage_diff.
+
+When reading a CSV, the position in the file is saved to public.storage_diff_checkpoints (or to --checkpoint-file)
+and extraction resumes from there on restart, including from rotated copies of the file such as diffs.csv.1 or
+diffs.csv.2.gz. Rows that can't be parsed are appended to --reject-file.`, storageDiffsSourceFlag),
 	Run: func(cmd *cobra.Command, args []string) {
 		SubCommand = cmd.CalledAs()
 		LogWithCommand = *logrus.WithField("SubCommand", SubCommand)
@@ -37,6 +45,8 @@ func init() {
 	rootCmd.AddCommand(extractDiffsCmd)
 	extractDiffsCmd.Flags().StringVarP(&storageDiffsSource, storageDiffsSourceFlag, "s", "csv", "where to get the state diffs: csv or geth")
 	extractDiffsCmd.Flags().StringVarP(&storageDiffsPath, storageDiffsPathFlag, "p", "", "location of storage diffs csv file")
+	extractDiffsCmd.Flags().StringVar(&checkpointFile, "checkpoint-file", "", "file to save the position in the csv to, instead of the database")
+	extractDiffsCmd.Flags().StringVar(&rejectFile, "reject-file", "", "file to append malformed csv rows to (defaults to the csv path with a .rejected suffix)")
 }
 
 func extractDiffs() {
@@ -68,7 +78,7 @@ func extractDiffs() {
 		msg := []byte("csv tail storage fetcher connection established\n")
 		statusWriter := fs.NewStatusWriter(healthCheckFile, msg)
 
-		storageFetcher = fetcher.NewCsvTailStorageFetcher(tailer, statusWriter)
+		storageFetcher = fetcher.NewCsvTailStorageFetcher(tailer, statusWriter, getCheckpointer(&db), getRejectWriter())
 	}
 
 	// extract diffs
@@ -78,3 +88,21 @@ func extractDiffs() {
 		LogWithCommand.Fatalf("extracting diffs failed: %s", err.Error())
 	}
 }
+
+func getCheckpointer(db *postgres.DB) fs.Checkpointer {
+	if checkpointFile != "" {
+		return fs.NewFileCheckpointer(checkpointFile)
+	}
+	source, absErr := filepath.Abs(storageDiffsPath)
+	if absErr != nil {
+		LogWithCommand.Fatalf("Error resolving storage diffs path: %s", absErr)
+	}
+	return storage.NewCheckpointRepository(db, source)
+}
+
+func getRejectWriter() fs.RejectWriter {
+	if rejectFile != "" {
+		return fs.NewRejectWriter(rejectFile)
+	}
+	return fs.NewRejectWriter(storageDiffsPath + ".rejected")
+}
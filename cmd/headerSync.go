@@ -31,7 +31,13 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var startingBlockFlagName = "starting-block-number"
+var (
+	startingBlockFlagName = "starting-block-number"
+	subscribeNewHeads     bool
+	backfillBatchSize     int
+	backfillWorkers       int
+	confirmationDepth     int64
+)
 
 // headerSyncCmd represents the headerSync command
 var headerSyncCmd = &cobra.Command{
@@ -39,7 +45,17 @@ var headerSyncCmd = &cobra.Command{
 	Short: "Syncs VulcanizeDB with local ethereum node's block headers",
 	Long: `Run this command to sync VulcanizeDB with an ethereum node. It populates
 Postgres with block headers. You may point to a config file, specify settings via 
-CLI flags, or it will attempt to run with default values.`,
+CLI flags, or it will attempt to run with default values.
+
+With --subscribe, new headers are inserted as the node streams them over a newHeads
+subscription, which requires a websocket or IPC connection. Headers are polled for
+while the subscription is down, and it's re-established on the next poll.
+
+Missing headers are backfilled in batches of --batch-size, with --backfill-workers
+batches fetched and inserted at once. Progress is logged as blocks per second with an ETA.
+
+Headers are marked finalized up to the node's "finalized" block, or, if the node doesn't
+support that block tag, up to --confirmation-depth blocks below the chain head.`,
 
 	Run: func(cmd *cobra.Command, args []string) {
 		SubCommand = cmd.CalledAs()
@@ -55,16 +71,27 @@ CLI flags, or it will attempt to run with default values.`,
 func init() {
 	rootCmd.AddCommand(headerSyncCmd)
 	headerSyncCmd.Flags().Int64VarP(&startingBlockNumber, startingBlockFlagName, "s", 0, "Block number to start syncing from")
+	headerSyncCmd.Flags().BoolVar(&subscribeNewHeads, "subscribe", false, "insert headers as the node streams them over a newHeads subscription, falling back to polling while it's down")
+	headerSyncCmd.Flags().IntVar(&backfillBatchSize, "batch-size", history.DefaultBackfillConfig.BatchSize, "number of missing headers fetched in each batch call to the node and inserted with each query")
+	headerSyncCmd.Flags().IntVar(&backfillWorkers, "backfill-workers", history.DefaultBackfillConfig.Workers, "number of batches of missing headers fetched and inserted at once")
+	headerSyncCmd.Flags().Int64Var(&confirmationDepth, "confirmation-depth", 0, "number of blocks below the chain head to treat as finalized if the node doesn't support the finalized block tag, defaults to 0 so headers are only marked finalized by the node")
 }
 
 func backFillAllHeaders(blockchain core.BlockChain, headerRepository datastore.HeaderRepository, missingBlocksPopulated chan int, startingBlockNumber int64) {
-	populated, err := history.PopulateMissingHeaders(blockchain, headerRepository, startingBlockNumber, validationWindowSize)
+	config := history.DefaultBackfillConfig
+	config.BatchSize = backfillBatchSize
+	config.Workers = backfillWorkers
+	populated, err := history.PopulateMissingHeaders(blockchain, headerRepository, startingBlockNumber, validationWindowSize, config)
 	if err != nil {
 		LogWithCommand.Errorf("backfillAllHeaders: Error populating headers: %s", err.Error())
 	}
 	missingBlocksPopulated <- populated
 }
 
+func syncNewHeads(subscriber history.HeaderSubscriber, subscriptionDropped chan error, startingBlockNumber int64) {
+	subscriptionDropped <- subscriber.SyncNewHeads(startingBlockNumber)
+}
+
 func headerSync() error {
 	ticker := time.NewTicker(pollingInterval)
 	defer ticker.Stop()
@@ -77,7 +104,10 @@ func headerSync() error {
 
 	headerRepository := repositories.NewHeaderRepository(&db)
 	validator := history.NewHeaderValidator(blockChain, headerRepository, validationWindowSize)
+	subscriber := history.NewHeaderSubscriber(blockChain, headerRepository, validationWindowSize)
+	finalityTracker := history.NewFinalityTracker(blockChain, headerRepository, confirmationDepth)
 	missingBlocksPopulated := make(chan int)
+	subscriptionDropped := make(chan error)
 
 	statusWriter := fs.NewStatusWriter("/tmp/header_sync_health_check", []byte("headerSync starting\n"))
 	writeErr := statusWriter.Write()
@@ -86,15 +116,33 @@ func headerSync() error {
 	}
 
 	go backFillAllHeaders(blockChain, headerRepository, missingBlocksPopulated, startingBlockNumber)
+	subscribed := subscribeNewHeads
+	if subscribed {
+		go syncNewHeads(subscriber, subscriptionDropped, startingBlockNumber)
+	}
 
 	for {
 		select {
 		case <-ticker.C:
+			_, finalityErr := finalityTracker.UpdateFinalizedHeaders()
+			if finalityErr != nil {
+				LogWithCommand.Errorf("headerSync: UpdateFinalizedHeaders failed: %s", finalityErr.Error())
+			}
+			if subscribed {
+				continue
+			}
 			window, err := validator.ValidateHeaders()
 			if err != nil {
 				LogWithCommand.Errorf("headerSync: ValidateHeaders failed: %s", err.Error())
 			}
 			LogWithCommand.Debug(window.GetString())
+			if subscribeNewHeads {
+				subscribed = true
+				go syncNewHeads(subscriber, subscriptionDropped, startingBlockNumber)
+			}
+		case err := <-subscriptionDropped:
+			LogWithCommand.Warnf("headerSync: newHeads subscription ended, polling for headers: %s", err.Error())
+			subscribed = false
 		case n := <-missingBlocksPopulated:
 			if n == 0 {
 				time.Sleep(3 * time.Second)
@@ -105,6 +153,12 @@ func headerSync() error {
 }
 
 func validateHeaderSyncArgs(blockChain *eth.BlockChain) error {
+	if backfillBatchSize < 1 || backfillWorkers < 1 {
+		return fmt.Errorf("--batch-size (%d) and --backfill-workers (%d) must be at least 1", backfillBatchSize, backfillWorkers)
+	}
+	if confirmationDepth < 0 {
+		return fmt.Errorf("--confirmation-depth (%d) can't be negative", confirmationDepth)
+	}
 	chainHead, err := blockChain.ChainHead()
 	if err != nil {
 		return fmt.Errorf("error getting last block from chain: %w", err)
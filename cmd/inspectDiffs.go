@@ -0,0 +1,110 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/makerdao/vulcanizedb/libraries/shared/storage"
+	"github.com/makerdao/vulcanizedb/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	inspectDiffsRequeue       bool
+	inspectDiffsStatuses      []string
+	inspectDiffsAddress       string
+	inspectDiffsStorageKey    string
+	inspectDiffsErrorContains string
+)
+
+// inspectDiffsCmd represents the inspectDiffs command
+var inspectDiffsCmd = &cobra.Command{
+	Use:   "inspectDiffs",
+	Short: "List failed and unrecognized storage diffs, optionally requeueing them.",
+	Long: `Storage diffs are marked 'failed' once their transformer has errored on them
+the number of times given by execute's --max-diff-attempts flag, and 'unrecognized'
+when no transformer recognizes their storage key. This command lists those diffs
+grouped by address, storage key, status, and last error.
+
+The filter flags narrow the diffs listed, or with --requeue the diffs reset to
+'new' so that they are retried by the storage watcher:
+./vulcanizedb inspectDiffs --config=./environments/config_name.toml --requeue --status=failed --address=0x...`,
+	Run: func(cmd *cobra.Command, args []string) {
+		SubCommand = cmd.CalledAs()
+		LogWithCommand = *logrus.WithField("SubCommand", SubCommand)
+		err := inspectDiffs()
+		if err != nil {
+			LogWithCommand.Fatalf("failed to inspect diffs: %s", err.Error())
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(inspectDiffsCmd)
+	inspectDiffsCmd.Flags().BoolVar(&inspectDiffsRequeue, "requeue", false, "reset matching diffs to 'new' instead of listing them")
+	inspectDiffsCmd.Flags().StringSliceVarP(&inspectDiffsStatuses, "status", "s", []string{storage.Failed, storage.Unrecognized}, "statuses of diffs to list or requeue (failed and/or unrecognized)")
+	inspectDiffsCmd.Flags().StringVarP(&inspectDiffsAddress, "address", "a", "", "only list or requeue diffs for this contract address")
+	inspectDiffsCmd.Flags().StringVarP(&inspectDiffsStorageKey, "storage-key", "k", "", "only list or requeue diffs for this storage key")
+	inspectDiffsCmd.Flags().StringVarP(&inspectDiffsErrorContains, "error-contains", "e", "", "only list or requeue diffs whose last error contains this text")
+}
+
+func inspectDiffs() error {
+	blockChain := getBlockChain()
+	db := utils.LoadPostgres(databaseConfig, blockChain.Node())
+	diffRepository := storage.NewDiffRepository(&db)
+
+	filter := storage.DiffFilter{
+		Statuses:      inspectDiffsStatuses,
+		ErrorContains: inspectDiffsErrorContains,
+	}
+	if inspectDiffsAddress != "" {
+		if !common.IsHexAddress(inspectDiffsAddress) {
+			return fmt.Errorf("invalid address: %s", inspectDiffsAddress)
+		}
+		filter.Address = common.HexToAddress(inspectDiffsAddress)
+	}
+	if inspectDiffsStorageKey != "" {
+		filter.StorageKey = common.HexToHash(inspectDiffsStorageKey)
+	}
+
+	if inspectDiffsRequeue {
+		requeued, requeueErr := diffRepository.RequeueDiffs(filter)
+		if requeueErr != nil {
+			return requeueErr
+		}
+		LogWithCommand.Infof("requeued %d storage diffs", requeued)
+		return nil
+	}
+
+	summaries, summariesErr := diffRepository.GetDiffSummaries(filter)
+	if summariesErr != nil {
+		return summariesErr
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "ADDRESS\tSTORAGE KEY\tSTATUS\tCOUNT\tBLOCKS\tMAX ATTEMPTS\tLAST ERROR")
+	for _, summary := range summaries {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%d\t%d-%d\t%d\t%s\n", summary.Address.Hex(), summary.StorageKey.Hex(),
+			summary.Status, summary.Count, summary.MinBlockHeight, summary.MaxBlockHeight, summary.MaxAttemptsCount,
+			summary.LastError)
+	}
+	return writer.Flush()
+}
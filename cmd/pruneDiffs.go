@@ -0,0 +1,109 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"errors"
+
+	"github.com/makerdao/vulcanizedb/libraries/shared/storage"
+	"github.com/makerdao/vulcanizedb/pkg/datastore/postgres"
+	"github.com/makerdao/vulcanizedb/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pruneDiffsArchiveDir string
+	pruneDiffsBatchSize  int
+	pruneDiffsDepth      int64
+	pruneDiffsFromBlock  int64
+	pruneDiffsRestore    bool
+	pruneDiffsStatuses   []string
+	pruneDiffsToBlock    int64
+)
+
+// pruneDiffsCmd represents the pruneDiffs command
+var pruneDiffsCmd = &cobra.Command{
+	Use:   "pruneDiffs",
+	Short: "Archive and delete old processed storage diffs.",
+	Long: `Storage diffs that are unwatched or noncanonical are never revisited by the
+storage watcher but remain in public.storage_diff. This command archives diffs
+with the given statuses that are at least --depth blocks below the latest header,
+then deletes them in batches.
+
+Diffs are moved to public.storage_diff_archive, or written to gzipped
+newline-delimited JSON files if --archive-dir is set:
+./vulcanizedb pruneDiffs --config=./environments/config_name.toml --depth=100000 --archive-dir=/data/diffs
+
+Transformed diffs can be pruned with --status=transformed, but those still
+referenced by a transformer table (through a foreign key on its diff_id) are
+always kept, since deleting them would cascade to the transformed data. Most
+transformed diffs are referenced this way.
+
+Pass --restore with a block range to return archived diffs to public.storage_diff
+with their original IDs and statuses:
+./vulcanizedb pruneDiffs --config=./environments/config_name.toml --restore --from-block=100 --to-block=200`,
+	Run: func(cmd *cobra.Command, args []string) {
+		SubCommand = cmd.CalledAs()
+		LogWithCommand = *logrus.WithField("SubCommand", SubCommand)
+		err := pruneDiffs()
+		if err != nil {
+			LogWithCommand.Fatalf("failed to prune diffs: %s", err.Error())
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pruneDiffsCmd)
+	pruneDiffsCmd.Flags().Int64VarP(&pruneDiffsDepth, "depth", "d", 100000, "number of blocks below the latest header that diffs must be to be pruned")
+	pruneDiffsCmd.Flags().StringSliceVarP(&pruneDiffsStatuses, "status", "s", storage.DefaultPruneStatuses, "statuses of diffs to prune")
+	pruneDiffsCmd.Flags().StringVarP(&pruneDiffsArchiveDir, "archive-dir", "a", "", "directory to write archived diffs to, instead of the archive table")
+	pruneDiffsCmd.Flags().IntVarP(&pruneDiffsBatchSize, "batch-size", "n", 1000, "number of diffs to archive and delete per batch")
+	pruneDiffsCmd.Flags().BoolVar(&pruneDiffsRestore, "restore", false, "restore archived diffs in the block range instead of pruning")
+	pruneDiffsCmd.Flags().Int64Var(&pruneDiffsFromBlock, "from-block", -1, "first block of the range to restore")
+	pruneDiffsCmd.Flags().Int64Var(&pruneDiffsToBlock, "to-block", -1, "last block of the range to restore")
+}
+
+func pruneDiffs() error {
+	blockChain := getBlockChain()
+	db := utils.LoadPostgres(databaseConfig, blockChain.Node())
+	pruner := newDiffPruner(&db, pruneDiffsArchiveDir, pruneDiffsDepth, pruneDiffsStatuses, pruneDiffsBatchSize)
+
+	if pruneDiffsRestore {
+		if pruneDiffsFromBlock < 0 || pruneDiffsToBlock < pruneDiffsFromBlock {
+			return errors.New("restoring diffs requires a valid --from-block and --to-block")
+		}
+		restored, restoreErr := pruner.Restore(pruneDiffsFromBlock, pruneDiffsToBlock)
+		if restoreErr != nil {
+			return restoreErr
+		}
+		LogWithCommand.Infof("restored %d storage diffs from blocks %d to %d", restored, pruneDiffsFromBlock, pruneDiffsToBlock)
+		return nil
+	}
+
+	pruned, pruneErr := pruner.Prune()
+	LogWithCommand.Infof("pruned %d storage diffs", pruned)
+	return pruneErr
+}
+
+func newDiffPruner(db *postgres.DB, archiveDir string, depth int64, statuses []string, batchSize int) storage.DiffPruner {
+	var archive storage.DiffArchive
+	if archiveDir != "" {
+		archive = storage.NewFileArchive(archiveDir)
+	}
+	return storage.NewDiffPruner(db, archive, depth, statuses, batchSize)
+}
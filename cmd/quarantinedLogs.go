@@ -0,0 +1,103 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/makerdao/vulcanizedb/libraries/shared/logs"
+	"github.com/makerdao/vulcanizedb/pkg/datastore/postgres/repositories"
+	"github.com/makerdao/vulcanizedb/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	quarantinedLogsRetry       bool
+	quarantinedLogsTransformer string
+)
+
+// quarantinedLogsCmd represents the quarantinedLogs command
+var quarantinedLogsCmd = &cobra.Command{
+	Use:   "quarantinedLogs",
+	Short: "List event logs quarantined by failing transformers, optionally retrying them.",
+	Long: `When an event transformer fails on a log, the log is recorded in public.quarantined_logs
+with the transformer's name, its error, and the number of attempts, and the other
+transformers keep going. The event watcher retries quarantined logs whenever it runs
+out of new logs, until they've failed the number of times given by execute's
+--max-log-attempts flag. Logs still failing after that are only retried by this
+command, which lists them:
+./vulcanizedb quarantinedLogs --config=./environments/config_name.toml
+
+Pass --retry to execute the composed plugin's transformers on their quarantined logs,
+releasing the logs that now succeed, narrowed to one transformer with --transformer:
+./vulcanizedb quarantinedLogs --config=./environments/config_name.toml --retry --transformer=transformer1`,
+	Run: func(cmd *cobra.Command, args []string) {
+		SubCommand = cmd.CalledAs()
+		LogWithCommand = *logrus.WithField("SubCommand", SubCommand)
+		err := quarantinedLogs()
+		if err != nil {
+			LogWithCommand.Fatalf("failed to inspect quarantined logs: %s", err.Error())
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(quarantinedLogsCmd)
+	quarantinedLogsCmd.Flags().BoolVar(&quarantinedLogsRetry, "retry", false, "execute transformers on their quarantined logs instead of listing them")
+	quarantinedLogsCmd.Flags().StringVarP(&quarantinedLogsTransformer, "transformer", "n", "", "only list or retry logs quarantined by this transformer")
+}
+
+func quarantinedLogs() error {
+	blockChain := getBlockChain()
+	db := utils.LoadPostgres(databaseConfig, blockChain.Node())
+
+	if quarantinedLogsRetry {
+		genConfig, configErr := prepConfig()
+		if configErr != nil {
+			return fmt.Errorf("failed to prepare config: %w", configErr)
+		}
+		ethEventInitializers, _, _, exportErr := exportTransformers(genConfig)
+		if exportErr != nil {
+			return fmt.Errorf("exporting transformers failed: %w", exportErr)
+		}
+		delegator := logs.NewLogDelegator(&db)
+		for _, initializer := range ethEventInitializers {
+			delegator.AddTransformer(initializer(&db))
+		}
+		released, failed, retryErr := delegator.RetryQuarantinedLogs(quarantinedLogsTransformer)
+		if retryErr != nil {
+			return retryErr
+		}
+		LogWithCommand.Infof("released %d quarantined logs, %d still failing", released, failed)
+		return nil
+	}
+
+	quarantined, getErr := repositories.NewEventLogRepository(&db).GetQuarantinedLogs(quarantinedLogsTransformer)
+	if getErr != nil {
+		return getErr
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "TRANSFORMER\tLOG ID\tBLOCK\tTX HASH\tATTEMPTS\tLAST ATTEMPTED\tERROR")
+	for _, log := range quarantined {
+		fmt.Fprintf(writer, "%s\t%d\t%d\t%s\t%d\t%s\t%s\n", log.TransformerName, log.LogID, log.BlockNumber,
+			log.TxHash, log.Attempts, log.Updated.Format("2006-01-02 15:04:05"), log.Error)
+	}
+	return writer.Flush()
+}
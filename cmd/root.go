@@ -18,6 +18,7 @@ package cmd
 
 import (
 	"fmt"
+	"net/http"
 	"plugin"
 	"strings"
 	"time"
@@ -153,6 +154,7 @@ func init() {
 	rootCmd.PersistentFlags().String("database-user", "", "database user")
 	rootCmd.PersistentFlags().String("database-password", "", "database password")
 	rootCmd.PersistentFlags().String("client-ipcPath", "", "rpc path to client node or geth.ipc file")
+	rootCmd.PersistentFlags().String("client-headerDecoder", converters.AutoHeaderDecoderName, "header decoder for the client's chain (auto, poa, pow)")
 	rootCmd.PersistentFlags().String("exporter-name", "exporter", "name of exporter plugin")
 	rootCmd.PersistentFlags().String("log-level", logrus.InfoLevel.String(), "Log level (trace, debug, info, warn, error, fatal, panic")
 	rootCmd.PersistentFlags().String("sentry-dsn", "", "Sentry DSN")
@@ -164,6 +166,7 @@ func init() {
 	viper.BindPFlag("database.user", rootCmd.PersistentFlags().Lookup("database-user"))
 	viper.BindPFlag("database.password", rootCmd.PersistentFlags().Lookup("database-password"))
 	viper.BindPFlag("client.ipcPath", rootCmd.PersistentFlags().Lookup("client-ipcPath"))
+	viper.BindPFlag("client.headerDecoder", rootCmd.PersistentFlags().Lookup("client-headerDecoder"))
 	viper.BindPFlag("exporter.fileName", rootCmd.PersistentFlags().Lookup("exporter-name"))
 	viper.BindPFlag("log.level", rootCmd.PersistentFlags().Lookup("log-level"))
 	viper.BindPFlag("sentry.dsn", rootCmd.PersistentFlags().Lookup("sentry-dsn"))
@@ -190,7 +193,11 @@ func getBlockChain() *eth.BlockChain {
 	vdbEthClient := client.NewEthClient(ethClient)
 	vdbNode := node.MakeNode(rpcClient)
 	transactionConverter := converters.NewTransactionConverter(ethClient)
-	return eth.NewBlockChain(vdbEthClient, rpcClient, vdbNode, transactionConverter)
+	headerDecoder, err := converters.GetHeaderDecoder(viper.GetString("client.headerDecoder"))
+	if err != nil {
+		LogWithCommand.Fatal(err)
+	}
+	return eth.NewBlockChain(vdbEthClient, rpcClient, vdbNode, transactionConverter, headerDecoder)
 }
 
 func getClients() (client.RpcClient, *ethclient.Client) {
@@ -205,6 +212,19 @@ func getClients() (client.RpcClient, *ethclient.Client) {
 	return rpcClient, ethClient
 }
 
+// serveMetrics serves the published expvar metrics at /debug/vars on the given address, unless it's empty
+func serveMetrics(address string) {
+	if address == "" {
+		return
+	}
+	go func() {
+		err := http.ListenAndServe(address, nil)
+		if err != nil {
+			LogWithCommand.Errorf("error serving metrics on %s: %s", address, err.Error())
+		}
+	}()
+}
+
 func prepConfig() (config.Plugin, error) {
 	return config.PreparePluginConfig(SubCommand)
 }
@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE FUNCTION public.notify_inserted() RETURNS TRIGGER AS
+$$
+BEGIN
+    PERFORM pg_notify(TG_ARGV[0], '');
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+COMMENT ON FUNCTION public.notify_inserted() IS E'@omit';
+
+CREATE TRIGGER storage_diff_inserted
+    AFTER INSERT
+    ON public.storage_diff
+    FOR EACH STATEMENT
+EXECUTE PROCEDURE public.notify_inserted('storage_diff_inserted');
+
+CREATE TRIGGER event_logs_inserted
+    AFTER INSERT
+    ON public.event_logs
+    FOR EACH STATEMENT
+EXECUTE PROCEDURE public.notify_inserted('event_logs_inserted');
+
+CREATE TRIGGER headers_inserted
+    AFTER INSERT
+    ON public.headers
+    FOR EACH STATEMENT
+EXECUTE PROCEDURE public.notify_inserted('headers_inserted');
+
+-- +goose Down
+DROP TRIGGER headers_inserted ON public.headers;
+DROP TRIGGER event_logs_inserted ON public.event_logs;
+DROP TRIGGER storage_diff_inserted ON public.storage_diff;
+DROP FUNCTION public.notify_inserted();
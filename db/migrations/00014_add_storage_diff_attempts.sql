@@ -0,0 +1,46 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+ALTER TYPE public.diff_status ADD VALUE 'failed' AFTER 'unwatched';
+
+ALTER TABLE public.storage_diff
+    ADD COLUMN attempts       INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN last_error     TEXT,
+    ADD COLUMN last_attempted TIMESTAMP;
+
+CREATE INDEX CONCURRENTLY storage_diff_failed_status_index
+    ON public.storage_diff (status) WHERE status = 'failed';
+
+-- +goose Down
+UPDATE public.storage_diff SET status = 'new' WHERE status = 'failed';
+DROP INDEX storage_diff_new_status_index;
+DROP INDEX storage_diff_unrecognized_status_index;
+DROP INDEX storage_diff_pending_status_index;
+DROP INDEX storage_diff_failed_status_index;
+
+ALTER TABLE public.storage_diff
+    DROP COLUMN attempts,
+    DROP COLUMN last_error,
+    DROP COLUMN last_attempted;
+
+ALTER TABLE public.storage_diff ALTER COLUMN status DROP DEFAULT;
+ALTER TABLE public.storage_diff ALTER COLUMN status TYPE VARCHAR(255);
+
+DROP TYPE public.diff_status;
+CREATE TYPE public.diff_status AS ENUM (
+    'new',
+    'pending',
+    'transformed',
+    'unrecognized',
+    'noncanonical',
+    'unwatched'
+    );
+
+ALTER TABLE public.storage_diff ALTER COLUMN status TYPE public.diff_status USING (status::diff_status);
+ALTER TABLE public.storage_diff ALTER COLUMN status SET DEFAULT 'new';
+
+CREATE INDEX CONCURRENTLY storage_diff_new_status_index
+    ON public.storage_diff (status) WHERE status = 'new';
+CREATE INDEX CONCURRENTLY storage_diff_unrecognized_status_index
+    ON public.storage_diff (status) WHERE status = 'unrecognized';
+CREATE INDEX CONCURRENTLY storage_diff_pending_status_index
+    ON public.storage_diff (status) WHERE status = 'pending';
@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE public.storage_diff_reconciliations
+(
+    id                BIGSERIAL PRIMARY KEY,
+    block_height      BIGINT      NOT NULL,
+    canonical_hash    VARCHAR(66) NOT NULL,
+    stale_header_hash VARCHAR(66),
+    requeued_diffs    INTEGER     NOT NULL DEFAULT 0,
+    deleted_diffs     INTEGER     NOT NULL DEFAULT 0,
+    created           TIMESTAMP   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX storage_diff_reconciliations_block_height_index
+    ON public.storage_diff_reconciliations (block_height);
+
+-- +goose Down
+DROP TABLE public.storage_diff_reconciliations;
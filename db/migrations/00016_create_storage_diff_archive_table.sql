@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE public.storage_diff_archive
+(
+    id             BIGINT PRIMARY KEY,
+    address        BYTEA,
+    block_height   BIGINT,
+    block_hash     BYTEA,
+    storage_key    BYTEA,
+    storage_value  BYTEA,
+    eth_node_id    INTEGER     NOT NULL,
+    status         diff_status NOT NULL,
+    from_backfill  BOOLEAN     NOT NULL DEFAULT FALSE,
+    created        TIMESTAMP   NOT NULL,
+    updated        TIMESTAMP   NOT NULL,
+    attempts       INTEGER     NOT NULL DEFAULT 0,
+    last_error     TEXT,
+    last_attempted TIMESTAMP,
+    archived       TIMESTAMP   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX storage_diff_archive_block_height_index
+    ON public.storage_diff_archive (block_height);
+
+-- +goose Down
+DROP TABLE public.storage_diff_archive;
@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE public.storage_diff_checkpoints
+(
+    source      TEXT PRIMARY KEY,
+    fingerprint TEXT      NOT NULL,
+    byte_offset BIGINT    NOT NULL,
+    updated     TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE public.storage_diff_checkpoints;
@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE public.watched_log_backfills
+(
+    id               SERIAL PRIMARY KEY,
+    contract_address VARCHAR(42) NOT NULL,
+    topic_zero       VARCHAR(66) NOT NULL,
+    from_block       BIGINT      NOT NULL,
+    to_block         BIGINT      NOT NULL,
+    checked_through  BIGINT      NOT NULL,
+    created          TIMESTAMP   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX watched_log_backfills_pending_index
+    ON public.watched_log_backfills (topic_zero) WHERE checked_through < to_block;
+
+-- +goose Down
+DROP TABLE public.watched_log_backfills;
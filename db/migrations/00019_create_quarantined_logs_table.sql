@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE public.quarantined_logs
+(
+    id               SERIAL PRIMARY KEY,
+    log_id           BIGINT    NOT NULL REFERENCES public.event_logs (id) ON DELETE CASCADE,
+    transformer_name TEXT      NOT NULL,
+    error            TEXT      NOT NULL,
+    attempts         INTEGER   NOT NULL DEFAULT 1,
+    created          TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated          TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (log_id, transformer_name)
+);
+
+CREATE INDEX quarantined_logs_transformer_index ON public.quarantined_logs (transformer_name);
+
+-- +goose Down
+DROP TABLE public.quarantined_logs;
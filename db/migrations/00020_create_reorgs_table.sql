@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE public.reorgs
+(
+    id         SERIAL PRIMARY KEY,
+    depth      INTEGER       NOT NULL,
+    from_block BIGINT        NOT NULL,
+    to_block   BIGINT        NOT NULL,
+    old_hashes VARCHAR(66)[] NOT NULL,
+    new_hashes VARCHAR(66)[] NOT NULL,
+    created    TIMESTAMP     NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX reorgs_to_block_index ON public.reorgs (to_block);
+
+-- +goose Down
+DROP TABLE public.reorgs;
@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE public.headers
+    ADD COLUMN finalized BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX headers_unfinalized_index
+    ON public.headers (block_number) WHERE finalized = false;
+
+-- +goose Down
+DROP INDEX public.headers_unfinalized_index;
+
+ALTER TABLE public.headers
+    DROP COLUMN finalized;
@@ -40,7 +40,8 @@ CREATE TYPE public.diff_status AS ENUM (
     'transformed',
     'unrecognized',
     'noncanonical',
-    'unwatched'
+    'unwatched',
+    'failed'
 );
 
 
@@ -140,6 +141,27 @@ $$;
 COMMENT ON FUNCTION public.get_or_create_header(block_number bigint, hash character varying, raw jsonb, block_timestamp numeric, eth_node_id integer) IS '@omit';
 
 
+--
+-- Name: notify_inserted(); Type: FUNCTION; Schema: public; Owner: -
+--
+
+CREATE FUNCTION public.notify_inserted() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    PERFORM pg_notify(TG_ARGV[0], '');
+    RETURN NULL;
+END;
+$$;
+
+
+--
+-- Name: FUNCTION notify_inserted(); Type: COMMENT; Schema: public; Owner: -
+--
+
+COMMENT ON FUNCTION public.notify_inserted() IS '@omit';
+
+
 --
 -- Name: set_event_log_updated(); Type: FUNCTION; Schema: public; Owner: -
 --
@@ -391,7 +413,8 @@ CREATE TABLE public.headers (
     block_timestamp numeric,
     eth_node_id integer NOT NULL,
     created timestamp without time zone DEFAULT now() NOT NULL,
-    updated timestamp without time zone DEFAULT now() NOT NULL
+    updated timestamp without time zone DEFAULT now() NOT NULL,
+    finalized boolean DEFAULT false NOT NULL
 );
 
 
@@ -415,6 +438,41 @@ CREATE SEQUENCE public.headers_id_seq
 ALTER SEQUENCE public.headers_id_seq OWNED BY public.headers.id;
 
 
+--
+-- Name: quarantined_logs; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.quarantined_logs (
+    id integer NOT NULL,
+    log_id bigint NOT NULL,
+    transformer_name text NOT NULL,
+    error text NOT NULL,
+    attempts integer DEFAULT 1 NOT NULL,
+    created timestamp without time zone DEFAULT now() NOT NULL,
+    updated timestamp without time zone DEFAULT now() NOT NULL
+);
+
+
+--
+-- Name: quarantined_logs_id_seq; Type: SEQUENCE; Schema: public; Owner: -
+--
+
+CREATE SEQUENCE public.quarantined_logs_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: quarantined_logs_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: -
+--
+
+ALTER SEQUENCE public.quarantined_logs_id_seq OWNED BY public.quarantined_logs.id;
+
+
 --
 -- Name: receipts; Type: TABLE; Schema: public; Owner: -
 --
@@ -455,6 +513,41 @@ CREATE SEQUENCE public.receipts_id_seq
 ALTER SEQUENCE public.receipts_id_seq OWNED BY public.receipts.id;
 
 
+--
+-- Name: reorgs; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.reorgs (
+    id integer NOT NULL,
+    depth integer NOT NULL,
+    from_block bigint NOT NULL,
+    to_block bigint NOT NULL,
+    old_hashes character varying(66)[] NOT NULL,
+    new_hashes character varying(66)[] NOT NULL,
+    created timestamp without time zone DEFAULT now() NOT NULL
+);
+
+
+--
+-- Name: reorgs_id_seq; Type: SEQUENCE; Schema: public; Owner: -
+--
+
+CREATE SEQUENCE public.reorgs_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: reorgs_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: -
+--
+
+ALTER SEQUENCE public.reorgs_id_seq OWNED BY public.reorgs.id;
+
+
 --
 -- Name: storage_diff; Type: TABLE; Schema: public; Owner: -
 --
@@ -470,7 +563,10 @@ CREATE TABLE public.storage_diff (
     status public.diff_status DEFAULT 'new'::public.diff_status NOT NULL,
     from_backfill boolean DEFAULT false NOT NULL,
     created timestamp without time zone DEFAULT now() NOT NULL,
-    updated timestamp without time zone DEFAULT now() NOT NULL
+    updated timestamp without time zone DEFAULT now() NOT NULL,
+    attempts integer DEFAULT 0 NOT NULL,
+    last_error text,
+    last_attempted timestamp without time zone
 );
 
 
@@ -493,6 +589,75 @@ CREATE SEQUENCE public.storage_diff_id_seq
 ALTER SEQUENCE public.storage_diff_id_seq OWNED BY public.storage_diff.id;
 
 
+--
+-- Name: storage_diff_archive; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.storage_diff_archive (
+    id bigint NOT NULL,
+    address bytea,
+    block_height bigint,
+    block_hash bytea,
+    storage_key bytea,
+    storage_value bytea,
+    eth_node_id integer NOT NULL,
+    status public.diff_status NOT NULL,
+    from_backfill boolean DEFAULT false NOT NULL,
+    created timestamp without time zone NOT NULL,
+    updated timestamp without time zone NOT NULL,
+    attempts integer DEFAULT 0 NOT NULL,
+    last_error text,
+    last_attempted timestamp without time zone,
+    archived timestamp without time zone DEFAULT now() NOT NULL
+);
+
+
+--
+-- Name: storage_diff_checkpoints; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.storage_diff_checkpoints (
+    source text NOT NULL,
+    fingerprint text NOT NULL,
+    byte_offset bigint NOT NULL,
+    updated timestamp without time zone DEFAULT now() NOT NULL
+);
+
+
+--
+-- Name: storage_diff_reconciliations; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.storage_diff_reconciliations (
+    id bigint NOT NULL,
+    block_height bigint NOT NULL,
+    canonical_hash character varying(66) NOT NULL,
+    stale_header_hash character varying(66),
+    requeued_diffs integer DEFAULT 0 NOT NULL,
+    deleted_diffs integer DEFAULT 0 NOT NULL,
+    created timestamp without time zone DEFAULT now() NOT NULL
+);
+
+
+--
+-- Name: storage_diff_reconciliations_id_seq; Type: SEQUENCE; Schema: public; Owner: -
+--
+
+CREATE SEQUENCE public.storage_diff_reconciliations_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: storage_diff_reconciliations_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: -
+--
+
+ALTER SEQUENCE public.storage_diff_reconciliations_id_seq OWNED BY public.storage_diff_reconciliations.id;
+
+
 --
 -- Name: transactions; Type: TABLE; Schema: public; Owner: -
 --
@@ -535,6 +700,41 @@ CREATE SEQUENCE public.transactions_id_seq
 ALTER SEQUENCE public.transactions_id_seq OWNED BY public.transactions.id;
 
 
+--
+-- Name: watched_log_backfills; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.watched_log_backfills (
+    id integer NOT NULL,
+    contract_address character varying(42) NOT NULL,
+    topic_zero character varying(66) NOT NULL,
+    from_block bigint NOT NULL,
+    to_block bigint NOT NULL,
+    checked_through bigint NOT NULL,
+    created timestamp without time zone DEFAULT now() NOT NULL
+);
+
+
+--
+-- Name: watched_log_backfills_id_seq; Type: SEQUENCE; Schema: public; Owner: -
+--
+
+CREATE SEQUENCE public.watched_log_backfills_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: watched_log_backfills_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: -
+--
+
+ALTER SEQUENCE public.watched_log_backfills_id_seq OWNED BY public.watched_log_backfills.id;
+
+
 --
 -- Name: watched_logs; Type: TABLE; Schema: public; Owner: -
 --
@@ -608,6 +808,13 @@ ALTER TABLE ONLY public.goose_db_version ALTER COLUMN id SET DEFAULT nextval('pu
 ALTER TABLE ONLY public.headers ALTER COLUMN id SET DEFAULT nextval('public.headers_id_seq'::regclass);
 
 
+--
+-- Name: quarantined_logs id; Type: DEFAULT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.quarantined_logs ALTER COLUMN id SET DEFAULT nextval('public.quarantined_logs_id_seq'::regclass);
+
+
 --
 -- Name: receipts id; Type: DEFAULT; Schema: public; Owner: -
 --
@@ -615,6 +822,13 @@ ALTER TABLE ONLY public.headers ALTER COLUMN id SET DEFAULT nextval('public.head
 ALTER TABLE ONLY public.receipts ALTER COLUMN id SET DEFAULT nextval('public.receipts_id_seq'::regclass);
 
 
+--
+-- Name: reorgs id; Type: DEFAULT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.reorgs ALTER COLUMN id SET DEFAULT nextval('public.reorgs_id_seq'::regclass);
+
+
 --
 -- Name: storage_diff id; Type: DEFAULT; Schema: public; Owner: -
 --
@@ -622,6 +836,13 @@ ALTER TABLE ONLY public.receipts ALTER COLUMN id SET DEFAULT nextval('public.rec
 ALTER TABLE ONLY public.storage_diff ALTER COLUMN id SET DEFAULT nextval('public.storage_diff_id_seq'::regclass);
 
 
+--
+-- Name: storage_diff_reconciliations id; Type: DEFAULT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.storage_diff_reconciliations ALTER COLUMN id SET DEFAULT nextval('public.storage_diff_reconciliations_id_seq'::regclass);
+
+
 --
 -- Name: transactions id; Type: DEFAULT; Schema: public; Owner: -
 --
@@ -629,6 +850,13 @@ ALTER TABLE ONLY public.storage_diff ALTER COLUMN id SET DEFAULT nextval('public
 ALTER TABLE ONLY public.transactions ALTER COLUMN id SET DEFAULT nextval('public.transactions_id_seq'::regclass);
 
 
+--
+-- Name: watched_log_backfills id; Type: DEFAULT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.watched_log_backfills ALTER COLUMN id SET DEFAULT nextval('public.watched_log_backfills_id_seq'::regclass);
+
+
 --
 -- Name: watched_logs id; Type: DEFAULT; Schema: public; Owner: -
 --
@@ -724,6 +952,22 @@ ALTER TABLE ONLY public.headers
     ADD CONSTRAINT headers_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: quarantined_logs quarantined_logs_log_id_transformer_name_key; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.quarantined_logs
+    ADD CONSTRAINT quarantined_logs_log_id_transformer_name_key UNIQUE (log_id, transformer_name);
+
+
+--
+-- Name: quarantined_logs quarantined_logs_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.quarantined_logs
+    ADD CONSTRAINT quarantined_logs_pkey PRIMARY KEY (id);
+
+
 --
 -- Name: receipts receipts_header_id_transaction_id_key; Type: CONSTRAINT; Schema: public; Owner: -
 --
@@ -740,6 +984,14 @@ ALTER TABLE ONLY public.receipts
     ADD CONSTRAINT receipts_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: reorgs reorgs_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.reorgs
+    ADD CONSTRAINT reorgs_pkey PRIMARY KEY (id);
+
+
 --
 -- Name: storage_diff storage_diff_block_height_block_hash_address_storage_key_st_key; Type: CONSTRAINT; Schema: public; Owner: -
 --
@@ -756,6 +1008,30 @@ ALTER TABLE ONLY public.storage_diff
     ADD CONSTRAINT storage_diff_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: storage_diff_archive storage_diff_archive_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.storage_diff_archive
+    ADD CONSTRAINT storage_diff_archive_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: storage_diff_checkpoints storage_diff_checkpoints_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.storage_diff_checkpoints
+    ADD CONSTRAINT storage_diff_checkpoints_pkey PRIMARY KEY (source);
+
+
+--
+-- Name: storage_diff_reconciliations storage_diff_reconciliations_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.storage_diff_reconciliations
+    ADD CONSTRAINT storage_diff_reconciliations_pkey PRIMARY KEY (id);
+
+
 --
 -- Name: transactions transactions_hash_key; Type: CONSTRAINT; Schema: public; Owner: -
 --
@@ -772,6 +1048,14 @@ ALTER TABLE ONLY public.transactions
     ADD CONSTRAINT transactions_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: watched_log_backfills watched_log_backfills_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.watched_log_backfills
+    ADD CONSTRAINT watched_log_backfills_pkey PRIMARY KEY (id);
+
+
 --
 -- Name: watched_logs watched_logs_pkey; Type: CONSTRAINT; Schema: public; Owner: -
 --
@@ -822,6 +1106,20 @@ CREATE INDEX headers_block_timestamp_index ON public.headers USING btree (block_
 CREATE INDEX headers_eth_node ON public.headers USING btree (eth_node_id);
 
 
+--
+-- Name: headers_unfinalized_index; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX headers_unfinalized_index ON public.headers USING btree (block_number) WHERE (finalized = false);
+
+
+--
+-- Name: quarantined_logs_transformer_index; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX quarantined_logs_transformer_index ON public.quarantined_logs USING btree (transformer_name);
+
+
 --
 -- Name: receipts_contract_address; Type: INDEX; Schema: public; Owner: -
 --
@@ -836,6 +1134,20 @@ CREATE INDEX receipts_contract_address ON public.receipts USING btree (contract_
 CREATE INDEX receipts_transaction ON public.receipts USING btree (transaction_id);
 
 
+--
+-- Name: reorgs_to_block_index; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX reorgs_to_block_index ON public.reorgs USING btree (to_block);
+
+
+--
+-- Name: storage_diff_archive_block_height_index; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX storage_diff_archive_block_height_index ON public.storage_diff_archive USING btree (block_height);
+
+
 --
 -- Name: storage_diff_eth_node; Type: INDEX; Schema: public; Owner: -
 --
@@ -843,6 +1155,13 @@ CREATE INDEX receipts_transaction ON public.receipts USING btree (transaction_id
 CREATE INDEX storage_diff_eth_node ON public.storage_diff USING btree (eth_node_id);
 
 
+--
+-- Name: storage_diff_failed_status_index; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX storage_diff_failed_status_index ON public.storage_diff USING btree (status) WHERE (status = 'failed'::public.diff_status);
+
+
 --
 -- Name: storage_diff_new_status_index; Type: INDEX; Schema: public; Owner: -
 --
@@ -857,6 +1176,13 @@ CREATE INDEX storage_diff_new_status_index ON public.storage_diff USING btree (s
 CREATE INDEX storage_diff_pending_status_index ON public.storage_diff USING btree (status) WHERE (status = 'pending'::public.diff_status);
 
 
+--
+-- Name: storage_diff_reconciliations_block_height_index; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX storage_diff_reconciliations_block_height_index ON public.storage_diff_reconciliations USING btree (block_height);
+
+
 --
 -- Name: storage_diff_unrecognized_status_index; Type: INDEX; Schema: public; Owner: -
 --
@@ -871,6 +1197,13 @@ CREATE INDEX storage_diff_unrecognized_status_index ON public.storage_diff USING
 CREATE INDEX transactions_header ON public.transactions USING btree (header_id);
 
 
+--
+-- Name: watched_log_backfills_pending_index; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX watched_log_backfills_pending_index ON public.watched_log_backfills USING btree (topic_zero) WHERE (checked_through < to_block);
+
+
 --
 -- Name: event_logs event_log_updated; Type: TRIGGER; Schema: public; Owner: -
 --
@@ -878,6 +1211,13 @@ CREATE INDEX transactions_header ON public.transactions USING btree (header_id);
 CREATE TRIGGER event_log_updated BEFORE UPDATE ON public.event_logs FOR EACH ROW EXECUTE PROCEDURE public.set_event_log_updated();
 
 
+--
+-- Name: event_logs event_logs_inserted; Type: TRIGGER; Schema: public; Owner: -
+--
+
+CREATE TRIGGER event_logs_inserted AFTER INSERT ON public.event_logs FOR EACH STATEMENT EXECUTE PROCEDURE public.notify_inserted('event_logs_inserted');
+
+
 --
 -- Name: headers header_updated; Type: TRIGGER; Schema: public; Owner: -
 --
@@ -885,6 +1225,13 @@ CREATE TRIGGER event_log_updated BEFORE UPDATE ON public.event_logs FOR EACH ROW
 CREATE TRIGGER header_updated BEFORE UPDATE ON public.headers FOR EACH ROW EXECUTE PROCEDURE public.set_header_updated();
 
 
+--
+-- Name: headers headers_inserted; Type: TRIGGER; Schema: public; Owner: -
+--
+
+CREATE TRIGGER headers_inserted AFTER INSERT ON public.headers FOR EACH STATEMENT EXECUTE PROCEDURE public.notify_inserted('headers_inserted');
+
+
 --
 -- Name: receipts receipt_updated; Type: TRIGGER; Schema: public; Owner: -
 --
@@ -892,6 +1239,13 @@ CREATE TRIGGER header_updated BEFORE UPDATE ON public.headers FOR EACH ROW EXECU
 CREATE TRIGGER receipt_updated BEFORE UPDATE ON public.receipts FOR EACH ROW EXECUTE PROCEDURE public.set_receipt_updated();
 
 
+--
+-- Name: storage_diff storage_diff_inserted; Type: TRIGGER; Schema: public; Owner: -
+--
+
+CREATE TRIGGER storage_diff_inserted AFTER INSERT ON public.storage_diff FOR EACH STATEMENT EXECUTE PROCEDURE public.notify_inserted('storage_diff_inserted');
+
+
 --
 -- Name: storage_diff storage_updated; Type: TRIGGER; Schema: public; Owner: -
 --
@@ -946,6 +1300,14 @@ ALTER TABLE ONLY public.headers
     ADD CONSTRAINT headers_eth_node_id_fkey FOREIGN KEY (eth_node_id) REFERENCES public.eth_nodes(id) ON DELETE CASCADE;
 
 
+--
+-- Name: quarantined_logs quarantined_logs_log_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.quarantined_logs
+    ADD CONSTRAINT quarantined_logs_log_id_fkey FOREIGN KEY (log_id) REFERENCES public.event_logs(id) ON DELETE CASCADE;
+
+
 --
 -- Name: receipts receipts_contract_address_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
 --
@@ -45,7 +45,7 @@ func SetupBC() core.BlockChain {
 	blockChainClient := client.NewEthClient(ethClient)
 	madeNode := node.MakeNode(rpcClient)
 	transactionConverter := converters.NewTransactionConverter(ethClient)
-	blockChain := eth.NewBlockChain(blockChainClient, rpcClient, madeNode, transactionConverter)
+	blockChain := eth.NewBlockChain(blockChainClient, rpcClient, madeNode, transactionConverter, converters.AutoHeaderDecoder{})
 
 	return blockChain
 }
@@ -17,6 +17,7 @@
 package chunker
 
 import (
+	"bytes"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -30,16 +31,22 @@ type Chunker interface {
 }
 
 type LogChunker struct {
-	AddressToNames map[string][]string
-	NameToTopic0   map[string]common.Hash
+	AddressToNames      map[string][]string
+	NameToTopic0        map[string]common.Hash
+	NameToIndexedTopics map[string][3][]common.Hash
+	NameToAnonymous     map[string]bool
+	NameToDataSelector  map[string][]byte
 }
 
 // Returns a new log chunker with initialised maps.
 // Needs to have configs added with `AddConfigs` to consider logs for the respective transformer.
 func NewLogChunker() *LogChunker {
 	return &LogChunker{
-		AddressToNames: map[string][]string{},
-		NameToTopic0:   map[string]common.Hash{},
+		AddressToNames:      map[string][]string{},
+		NameToTopic0:        map[string]common.Hash{},
+		NameToIndexedTopics: map[string][3][]common.Hash{},
+		NameToAnonymous:     map[string]bool{},
+		NameToDataSelector:  map[string][]byte{},
 	}
 }
 
@@ -50,6 +57,13 @@ func (chunker *LogChunker) AddConfig(transformerConfig event.TransformerConfig)
 		chunker.AddressToNames[lowerCaseAddress] = append(chunker.AddressToNames[lowerCaseAddress], transformerConfig.TransformerName)
 		chunker.NameToTopic0[transformerConfig.TransformerName] = common.HexToHash(transformerConfig.Topic)
 	}
+	if transformerConfig.Anonymous {
+		chunker.NameToAnonymous[transformerConfig.TransformerName] = true
+		chunker.NameToDataSelector[transformerConfig.TransformerName] = common.FromHex(transformerConfig.DataSelector)
+	}
+	if len(transformerConfig.Topic1)+len(transformerConfig.Topic2)+len(transformerConfig.Topic3) > 0 {
+		chunker.NameToIndexedTopics[transformerConfig.TransformerName] = transformerConfig.IndexedTopics()
+	}
 }
 
 // Goes through a slice of logs, associating relevant logs (matching addresses and topic) with transformers
@@ -60,10 +74,42 @@ func (chunker *LogChunker) ChunkLogs(logs []core.EventLog) map[string][]core.Eve
 		relevantTransformers := chunker.AddressToNames[strings.ToLower(log.Log.Address.Hex())]
 
 		for _, t := range relevantTransformers {
-			if chunker.NameToTopic0[t] == log.Log.Topics[0] {
+			if chunker.matchesTopic0(t, log) && matchesIndexedTopics(chunker.NameToIndexedTopics[t], log.Log.Topics) {
 				chunks[t] = append(chunks[t], log)
 			}
 		}
 	}
 	return chunks
 }
+
+// An anonymous transformer has no topic0 to match, so it matches on its data selector (if any) instead. Other
+// transformers never match logs without topics, like those emitted with LOG0.
+func (chunker *LogChunker) matchesTopic0(name string, log core.EventLog) bool {
+	if chunker.NameToAnonymous[name] {
+		return bytes.HasPrefix(log.Log.Data, chunker.NameToDataSelector[name])
+	}
+	return len(log.Log.Topics) > 0 && chunker.NameToTopic0[name] == log.Log.Topics[0]
+}
+
+// Logs for several transformers are fetched together, so a log can match another transformer's topic1-3 filters
+// without matching its own
+func matchesIndexedTopics(indexedTopics [3][]common.Hash, topics []common.Hash) bool {
+	for i, values := range indexedTopics {
+		if len(values) == 0 {
+			continue
+		}
+		if len(topics) <= i+1 || !containsHash(values, topics[i+1]) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsHash(hashes []common.Hash, hash common.Hash) bool {
+	for _, h := range hashes {
+		if h == hash {
+			return true
+		}
+	}
+	return false
+}
@@ -106,6 +106,100 @@ var _ = Describe("Log chunker", func() {
 			Expect(chunks["TransformerB"]).To(BeEmpty())
 			Expect(chunks["TransformerC"]).To(ContainElement(log5))
 		})
+
+		It("only associates logs matching a transformer's topic1-3 filters", func() {
+			configD := event.TransformerConfig{
+				TransformerName:   "TransformerD",
+				ContractAddresses: []string{"0x00000000000000000000000000000000000000A1"},
+				Topic:             "0xA",
+				Topic1:            []string{"0x00000000000000000000000000000000000000D1"},
+			}
+			chunker.AddConfig(configD)
+			configE := event.TransformerConfig{
+				TransformerName:   "TransformerE",
+				ContractAddresses: []string{"0x00000000000000000000000000000000000000A1"},
+				Topic:             "0xA",
+				Topic2:            []string{"0xE2"},
+			}
+			chunker.AddConfig(configE)
+			matchingTopic1Log := core.EventLog{
+				Log: types.Log{
+					Address: common.HexToAddress("0xA1"),
+					Topics:  []common.Hash{common.HexToHash("0xA"), common.HexToHash("0xD1")},
+				},
+			}
+			otherTopic1Log := core.EventLog{
+				Log: types.Log{
+					Address: common.HexToAddress("0xA1"),
+					Topics:  []common.Hash{common.HexToHash("0xA"), common.HexToHash("0xD2")},
+				},
+			}
+
+			chunks := chunker.ChunkLogs([]core.EventLog{matchingTopic1Log, otherTopic1Log})
+
+			Expect(chunks["TransformerA"]).To(ConsistOf(matchingTopic1Log, otherTopic1Log))
+			Expect(chunks["TransformerD"]).To(ConsistOf(matchingTopic1Log))
+			Expect(chunks["TransformerE"]).To(BeEmpty())
+		})
+
+		It("does not associate logs without topics with transformers expecting a topic0", func() {
+			topiclessLog := core.EventLog{
+				Log: types.Log{
+					Address: common.HexToAddress("0xA1"),
+					Data:    []byte{1, 2, 3},
+				},
+			}
+
+			chunks := chunker.ChunkLogs([]core.EventLog{topiclessLog})
+
+			Expect(chunks).To(BeEmpty())
+		})
+
+		It("associates logs with anonymous transformers on address alone", func() {
+			configD := event.TransformerConfig{
+				TransformerName:   "TransformerD",
+				ContractAddresses: []string{"0x00000000000000000000000000000000000000A1"},
+				Anonymous:         true,
+			}
+			chunker.AddConfig(configD)
+			topiclessLog := core.EventLog{
+				Log: types.Log{
+					Address: common.HexToAddress("0xA1"),
+					Data:    []byte{1, 2, 3},
+				},
+			}
+
+			chunks := chunker.ChunkLogs([]core.EventLog{log1, log2, log3, topiclessLog})
+
+			Expect(chunks["TransformerA"]).To(ConsistOf(log1))
+			Expect(chunks["TransformerD"]).To(ConsistOf(log1, log2, topiclessLog))
+		})
+
+		It("only associates logs starting with an anonymous transformer's data selector", func() {
+			configD := event.TransformerConfig{
+				TransformerName:   "TransformerD",
+				ContractAddresses: []string{"0x00000000000000000000000000000000000000A1"},
+				Anonymous:         true,
+				DataSelector:      "0x0102",
+			}
+			chunker.AddConfig(configD)
+			matchingLog := core.EventLog{
+				Log: types.Log{
+					Address: common.HexToAddress("0xA1"),
+					Data:    []byte{1, 2, 3},
+				},
+			}
+			otherLog := core.EventLog{
+				Log: types.Log{
+					Address: common.HexToAddress("0xA1"),
+					Data:    []byte{1, 3, 3},
+				},
+			}
+
+			chunks := chunker.ChunkLogs([]core.EventLog{matchingLog, otherLog})
+
+			Expect(chunks["TransformerD"]).To(ConsistOf(matchingLog))
+		})
 	})
 })
 
@@ -0,0 +1,217 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/makerdao/vulcanizedb/libraries/shared/repository"
+	"github.com/makerdao/vulcanizedb/pkg/core"
+	"github.com/makerdao/vulcanizedb/pkg/datastore/postgres"
+	"github.com/makerdao/vulcanizedb/pkg/eth"
+)
+
+// ErrUnexpectedEventLog is returned when an ABIConverter gets a log that isn't from its event
+var ErrUnexpectedEventLog = fmt.Errorf("log topic0 doesn't match event signature")
+
+// ABIConverter is a Transformer that decodes logs for an event in the contract ABI into models for a table with a
+// column per event argument, as generated by GenerateEventMigration
+type ABIConverter struct {
+	EventName  string
+	SchemaName SchemaName
+	TableName  TableName
+}
+
+func NewABIConverter(eventName string, schemaName SchemaName, tableName TableName) ABIConverter {
+	return ABIConverter{
+		EventName:  eventName,
+		SchemaName: schemaName,
+		TableName:  tableName,
+	}
+}
+
+func (converter ABIConverter) ToModels(contractAbi string, logs []core.EventLog, db *postgres.DB) ([]InsertionModel, error) {
+	event, columns, eventErr := converter.event(contractAbi)
+	if eventErr != nil {
+		return nil, eventErr
+	}
+
+	var models []InsertionModel
+	for _, log := range logs {
+		values, decodeErr := DecodeEventLog(event, columns, log.Log)
+		if decodeErr != nil {
+			return nil, fmt.Errorf("error decoding %s log %d: %w", converter.EventName, log.ID, decodeErr)
+		}
+
+		addressID, addressErr := repository.GetOrCreateAddress(db, log.Log.Address.Hex())
+		if addressErr != nil {
+			return nil, fmt.Errorf("error getting or creating address for %s log %d: %w", converter.EventName, log.ID, addressErr)
+		}
+		values[HeaderFK] = log.HeaderID
+		values[LogFK] = log.ID
+		values[AddressFK] = addressID
+
+		orderedColumns := []ColumnName{HeaderFK, LogFK, AddressFK}
+		for _, column := range columns {
+			orderedColumns = append(orderedColumns, column.Name)
+		}
+		models = append(models, InsertionModel{
+			SchemaName:     converter.SchemaName,
+			TableName:      converter.TableName,
+			OrderedColumns: orderedColumns,
+			ColumnValues:   values,
+		})
+	}
+	return models, nil
+}
+
+func (converter ABIConverter) event(contractAbi string) (abi.Event, []EventColumn, error) {
+	parsedAbi, parseErr := eth.ParseAbi(contractAbi)
+	if parseErr != nil {
+		return abi.Event{}, nil, parseErr
+	}
+	event, ok := parsedAbi.Events[converter.EventName]
+	if !ok {
+		return abi.Event{}, nil, fmt.Errorf("%w: %s", ErrEventNotInAbi, converter.EventName)
+	}
+	columns, columnsErr := EventColumns(event)
+	return event, columns, columnsErr
+}
+
+// DecodeEventLog decodes a log's indexed and non-indexed event arguments into values for their columns
+func DecodeEventLog(event abi.Event, columns []EventColumn, log types.Log) (ColumnValues, error) {
+	values := ColumnValues{}
+	topics := log.Topics
+	if !event.Anonymous {
+		if len(topics) == 0 || topics[0] != event.ID {
+			return nil, ErrUnexpectedEventLog
+		}
+		topics = topics[1:]
+	}
+
+	var indexed, nonIndexed abi.Arguments
+	var indexedColumns, nonIndexedColumns []EventColumn
+	for _, column := range columns {
+		// Name arguments for their columns, so unnamed arguments can be told apart
+		argument := column.Argument
+		argument.Name = string(column.Name)
+		if argument.Indexed {
+			indexed = append(indexed, argument)
+			indexedColumns = append(indexedColumns, column)
+		} else {
+			nonIndexed = append(nonIndexed, argument)
+			nonIndexedColumns = append(nonIndexedColumns, column)
+		}
+	}
+
+	topicValues := map[string]interface{}{}
+	parseErr := abi.ParseTopicsIntoMap(topicValues, indexed, topics)
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	for _, column := range indexedColumns {
+		value, valueErr := toColumnValue(topicType(column.Argument.Type), topicValues[string(column.Name)])
+		if valueErr != nil {
+			return nil, valueErr
+		}
+		values[column.Name] = value
+	}
+
+	if len(nonIndexed) > 0 {
+		dataValues, unpackErr := nonIndexed.UnpackValues(log.Data)
+		if unpackErr != nil {
+			return nil, unpackErr
+		}
+		for i, column := range nonIndexedColumns {
+			value, valueErr := toColumnValue(column.Argument.Type, dataValues[i])
+			if valueErr != nil {
+				return nil, valueErr
+			}
+			values[column.Name] = value
+		}
+	}
+	return values, nil
+}
+
+// toColumnValue converts a decoded argument to a value PersistModels can insert into its column type
+func toColumnValue(argumentType abi.Type, value interface{}) (interface{}, error) {
+	switch argumentType.T {
+	case abi.SliceTy, abi.ArrayTy, abi.TupleTy:
+		encoded, marshalErr := json.Marshal(toJSONValue(argumentType, value))
+		if marshalErr != nil {
+			return nil, fmt.Errorf("error encoding event argument as json: %w", marshalErr)
+		}
+		return string(encoded), nil
+	case abi.BytesTy, abi.BoolTy, abi.StringTy:
+		return value, nil
+	}
+	return toScalarValue(value), nil
+}
+
+// Lists are encoded element by element, so uint8 lists aren't mistaken for bytes
+func toJSONValue(argumentType abi.Type, value interface{}) interface{} {
+	switch argumentType.T {
+	case abi.SliceTy, abi.ArrayTy:
+		list := reflect.ValueOf(value)
+		elements := make([]interface{}, list.Len())
+		for i := range elements {
+			elements[i] = toJSONValue(*argumentType.Elem, list.Index(i).Interface())
+		}
+		return elements
+	case abi.IntTy, abi.UintTy:
+		// Encode big integers as exact json numbers
+		return json.Number(toScalarValue(value).(string))
+	}
+	return value
+}
+
+func toScalarValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case common.Address:
+		return v.Hex()
+	case common.Hash:
+		return v.Hex()
+	case *big.Int:
+		return v.String()
+	case uint8, uint16, uint32, uint64, int8, int16, int32, int64:
+		return fmt.Sprint(v)
+	}
+
+	reflected := reflect.ValueOf(value)
+	if reflected.Kind() == reflect.Array && reflected.Type().Elem().Kind() == reflect.Uint8 {
+		bytes := make([]byte, reflected.Len())
+		reflect.Copy(reflect.ValueOf(bytes), reflected)
+		return hexutil.Encode(bytes)
+	}
+	return value
+}
+
+// Indexed arguments of dynamic types are decoded as the hash of their value
+func topicType(argumentType abi.Type) abi.Type {
+	switch argumentType.T {
+	case abi.StringTy, abi.BytesTy, abi.SliceTy, abi.ArrayTy, abi.TupleTy:
+		return abi.Type{T: abi.HashTy, Size: common.HashLength}
+	}
+	return argumentType
+}
@@ -0,0 +1,221 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package event_test
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/makerdao/vulcanizedb/libraries/shared/factories/event"
+	"github.com/makerdao/vulcanizedb/libraries/shared/test_data"
+	"github.com/makerdao/vulcanizedb/pkg/core"
+	"github.com/makerdao/vulcanizedb/pkg/datastore/postgres/repositories"
+	"github.com/makerdao/vulcanizedb/pkg/fakes"
+	"github.com/makerdao/vulcanizedb/test_config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const exampleAbi = `[{"anonymous": false, "name": "ExampleEvent", "type": "event", "inputs": [
+	{"indexed": true, "name": "src", "type": "address"},
+	{"indexed": true, "name": "label", "type": "bytes32"},
+	{"indexed": true, "name": "tag", "type": "string"},
+	{"indexed": false, "name": "wadAmount", "type": "uint256"},
+	{"indexed": false, "name": "_ok", "type": "bool"},
+	{"indexed": false, "name": "", "type": "int8"},
+	{"indexed": false, "name": "note", "type": "string"},
+	{"indexed": false, "name": "data", "type": "bytes"},
+	{"indexed": false, "name": "sizes", "type": "uint8[]"},
+	{"indexed": false, "name": "selector", "type": "bytes4"}
+]}]`
+
+var _ = Describe("ABI converter", func() {
+	var (
+		exampleEvent abi.Event
+		exampleLog   types.Log
+		src          = common.HexToAddress("0x2F0b23f53734252Bda2277357e97e1517d6B042A")
+		label        = common.HexToHash("0x4554482d41000000000000000000000000000000000000000000000000000000")
+		selector     = [4]byte{0x12, 0x34, 0x56, 0x78}
+	)
+
+	BeforeEach(func() {
+		parsedAbi, parseErr := abi.JSON(strings.NewReader(exampleAbi))
+		Expect(parseErr).NotTo(HaveOccurred())
+		exampleEvent = parsedAbi.Events["ExampleEvent"]
+		data, packErr := exampleEvent.Inputs.NonIndexed().Pack(big.NewInt(1000), true, int8(-3), "a note",
+			[]byte{1, 2, 3}, []uint8{4, 5}, selector)
+		Expect(packErr).NotTo(HaveOccurred())
+		exampleLog = types.Log{
+			Address: src,
+			Topics: []common.Hash{
+				exampleEvent.ID,
+				common.BytesToHash(src.Bytes()),
+				label,
+				crypto.Keccak256Hash([]byte("a tag")),
+			},
+			Data: data,
+		}
+	})
+
+	Describe("EventColumns", func() {
+		It("has a snake cased column per argument with its postgres type", func() {
+			columns, err := event.EventColumns(exampleEvent)
+
+			Expect(err).NotTo(HaveOccurred())
+			var names []event.ColumnName
+			var columnTypes []string
+			for _, column := range columns {
+				names = append(names, column.Name)
+				columnTypes = append(columnTypes, column.Type)
+			}
+			Expect(names).To(Equal([]event.ColumnName{
+				"src", "label", "tag", "wad_amount", "ok", "arg5", "note", "data", "sizes", "selector"}))
+			Expect(columnTypes).To(Equal([]string{"VARCHAR(42)", "VARCHAR(66)", "VARCHAR(66)", "NUMERIC", "BOOLEAN",
+				"NUMERIC", "TEXT", "BYTEA", "JSONB", "VARCHAR(10)"}))
+		})
+
+		It("appends an underscore to columns that would be postgres keywords", func() {
+			transferAbi := `[{"name": "Transfer", "type": "event", "inputs": [
+				{"indexed": true, "name": "from", "type": "address"},
+				{"indexed": true, "name": "to", "type": "address"},
+				{"indexed": false, "name": "value", "type": "uint256"}]}]`
+			parsedAbi, parseErr := abi.JSON(strings.NewReader(transferAbi))
+			Expect(parseErr).NotTo(HaveOccurred())
+
+			columns, err := event.EventColumns(parsedAbi.Events["Transfer"])
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(columns[0].Name).To(Equal(event.ColumnName("from_")))
+			Expect(columns[1].Name).To(Equal(event.ColumnName("to_")))
+			Expect(columns[2].Name).To(Equal(event.ColumnName("value")))
+		})
+
+		It("appends an underscore to columns that would clash with the id or foreign key columns", func() {
+			transferSingleAbi := `[{"name": "TransferSingle", "type": "event", "inputs": [
+				{"indexed": true, "name": "operator", "type": "address"},
+				{"indexed": false, "name": "id", "type": "uint256"},
+				{"indexed": false, "name": "logId", "type": "uint256"}]}]`
+			parsedAbi, parseErr := abi.JSON(strings.NewReader(transferSingleAbi))
+			Expect(parseErr).NotTo(HaveOccurred())
+
+			columns, err := event.EventColumns(parsedAbi.Events["TransferSingle"])
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(columns[0].Name).To(Equal(event.ColumnName("operator")))
+			Expect(columns[1].Name).To(Equal(event.ColumnName("id_")))
+			Expect(columns[2].Name).To(Equal(event.ColumnName("log_id_")))
+		})
+
+		It("returns an error if two arguments map to the same column", func() {
+			clashingAbi := `[{"name": "Clash", "type": "event", "inputs": [
+				{"name": "_value", "type": "uint256"},
+				{"name": "value", "type": "uint256"}]}]`
+			parsedAbi, parseErr := abi.JSON(strings.NewReader(clashingAbi))
+			Expect(parseErr).NotTo(HaveOccurred())
+
+			_, err := event.EventColumns(parsedAbi.Events["Clash"])
+
+			Expect(err).To(MatchError(event.ErrDuplicateColumn))
+		})
+	})
+
+	Describe("DecodeEventLog", func() {
+		It("decodes indexed and non-indexed arguments into column values", func() {
+			columns, columnsErr := event.EventColumns(exampleEvent)
+			Expect(columnsErr).NotTo(HaveOccurred())
+
+			values, err := event.DecodeEventLog(exampleEvent, columns, exampleLog)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(values).To(Equal(event.ColumnValues{
+				"src":        src.Hex(),
+				"label":      label.Hex(),
+				"tag":        crypto.Keccak256Hash([]byte("a tag")).Hex(),
+				"wad_amount": "1000",
+				"ok":         true,
+				"arg5":       "-3",
+				"note":       "a note",
+				"data":       []byte{1, 2, 3},
+				"sizes":      "[4,5]",
+				"selector":   "0x12345678",
+			}))
+		})
+
+		It("returns an error if the log isn't for the event", func() {
+			columns, columnsErr := event.EventColumns(exampleEvent)
+			Expect(columnsErr).NotTo(HaveOccurred())
+			exampleLog.Topics[0] = fakes.FakeHash
+
+			_, err := event.DecodeEventLog(exampleEvent, columns, exampleLog)
+
+			Expect(err).To(MatchError(event.ErrUnexpectedEventLog))
+		})
+	})
+
+	Describe("ToModels", func() {
+		var db = test_config.NewTestDB(test_config.NewTestNode())
+
+		BeforeEach(func() {
+			test_config.CleanTestDB(db)
+		})
+
+		It("returns an error if the event isn't in the ABI", func() {
+			converter := event.NewABIConverter("MissingEvent", "public", "missing_event")
+
+			_, err := converter.ToModels(exampleAbi, []core.EventLog{{Log: exampleLog}}, db)
+
+			Expect(err).To(MatchError(event.ErrEventNotInAbi))
+		})
+
+		It("persists models to the table from the generated migration", func() {
+			migration, migrationErr := event.GenerateEventMigration("public", "example_event", exampleEvent)
+			Expect(migrationErr).NotTo(HaveOccurred())
+			up := strings.Split(strings.Split(migration, "-- +goose Up")[1], "-- +goose Down")[0]
+			db.MustExec(up)
+			defer db.MustExec(`DROP TABLE public.example_event`)
+
+			headerID, headerErr := repositories.NewHeaderRepository(db).CreateOrUpdateHeader(fakes.FakeHeader)
+			Expect(headerErr).NotTo(HaveOccurred())
+			eventLog := test_data.CreateTestLog(headerID, db)
+			eventLog.Log = exampleLog
+			converter := event.NewABIConverter("ExampleEvent", "public", "example_event")
+
+			models, err := converter.ToModels(exampleAbi, []core.EventLog{eventLog}, db)
+			Expect(err).NotTo(HaveOccurred())
+			persistErr := event.PersistModels(models, db)
+			Expect(persistErr).NotTo(HaveOccurred())
+
+			var persisted struct {
+				Src       string
+				WadAmount string `db:"wad_amount"`
+				Sizes     string
+				Address   string
+			}
+			readErr := db.Get(&persisted, `SELECT src, wad_amount, sizes, addresses.address FROM public.example_event
+				JOIN public.addresses ON addresses.id = example_event.address_id`)
+			Expect(readErr).NotTo(HaveOccurred())
+			Expect(persisted.Src).To(Equal(src.Hex()))
+			Expect(persisted.WadAmount).To(Equal("1000"))
+			Expect(persisted.Sizes).To(Equal("[4, 5]"))
+			Expect(persisted.Address).To(Equal(src.Hex()))
+		})
+	})
+})
@@ -0,0 +1,143 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package event
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+var (
+	// ErrEventNotInAbi is returned when a generic event transformer's event isn't in its contract ABI
+	ErrEventNotInAbi = fmt.Errorf("event not found in contract abi")
+	// ErrDuplicateColumn is returned when two of an event's arguments map to the same column name
+	ErrDuplicateColumn = fmt.Errorf("event arguments map to the same column name")
+
+	wordBoundary   = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+	invalidColumn  = regexp.MustCompile(`[^a-z0-9_]`)
+	reservedColumn = map[ColumnName]bool{"id": true, HeaderFK: true, LogFK: true, AddressFK: true}
+	// Postgres keywords that can't be used as unquoted column names, like an ERC20 Transfer's "from" and "to"
+	reservedKeyword = map[ColumnName]bool{
+		"all": true, "analyse": true, "analyze": true, "and": true, "any": true, "array": true, "as": true,
+		"asc": true, "asymmetric": true, "both": true, "case": true, "cast": true, "check": true, "collate": true,
+		"column": true, "constraint": true, "create": true, "current_catalog": true, "current_date": true,
+		"current_role": true, "current_time": true, "current_timestamp": true, "current_user": true,
+		"default": true, "deferrable": true, "desc": true, "distinct": true, "do": true, "else": true, "end": true,
+		"except": true, "false": true, "fetch": true, "for": true, "foreign": true, "from": true, "grant": true,
+		"group": true, "having": true, "in": true, "initially": true, "intersect": true, "into": true,
+		"lateral": true, "leading": true, "limit": true, "localtime": true, "localtimestamp": true, "not": true,
+		"null": true, "offset": true, "on": true, "only": true, "or": true, "order": true, "placing": true,
+		"primary": true, "references": true, "returning": true, "select": true, "session_user": true,
+		"some": true, "symmetric": true, "table": true, "then": true, "to": true, "trailing": true, "true": true,
+		"union": true, "unique": true, "user": true, "using": true, "variadic": true, "when": true, "where": true,
+		"window": true, "with": true,
+	}
+)
+
+// EventColumn is the column holding one of an event's arguments in the event's generated table
+type EventColumn struct {
+	Name     ColumnName
+	Type     string // Postgres column type
+	Argument abi.Argument
+}
+
+// EventColumns returns a column per argument of the event, in the order they're declared. Columns are named for the
+// arguments in snake case, or arg0, arg1... for unnamed arguments, with an underscore appended to Postgres keywords
+// and to the id and foreign key columns every event table has. Arguments that still map to the same column, like
+// "_value" and "value", are an error.
+func EventColumns(event abi.Event) ([]EventColumn, error) {
+	columns := make([]EventColumn, len(event.Inputs))
+	seen := make(map[ColumnName]bool, len(event.Inputs))
+	for i, argument := range event.Inputs {
+		name := ColumnName(fmt.Sprintf("arg%d", i))
+		if argument.Name != "" {
+			name = ToColumnName(argument.Name)
+		}
+		if reservedKeyword[name] || reservedColumn[name] {
+			name += "_"
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateColumn, name)
+		}
+		seen[name] = true
+		columns[i] = EventColumn{Name: name, Type: PostgresType(argument), Argument: argument}
+	}
+	return columns, nil
+}
+
+// ToColumnName converts an event or argument name like "_newOwner" to snake case, like "new_owner"
+func ToColumnName(name string) ColumnName {
+	snake := strings.ToLower(wordBoundary.ReplaceAllString(name, "${1}_${2}"))
+	return ColumnName(strings.Trim(invalidColumn.ReplaceAllString(snake, "_"), "_"))
+}
+
+// PostgresType maps an event argument's Solidity type to the column type its decoded value is stored as. Indexed
+// arguments of dynamic types are only available as the keccak256 hash of their value.
+func PostgresType(argument abi.Argument) string {
+	switch argument.Type.T {
+	case abi.StringTy, abi.BytesTy, abi.SliceTy, abi.ArrayTy, abi.TupleTy:
+		if argument.Indexed {
+			return "VARCHAR(66)"
+		}
+	}
+	switch argument.Type.T {
+	case abi.AddressTy:
+		return "VARCHAR(42)"
+	case abi.IntTy, abi.UintTy:
+		return "NUMERIC"
+	case abi.BoolTy:
+		return "BOOLEAN"
+	case abi.StringTy:
+		return "TEXT"
+	case abi.BytesTy:
+		return "BYTEA"
+	case abi.FixedBytesTy, abi.HashTy, abi.FunctionTy:
+		return fmt.Sprintf("VARCHAR(%d)", 2+2*argument.Type.Size)
+	default:
+		return "JSONB"
+	}
+}
+
+// GenerateEventMigration returns a goose migration creating the table an ABIConverter persists the event to, with a
+// column per event argument along with the header, log and contract address they came from
+func GenerateEventMigration(schema SchemaName, table TableName, event abi.Event) (string, error) {
+	columns, columnsErr := EventColumns(event)
+	if columnsErr != nil {
+		return "", columnsErr
+	}
+
+	var migration strings.Builder
+	migration.WriteString("-- +goose Up\n")
+	fmt.Fprintf(&migration, "-- Generated from event %s\n", event.Sig)
+	fmt.Fprintf(&migration, "CREATE TABLE %s.%s\n(\n", schema, table)
+	migration.WriteString("    id         SERIAL PRIMARY KEY,\n")
+	fmt.Fprintf(&migration, "    %-10s INTEGER NOT NULL REFERENCES public.headers (id) ON DELETE CASCADE,\n", HeaderFK)
+	fmt.Fprintf(&migration, "    %-10s BIGINT  NOT NULL REFERENCES public.event_logs (id) ON DELETE CASCADE,\n", LogFK)
+	fmt.Fprintf(&migration, "    %-10s BIGINT  NOT NULL REFERENCES public.addresses (id) ON DELETE CASCADE,\n", AddressFK)
+	for _, column := range columns {
+		fmt.Fprintf(&migration, "    %s %s,\n", column.Name, column.Type)
+	}
+	fmt.Fprintf(&migration, "    UNIQUE (%s, %s)\n);\n\n", HeaderFK, LogFK)
+	fmt.Fprintf(&migration, "CREATE INDEX %s_log_index ON %s.%s (%s);\n", table, schema, table, LogFK)
+	fmt.Fprintf(&migration, "CREATE INDEX %s_address_index ON %s.%s (%s);\n\n", table, schema, table, AddressFK)
+	migration.WriteString("-- +goose Down\n")
+	fmt.Fprintf(&migration, "DROP TABLE %s.%s;\n", schema, table)
+	return migration.String(), nil
+}
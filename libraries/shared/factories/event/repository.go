@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
 	"github.com/makerdao/vulcanizedb/pkg/datastore/postgres"
 	"github.com/makerdao/vulcanizedb/utils"
@@ -30,6 +31,12 @@ import (
 // SetLogTransformedQuery marks the log as transformed in the database
 const SetLogTransformedQuery = `UPDATE public.event_logs SET transformed = true WHERE id = $1`
 
+// SetLogsTransformedQuery marks an array of logs as transformed in the database
+const SetLogsTransformedQuery = `UPDATE public.event_logs SET transformed = true WHERE id = ANY($1::BIGINT[])`
+
+// maxQueryParameters is the most bind parameters postgres accepts in a single statement
+const maxQueryParameters = 65535
+
 // ErrEmptyModelSlice is returned when PersistModel gets 0 InsertionModels
 var ErrEmptyModelSlice = fmt.Errorf("repository got empty model slice")
 
@@ -106,9 +113,39 @@ func GenerateInsertionQuery(model InsertionModel) string {
 		strings.Join(updateOnConflict, ", "))
 }
 
+// GenerateBulkInsertionQuery creates an SQL insertion query for a number of rows of the model's table and columns.
+// Conflicting rows are updated from the inserted values, so a row must appear at most once per query.
+func GenerateBulkInsertionQuery(model InsertionModel, rows int) string {
+	columns := len(model.OrderedColumns)
+	valueRows := make([]string, rows)
+	for row := 0; row < rows; row++ {
+		valuePlaceholders := make([]string, columns)
+		for i := 0; i < columns; i++ {
+			valuePlaceholders[i] = fmt.Sprintf("$%d", 1+row*columns+i)
+		}
+		valueRows[row] = "(" + strings.Join(valuePlaceholders, ", ") + ")"
+	}
+	var updateOnConflict []string
+	for _, column := range model.OrderedColumns {
+		updateOnConflict = append(updateOnConflict, fmt.Sprintf("%s = EXCLUDED.%s", column, column))
+	}
+
+	baseQuery := `INSERT INTO %v.%v (%v) VALUES %v
+		ON CONFLICT (header_id, log_id) DO UPDATE SET %v;`
+
+	return fmt.Sprintf(baseQuery,
+		model.SchemaName,
+		model.TableName,
+		joinOrderedColumns(model.OrderedColumns),
+		strings.Join(valueRows, ", "),
+		strings.Join(updateOnConflict, ", "))
+}
+
 /*
-PersistModels generates an insertion query and persists to the DB, given a slice of InsertionModels.
+PersistModels generates insertion queries and persists to the DB, given a slice of InsertionModels.
 ColumnValues are restricted to []byte, bool, float64, int64, string, time.Time.
+Models for the same table and columns are inserted together in multi-row queries, and every model's log is marked
+transformed in a single update. When models share a header and log, the last one is persisted.
 
 testModel = shared.InsertionModel{
 	SchemaName:     "public"
@@ -126,46 +163,104 @@ func PersistModels(models []InsertionModel, db *postgres.DB) error {
 		return ErrEmptyModelSlice
 	}
 
-	tx, dbErr := db.Beginx()
-	if dbErr != nil {
-		return dbErr
-	}
-
+	// Check whether or not PG can accept the type of each value before starting on the inserts
 	for _, model := range models {
-		// Maps can't be iterated over in a reliable manner, so we rely on OrderedColumns to define the order to insert
-		// tx.Exec is variadically typed in the args, so if we wrap in []interface{} we can apply them all automatically
-		var args []interface{}
 		for _, col := range model.OrderedColumns {
 			value := model.ColumnValues[col]
-			// Check whether or not PG can accept the type of value in the model
 			okPgValue := isValidValue(value)
 			if !okPgValue {
 				logrus.WithField("model", model).Errorf("PG cannot handle value of this type: %T", value)
 				return ErrUnsupportedValue(value)
 			}
-			args = append(args, value)
 		}
+	}
+
+	tx, dbErr := db.Beginx()
+	if dbErr != nil {
+		return dbErr
+	}
+
+	var logIDs []interface{}
+	for _, group := range groupModels(models) {
+		insertErr := insertModels(tx, group)
+		if insertErr != nil {
+			utils.RollbackAndLogFailure(tx, insertErr, string(group[0].SchemaName)+"."+string(group[0].TableName))
+			return insertErr
+		}
+		for _, model := range group {
+			logIDs = append(logIDs, model.ColumnValues[LogFK])
+		}
+	}
 
-		insertionQuery := GetMemoizedQuery(model)
-		_, execErr := tx.Exec(insertionQuery, args...) // couldn't pass varying types in bulk with args :: []string
+	_, logErr := tx.Exec(SetLogsTransformedQuery, pq.Array(logIDs))
+	if logErr != nil {
+		utils.RollbackAndLogFailure(tx, logErr, "event_logs.transformed")
+		return logErr
+	}
+
+	return tx.Commit()
+}
+
+// Inserts models for the same table and columns, a single model with its memoized query and many in as few
+// multi-row queries as the bind parameter limit allows
+func insertModels(tx *sqlx.Tx, models []InsertionModel) error {
+	if len(models) == 1 {
+		_, execErr := tx.Exec(GetMemoizedQuery(models[0]), modelArgs(models[0])...)
+		return execErr
+	}
 
+	rowsPerQuery := maxQueryParameters / len(models[0].OrderedColumns)
+	for start := 0; start < len(models); start += rowsPerQuery {
+		end := start + rowsPerQuery
+		if end > len(models) {
+			end = len(models)
+		}
+		// Maps can't be iterated over in a reliable manner, so we rely on OrderedColumns to define the order to insert
+		var args []interface{}
+		for _, model := range models[start:end] {
+			args = append(args, modelArgs(model)...)
+		}
+		_, execErr := tx.Exec(GenerateBulkInsertionQuery(models[start], end-start), args...)
 		if execErr != nil {
-			rollbackErr := tx.Rollback()
-			if rollbackErr != nil {
-				logrus.Error("failed to rollback ", rollbackErr)
-			}
 			return execErr
 		}
+	}
+	return nil
+}
 
-		_, logErr := tx.Exec(SetLogTransformedQuery, model.ColumnValues[LogFK])
+func modelArgs(model InsertionModel) []interface{} {
+	var args []interface{}
+	for _, col := range model.OrderedColumns {
+		args = append(args, model.ColumnValues[col])
+	}
+	return args
+}
 
-		if logErr != nil {
-			utils.RollbackAndLogFailure(tx, logErr, "event_logs.transformed")
-			return logErr
+// Groups models by table and columns in the order they first appear. Within a group only the last model for a
+// header and log is kept, since a multi-row upsert can't update the same row twice.
+func groupModels(models []InsertionModel) [][]InsertionModel {
+	var groupKeys []string
+	groups := make(map[string][]InsertionModel)
+	rowIndexes := make(map[string]int)
+	for _, model := range models {
+		groupKey := fmt.Sprintf("%s.%s(%s)", model.SchemaName, model.TableName, joinOrderedColumns(model.OrderedColumns))
+		if _, ok := groups[groupKey]; !ok {
+			groupKeys = append(groupKeys, groupKey)
 		}
+		rowKey := fmt.Sprintf("%s/%v/%v", groupKey, model.ColumnValues[HeaderFK], model.ColumnValues[LogFK])
+		if i, ok := rowIndexes[rowKey]; ok {
+			groups[groupKey][i] = model
+			continue
+		}
+		rowIndexes[rowKey] = len(groups[groupKey])
+		groups[groupKey] = append(groups[groupKey], model)
 	}
 
-	return tx.Commit()
+	grouped := make([][]InsertionModel, len(groupKeys))
+	for i, groupKey := range groupKeys {
+		grouped[i] = groups[groupKey]
+	}
+	return grouped
 }
 
 func isValidValue(value interface{}) bool {
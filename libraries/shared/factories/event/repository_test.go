@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"math/big"
 
+	"github.com/lib/pq"
 	"github.com/makerdao/vulcanizedb/libraries/shared/factories/event"
 	"github.com/makerdao/vulcanizedb/libraries/shared/test_data"
 	"github.com/makerdao/vulcanizedb/pkg/datastore"
@@ -186,6 +187,88 @@ var _ = Describe("Repository", func() {
 			Expect(actualQuery).To(Equal(expectedQuery))
 		})
 
+		It("generates correct bulk queries", func() {
+			actualQuery := event.GenerateBulkInsertionQuery(testModel, 2)
+			expectedQuery := `INSERT INTO public.testEvent (header_id, log_id, variable1) VALUES ($1, $2, $3), ($4, $5, $6)
+		ON CONFLICT (header_id, log_id) DO UPDATE SET header_id = EXCLUDED.header_id, log_id = EXCLUDED.log_id, variable1 = EXCLUDED.variable1;`
+			Expect(actualQuery).To(Equal(expectedQuery))
+		})
+
+		It("persists many models for a table together", func() {
+			var models []event.InsertionModel
+			var logIDs []int64
+			for i := 0; i < 3; i++ {
+				eventLog := test_data.CreateTestLog(headerID, db)
+				logIDs = append(logIDs, eventLog.ID)
+				models = append(models, event.InsertionModel{
+					SchemaName:     "public",
+					TableName:      "testEvent",
+					OrderedColumns: []event.ColumnName{event.HeaderFK, event.LogFK, "variable1"},
+					ColumnValues: event.ColumnValues{
+						event.HeaderFK: headerID,
+						event.LogFK:    eventLog.ID,
+						"variable1":    fmt.Sprintf("value%d", i),
+					},
+				})
+			}
+			// A later model for the same log replaces the earlier one
+			conflictingModel := models[0]
+			conflictingModel.ColumnValues = event.ColumnValues{
+				event.HeaderFK: headerID,
+				event.LogFK:    logIDs[0],
+				"variable1":    "conflictingValue",
+			}
+			models = append(models, conflictingModel)
+
+			createErr := event.PersistModels(models, db)
+			Expect(createErr).NotTo(HaveOccurred())
+
+			var res []FakeEvent
+			dbErr := db.Select(&res, `SELECT log_id, variable1 FROM public.testEvent ORDER BY log_id`)
+			Expect(dbErr).NotTo(HaveOccurred())
+			Expect(res).To(ConsistOf(
+				FakeEvent{LogID: fmt.Sprint(logIDs[0]), Variable1: "conflictingValue"},
+				FakeEvent{LogID: fmt.Sprint(logIDs[1]), Variable1: "value1"},
+				FakeEvent{LogID: fmt.Sprint(logIDs[2]), Variable1: "value2"},
+			))
+			var transformedCount int
+			countErr := db.Get(&transformedCount, `SELECT count(*) FROM public.event_logs WHERE id = ANY($1) AND transformed`,
+				pq.Array(logIDs))
+			Expect(countErr).NotTo(HaveOccurred())
+			Expect(transformedCount).To(Equal(3))
+		})
+
+		It("persists models for different tables in one call", func() {
+			db.MustExec(`CREATE TABLE public.otherTestEvent(
+				id        SERIAL PRIMARY KEY,
+				header_id INTEGER NOT NULL REFERENCES public.headers (id) ON DELETE CASCADE,
+				log_id    BIGINT  NOT NULL REFERENCES public.event_logs (id) ON DELETE CASCADE,
+				variable2 TEXT,
+				UNIQUE (header_id, log_id)
+			);`)
+			defer db.MustExec(`DROP TABLE public.otherTestEvent;`)
+			otherLog := test_data.CreateTestLog(headerID, db)
+			otherModel := event.InsertionModel{
+				SchemaName:     "public",
+				TableName:      "otherTestEvent",
+				OrderedColumns: []event.ColumnName{event.HeaderFK, event.LogFK, "variable2"},
+				ColumnValues: event.ColumnValues{
+					event.HeaderFK: headerID,
+					event.LogFK:    otherLog.ID,
+					"variable2":    "otherValue",
+				},
+			}
+
+			createErr := event.PersistModels([]event.InsertionModel{testModel, otherModel}, db)
+			Expect(createErr).NotTo(HaveOccurred())
+
+			var variable1, variable2 string
+			Expect(db.Get(&variable1, `SELECT variable1 FROM public.testEvent`)).To(Succeed())
+			Expect(db.Get(&variable2, `SELECT variable2 FROM public.otherTestEvent`)).To(Succeed())
+			Expect(variable1).To(Equal("value1"))
+			Expect(variable2).To(Equal("otherValue"))
+		})
+
 		It("marks log transformed", func() {
 			createErr := event.PersistModels([]event.InsertionModel{testModel}, db)
 			Expect(createErr).NotTo(HaveOccurred())
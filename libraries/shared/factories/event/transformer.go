@@ -35,10 +35,27 @@ type TransformerConfig struct {
 	ContractAddresses   []string
 	ContractAbi         string
 	Topic               string
+	Topic1              []string // optional: only logs with one of these values as topic1, e.g. an indexed recipient
+	Topic2              []string // optional: only logs with one of these values as topic2
+	Topic3              []string // optional: only logs with one of these values as topic3
+	Anonymous           bool     // optional: match logs on contract address alone, for anonymous events and logs without topics
+	DataSelector        string   // optional: for an anonymous transformer, only logs whose data starts with these bytes
 	StartingBlockNumber int64
 	EndingBlockNumber   int64 // Set -1 for indefinite transformer
 }
 
+// IndexedTopics returns the transformer's topic1-3 filters as hashes, with nil for a topic that isn't filtered.
+// Values shorter than 32 bytes, like addresses, are left-padded as they are in indexed event arguments.
+func (config TransformerConfig) IndexedTopics() [3][]common.Hash {
+	var topics [3][]common.Hash
+	for i, values := range [3][]string{config.Topic1, config.Topic2, config.Topic3} {
+		for _, value := range values {
+			topics[i] = append(topics[i], common.HexToHash(value))
+		}
+	}
+	return topics
+}
+
 func HexStringsToAddresses(strings []string) (addresses []common.Address) {
 	for _, hexString := range strings {
 		addresses = append(addresses, common.HexToAddress(hexString))
@@ -0,0 +1,267 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package storage
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/makerdao/vulcanizedb/libraries/shared/factories/event"
+	"github.com/makerdao/vulcanizedb/libraries/shared/storage/types"
+	"github.com/makerdao/vulcanizedb/pkg/datastore/postgres"
+	"github.com/sirupsen/logrus"
+)
+
+// DiffFK is the name of storage diff foreign key columns
+const DiffFK event.ColumnName = "diff_id"
+
+// HeaderFK is the name of header foreign key columns
+const HeaderFK = event.HeaderFK
+
+var (
+	// ErrUnknownStorageValue is returned when no table is declared for a value's metadata
+	ErrUnknownStorageValue = fmt.Errorf("no table declared for storage value")
+	// ErrMissingStorageKey is returned when metadata lacks a key the value's table has a column for
+	ErrMissingStorageKey = fmt.Errorf("storage value metadata missing key")
+	// ErrSplitPackedSlot is returned when values packed in a slot are declared in different tables
+	ErrSplitPackedSlot = fmt.Errorf("packed storage values declared in different tables")
+)
+
+// StorageInsertionModel contains everything the repository needs to persist a storage value. Rows are upserted on
+// (diff_id, header_id), so OrderedColumns must include DiffFK and HeaderFK.
+type StorageInsertionModel struct {
+	SchemaName     event.SchemaName
+	TableName      event.TableName
+	OrderedColumns []event.ColumnName // Defines the fields to insert, and in which order the table expects them
+	ColumnValues   event.ColumnValues // Associated values for columns, restricted to []byte, bool, float64, int64, string, time.Time
+}
+
+// ValueTable declares the table values of one or more storage variables are persisted to
+type ValueTable struct {
+	SchemaName   event.SchemaName
+	TableName    event.TableName
+	KeyColumns   map[types.Key]event.ColumnName // columns for the mapping keys in the values' metadata
+	ValueColumns map[string]event.ColumnName    // columns for values by metadata name; packed values share one row
+}
+
+// StorageModelToQuery stores memoised insertion queries to minimise computation. Transformers for different contracts
+// run concurrently, so access it while holding storageModelToQueryLock.
+var (
+	StorageModelToQuery     = map[string]string{}
+	storageModelToQueryLock sync.RWMutex
+)
+
+// GetMemoizedStorageQuery gets/creates a DB insertion query for the model
+func GetMemoizedStorageQuery(model StorageInsertionModel) string {
+	// Values of different variables and packed slots may share a table, so the columns are part of the key
+	queryKey := fmt.Sprintf("%s.%s(%s)", model.SchemaName, model.TableName, joinColumns(model.OrderedColumns))
+	storageModelToQueryLock.RLock()
+	query, queryMemoized := StorageModelToQuery[queryKey]
+	storageModelToQueryLock.RUnlock()
+	if !queryMemoized {
+		query = GenerateStorageInsertionQuery(model)
+		storageModelToQueryLock.Lock()
+		StorageModelToQuery[queryKey] = query
+		storageModelToQueryLock.Unlock()
+	}
+	return query
+}
+
+// GenerateStorageInsertionQuery creates an SQL upsert query from an insertion model.
+// Should be called through GetMemoizedStorageQuery, so the query is not generated for every diff.
+func GenerateStorageInsertionQuery(model StorageInsertionModel) string {
+	var valuePlaceholders []string
+	var updateOnConflict []string
+	for i := 0; i < len(model.OrderedColumns); i++ {
+		valuePlaceholder := fmt.Sprintf("$%d", 1+i)
+		valuePlaceholders = append(valuePlaceholders, valuePlaceholder)
+		updateOnConflict = append(updateOnConflict,
+			fmt.Sprintf("%s = %s", model.OrderedColumns[i], valuePlaceholder))
+	}
+
+	baseQuery := `INSERT INTO %v.%v (%v) VALUES(%v)
+		ON CONFLICT (diff_id, header_id) DO UPDATE SET %v;`
+
+	return fmt.Sprintf(baseQuery,
+		model.SchemaName,
+		model.TableName,
+		joinColumns(model.OrderedColumns),
+		strings.Join(valuePlaceholders, ", "),
+		strings.Join(updateOnConflict, ", "))
+}
+
+// PersistStorageModel upserts the model's row
+func PersistStorageModel(model StorageInsertionModel, db *postgres.DB) error {
+	var args []interface{}
+	for _, col := range model.OrderedColumns {
+		value := model.ColumnValues[col]
+		if !driver.IsValue(value) {
+			logrus.WithField("model", model).Errorf("PG cannot handle value of this type: %T", value)
+			return event.ErrUnsupportedValue(value)
+		}
+		args = append(args, value)
+	}
+
+	_, execErr := db.Exec(GetMemoizedStorageQuery(model), args...)
+	if execErr != nil {
+		return fmt.Errorf("error persisting storage value to %s.%s: %w", model.SchemaName, model.TableName, execErr)
+	}
+	return nil
+}
+
+// GenericRepository persists decoded storage values to tables declared by value name, replacing hand-written
+// Repository implementations that switch on metadata.Name
+type GenericRepository struct {
+	tables map[string]ValueTable
+	db     *postgres.DB
+}
+
+// NewGenericRepository indexes the given tables by the names of the values they hold. Each value name may only be
+// declared in one table.
+func NewGenericRepository(tables []ValueTable) (*GenericRepository, error) {
+	tablesByValue := make(map[string]ValueTable)
+	for _, table := range tables {
+		for name := range table.ValueColumns {
+			if _, ok := tablesByValue[name]; ok {
+				return nil, fmt.Errorf("storage value %s declared in more than one table", name)
+			}
+			tablesByValue[name] = table
+		}
+	}
+	return &GenericRepository{tables: tablesByValue}, nil
+}
+
+func (repository *GenericRepository) SetDB(db *postgres.DB) {
+	repository.db = db
+}
+
+func (repository *GenericRepository) Create(diffID, headerID int64, metadata types.ValueMetadata, value interface{}) error {
+	model, modelErr := repository.ToModel(diffID, headerID, metadata, value)
+	if modelErr != nil {
+		return modelErr
+	}
+	return PersistStorageModel(model, repository.db)
+}
+
+// ToModel builds the insertion model for a decoded value. Packed slots become a single row with a column per value.
+func (repository *GenericRepository) ToModel(diffID, headerID int64, metadata types.ValueMetadata, value interface{}) (StorageInsertionModel, error) {
+	if decodeErr, ok := value.(error); ok {
+		return StorageInsertionModel{}, fmt.Errorf("error decoding storage value %s: %w", metadata.Name, decodeErr)
+	}
+
+	table, tableErr := repository.getTable(metadata)
+	if tableErr != nil {
+		return StorageInsertionModel{}, tableErr
+	}
+
+	model := StorageInsertionModel{
+		SchemaName:     table.SchemaName,
+		TableName:      table.TableName,
+		OrderedColumns: []event.ColumnName{DiffFK, HeaderFK},
+		ColumnValues:   event.ColumnValues{DiffFK: diffID, HeaderFK: headerID},
+	}
+
+	keyColumnsErr := addKeyColumns(&model, table, metadata)
+	if keyColumnsErr != nil {
+		return StorageInsertionModel{}, keyColumnsErr
+	}
+
+	if metadata.Type != types.PackedSlot {
+		column := table.ValueColumns[metadata.Name]
+		model.OrderedColumns = append(model.OrderedColumns, column)
+		model.ColumnValues[column] = value
+		return model, nil
+	}
+
+	packedValues, ok := value.(map[int]string)
+	if !ok {
+		return StorageInsertionModel{}, fmt.Errorf("unexpected value for packed slot: %v (%T)", value, value)
+	}
+	for _, position := range sortedPositions(metadata.PackedNames) {
+		column := table.ValueColumns[metadata.PackedNames[position]]
+		model.OrderedColumns = append(model.OrderedColumns, column)
+		model.ColumnValues[column] = packedValues[position]
+	}
+	return model, nil
+}
+
+// getTable finds the table declared for the value, or for every value in a packed slot
+func (repository *GenericRepository) getTable(metadata types.ValueMetadata) (ValueTable, error) {
+	if metadata.Type != types.PackedSlot {
+		table, ok := repository.tables[metadata.Name]
+		if !ok {
+			return ValueTable{}, fmt.Errorf("%w: %s", ErrUnknownStorageValue, metadata.Name)
+		}
+		return table, nil
+	}
+
+	var result ValueTable
+	for i, position := range sortedPositions(metadata.PackedNames) {
+		name := metadata.PackedNames[position]
+		table, ok := repository.tables[name]
+		if !ok {
+			return ValueTable{}, fmt.Errorf("%w: %s", ErrUnknownStorageValue, name)
+		}
+		if i == 0 {
+			result = table
+		} else if table.SchemaName != result.SchemaName || table.TableName != result.TableName {
+			return ValueTable{}, fmt.Errorf("%w: %s.%s and %s.%s", ErrSplitPackedSlot, result.SchemaName,
+				result.TableName, table.SchemaName, table.TableName)
+		}
+	}
+	return result, nil
+}
+
+// addKeyColumns adds columns for the value's mapping keys, ordered by key name
+func addKeyColumns(model *StorageInsertionModel, table ValueTable, metadata types.ValueMetadata) error {
+	var keys []types.Key
+	for key := range table.KeyColumns {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	for _, key := range keys {
+		keyValue, ok := metadata.Keys[key]
+		if !ok {
+			return fmt.Errorf("%w: %s for %s", ErrMissingStorageKey, key, metadata.Name)
+		}
+		column := table.KeyColumns[key]
+		model.OrderedColumns = append(model.OrderedColumns, column)
+		model.ColumnValues[column] = keyValue
+	}
+	return nil
+}
+
+func sortedPositions(packedNames map[int]string) []int {
+	var positions []int
+	for position := range packedNames {
+		positions = append(positions, position)
+	}
+	sort.Ints(positions)
+	return positions
+}
+
+func joinColumns(columns []event.ColumnName) string {
+	var stringColumns []string
+	for _, columnName := range columns {
+		stringColumns = append(stringColumns, string(columnName))
+	}
+	return strings.Join(stringColumns, ", ")
+}
@@ -0,0 +1,244 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package storage_test
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+
+	"github.com/makerdao/vulcanizedb/libraries/shared/factories/event"
+	"github.com/makerdao/vulcanizedb/libraries/shared/factories/storage"
+	storage2 "github.com/makerdao/vulcanizedb/libraries/shared/storage"
+	"github.com/makerdao/vulcanizedb/libraries/shared/storage/types"
+	"github.com/makerdao/vulcanizedb/libraries/shared/test_data"
+	"github.com/makerdao/vulcanizedb/pkg/datastore/postgres/repositories"
+	"github.com/makerdao/vulcanizedb/pkg/fakes"
+	"github.com/makerdao/vulcanizedb/test_config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Storage insertion models", func() {
+	var (
+		diffID, headerID int64
+		vowTable         = storage.ValueTable{
+			SchemaName:   "public",
+			TableName:    "vow",
+			ValueColumns: map[string]event.ColumnName{"vat": "vat", "wait": "wait", "sump": "sump"},
+		}
+		ilkTable = storage.ValueTable{
+			SchemaName:   "public",
+			TableName:    "ilk",
+			KeyColumns:   map[types.Key]event.ColumnName{"ilk": "ilk"},
+			ValueColumns: map[string]event.ColumnName{"rate": "rate"},
+		}
+		repository *storage.GenericRepository
+	)
+
+	BeforeEach(func() {
+		diffID = rand.Int63()
+		headerID = rand.Int63()
+		var repositoryErr error
+		repository, repositoryErr = storage.NewGenericRepository([]storage.ValueTable{vowTable, ilkTable})
+		Expect(repositoryErr).NotTo(HaveOccurred())
+	})
+
+	Describe("NewGenericRepository", func() {
+		It("returns an error if a value is declared in more than one table", func() {
+			_, err := storage.NewGenericRepository([]storage.ValueTable{vowTable, vowTable})
+
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("ToModel", func() {
+		It("maps a value to its declared column", func() {
+			metadata := types.GetValueMetadata("vat", nil, types.Address)
+			vat := test_data.FakeAddress().Hex()
+
+			model, err := repository.ToModel(diffID, headerID, metadata, vat)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(model).To(Equal(storage.StorageInsertionModel{
+				SchemaName:     "public",
+				TableName:      "vow",
+				OrderedColumns: []event.ColumnName{storage.DiffFK, storage.HeaderFK, "vat"},
+				ColumnValues: event.ColumnValues{
+					storage.DiffFK:   diffID,
+					storage.HeaderFK: headerID,
+					"vat":            vat,
+				},
+			}))
+		})
+
+		It("includes columns for mapping keys", func() {
+			metadata := types.GetValueMetadata("rate", map[types.Key]string{"ilk": "ETH-A"}, types.Uint256)
+
+			model, err := repository.ToModel(diffID, headerID, metadata, "123")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(model.OrderedColumns).To(Equal([]event.ColumnName{storage.DiffFK, storage.HeaderFK, "ilk", "rate"}))
+			Expect(model.ColumnValues["ilk"]).To(Equal("ETH-A"))
+			Expect(model.ColumnValues["rate"]).To(Equal("123"))
+		})
+
+		It("maps a packed slot to one row with a column per value", func() {
+			metadata := types.GetValueMetadataForPackedSlot("packed", nil, types.PackedSlot,
+				map[int]string{0: "wait", 1: "sump"}, map[int]types.ValueType{0: types.Uint48, 1: types.Uint48})
+
+			model, err := repository.ToModel(diffID, headerID, metadata, map[int]string{0: "1", 1: "2"})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(model.TableName).To(Equal(event.TableName("vow")))
+			Expect(model.OrderedColumns).To(Equal([]event.ColumnName{storage.DiffFK, storage.HeaderFK, "wait", "sump"}))
+			Expect(model.ColumnValues["wait"]).To(Equal("1"))
+			Expect(model.ColumnValues["sump"]).To(Equal("2"))
+		})
+
+		It("returns an error if no table is declared for the value", func() {
+			metadata := types.GetValueMetadata("unknown", nil, types.Uint256)
+
+			_, err := repository.ToModel(diffID, headerID, metadata, "1")
+
+			Expect(err).To(MatchError(storage.ErrUnknownStorageValue))
+		})
+
+		It("returns an error if the metadata is missing a key column", func() {
+			metadata := types.GetValueMetadata("rate", nil, types.Uint256)
+
+			_, err := repository.ToModel(diffID, headerID, metadata, "1")
+
+			Expect(err).To(MatchError(storage.ErrMissingStorageKey))
+		})
+
+		It("returns an error if packed values are declared in different tables", func() {
+			metadata := types.GetValueMetadataForPackedSlot("packed", nil, types.PackedSlot,
+				map[int]string{0: "wait", 1: "rate"}, map[int]types.ValueType{0: types.Uint48, 1: types.Uint48})
+
+			_, err := repository.ToModel(diffID, headerID, metadata, map[int]string{0: "1", 1: "2"})
+
+			Expect(err).To(MatchError(storage.ErrSplitPackedSlot))
+		})
+
+		It("returns an error if the value couldn't be decoded", func() {
+			metadata := types.GetValueMetadata("vat", nil, types.Address)
+
+			_, err := repository.ToModel(diffID, headerID, metadata, fakes.FakeError)
+
+			Expect(err).To(MatchError(fakes.FakeError))
+		})
+	})
+
+	Describe("GenerateStorageInsertionQuery", func() {
+		It("upserts on the diff and header", func() {
+			query := storage.GenerateStorageInsertionQuery(storage.StorageInsertionModel{
+				SchemaName:     "public",
+				TableName:      "vow",
+				OrderedColumns: []event.ColumnName{storage.DiffFK, storage.HeaderFK, "vat"},
+			})
+
+			Expect(query).To(Equal(`INSERT INTO public.vow (diff_id, header_id, vat) VALUES($1, $2, $3)
+		ON CONFLICT (diff_id, header_id) DO UPDATE SET diff_id = $1, header_id = $2, vat = $3;`))
+		})
+	})
+
+	Describe("GetMemoizedStorageQuery", func() {
+		It("can be called from transformers running concurrently", func() {
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					defer GinkgoRecover()
+					query := storage.GetMemoizedStorageQuery(storage.StorageInsertionModel{
+						SchemaName:     "public",
+						TableName:      event.TableName(fmt.Sprintf("concurrent_%d", i)),
+						OrderedColumns: []event.ColumnName{storage.DiffFK, storage.HeaderFK},
+					})
+					Expect(query).To(ContainSubstring(fmt.Sprintf("public.concurrent_%d", i)))
+				}(i)
+			}
+			wg.Wait()
+		})
+	})
+
+	Describe("Create", func() {
+		const createTestTableQuery = `CREATE TABLE public.vow(
+			id        SERIAL PRIMARY KEY,
+			diff_id   BIGINT  NOT NULL REFERENCES public.storage_diff (id) ON DELETE CASCADE,
+			header_id INTEGER NOT NULL REFERENCES public.headers (id) ON DELETE CASCADE,
+			vat       TEXT,
+			wait      NUMERIC,
+			sump      NUMERIC,
+			UNIQUE (diff_id, header_id)
+		);`
+
+		var db = test_config.NewTestDB(test_config.NewTestNode())
+
+		BeforeEach(func() {
+			test_config.CleanTestDB(db)
+			db.MustExec(createTestTableQuery)
+			var headerErr, diffErr error
+			headerID, headerErr = repositories.NewHeaderRepository(db).CreateOrUpdateHeader(fakes.FakeHeader)
+			Expect(headerErr).NotTo(HaveOccurred())
+			diffID, diffErr = storage2.NewDiffRepository(db).CreateStorageDiff(types.RawDiff{
+				Address:      test_data.FakeAddress(),
+				BlockHash:    test_data.FakeHash(),
+				BlockHeight:  rand.Int(),
+				StorageKey:   test_data.FakeHash(),
+				StorageValue: test_data.FakeHash(),
+			})
+			Expect(diffErr).NotTo(HaveOccurred())
+			repository.SetDB(db)
+		})
+
+		AfterEach(func() {
+			db.MustExec(`DROP TABLE public.vow;`)
+		})
+
+		It("persists a packed slot as one row", func() {
+			metadata := types.GetValueMetadataForPackedSlot("packed", nil, types.PackedSlot,
+				map[int]string{0: "wait", 1: "sump"}, map[int]types.ValueType{0: types.Uint48, 1: types.Uint48})
+
+			createErr := repository.Create(diffID, headerID, metadata, map[int]string{0: "1", 1: "2"})
+
+			Expect(createErr).NotTo(HaveOccurred())
+			var result struct {
+				Wait string
+				Sump string
+			}
+			getErr := db.Get(&result, `SELECT wait, sump FROM public.vow WHERE diff_id = $1`, diffID)
+			Expect(getErr).NotTo(HaveOccurred())
+			Expect(result.Wait).To(Equal("1"))
+			Expect(result.Sump).To(Equal("2"))
+		})
+
+		It("updates the row if the diff is persisted again", func() {
+			metadata := types.GetValueMetadata("wait", nil, types.Uint48)
+			Expect(repository.Create(diffID, headerID, metadata, "1")).To(Succeed())
+
+			createErr := repository.Create(diffID, headerID, metadata, "2")
+
+			Expect(createErr).NotTo(HaveOccurred())
+			var waits []string
+			getErr := db.Select(&waits, `SELECT wait FROM public.vow`)
+			Expect(getErr).NotTo(HaveOccurred())
+			Expect(waits).To(ConsistOf("2"))
+		})
+	})
+})
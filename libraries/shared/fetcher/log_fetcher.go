@@ -17,16 +17,31 @@
 package fetcher
 
 import (
+	"math/big"
+	"strings"
+
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/sirupsen/logrus"
 
 	"github.com/makerdao/vulcanizedb/pkg/core"
 )
 
+// Substrings of the errors nodes and providers return when a log query spans too many blocks or matches too many logs
+var tooManyResultsMessages = []string{
+	"query returned more than",
+	"response size exceeded",
+	"response size should not",
+	"exceed maximum block range",
+	"block range is too wide",
+}
+
+// ILogFetcher fetches logs from any of the addresses matching the topics, which are in the form of
+// ethereum.FilterQuery's Topics: one slice of acceptable values per topic position, with an empty slice matching any
 type ILogFetcher interface {
-	FetchLogs(contractAddresses []common.Address, topics []common.Hash, missingHeader core.Header) ([]types.Log, error)
-	// TODO Extend FetchLogs for doing several blocks at a time
+	FetchLogs(contractAddresses []common.Address, topics [][]common.Hash, missingHeader core.Header) ([]types.Log, error)
+	FetchLogsInRange(contractAddresses []common.Address, topics [][]common.Hash, fromBlock, toBlock int64) ([]types.Log, error)
 }
 
 type LogFetcher struct {
@@ -39,14 +54,14 @@ func NewLogFetcher(blockchain core.BlockChain) *LogFetcher {
 	}
 }
 
-// Checks all topic0s, on all addresses, fetching matching logs for the given header
-func (logFetcher LogFetcher) FetchLogs(addresses []common.Address, topic0s []common.Hash, header core.Header) ([]types.Log, error) {
+// Checks all topics, on all addresses, fetching matching logs for the given header
+func (logFetcher LogFetcher) FetchLogs(addresses []common.Address, topics [][]common.Hash, header core.Header) ([]types.Log, error) {
 	blockHash := common.HexToHash(header.Hash)
 	query := ethereum.FilterQuery{
 		BlockHash: &blockHash,
 		Addresses: addresses,
-		// Search for _any_ of the topics in topic0 position; see docs on `FilterQuery`
-		Topics: [][]common.Hash{topic0s},
+		// Search for _any_ of the topics in each position; see docs on `FilterQuery`
+		Topics: topics,
 	}
 
 	logs, err := logFetcher.blockChain.GetEthLogsWithCustomQuery(query)
@@ -57,3 +72,45 @@ func (logFetcher LogFetcher) FetchLogs(addresses []common.Address, topic0s []com
 
 	return logs, nil
 }
+
+// Checks all topics, on all addresses, fetching matching logs from fromBlock to toBlock inclusive. If the node
+// rejects the query for returning too many results, the range is split in half and each half fetched separately.
+func (logFetcher LogFetcher) FetchLogsInRange(addresses []common.Address, topics [][]common.Hash, fromBlock, toBlock int64) ([]types.Log, error) {
+	query := ethereum.FilterQuery{
+		FromBlock: big.NewInt(fromBlock),
+		ToBlock:   big.NewInt(toBlock),
+		Addresses: addresses,
+		Topics:    topics,
+	}
+
+	logs, err := logFetcher.blockChain.GetEthLogsWithCustomQuery(query)
+	if err == nil {
+		return logs, nil
+	}
+	if fromBlock >= toBlock || !IsTooManyResultsError(err) {
+		return []types.Log{}, err
+	}
+
+	middle := fromBlock + (toBlock-fromBlock)/2
+	logrus.Debugf("splitting log query for blocks %d-%d: %s", fromBlock, toBlock, err.Error())
+	lowerLogs, lowerErr := logFetcher.FetchLogsInRange(addresses, topics, fromBlock, middle)
+	if lowerErr != nil {
+		return []types.Log{}, lowerErr
+	}
+	upperLogs, upperErr := logFetcher.FetchLogsInRange(addresses, topics, middle+1, toBlock)
+	if upperErr != nil {
+		return []types.Log{}, upperErr
+	}
+	return append(lowerLogs, upperLogs...), nil
+}
+
+// IsTooManyResultsError reports whether a log query failed because its range should be narrowed
+func IsTooManyResultsError(err error) bool {
+	message := strings.ToLower(err.Error())
+	for _, tooManyResults := range tooManyResultsMessages {
+		if strings.Contains(message, tooManyResults) {
+			return true
+		}
+	}
+	return false
+}
@@ -17,8 +17,12 @@
 package fetcher_test
 
 import (
+	"errors"
+	"math/big"
+
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -41,7 +45,7 @@ var _ = Describe("LogFetcher", func() {
 
 			topicZeros := []common.Hash{common.BytesToHash([]byte{1, 2, 3, 4, 5})}
 
-			_, err := logFetcher.FetchLogs(addresses, topicZeros, header)
+			_, err := logFetcher.FetchLogs(addresses, [][]common.Hash{topicZeros}, header)
 
 			address1 := common.HexToAddress("0xfakeAddress")
 			address2 := common.HexToAddress("0xanotherFakeAddress")
@@ -61,10 +65,88 @@ var _ = Describe("LogFetcher", func() {
 			blockChain.SetGetEthLogsWithCustomQueryErr(fakes.FakeError)
 			logFetcher := fetcher.NewLogFetcher(blockChain)
 
-			_, err := logFetcher.FetchLogs([]common.Address{}, []common.Hash{}, core.Header{})
+			_, err := logFetcher.FetchLogs([]common.Address{}, [][]common.Hash{}, core.Header{})
 
 			Expect(err).To(HaveOccurred())
 			Expect(err).To(MatchError(fakes.FakeError))
 		})
 	})
+
+	Describe("FetchLogsInRange", func() {
+		var (
+			blockChain *fakes.MockBlockChain
+			logFetcher *fetcher.LogFetcher
+			addresses  = []common.Address{common.HexToAddress("0xfakeAddress")}
+			topicZeros = []common.Hash{common.BytesToHash([]byte{1, 2, 3, 4, 5})}
+		)
+
+		BeforeEach(func() {
+			blockChain = fakes.NewMockBlockChain()
+			logFetcher = fetcher.NewLogFetcher(blockChain)
+		})
+
+		It("fetches logs from the block range", func() {
+			_, err := logFetcher.FetchLogsInRange(addresses, [][]common.Hash{topicZeros}, 10, 20)
+
+			Expect(err).NotTo(HaveOccurred())
+			blockChain.AssertGetEthLogsWithCustomQueryCalledWith(ethereum.FilterQuery{
+				FromBlock: big.NewInt(10),
+				ToBlock:   big.NewInt(20),
+				Addresses: addresses,
+				Topics:    [][]common.Hash{topicZeros},
+			})
+		})
+
+		It("splits the range in half when the node returns too many results", func() {
+			blockChain.LogQueryFunc = func(query ethereum.FilterQuery) ([]types.Log, error) {
+				if query.ToBlock.Int64()-query.FromBlock.Int64() > 2 {
+					return nil, errors.New("query returned more than 10000 results")
+				}
+				return []types.Log{{BlockNumber: query.FromBlock.Uint64()}}, nil
+			}
+
+			logs, err := logFetcher.FetchLogsInRange(addresses, [][]common.Hash{topicZeros}, 10, 20)
+
+			Expect(err).NotTo(HaveOccurred())
+			var ranges [][2]int64
+			for _, query := range blockChain.LogQueries {
+				ranges = append(ranges, [2]int64{query.FromBlock.Int64(), query.ToBlock.Int64()})
+			}
+			Expect(ranges).To(Equal([][2]int64{
+				{10, 20}, {10, 15}, {10, 12}, {13, 15}, {16, 20}, {16, 18}, {19, 20},
+			}))
+			Expect(logs).To(Equal([]types.Log{
+				{BlockNumber: 10}, {BlockNumber: 13}, {BlockNumber: 16}, {BlockNumber: 19},
+			}))
+		})
+
+		It("returns an error that isn't about too many results without splitting", func() {
+			blockChain.SetGetEthLogsWithCustomQueryErr(fakes.FakeError)
+
+			_, err := logFetcher.FetchLogsInRange(addresses, [][]common.Hash{topicZeros}, 10, 20)
+
+			Expect(err).To(MatchError(fakes.FakeError))
+			Expect(len(blockChain.LogQueries)).To(Equal(1))
+		})
+
+		It("returns a rate limit error without splitting", func() {
+			rateLimited := errors.New("429 Too Many Requests: request limit exceeded")
+			blockChain.SetGetEthLogsWithCustomQueryErr(rateLimited)
+
+			_, err := logFetcher.FetchLogsInRange(addresses, [][]common.Hash{topicZeros}, 10, 20)
+
+			Expect(err).To(MatchError(rateLimited))
+			Expect(len(blockChain.LogQueries)).To(Equal(1))
+		})
+
+		It("returns an error if a single block returns too many results", func() {
+			tooManyResults := errors.New("Log response size exceeded")
+			blockChain.SetGetEthLogsWithCustomQueryErr(tooManyResults)
+
+			_, err := logFetcher.FetchLogsInRange(addresses, [][]common.Hash{topicZeros}, 10, 11)
+
+			Expect(err).To(MatchError(tooManyResults))
+			Expect(len(blockChain.LogQueries)).To(Equal(2))
+		})
+	})
 })
@@ -0,0 +1,112 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package logs
+
+import (
+	"encoding/json"
+	"expvar"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/makerdao/vulcanizedb/pkg/core"
+)
+
+// BloomFilterStats counts headers tested against the watched logs with their logsBloom, and those skipped because
+// their bloom contains none of them
+type BloomFilterStats struct {
+	tested  int64
+	skipped int64
+}
+
+func (stats *BloomFilterStats) Tested() int64 {
+	return atomic.LoadInt64(&stats.tested)
+}
+
+func (stats *BloomFilterStats) Skipped() int64 {
+	return atomic.LoadInt64(&stats.skipped)
+}
+
+// SkipRate is the fraction of tested headers that were skipped
+func (stats *BloomFilterStats) SkipRate() float64 {
+	tested := stats.Tested()
+	if tested == 0 {
+		return 0
+	}
+	return float64(stats.Skipped()) / float64(tested)
+}
+
+// Publish exposes the stats as an expvar metric with the given name, which is served at /debug/vars by an HTTP server
+// using the default ServeMux. It panics if a metric with the name is already published.
+func (stats *BloomFilterStats) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return map[string]interface{}{
+			"tested":   stats.Tested(),
+			"skipped":  stats.Skipped(),
+			"skipRate": stats.SkipRate(),
+		}
+	}))
+}
+
+func (stats *BloomFilterStats) record(tested, skipped int64) {
+	atomic.AddInt64(&stats.tested, tested)
+	atomic.AddInt64(&stats.skipped, skipped)
+}
+
+// headerBloom returns the logsBloom from a header's raw JSON, and false if the header has no usable bloom
+func headerBloom(header core.Header) (types.Bloom, bool) {
+	var raw struct {
+		Bloom *types.Bloom `json:"logsBloom"`
+	}
+	if len(header.Raw) == 0 || json.Unmarshal(header.Raw, &raw) != nil || raw.Bloom == nil {
+		return types.Bloom{}, false
+	}
+	return *raw.Bloom, true
+}
+
+// bloomMayContainLogs tests whether a block with the given bloom may have a log from one of the addresses with one of
+// the topic0s (or any topic0 if there are none), and one of the values of each filtered indexed topic. A false result
+// is certain; a true result may be a false positive.
+func bloomMayContainLogs(bloom types.Bloom, addresses []common.Address, topics []common.Hash, indexedTopics [3][]common.Hash) bool {
+	if !bloomContainsAnyAddress(bloom, addresses) || (len(topics) > 0 && !bloomContainsAnyTopic(bloom, topics)) {
+		return false
+	}
+	for _, values := range indexedTopics {
+		if len(values) > 0 && !bloomContainsAnyTopic(bloom, values) {
+			return false
+		}
+	}
+	return true
+}
+
+func bloomContainsAnyAddress(bloom types.Bloom, addresses []common.Address) bool {
+	for _, address := range addresses {
+		if types.BloomLookup(bloom, address) {
+			return true
+		}
+	}
+	return false
+}
+
+func bloomContainsAnyTopic(bloom types.Bloom, topics []common.Hash) bool {
+	for _, topic := range topics {
+		if types.BloomLookup(bloom, topic) {
+			return true
+		}
+	}
+	return false
+}
@@ -18,6 +18,7 @@ package logs
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/makerdao/vulcanizedb/libraries/shared/chunker"
 	"github.com/makerdao/vulcanizedb/libraries/shared/factories/event"
@@ -28,6 +29,9 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// DefaultMaxLogAttempts is the number of failed executions before a quarantined log is no longer retried automatically
+const DefaultMaxLogAttempts = 3
+
 var (
 	ErrNoLogs         = errors.New("no logs available for transforming")
 	ErrNoTransformers = errors.New("no event transformers configured in the log delegator")
@@ -39,15 +43,18 @@ type ILogDelegator interface {
 }
 
 type LogDelegator struct {
-	Chunker       chunker.Chunker
-	LogRepository datastore.EventLogRepository
-	Transformers  []event.ITransformer
+	Chunker        chunker.Chunker
+	LogRepository  datastore.EventLogRepository
+	Transformers   []event.ITransformer
+	FinalizedOnly  bool // optional: only delegate logs from headers that headerSync has marked finalized
+	MaxLogAttempts int  // the number of failed executions before a quarantined log is only retried by quarantinedLogs; < 1 retries forever
 }
 
 func NewLogDelegator(db *postgres.DB) *LogDelegator {
 	return &LogDelegator{
-		Chunker:       chunker.NewLogChunker(),
-		LogRepository: repositories.NewEventLogRepository(db),
+		Chunker:        chunker.NewLogChunker(),
+		LogRepository:  repositories.NewEventLogRepository(db),
+		MaxLogAttempts: DefaultMaxLogAttempts,
 	}
 }
 
@@ -63,7 +70,7 @@ func (delegator *LogDelegator) DelegateLogs(limit int) error {
 
 	minID := 0
 	for {
-		persistedLogs, fetchErr := delegator.LogRepository.GetUntransformedEventLogs(minID, limit)
+		persistedLogs, fetchErr := delegator.getUntransformedLogs(minID, limit)
 		if fetchErr != nil {
 			logrus.Warnf("error loading logs from db: %s", fetchErr.Error())
 			return fetchErr
@@ -72,6 +79,11 @@ func (delegator *LogDelegator) DelegateLogs(limit int) error {
 		lenPersistedLogs := len(persistedLogs)
 
 		if lenPersistedLogs < 1 {
+			retryErr := delegator.retryQuarantinedLogs("", delegator.MaxLogAttempts)
+			if retryErr != nil {
+				logrus.Warnf("error retrying quarantined logs: %s", retryErr)
+				return retryErr
+			}
 			return ErrNoLogs
 		} else {
 			minID = int(persistedLogs[lenPersistedLogs-1].ID)
@@ -89,6 +101,16 @@ func (delegator *LogDelegator) DelegateLogs(limit int) error {
 	}
 }
 
+func (delegator *LogDelegator) getUntransformedLogs(minID, limit int) ([]core.EventLog, error) {
+	if delegator.FinalizedOnly {
+		return delegator.LogRepository.GetUntransformedFinalizedEventLogs(minID, limit)
+	}
+	return delegator.LogRepository.GetUntransformedEventLogs(minID, limit)
+}
+
+// Runs each transformer over its chunk of the logs. A transformer failing doesn't stop the others, instead the logs
+// it fails on are quarantined so they aren't delegated again until retried. Logs quarantined fewer than MaxLogAttempts
+// times are retried once there are no new logs to delegate, so a transient error doesn't keep a log out for good.
 func (delegator *LogDelegator) delegateLogs(logs []core.EventLog) error {
 	chunkedLogs := delegator.Chunker.ChunkLogs(logs)
 	for _, t := range delegator.Transformers {
@@ -97,8 +119,86 @@ func (delegator *LogDelegator) delegateLogs(logs []core.EventLog) error {
 		err := t.Execute(logChunk)
 		if err != nil {
 			logrus.Warnf("%v transformer failed to execute in watcher: %v", transformerName, err)
-			return err
+			quarantineErr := delegator.quarantineFailedLogs(t, logChunk, err)
+			if quarantineErr != nil {
+				return quarantineErr
+			}
 		}
 	}
 	return nil
 }
+
+// RetryQuarantinedLogs executes transformers on the logs they quarantined one at a time, releasing the logs they
+// succeed on and counting another attempt for the rest. An empty transformer name retries every transformer.
+// Returns the number of logs released and the number still quarantined.
+func (delegator *LogDelegator) RetryQuarantinedLogs(transformerName string) (int, int, error) {
+	return delegator.retryLogs(transformerName, 0)
+}
+
+// Retries the logs quarantined fewer than maxAttempts times, or every quarantined log if maxAttempts < 1
+func (delegator *LogDelegator) retryQuarantinedLogs(transformerName string, maxAttempts int) error {
+	released, failed, err := delegator.retryLogs(transformerName, maxAttempts)
+	if released > 0 || failed > 0 {
+		logrus.Infof("retried quarantined logs: released %d, %d still failing", released, failed)
+	}
+	return err
+}
+
+func (delegator *LogDelegator) retryLogs(transformerName string, maxAttempts int) (int, int, error) {
+	released, failed := 0, 0
+	for _, t := range delegator.Transformers {
+		name := t.GetConfig().TransformerName
+		if transformerName != "" && name != transformerName {
+			continue
+		}
+		quarantinedLogs, getErr := delegator.LogRepository.GetQuarantinedEventLogs(name, maxAttempts)
+		if getErr != nil {
+			return released, failed, fmt.Errorf("error getting logs quarantined by %s transformer: %w", name, getErr)
+		}
+		for _, log := range quarantinedLogs {
+			executeErr := t.Execute([]core.EventLog{log})
+			if executeErr != nil {
+				failed++
+				quarantineErr := delegator.quarantineLog(name, log, executeErr)
+				if quarantineErr != nil {
+					return released, failed, quarantineErr
+				}
+				continue
+			}
+			released++
+			removeErr := delegator.LogRepository.RemoveQuarantinedLog(log.ID, name)
+			if removeErr != nil {
+				return released, failed, fmt.Errorf("error releasing log %d quarantined by %s transformer: %w", log.ID, name, removeErr)
+			}
+		}
+	}
+	return released, failed, nil
+}
+
+// Executes the transformer on each log of a chunk it failed on, so only the logs it fails on are quarantined
+func (delegator *LogDelegator) quarantineFailedLogs(t event.ITransformer, logs []core.EventLog, chunkErr error) error {
+	transformerName := t.GetConfig().TransformerName
+	for _, log := range logs {
+		err := chunkErr
+		if len(logs) > 1 {
+			err = t.Execute([]core.EventLog{log})
+		}
+		if err != nil {
+			quarantineErr := delegator.quarantineLog(transformerName, log, err)
+			if quarantineErr != nil {
+				return quarantineErr
+			}
+		}
+	}
+	return nil
+}
+
+func (delegator *LogDelegator) quarantineLog(transformerName string, log core.EventLog, transformErr error) error {
+	logrus.WithFields(logrus.Fields{"transformer": transformerName, "logID": log.ID}).
+		Warnf("quarantining log: %v", transformErr)
+	quarantineErr := delegator.LogRepository.QuarantineLog(log.ID, transformerName, transformErr)
+	if quarantineErr != nil {
+		return fmt.Errorf("error quarantining log %d for %s transformer: %w", log.ID, transformerName, quarantineErr)
+	}
+	return nil
+}
@@ -81,6 +81,18 @@ var _ = Describe("Log delegator", func() {
 			Expect(err).To(MatchError(fakes.FakeError))
 		})
 
+		It("gets logs from finalized headers if only finalized logs are delegated", func() {
+			mockLogRepository := &fakes.MockEventLogRepository{}
+			delegator := newDelegator(mockLogRepository)
+			delegator.FinalizedOnly = true
+			delegator.AddTransformer(&mocks.MockEventTransformer{})
+
+			err := delegator.DelegateLogs(0)
+
+			Expect(err).To(MatchError(logs.ErrNoLogs))
+			Expect(mockLogRepository.GetFinalizedCalled).To(BeTrue())
+		})
+
 		It("returns logs.ErrNoLogs if no logs returned on initial call", func() {
 			delegator := newDelegator(&fakes.MockEventLogRepository{})
 			delegator.AddTransformer(&mocks.MockEventTransformer{})
@@ -150,14 +162,161 @@ var _ = Describe("Log delegator", func() {
 			Expect(fakeTransformer.PassedLogs).To(Equal(fakeEventLogs))
 		})
 
-		It("returns error if transformer returns an error", func() {
-			mockLogRepository := &fakes.MockEventLogRepository{}
-			mockLogRepository.ReturnLogs = []core.EventLog{{}}
-			delegator := newDelegator(mockLogRepository)
-			fakeTransformer := &mocks.MockEventTransformer{ExecuteError: fakes.FakeError}
+		Describe("when a transformer fails", func() {
+			var (
+				config            = mocks.FakeTransformerConfig
+				fakeEventLogs     []core.EventLog
+				mockLogRepository *fakes.MockEventLogRepository
+				delegator         *logs.LogDelegator
+			)
+
+			BeforeEach(func() {
+				fakeGethLog := types.Log{
+					Address: common.HexToAddress(config.ContractAddresses[0]),
+					Topics:  []common.Hash{common.HexToHash(config.Topic)},
+				}
+				fakeEventLogs = []core.EventLog{{ID: 1, Log: fakeGethLog}, {ID: 2, Log: fakeGethLog}, {ID: 3, Log: fakeGethLog}}
+				mockLogRepository = &fakes.MockEventLogRepository{}
+				mockLogRepository.ReturnLogs = fakeEventLogs
+				delegator = newDelegator(mockLogRepository)
+			})
+
+			It("quarantines only the logs the transformer fails on", func() {
+				fakeTransformer := &mocks.MockEventTransformer{ExecuteError: fakes.FakeError, FailingLogIDs: map[int64]bool{2: true}}
+				fakeTransformer.SetTransformerConfig(config)
+				delegator.AddTransformer(fakeTransformer)
+
+				err := delegator.DelegateLogs(len(fakeEventLogs) + 1)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mockLogRepository.QuarantinedLogIDs).To(Equal(map[string][]int64{config.TransformerName: {2}}))
+				Expect(mockLogRepository.QuarantinedErrors[config.TransformerName]).To(ConsistOf(fakes.FakeError))
+				Expect(fakeTransformer.TransformedLogs).To(Equal([]core.EventLog{fakeEventLogs[0], fakeEventLogs[2]}))
+			})
+
+			It("keeps executing the other transformers", func() {
+				failingTransformer := &mocks.MockEventTransformer{ExecuteError: fakes.FakeError}
+				failingTransformer.SetTransformerConfig(config)
+				healthyConfig := config
+				healthyConfig.TransformerName = "HealthyTransformer"
+				healthyTransformer := &mocks.MockEventTransformer{}
+				healthyTransformer.SetTransformerConfig(healthyConfig)
+				delegator.AddTransformer(failingTransformer)
+				delegator.AddTransformer(healthyTransformer)
+
+				err := delegator.DelegateLogs(len(fakeEventLogs) + 1)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mockLogRepository.QuarantinedLogIDs).To(Equal(map[string][]int64{config.TransformerName: {1, 2, 3}}))
+				Expect(healthyTransformer.PassedLogs).To(Equal(fakeEventLogs))
+			})
+
+			It("returns error if quarantining a log fails", func() {
+				mockLogRepository.QuarantineError = fakes.FakeError
+				fakeTransformer := &mocks.MockEventTransformer{ExecuteError: fakes.FakeError}
+				fakeTransformer.SetTransformerConfig(config)
+				delegator.AddTransformer(fakeTransformer)
+
+				err := delegator.DelegateLogs(len(fakeEventLogs) + 1)
+
+				Expect(err).To(HaveOccurred())
+				Expect(err).To(MatchError(fakes.FakeError))
+			})
+		})
+
+		Describe("when there are no logs left to delegate", func() {
+			var (
+				config            = mocks.FakeTransformerConfig
+				mockLogRepository *fakes.MockEventLogRepository
+				delegator         *logs.LogDelegator
+			)
+
+			BeforeEach(func() {
+				mockLogRepository = &fakes.MockEventLogRepository{
+					QuarantinedEventLogsReturn: map[string][]core.EventLog{config.TransformerName: {{ID: 1}, {ID: 2}}},
+				}
+				delegator = newDelegator(mockLogRepository)
+				delegator.MaxLogAttempts = 3
+			})
+
+			It("retries logs quarantined fewer than max attempts times", func() {
+				fakeTransformer := &mocks.MockEventTransformer{ExecuteError: fakes.FakeError, FailingLogIDs: map[int64]bool{2: true}}
+				fakeTransformer.SetTransformerConfig(config)
+				delegator.AddTransformer(fakeTransformer)
+
+				err := delegator.DelegateLogs(1)
+
+				Expect(err).To(MatchError(logs.ErrNoLogs))
+				Expect(mockLogRepository.PassedQuarantineMaxAttempts).To(ConsistOf(3))
+				Expect(mockLogRepository.RemovedQuarantinedLogIDs).To(Equal(map[string][]int64{config.TransformerName: {1}}))
+				Expect(mockLogRepository.QuarantinedLogIDs).To(Equal(map[string][]int64{config.TransformerName: {2}}))
+			})
+
+			It("returns error if getting quarantined logs fails", func() {
+				mockLogRepository.GetQuarantinedError = fakes.FakeError
+				fakeTransformer := &mocks.MockEventTransformer{}
+				fakeTransformer.SetTransformerConfig(config)
+				delegator.AddTransformer(fakeTransformer)
+
+				err := delegator.DelegateLogs(1)
+
+				Expect(err).To(MatchError(fakes.FakeError))
+			})
+		})
+	})
+
+	Describe("RetryQuarantinedLogs", func() {
+		var (
+			config            = mocks.FakeTransformerConfig
+			quarantinedLogs   = []core.EventLog{{ID: 1}, {ID: 2}}
+			mockLogRepository *fakes.MockEventLogRepository
+			delegator         *logs.LogDelegator
+		)
+
+		BeforeEach(func() {
+			mockLogRepository = &fakes.MockEventLogRepository{
+				QuarantinedEventLogsReturn: map[string][]core.EventLog{config.TransformerName: quarantinedLogs},
+			}
+			delegator = newDelegator(mockLogRepository)
+		})
+
+		It("releases logs the transformer now succeeds on and re-quarantines the rest", func() {
+			fakeTransformer := &mocks.MockEventTransformer{ExecuteError: fakes.FakeError, FailingLogIDs: map[int64]bool{2: true}}
+			fakeTransformer.SetTransformerConfig(config)
 			delegator.AddTransformer(fakeTransformer)
 
-			err := delegator.DelegateLogs(1)
+			released, failed, err := delegator.RetryQuarantinedLogs("")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(mockLogRepository.PassedQuarantineMaxAttempts).To(ConsistOf(0))
+			Expect(released).To(Equal(1))
+			Expect(failed).To(Equal(1))
+			Expect(mockLogRepository.RemovedQuarantinedLogIDs).To(Equal(map[string][]int64{config.TransformerName: {1}}))
+			Expect(mockLogRepository.QuarantinedLogIDs).To(Equal(map[string][]int64{config.TransformerName: {2}}))
+		})
+
+		It("only retries the named transformer", func() {
+			fakeTransformer := &mocks.MockEventTransformer{}
+			fakeTransformer.SetTransformerConfig(config)
+			otherConfig := config
+			otherConfig.TransformerName = "OtherTransformer"
+			otherTransformer := &mocks.MockEventTransformer{}
+			otherTransformer.SetTransformerConfig(otherConfig)
+			delegator.AddTransformer(fakeTransformer)
+			delegator.AddTransformer(otherTransformer)
+
+			_, _, err := delegator.RetryQuarantinedLogs(otherConfig.TransformerName)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(mockLogRepository.PassedQuarantineTransformers).To(ConsistOf(otherConfig.TransformerName))
+			Expect(fakeTransformer.ExecuteWasCalled).To(BeFalse())
+		})
+
+		It("returns error if getting quarantined logs fails", func() {
+			mockLogRepository.GetQuarantinedError = fakes.FakeError
+			delegator.AddTransformer(&mocks.MockEventTransformer{})
+
+			_, _, err := delegator.RetryQuarantinedLogs("")
 
 			Expect(err).To(HaveOccurred())
 			Expect(err).To(MatchError(fakes.FakeError))
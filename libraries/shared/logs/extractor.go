@@ -19,9 +19,12 @@ package logs
 import (
 	"errors"
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/makerdao/vulcanizedb/libraries/shared/constants"
 	"github.com/makerdao/vulcanizedb/libraries/shared/factories/event"
 	"github.com/makerdao/vulcanizedb/libraries/shared/fetcher"
@@ -30,6 +33,7 @@ import (
 	"github.com/makerdao/vulcanizedb/pkg/datastore"
 	"github.com/makerdao/vulcanizedb/pkg/datastore/postgres"
 	"github.com/makerdao/vulcanizedb/pkg/datastore/postgres/repositories"
+	"github.com/makerdao/vulcanizedb/pkg/eth"
 	"github.com/makerdao/vulcanizedb/utils"
 	"github.com/sirupsen/logrus"
 )
@@ -41,7 +45,11 @@ var (
 	EndInterval           BlockIdentifier = "end"
 	ErrNoUncheckedHeaders                 = errors.New("no unchecked headers available for log fetching")
 	ErrNoWatchedAddresses                 = errors.New("no watched addresses configured in the log extractor")
+	ErrHeaderHashMismatch                 = errors.New("header hash doesn't match hash of logs at its block number")
 	HeaderChunkSize       int64           = 1000
+	WorkerMaxAttempts                     = 5
+	WorkerMaxBackoff                      = time.Minute
+	DefaultWorkerBackoff                  = time.Second
 )
 
 type ILogExtractor interface {
@@ -52,6 +60,7 @@ type ILogExtractor interface {
 
 type LogExtractor struct {
 	Addresses                []common.Address
+	BlockChain               core.BlockChain // used to verify stored header hashes in spans fetched by block range
 	CheckedHeadersRepository datastore.CheckedHeadersRepository
 	CheckedLogsRepository    datastore.CheckedLogsRepository
 	Fetcher                  fetcher.ILogFetcher
@@ -61,14 +70,24 @@ type LogExtractor struct {
 	EndingBlock              *int64
 	Syncer                   transactions.ITransactionsSyncer
 	Topics                   []common.Hash
+	IndexedTopics            [3][]common.Hash // topic1-3 values to query for, with nil matching any value
+	unfilteredTopics         [3]bool
+	anonymousAddresses       []common.Address // addresses with an anonymous transformer, whose logs are fetched with any topic0
 	Throttler                utils.ThrottlerFuncWithArg
 	minWaitTime              time.Duration
 	RecheckHeaderCap         int64
+	LogRangeSize             int64 // optional: fetch logs for spans of up to this many blocks per query, rather than per header
+	Workers                  int   // optional: check this many headers (or spans) at once, rather than one at a time
+	WorkerBackoff            time.Duration
+	BloomFilter              bool // optional: skip fetching logs for headers whose logsBloom has none of the watched logs
+	BloomFilterStats         *BloomFilterStats
+	FinalizedOnly            bool // optional: leave headers unchecked until headerSync marks them finalized
 }
 
 func NewLogExtractor(db *postgres.DB, bc core.BlockChain, chr datastore.CheckedHeadersRepository) *LogExtractor {
 	throttler := utils.NewThrottlerWithArgs(&utils.StandardTimer{})
 	return &LogExtractor{
+		BlockChain:               bc,
 		CheckedHeadersRepository: chr,
 		CheckedLogsRepository:    repositories.NewCheckedLogsRepository(db),
 		Fetcher:                  fetcher.NewLogFetcher(bc),
@@ -77,6 +96,8 @@ func NewLogExtractor(db *postgres.DB, bc core.BlockChain, chr datastore.CheckedH
 		Syncer:                   transactions.NewTransactionsSyncer(db, bc),
 		Throttler:                throttler.Throttle,
 		RecheckHeaderCap:         constants.RecheckHeaderCap,
+		WorkerBackoff:            DefaultWorkerBackoff,
+		BloomFilterStats:         &BloomFilterStats{},
 	}
 }
 
@@ -97,10 +118,87 @@ func (extractor *LogExtractor) AddTransformerConfig(config event.TransformerConf
 
 	addresses := event.HexStringsToAddresses(config.ContractAddresses)
 	extractor.Addresses = append(extractor.Addresses, addresses...)
-	extractor.Topics = append(extractor.Topics, common.HexToHash(config.Topic))
+	if config.Anonymous {
+		extractor.anonymousAddresses = append(extractor.anonymousAddresses, addresses...)
+	} else {
+		extractor.Topics = append(extractor.Topics, common.HexToHash(config.Topic))
+	}
+	extractor.addIndexedTopics(config.IndexedTopics())
 	return nil
 }
 
+// addIndexedTopics merges a transformer's topic1-3 filters into the query. A topic can only be filtered if every
+// transformer filters it, since logs for a transformer that doesn't must be fetched whatever the topic's value.
+func (extractor *LogExtractor) addIndexedTopics(topics [3][]common.Hash) {
+	for i, values := range topics {
+		if len(values) == 0 || extractor.unfilteredTopics[i] {
+			extractor.unfilteredTopics[i] = true
+			extractor.IndexedTopics[i] = nil
+			continue
+		}
+		extractor.IndexedTopics[i] = append(extractor.IndexedTopics[i], values...)
+	}
+}
+
+// logQuery is the addresses and topic0s of one query for watched logs, with nil topic0s matching any topic0 (or none)
+type logQuery struct {
+	addresses []common.Address
+	topic0s   []common.Hash
+}
+
+// logQueries returns the queries to fetch watched logs with. Logs from addresses with an anonymous transformer are
+// fetched with any topic0, and logs from the other watched addresses only with the watched topic0s, so that an
+// anonymous transformer doesn't pull in other contracts' logs that no transformer converts.
+func (extractor *LogExtractor) logQueries() []logQuery {
+	var queries []logQuery
+	topicAddresses := extractor.topicAddresses()
+	if len(topicAddresses) > 0 {
+		queries = append(queries, logQuery{addresses: topicAddresses, topic0s: extractor.Topics})
+	}
+	if len(extractor.anonymousAddresses) > 0 {
+		queries = append(queries, logQuery{addresses: extractor.anonymousAddresses})
+	}
+	return queries
+}
+
+// topicAddresses returns the watched addresses without an anonymous transformer, since logs from those are already
+// fetched whatever their topic0
+func (extractor *LogExtractor) topicAddresses() []common.Address {
+	anonymous := make(map[common.Address]bool, len(extractor.anonymousAddresses))
+	for _, address := range extractor.anonymousAddresses {
+		anonymous[address] = true
+	}
+	var addresses []common.Address
+	for _, address := range extractor.Addresses {
+		if !anonymous[address] {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
+}
+
+// queryTopics returns the topics to fetch logs for in the form of ethereum.FilterQuery's Topics. Trailing unfiltered
+// positions are dropped, since nodes only match logs with at least as many topics as the query has positions.
+func (extractor *LogExtractor) queryTopics(topic0s []common.Hash) [][]common.Hash {
+	topics := [][]common.Hash{topic0s}
+	for _, values := range extractor.IndexedTopics {
+		topics = append(topics, values)
+	}
+	for len(topics) > 0 && len(topics[len(topics)-1]) == 0 {
+		topics = topics[:len(topics)-1]
+	}
+	return topics
+}
+
+// watchedTopic0 is the topic0 a transformer's logs are tracked by in watched_logs, which is empty for an anonymous
+// transformer
+func watchedTopic0(config event.TransformerConfig) string {
+	if config.Anonymous {
+		return ""
+	}
+	return config.Topic
+}
+
 func shouldResetStartingBlockToEarlierTransformerBlock(currentTransformerBlock int64, extractorBlock *int64) bool {
 	isExtractorBlockNil := extractorBlock == nil
 	if isExtractorBlockNil {
@@ -137,26 +235,216 @@ func (extractor LogExtractor) ExtractLogs(recheckHeaders constants.TransformerEx
 		logrus.Warnf("error fetching missing headers: %s", uncheckedHeadersErr)
 		return fmt.Errorf("error getting unchecked headers to check for logs: %w", uncheckedHeadersErr)
 	}
+	if extractor.FinalizedOnly {
+		uncheckedHeaders = finalizedHeaders(uncheckedHeaders)
+	}
+
+	backfills, backfillsErr := extractor.CheckedLogsRepository.PendingLogBackfills()
+	if backfillsErr != nil {
+		logrus.Warnf("error fetching pending log backfills: %s", backfillsErr)
+		return fmt.Errorf("error getting pending log backfills: %w", backfillsErr)
+	}
 
-	if len(uncheckedHeaders) < 1 {
+	if len(uncheckedHeaders) < 1 && len(backfills) < 1 {
 		return ErrNoUncheckedHeaders
 	}
 
-	for _, header := range uncheckedHeaders {
-		err := extractor.fetchAndPersistLogsForHeader(header)
+	if len(uncheckedHeaders) > 0 {
+		checkErr := extractor.runChecks(extractor.buildChecks(uncheckedHeaders, extractor.markHeaderChecked))
+		if checkErr != nil {
+			return checkErr
+		}
+		extractor.logBloomFilterStats()
+	}
+
+	for _, backfill := range backfills {
+		backfillErr := extractor.catchUpLogBackfill(backfill)
+		if backfillErr != nil {
+			return backfillErr
+		}
+	}
+	return nil
+}
+
+func finalizedHeaders(headers []core.Header) []core.Header {
+	var finalized []core.Header
+	for _, header := range headers {
+		if header.Finalized {
+			finalized = append(finalized, header)
+		}
+	}
+	return finalized
+}
+
+// buildChecks returns a check per header, or per span of headers if LogRangeSize is set, that fetches and persists
+// the headers' logs and then calls afterHeader for each of them
+func (extractor LogExtractor) buildChecks(headers []core.Header, afterHeader func(core.Header) error) []func() error {
+	var checks []func() error
+	if extractor.LogRangeSize > 1 {
+		for _, span := range extractor.spanHeaders(headers) {
+			span := span
+			checks = append(checks, func() error {
+				return extractor.fetchAndPersistLogsForSpan(span, afterHeader)
+			})
+		}
+	} else {
+		for _, header := range headers {
+			header := header
+			checks = append(checks, func() error {
+				return extractor.checkHeader(header, afterHeader)
+			})
+		}
+	}
+	return checks
+}
+
+func (extractor LogExtractor) runChecks(checks []func() error) error {
+	if extractor.Workers > 1 {
+		return extractor.runChecksConcurrently(checks)
+	}
+	for _, check := range checks {
+		err := check()
 		if err != nil {
-			return fmt.Errorf("error fetching and persisting logs for header with id %d: %w", header.Id, err)
+			return err
 		}
+	}
+	return nil
+}
+
+// catchUpLogBackfill checks the next HeaderChunkSize blocks of a backfill for only the backfill's addresses + topic0,
+// so that a newly watched log catches up on headers that were already checked while other logs keep up with the head
+func (extractor LogExtractor) catchUpLogBackfill(backfill core.LogBackfill) error {
+	fromBlock := backfill.CheckedThrough + 1
+	toBlock := fromBlock + HeaderChunkSize - 1
+	if toBlock > backfill.ToBlock {
+		toBlock = backfill.ToBlock
+	}
+	logrus.Infof("catching up logs for topic 0 %s on blocks %d-%d of %d-%d", backfill.Topic0, fromBlock, toBlock,
+		backfill.FromBlock, backfill.ToBlock)
 
-		markHeaderCheckedErr := extractor.CheckedHeadersRepository.MarkHeaderChecked(header.Id)
-		if markHeaderCheckedErr != nil {
-			logWarn("error marking header checked: %s", markHeaderCheckedErr, header)
-			return markHeaderCheckedErr
+	headers, headersErr := extractor.HeaderRepository.GetHeadersInRange(fromBlock, toBlock)
+	if headersErr != nil {
+		logrus.Warnf("error fetching headers for log backfill: %s", headersErr)
+		return fmt.Errorf("error getting headers to catch up logs for topic 0 %s: %w", backfill.Topic0, headersErr)
+	}
+	if extractor.FinalizedOnly {
+		// headers are finalized up to a block, so stop the chunk before the first header that isn't
+		for i, header := range headers {
+			if !header.Finalized {
+				headers = headers[:i]
+				toBlock = header.BlockNumber - 1
+				break
+			}
+		}
+		if toBlock < fromBlock {
+			logrus.Debugf("waiting for block %d to be finalized to catch up logs for topic 0 %s", fromBlock, backfill.Topic0)
+			return nil
 		}
 	}
+
+	catchUp := extractor
+	catchUp.Addresses = event.HexStringsToAddresses(backfill.Addresses)
+	catchUp.anonymousAddresses = nil
+	if backfill.Topic0 == "" {
+		catchUp.anonymousAddresses = catchUp.Addresses
+	}
+	catchUp.Topics = []common.Hash{common.HexToHash(backfill.Topic0)}
+	catchUp.IndexedTopics = [3][]common.Hash{}
+	checkErr := catchUp.runChecks(catchUp.buildChecks(headers, nil))
+	if checkErr != nil {
+		return fmt.Errorf("error catching up logs for topic 0 %s: %w", backfill.Topic0, checkErr)
+	}
+
+	updateErr := extractor.CheckedLogsRepository.UpdateLogBackfill(backfill, toBlock)
+	if updateErr != nil {
+		return fmt.Errorf("error updating log backfill for topic 0 %s: %w", backfill.Topic0, updateErr)
+	}
 	return nil
 }
 
+// checkHeader persists the header's logs before calling afterHeader, so a header is never marked checked without them
+func (extractor LogExtractor) checkHeader(header core.Header, afterHeader func(core.Header) error) error {
+	err := extractor.fetchAndPersistLogsForHeader(header)
+	if err != nil {
+		return fmt.Errorf("error fetching and persisting logs for header with id %d: %w", header.Id, err)
+	}
+	if afterHeader == nil {
+		return nil
+	}
+	return afterHeader(header)
+}
+
+// runChecksConcurrently runs checks on a pool of Workers. A worker whose check fails waits WorkerBackoff before
+// retrying it, doubling the wait on each consecutive failure up to WorkerMaxBackoff, and resetting it once a check
+// succeeds. If a check still fails after WorkerMaxAttempts, no further checks are started and its error is returned
+// once the other workers finish.
+func (extractor LogExtractor) runChecksConcurrently(checks []func() error) error {
+	pending := make(chan func() error)
+	quit := make(chan struct{})
+	var (
+		failure     error
+		failureOnce sync.Once
+		wg          sync.WaitGroup
+	)
+
+	for worker := 0; worker < extractor.Workers; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			var backoff time.Duration
+			for check := range pending {
+				err := extractor.runCheckWithBackoff(worker, check, &backoff, quit)
+				if err != nil {
+					failureOnce.Do(func() {
+						failure = err
+						close(quit)
+					})
+					return
+				}
+			}
+		}(worker)
+	}
+
+SendChecks:
+	for _, check := range checks {
+		select {
+		case pending <- check:
+		case <-quit:
+			break SendChecks
+		}
+	}
+	close(pending)
+	wg.Wait()
+	return failure
+}
+
+func (extractor LogExtractor) runCheckWithBackoff(worker int, check func() error, backoff *time.Duration, quit <-chan struct{}) error {
+	for attempt := 1; ; attempt++ {
+		err := check()
+		if err == nil {
+			*backoff = 0
+			return nil
+		}
+		if attempt >= WorkerMaxAttempts {
+			return err
+		}
+
+		*backoff *= 2
+		if *backoff == 0 {
+			*backoff = extractor.WorkerBackoff
+		}
+		if *backoff > WorkerMaxBackoff {
+			*backoff = WorkerMaxBackoff
+		}
+		logrus.Warnf("log extractor worker %d retrying in %s: %s", worker, backoff.String(), err.Error())
+		select {
+		case <-time.After(*backoff):
+		case <-quit:
+			return err
+		}
+	}
+}
+
 // BackFillLogs fetches and persists watched logs from provided range of headers
 func (extractor LogExtractor) BackFillLogs(endingBlock int64) error {
 	if len(extractor.Addresses) < 1 {
@@ -177,6 +465,19 @@ func (extractor LogExtractor) BackFillLogs(endingBlock int64) error {
 			return fmt.Errorf("error getting unchecked headers to check for logs: %w", headersErr)
 		}
 
+		if extractor.LogRangeSize > 1 {
+			for _, span := range extractor.spanHeaders(headers) {
+				fetchAndPersistSpan := func(core.Header) error {
+					return extractor.fetchAndPersistLogsForSpan(span, nil)
+				}
+				err := extractor.Throttler(extractor.minWaitTime, fetchAndPersistSpan, span[0])
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
 		for _, header := range headers {
 			err := extractor.Throttler(extractor.minWaitTime, extractor.fetchAndPersistLogsForHeader, header)
 			if err != nil {
@@ -238,28 +539,286 @@ func (extractor *LogExtractor) getCheckCount(recheckHeaders constants.Transforme
 	return extractor.RecheckHeaderCap
 }
 
+// updateCheckedHeaders marks a transformer's log watched, and schedules a backfill of headers that were checked
+// before it was watched, from the transformer's starting block through the last checked header
 func (extractor *LogExtractor) updateCheckedHeaders(config event.TransformerConfig) error {
-	alreadyWatchingLog, watchingLogErr := extractor.CheckedLogsRepository.AlreadyWatchingLog(config.ContractAddresses, config.Topic)
+	topic0 := watchedTopic0(config)
+	alreadyWatchingLog, watchingLogErr := extractor.CheckedLogsRepository.AlreadyWatchingLog(config.ContractAddresses, topic0)
 	if watchingLogErr != nil {
 		return watchingLogErr
 	}
-	if !alreadyWatchingLog {
-		logrus.Warnf("new event log for topic 0 %s detected, back-fill may be required for already-checked headers", config.Topic)
-		markLogWatchedErr := extractor.CheckedLogsRepository.MarkLogWatched(config.ContractAddresses, config.Topic)
-		if markLogWatchedErr != nil {
-			return markLogWatchedErr
-		}
+	if alreadyWatchingLog {
+		return nil
 	}
-	return nil
+
+	unwatchedAddresses, unwatchedErr := extractor.CheckedLogsRepository.UnwatchedAddresses(config.ContractAddresses, topic0)
+	if unwatchedErr != nil {
+		return unwatchedErr
+	}
+	markLogWatchedErr := extractor.CheckedLogsRepository.MarkLogWatched(config.ContractAddresses, topic0)
+	if markLogWatchedErr != nil {
+		return markLogWatchedErr
+	}
+	if len(unwatchedAddresses) < 1 {
+		return nil
+	}
+
+	lastChecked, lastCheckedErr := extractor.CheckedHeadersRepository.LastCheckedBlockNumber()
+	if lastCheckedErr != nil {
+		return fmt.Errorf("error getting last checked header for new event log: %w", lastCheckedErr)
+	}
+	backfillTo := lastChecked
+	if config.EndingBlockNumber != -1 && config.EndingBlockNumber < backfillTo {
+		backfillTo = config.EndingBlockNumber
+	}
+	if backfillTo < config.StartingBlockNumber {
+		return nil
+	}
+
+	logrus.Infof("new event log for topic 0 %s detected, catching up blocks %d-%d", topic0,
+		config.StartingBlockNumber, backfillTo)
+	return extractor.CheckedLogsRepository.CreateLogBackfill(unwatchedAddresses, topic0,
+		config.StartingBlockNumber, backfillTo)
 }
 
 func (extractor *LogExtractor) fetchAndPersistLogsForHeader(header core.Header) error {
-	logs, fetchLogsErr := extractor.Fetcher.FetchLogs(extractor.Addresses, extractor.Topics, header)
+	if extractor.BloomFilter {
+		skip := extractor.bloomExcludesLogs(header)
+		extractor.recordBloomFilter(1, skip)
+		if skip {
+			return nil
+		}
+	}
+	return extractor.fetchAndPersistLogsForHeaderHash(header)
+}
+
+func (extractor *LogExtractor) fetchAndPersistLogsForHeaderHash(header core.Header) error {
+	logs, fetchLogsErr := extractor.fetchLogs(header)
 	if fetchLogsErr != nil {
 		logWarn("error fetching logs for header: %s", fetchLogsErr, header)
 		return fmt.Errorf("error fetching logs for block %d: %w", header.BlockNumber, fetchLogsErr)
 	}
+	return extractor.persistLogsForHeader(header, logs)
+}
+
+func (extractor *LogExtractor) fetchLogs(header core.Header) ([]types.Log, error) {
+	var logs []types.Log
+	for _, query := range extractor.logQueries() {
+		queryLogs, err := extractor.Fetcher.FetchLogs(query.addresses, extractor.queryTopics(query.topic0s), header)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, queryLogs...)
+	}
+	return logs, nil
+}
+
+func (extractor *LogExtractor) fetchLogsInRange(fromBlock, toBlock int64) ([]types.Log, error) {
+	var logs []types.Log
+	for _, query := range extractor.logQueries() {
+		queryLogs, err := extractor.Fetcher.FetchLogsInRange(query.addresses, extractor.queryTopics(query.topic0s), fromBlock, toBlock)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, queryLogs...)
+	}
+	return logs, nil
+}
+
+// spanHeaders sorts headers by block number and groups them into spans covering at most LogRangeSize blocks
+func (extractor *LogExtractor) spanHeaders(headers []core.Header) [][]core.Header {
+	sorted := make([]core.Header, len(headers))
+	copy(sorted, headers)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].BlockNumber < sorted[j].BlockNumber
+	})
+
+	var spans [][]core.Header
+	var span []core.Header
+	for _, header := range sorted {
+		if len(span) > 0 && header.BlockNumber-span[0].BlockNumber >= extractor.LogRangeSize {
+			spans = append(spans, span)
+			span = nil
+		}
+		span = append(span, header)
+	}
+	if len(span) > 0 {
+		spans = append(spans, span)
+	}
+	return spans
+}
+
+// fetchAndPersistLogsForSpan fetches logs for the span's blocks with one range query and matches them to headers by
+// hash. If the node returned logs from a different block at a header's height, that header's logs are fetched by its
+// hash instead, so that a stale header is caught just as it would be when fetching per header.
+func (extractor *LogExtractor) fetchAndPersistLogsForSpan(span []core.Header, afterHeader func(core.Header) error) error {
+	if extractor.BloomFilter {
+		skip := extractor.bloomExcludesSpan(span)
+		extractor.recordBloomFilter(int64(len(span)), skip)
+		if skip {
+			return extractor.afterHeaders(span, afterHeader)
+		}
+	}
+
+	fromBlock, toBlock := span[0].BlockNumber, span[len(span)-1].BlockNumber
+	logs, fetchLogsErr := extractor.fetchLogsInRange(fromBlock, toBlock)
+	if fetchLogsErr != nil {
+		logrus.Warnf("error fetching logs for blocks %d-%d: %s", fromBlock, toBlock, fetchLogsErr.Error())
+		return fmt.Errorf("error fetching logs for blocks %d-%d: %w", fromBlock, toBlock, fetchLogsErr)
+	}
+
+	logsByHash := make(map[common.Hash][]types.Log)
+	hashesByBlock := make(map[int64]map[common.Hash]bool)
+	for _, log := range logs {
+		logsByHash[log.BlockHash] = append(logsByHash[log.BlockHash], log)
+		blockNumber := int64(log.BlockNumber)
+		if hashesByBlock[blockNumber] == nil {
+			hashesByBlock[blockNumber] = make(map[common.Hash]bool)
+		}
+		hashesByBlock[blockNumber][log.BlockHash] = true
+	}
+
+	// headers with logs in the range are checked against the logs' block hashes; the rest are checked against the node
+	var blockNumbersWithoutLogs []int64
+	for _, header := range span {
+		if len(hashesByBlock[header.BlockNumber]) == 0 {
+			blockNumbersWithoutLogs = append(blockNumbersWithoutLogs, header.BlockNumber)
+		}
+	}
+	canonicalHashes, hashesErr := extractor.getCanonicalHashes(blockNumbersWithoutLogs)
+	if hashesErr != nil {
+		return fmt.Errorf("error verifying header hashes for blocks %d-%d: %w", fromBlock, toBlock, hashesErr)
+	}
+
+	for _, header := range span {
+		headerHash := common.HexToHash(header.Hash)
+		headerLogs := logsByHash[headerHash]
+		fetchByHash := false
+		if hasOtherHash(hashesByBlock[header.BlockNumber], headerHash) {
+			logWarn("logs in range query are from another block at header's height, fetching by hash: %s",
+				ErrHeaderHashMismatch, header)
+			fetchByHash = true
+		} else if len(hashesByBlock[header.BlockNumber]) == 0 && canonicalHashes[header.BlockNumber] != headerHash {
+			logWarn("header isn't the node's block at its height, fetching by hash: %s", ErrHeaderHashMismatch, header)
+			fetchByHash = true
+		}
+
+		var err error
+		if fetchByHash {
+			err = extractor.fetchAndPersistLogsForHeaderHash(header)
+		} else {
+			err = extractor.persistLogsForHeader(header, headerLogs)
+		}
+		if err != nil {
+			return fmt.Errorf("error fetching and persisting logs for header with id %d: %w", header.Id, err)
+		}
+
+		if afterHeader != nil {
+			afterErr := afterHeader(header)
+			if afterErr != nil {
+				return afterErr
+			}
+		}
+	}
+	return nil
+}
+
+// getCanonicalHashes fetches the node's header hashes at the given block numbers, in batches of at most
+// eth.MAX_BATCH_SIZE. Block numbers the node has no header for are omitted.
+func (extractor *LogExtractor) getCanonicalHashes(blockNumbers []int64) (map[int64]common.Hash, error) {
+	hashes := make(map[int64]common.Hash, len(blockNumbers))
+	for start := 0; start < len(blockNumbers); start += eth.MAX_BATCH_SIZE {
+		end := start + eth.MAX_BATCH_SIZE
+		if end > len(blockNumbers) {
+			end = len(blockNumbers)
+		}
+		headers, err := extractor.BlockChain.GetHeadersByNumbers(blockNumbers[start:end])
+		if err != nil {
+			return nil, err
+		}
+		for _, header := range headers {
+			hashes[header.BlockNumber] = common.HexToHash(header.Hash)
+		}
+	}
+	return hashes, nil
+}
+
+func (extractor *LogExtractor) afterHeaders(headers []core.Header, afterHeader func(core.Header) error) error {
+	if afterHeader == nil {
+		return nil
+	}
+	for _, header := range headers {
+		err := afterHeader(header)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bloomExcludesLogs is true if the header's logsBloom shows it has none of the watched logs. Headers without a
+// logsBloom in their raw JSON are never excluded.
+func (extractor *LogExtractor) bloomExcludesLogs(header core.Header) bool {
+	bloom, ok := headerBloom(header)
+	if !ok {
+		return false
+	}
+	for _, query := range extractor.logQueries() {
+		if bloomMayContainLogs(bloom, query.addresses, query.topic0s, extractor.IndexedTopics) {
+			return false
+		}
+	}
+	return true
+}
+
+func (extractor *LogExtractor) bloomExcludesSpan(span []core.Header) bool {
+	for _, header := range span {
+		if !extractor.bloomExcludesLogs(header) {
+			return false
+		}
+	}
+	return true
+}
+
+func (extractor *LogExtractor) recordBloomFilter(headers int64, skipped bool) {
+	if extractor.BloomFilterStats == nil {
+		return
+	}
+	if skipped {
+		extractor.BloomFilterStats.record(headers, headers)
+	} else {
+		extractor.BloomFilterStats.record(headers, 0)
+	}
+}
+
+func (extractor *LogExtractor) logBloomFilterStats() {
+	if !extractor.BloomFilter || extractor.BloomFilterStats == nil {
+		return
+	}
+	stats := extractor.BloomFilterStats
+	logrus.Infof("logsBloom prefilter skipped %d of %d headers (%.1f%%)", stats.Skipped(), stats.Tested(),
+		stats.SkipRate()*100)
+}
+
+func hasOtherHash(hashes map[common.Hash]bool, hash common.Hash) bool {
+	for other := range hashes {
+		if other != hash {
+			return true
+		}
+	}
+	return false
+}
+
+func (extractor LogExtractor) markHeaderChecked(header core.Header) error {
+	markHeaderCheckedErr := extractor.CheckedHeadersRepository.MarkHeaderChecked(header.Id)
+	if markHeaderCheckedErr != nil {
+		logWarn("error marking header checked: %s", markHeaderCheckedErr, header)
+		return markHeaderCheckedErr
+	}
+	return nil
+}
 
+func (extractor *LogExtractor) persistLogsForHeader(header core.Header, logs []types.Log) error {
 	if len(logs) > 0 {
 		transactionsSyncErr := extractor.Syncer.SyncTransactions(header.Id, logs)
 		if transactionsSyncErr != nil {
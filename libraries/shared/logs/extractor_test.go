@@ -17,9 +17,15 @@
 package logs_test
 
 import (
+	"expvar"
+	"fmt"
+	"math/big"
 	"math/rand"
+	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/makerdao/vulcanizedb/libraries/shared/constants"
 	"github.com/makerdao/vulcanizedb/libraries/shared/factories/event"
@@ -124,6 +130,57 @@ var _ = Describe("Log extractor", func() {
 			Expect(extractor.Topics).To(Equal([]common.Hash{common.HexToHash(topic)}))
 		})
 
+		It("doesn't add an anonymous transformer's topic to extractor's watched topics", func() {
+			anonymousConfig := event.TransformerConfig{
+				ContractAddresses: []string{fakes.FakeAddress.Hex()},
+				Topic:             "0x1",
+				Anonymous:         true,
+			}
+
+			err := extractor.AddTransformerConfig(anonymousConfig)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(extractor.Topics).To(BeEmpty())
+		})
+
+		It("merges transformers' topic1-3 filters", func() {
+			first := event.TransformerConfig{
+				ContractAddresses: []string{fakes.FakeAddress.Hex()},
+				Topic:             "0x1",
+				Topic1:            []string{"0x11"},
+				Topic2:            []string{"0x21"},
+			}
+			second := event.TransformerConfig{
+				ContractAddresses: []string{fakes.FakeAddress.Hex()},
+				Topic:             "0x2",
+				Topic1:            []string{"0x12"},
+			}
+
+			Expect(extractor.AddTransformerConfig(first)).To(Succeed())
+			Expect(extractor.AddTransformerConfig(second)).To(Succeed())
+
+			Expect(extractor.IndexedTopics).To(Equal([3][]common.Hash{
+				{common.HexToHash("0x11"), common.HexToHash("0x12")}, nil, nil,
+			}))
+		})
+
+		It("doesn't filter a topic once any transformer doesn't filter it", func() {
+			filtered := event.TransformerConfig{
+				ContractAddresses: []string{fakes.FakeAddress.Hex()},
+				Topic:             "0x1",
+				Topic1:            []string{"0x11"},
+			}
+			unfiltered := event.TransformerConfig{
+				ContractAddresses: []string{fakes.FakeAddress.Hex()},
+				Topic:             "0x2",
+			}
+
+			Expect(extractor.AddTransformerConfig(unfiltered)).To(Succeed())
+			Expect(extractor.AddTransformerConfig(filtered)).To(Succeed())
+
+			Expect(extractor.IndexedTopics).To(Equal([3][]common.Hash{}))
+		})
+
 		It("returns error if checking whether log has been checked returns error", func() {
 			checkedLogsRepository.AlreadyWatchingLogError = fakes.FakeError
 
@@ -148,6 +205,17 @@ var _ = Describe("Log extractor", func() {
 				Expect(checkedLogsRepository.MarkLogWatchedTopicZero).To(Equal(config.Topic))
 			})
 
+			It("persists that an anonymous transformer's log is watched without a topic0", func() {
+				config := getTransformerConfig(rand.Int63(), defaultEndingBlockNumber)
+				config.Anonymous = true
+
+				err := extractor.AddTransformerConfig(config)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(checkedLogsRepository.MarkLogWatchedAddresses).To(Equal(config.ContractAddresses))
+				Expect(checkedLogsRepository.MarkLogWatchedTopicZero).To(BeEmpty())
+			})
+
 			It("returns error if marking logs watched returns error", func() {
 				checkedLogsRepository.MarkLogWatchedError = fakes.FakeError
 
@@ -156,6 +224,84 @@ var _ = Describe("Log extractor", func() {
 				Expect(err).To(HaveOccurred())
 				Expect(err).To(MatchError(fakes.FakeError))
 			})
+
+			It("schedules a backfill of already checked headers for unwatched addresses", func() {
+				unwatchedAddress := fakes.FakeAddress.Hex()
+				checkedLogsRepository.UnwatchedAddressesReturn = []string{unwatchedAddress}
+				checkedHeadersRepository.LastCheckedBlockNumberReturn = 500
+				config := getTransformerConfig(100, defaultEndingBlockNumber)
+
+				err := extractor.AddTransformerConfig(config)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(checkedLogsRepository.CreatedLogBackfills).To(ConsistOf(core.LogBackfill{
+					Addresses:      []string{unwatchedAddress},
+					Topic0:         config.Topic,
+					FromBlock:      100,
+					ToBlock:        500,
+					CheckedThrough: 99,
+				}))
+			})
+
+			It("ends the backfill at the transformer's ending block", func() {
+				checkedLogsRepository.UnwatchedAddressesReturn = []string{fakes.FakeAddress.Hex()}
+				checkedHeadersRepository.LastCheckedBlockNumberReturn = 500
+
+				err := extractor.AddTransformerConfig(getTransformerConfig(100, 200))
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(len(checkedLogsRepository.CreatedLogBackfills)).To(Equal(1))
+				Expect(checkedLogsRepository.CreatedLogBackfills[0].ToBlock).To(Equal(int64(200)))
+			})
+
+			It("does not schedule a backfill if no headers from the transformer's starting block were checked", func() {
+				checkedLogsRepository.UnwatchedAddressesReturn = []string{fakes.FakeAddress.Hex()}
+				checkedHeadersRepository.LastCheckedBlockNumberReturn = 99
+
+				err := extractor.AddTransformerConfig(getTransformerConfig(100, defaultEndingBlockNumber))
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(checkedLogsRepository.CreatedLogBackfills).To(BeEmpty())
+			})
+
+			It("returns error if getting unwatched addresses returns error", func() {
+				checkedLogsRepository.UnwatchedAddressesError = fakes.FakeError
+
+				err := extractor.AddTransformerConfig(getTransformerConfig(rand.Int63(), defaultEndingBlockNumber))
+
+				Expect(err).To(MatchError(fakes.FakeError))
+			})
+
+			It("returns error if getting the last checked header returns error", func() {
+				checkedLogsRepository.UnwatchedAddressesReturn = []string{fakes.FakeAddress.Hex()}
+				checkedHeadersRepository.LastCheckedBlockNumberReturnError = fakes.FakeError
+
+				err := extractor.AddTransformerConfig(getTransformerConfig(rand.Int63(), defaultEndingBlockNumber))
+
+				Expect(err).To(MatchError(fakes.FakeError))
+			})
+
+			It("returns error if scheduling the backfill returns error", func() {
+				checkedLogsRepository.UnwatchedAddressesReturn = []string{fakes.FakeAddress.Hex()}
+				checkedHeadersRepository.LastCheckedBlockNumberReturn = 500
+				checkedLogsRepository.CreateLogBackfillError = fakes.FakeError
+
+				err := extractor.AddTransformerConfig(getTransformerConfig(100, defaultEndingBlockNumber))
+
+				Expect(err).To(MatchError(fakes.FakeError))
+			})
+		})
+
+		It("does not schedule a backfill when log was previously watched", func() {
+			checkedLogsRepository.AlreadyWatchingLogReturn = true
+			checkedLogsRepository.UnwatchedAddressesReturn = []string{fakes.FakeAddress.Hex()}
+			checkedHeadersRepository.LastCheckedBlockNumberReturn = 500
+
+			err := extractor.AddTransformerConfig(getTransformerConfig(100, defaultEndingBlockNumber))
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(checkedLogsRepository.MarkLogWatchedAddresses).To(BeNil())
+			Expect(checkedLogsRepository.CreatedLogBackfills).To(BeEmpty())
 		})
 	})
 
@@ -260,6 +406,96 @@ var _ = Describe("Log extractor", func() {
 				Expect(mockLogFetcher.ContractAddresses).To(Equal(expectedAddresses))
 			})
 
+			It("fetches logs matching the transformer's topic1-3 filters", func() {
+				addUncheckedHeader(extractor)
+				config := event.TransformerConfig{
+					ContractAddresses:   []string{fakes.FakeAddress.Hex()},
+					Topic:               fakes.FakeHash.Hex(),
+					Topic2:              []string{fakes.AnotherFakeAddress.Hex()},
+					StartingBlockNumber: rand.Int63(),
+				}
+				addTransformerErr := extractor.AddTransformerConfig(config)
+				Expect(addTransformerErr).NotTo(HaveOccurred())
+				mockLogFetcher := &mocks.MockLogFetcher{}
+				extractor.Fetcher = mockLogFetcher
+
+				err := extractor.ExtractLogs(constants.HeaderUnchecked)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mockLogFetcher.QueryTopics).To(Equal([][]common.Hash{
+					{common.HexToHash(config.Topic)},
+					nil,
+					{common.BytesToHash(fakes.AnotherFakeAddress.Bytes())},
+				}))
+			})
+
+			It("fetches logs with any topics once an anonymous transformer is added", func() {
+				addUncheckedHeader(extractor)
+				addTransformerConfig(extractor)
+				anonymousConfig := event.TransformerConfig{
+					ContractAddresses:   []string{fakes.AnotherFakeAddress.Hex()},
+					Anonymous:           true,
+					StartingBlockNumber: rand.Int63(),
+				}
+				Expect(extractor.AddTransformerConfig(anonymousConfig)).To(Succeed())
+				mockLogFetcher := &mocks.MockLogFetcher{}
+				extractor.Fetcher = mockLogFetcher
+
+				err := extractor.ExtractLogs(constants.HeaderUnchecked)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mockLogFetcher.FetchCalled).To(BeTrue())
+				Expect(mockLogFetcher.QueryTopics).To(BeEmpty())
+				Expect(mockLogFetcher.ContractAddresses).To(ContainElement(fakes.AnotherFakeAddress))
+			})
+
+			It("fetches logs with any topics from only the anonymous transformer's addresses", func() {
+				addUncheckedHeader(extractor)
+				config := event.TransformerConfig{
+					ContractAddresses:   []string{fakes.FakeAddress.Hex()},
+					Topic:               fakes.FakeHash.Hex(),
+					StartingBlockNumber: rand.Int63(),
+				}
+				Expect(extractor.AddTransformerConfig(config)).To(Succeed())
+				anonymousConfig := event.TransformerConfig{
+					ContractAddresses:   []string{fakes.AnotherFakeAddress.Hex()},
+					Anonymous:           true,
+					StartingBlockNumber: rand.Int63(),
+				}
+				Expect(extractor.AddTransformerConfig(anonymousConfig)).To(Succeed())
+				mockLogFetcher := &mocks.MockLogFetcher{}
+				extractor.Fetcher = mockLogFetcher
+
+				err := extractor.ExtractLogs(constants.HeaderUnchecked)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mockLogFetcher.PassedAddresses).To(Equal([][]common.Address{
+					{fakes.FakeAddress}, {fakes.AnotherFakeAddress},
+				}))
+				Expect(mockLogFetcher.PassedQueryTopics).To(Equal([][][]common.Hash{{{fakes.FakeHash}}, {}}))
+			})
+
+			It("persists logs from both the topic0 and anonymous queries", func() {
+				addUncheckedHeader(extractor)
+				addTransformerConfig(extractor)
+				anonymousConfig := event.TransformerConfig{
+					ContractAddresses:   []string{fakes.AnotherFakeAddress.Hex()},
+					Anonymous:           true,
+					StartingBlockNumber: rand.Int63(),
+				}
+				Expect(extractor.AddTransformerConfig(anonymousConfig)).To(Succeed())
+				mockLogFetcher := &mocks.MockLogFetcher{ReturnLogs: []types.Log{{Data: []byte{}}}}
+				extractor.Fetcher = mockLogFetcher
+				mockLogRepository := &fakes.MockEventLogRepository{}
+				extractor.LogRepository = mockLogRepository
+
+				err := extractor.ExtractLogs(constants.HeaderUnchecked)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(len(mockLogFetcher.PassedHeaders)).To(Equal(2))
+				Expect(mockLogRepository.PassedLogs).To(HaveLen(2))
+			})
+
 			It("returns error if fetching logs fails", func() {
 				addUncheckedHeader(extractor)
 				addTransformerConfig(extractor)
@@ -364,6 +600,40 @@ var _ = Describe("Log extractor", func() {
 				Expect(mockCheckedHeadersRepository.MarkHeaderCheckedHeaderID).To(Equal(headerID))
 			})
 
+			Describe("when only finalized headers are checked", func() {
+				var mockCheckedHeadersRepository *fakes.MockCheckedHeadersRepository
+
+				BeforeEach(func() {
+					addTransformerConfig(extractor)
+					extractor.FinalizedOnly = true
+					mockCheckedHeadersRepository = &fakes.MockCheckedHeadersRepository{}
+					extractor.CheckedHeadersRepository = mockCheckedHeadersRepository
+				})
+
+				It("checks finalized headers", func() {
+					addFetchedLog(extractor)
+					headerID := rand.Int63()
+					mockCheckedHeadersRepository.UncheckedHeadersReturnHeaders = []core.Header{{Id: headerID, Finalized: true}}
+
+					err := extractor.ExtractLogs(constants.HeaderUnchecked)
+
+					Expect(err).NotTo(HaveOccurred())
+					Expect(mockCheckedHeadersRepository.MarkHeaderCheckedHeaderID).To(Equal(headerID))
+				})
+
+				It("leaves headers that aren't finalized unchecked", func() {
+					mockLogFetcher := &mocks.MockLogFetcher{}
+					extractor.Fetcher = mockLogFetcher
+					mockCheckedHeadersRepository.UncheckedHeadersReturnHeaders = []core.Header{{Id: rand.Int63()}}
+
+					err := extractor.ExtractLogs(constants.HeaderUnchecked)
+
+					Expect(err).To(MatchError(logs.ErrNoUncheckedHeaders))
+					Expect(mockLogFetcher.FetchCalled).To(BeFalse())
+					Expect(mockCheckedHeadersRepository.MarkHeaderCheckedHeaderID).To(BeZero())
+				})
+			})
+
 			It("returns error if marking header checked fails", func() {
 				addFetchedLog(extractor)
 				addTransformerConfig(extractor)
@@ -387,6 +657,423 @@ var _ = Describe("Log extractor", func() {
 				Expect(err).NotTo(HaveOccurred())
 			})
 		})
+
+		Describe("when there are pending log backfills", func() {
+			var (
+				backfill         core.LogBackfill
+				headerRepository *fakes.MockHeaderRepository
+				mockLogFetcher   *mocks.MockLogFetcher
+			)
+
+			BeforeEach(func() {
+				addTransformerConfig(extractor)
+				backfill = core.LogBackfill{
+					Addresses:      []string{common.HexToAddress("0x123").Hex()},
+					Topic0:         common.HexToHash("0x456").Hex(),
+					FromBlock:      100,
+					ToBlock:        100 + logs.HeaderChunkSize*2,
+					CheckedThrough: 99,
+				}
+				checkedLogsRepository.PendingLogBackfillsReturn = []core.LogBackfill{backfill}
+				headerRepository = &fakes.MockHeaderRepository{AllHeaders: []core.Header{fakeHeader(1, 100)}}
+				extractor.HeaderRepository = headerRepository
+				mockLogFetcher = &mocks.MockLogFetcher{}
+				extractor.Fetcher = mockLogFetcher
+			})
+
+			It("checks the next chunk of headers even if there are no unchecked headers", func() {
+				err := extractor.ExtractLogs(constants.HeaderUnchecked)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(headerRepository.GetHeadersInRangeStartingBlocks).To(Equal([]int64{100}))
+				Expect(headerRepository.GetHeadersInRangeEndingBlocks).To(Equal([]int64{99 + logs.HeaderChunkSize}))
+			})
+
+			It("fetches logs for only the backfill's addresses and topic", func() {
+				err := extractor.ExtractLogs(constants.HeaderUnchecked)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mockLogFetcher.ContractAddresses).To(Equal(event.HexStringsToAddresses(backfill.Addresses)))
+				Expect(mockLogFetcher.Topics).To(Equal([]common.Hash{common.HexToHash(backfill.Topic0)}))
+			})
+
+			It("fetches logs with any topics for an anonymous transformer's backfill", func() {
+				backfill.Topic0 = ""
+				checkedLogsRepository.PendingLogBackfillsReturn = []core.LogBackfill{backfill}
+
+				err := extractor.ExtractLogs(constants.HeaderUnchecked)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mockLogFetcher.ContractAddresses).To(Equal(event.HexStringsToAddresses(backfill.Addresses)))
+				Expect(mockLogFetcher.QueryTopics).To(BeEmpty())
+			})
+
+			It("records progress without marking headers checked", func() {
+				err := extractor.ExtractLogs(constants.HeaderUnchecked)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(checkedLogsRepository.UpdatedLogBackfills).To(Equal([]core.LogBackfill{backfill}))
+				Expect(checkedLogsRepository.UpdatedLogBackfillCheckedThrough).To(Equal([]int64{99 + logs.HeaderChunkSize}))
+				Expect(checkedHeadersRepository.MarkHeaderCheckedHeaderIDs).To(BeEmpty())
+			})
+
+			It("ends the last chunk at the backfill's final block", func() {
+				backfill.CheckedThrough = backfill.ToBlock - 10
+				checkedLogsRepository.PendingLogBackfillsReturn = []core.LogBackfill{backfill}
+
+				err := extractor.ExtractLogs(constants.HeaderUnchecked)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(checkedLogsRepository.UpdatedLogBackfillCheckedThrough).To(Equal([]int64{backfill.ToBlock}))
+			})
+
+			It("checks unchecked headers for all watched logs first", func() {
+				checkedHeadersRepository.UncheckedHeadersReturnHeaders = []core.Header{fakeHeader(2, 5000)}
+
+				err := extractor.ExtractLogs(constants.HeaderUnchecked)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(checkedHeadersRepository.MarkHeaderCheckedHeaderIDs).To(Equal([]int64{2}))
+				Expect(checkedLogsRepository.UpdatedLogBackfills).To(Equal([]core.LogBackfill{backfill}))
+			})
+
+			Describe("when only finalized headers are checked", func() {
+				BeforeEach(func() {
+					extractor.FinalizedOnly = true
+				})
+
+				It("stops the chunk before the first header that isn't finalized", func() {
+					finalizedHeader := fakeHeader(1, 100)
+					finalizedHeader.Finalized = true
+					headerRepository.AllHeaders = []core.Header{finalizedHeader, fakeHeader(2, 101), fakeHeader(3, 102)}
+
+					err := extractor.ExtractLogs(constants.HeaderUnchecked)
+
+					Expect(err).NotTo(HaveOccurred())
+					Expect(mockLogFetcher.FetchCalled).To(BeTrue())
+					Expect(checkedLogsRepository.UpdatedLogBackfillCheckedThrough).To(Equal([]int64{100}))
+				})
+
+				It("doesn't check headers or record progress until the next block is finalized", func() {
+					err := extractor.ExtractLogs(constants.HeaderUnchecked)
+
+					Expect(err).NotTo(HaveOccurred())
+					Expect(mockLogFetcher.FetchCalled).To(BeFalse())
+					Expect(checkedLogsRepository.UpdatedLogBackfills).To(BeEmpty())
+				})
+			})
+
+			It("does not record progress if fetching logs fails", func() {
+				mockLogFetcher.ReturnError = fakes.FakeError
+
+				err := extractor.ExtractLogs(constants.HeaderUnchecked)
+
+				Expect(err).To(MatchError(fakes.FakeError))
+				Expect(checkedLogsRepository.UpdatedLogBackfills).To(BeEmpty())
+			})
+
+			It("returns error if getting pending backfills fails", func() {
+				checkedLogsRepository.PendingLogBackfillsError = fakes.FakeError
+
+				err := extractor.ExtractLogs(constants.HeaderUnchecked)
+
+				Expect(err).To(MatchError(fakes.FakeError))
+			})
+
+			It("returns error if recording progress fails", func() {
+				checkedLogsRepository.UpdateLogBackfillError = fakes.FakeError
+
+				err := extractor.ExtractLogs(constants.HeaderUnchecked)
+
+				Expect(err).To(MatchError(fakes.FakeError))
+			})
+		})
+
+		Describe("when checking headers concurrently", func() {
+			var (
+				mockCheckedHeadersRepository *fakes.MockCheckedHeadersRepository
+				headers                      []core.Header
+			)
+
+			BeforeEach(func() {
+				addTransformerConfig(extractor)
+				extractor.Workers = 3
+				extractor.WorkerBackoff = time.Millisecond
+				headers = []core.Header{fakeHeader(1, 1), fakeHeader(2, 2), fakeHeader(3, 3)}
+				mockCheckedHeadersRepository = &fakes.MockCheckedHeadersRepository{UncheckedHeadersReturnHeaders: headers}
+				extractor.CheckedHeadersRepository = mockCheckedHeadersRepository
+			})
+
+			It("checks headers on several workers at once", func() {
+				fetcher := &concurrentLogFetcher{arrived: make(chan struct{}, len(headers)), expected: len(headers)}
+				extractor.Fetcher = fetcher
+
+				err := extractor.ExtractLogs(constants.HeaderUnchecked)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mockCheckedHeadersRepository.MarkHeaderCheckedHeaderIDs).To(ConsistOf(int64(1), int64(2), int64(3)))
+			})
+
+			It("retries a header after backing off", func() {
+				extractor.Fetcher = &concurrentLogFetcher{failures: 2}
+
+				err := extractor.ExtractLogs(constants.HeaderUnchecked)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mockCheckedHeadersRepository.MarkHeaderCheckedHeaderIDs).To(ConsistOf(int64(1), int64(2), int64(3)))
+			})
+
+			It("returns error without marking the header checked once its retries are exhausted", func() {
+				extractor.Fetcher = &concurrentLogFetcher{failures: 100}
+
+				err := extractor.ExtractLogs(constants.HeaderUnchecked)
+
+				Expect(err).To(MatchError(fakes.FakeError))
+				Expect(mockCheckedHeadersRepository.MarkHeaderCheckedHeaderIDs).To(BeEmpty())
+			})
+		})
+
+		Describe("when fetching logs by block range", func() {
+			var (
+				mockLogFetcher               *mocks.MockLogFetcher
+				mockLogRepository            *fakes.MockEventLogRepository
+				mockCheckedHeadersRepository *fakes.MockCheckedHeadersRepository
+				headers                      []core.Header
+			)
+
+			BeforeEach(func() {
+				addTransformerConfig(extractor)
+				extractor.LogRangeSize = 4
+				mockLogFetcher = &mocks.MockLogFetcher{}
+				extractor.Fetcher = mockLogFetcher
+				mockLogRepository = &fakes.MockEventLogRepository{}
+				extractor.LogRepository = mockLogRepository
+				headers = []core.Header{
+					fakeHeader(5, 5), fakeHeader(1, 1), fakeHeader(3, 3), fakeHeader(2, 2),
+				}
+				mockCheckedHeadersRepository = &fakes.MockCheckedHeadersRepository{UncheckedHeadersReturnHeaders: headers}
+				extractor.CheckedHeadersRepository = mockCheckedHeadersRepository
+				extractor.BlockChain = canonicalBlockChain(headers...)
+			})
+
+			It("fetches logs for spans of at most LogRangeSize blocks", func() {
+				err := extractor.ExtractLogs(constants.HeaderUnchecked)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mockLogFetcher.PassedRanges).To(Equal([][2]int64{{1, 3}, {5, 5}}))
+				Expect(mockLogFetcher.FetchCalled).To(BeFalse())
+			})
+
+			It("persists logs with the header matching their block hash and marks headers checked", func() {
+				mockLogFetcher.ReturnRangeLogs = []types.Log{fakeLogAt(headers[2]), fakeLogAt(headers[1])}
+
+				err := extractor.ExtractLogs(constants.HeaderUnchecked)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mockLogRepository.CreatedLogs).To(Equal(map[int64][]types.Log{
+					1: {fakeLogAt(headers[1])},
+					3: {fakeLogAt(headers[2])},
+				}))
+				Expect(mockCheckedHeadersRepository.MarkHeaderCheckedHeaderID).To(Equal(int64(5)))
+			})
+
+			It("fetches logs by hash for a header whose height has logs from another block", func() {
+				staleLog := fakeLogAt(headers[3])
+				staleLog.BlockHash = common.HexToHash("0xabc")
+				mockLogFetcher.ReturnRangeLogs = []types.Log{staleLog}
+
+				err := extractor.ExtractLogs(constants.HeaderUnchecked)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mockLogFetcher.PassedHeaders).To(Equal([]core.Header{headers[3]}))
+				Expect(mockLogRepository.CreatedLogs).To(BeEmpty())
+			})
+
+			It("verifies the hashes of headers without logs in the span against the node", func() {
+				mockLogFetcher.ReturnRangeLogs = []types.Log{fakeLogAt(headers[2])}
+				mockBlockChain := canonicalBlockChain(headers...)
+				extractor.BlockChain = mockBlockChain
+
+				err := extractor.ExtractLogs(constants.HeaderUnchecked)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mockBlockChain.GetHeadersByNumbersPassedNumbers).To(Equal([][]int64{{1, 2}, {5}}))
+				Expect(mockLogFetcher.PassedHeaders).To(BeEmpty())
+			})
+
+			It("fetches logs by hash for a header without logs in the span that isn't the node's block", func() {
+				staleHeader := headers[3]
+				staleHeader.Hash = common.HexToHash("0xabc").Hex()
+				extractor.BlockChain = canonicalBlockChain(headers[0], headers[1], headers[2], staleHeader)
+
+				err := extractor.ExtractLogs(constants.HeaderUnchecked)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mockLogFetcher.PassedHeaders).To(Equal([]core.Header{headers[3]}))
+			})
+
+			It("fetches logs by hash for a header without logs in the span that the node has no block for", func() {
+				extractor.BlockChain = canonicalBlockChain(headers[0], headers[1], headers[2])
+
+				err := extractor.ExtractLogs(constants.HeaderUnchecked)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mockLogFetcher.PassedHeaders).To(Equal([]core.Header{headers[3]}))
+			})
+
+			It("returns error without marking headers checked if verifying header hashes fails", func() {
+				mockBlockChain := canonicalBlockChain(headers...)
+				mockBlockChain.GetHeadersByNumbersErr = fakes.FakeError
+				extractor.BlockChain = mockBlockChain
+
+				err := extractor.ExtractLogs(constants.HeaderUnchecked)
+
+				Expect(err).To(MatchError(fakes.FakeError))
+				Expect(mockCheckedHeadersRepository.MarkHeaderCheckedHeaderID).To(BeZero())
+			})
+
+			It("returns error if fetching logs for a header with a mismatched hash fails", func() {
+				staleLog := fakeLogAt(headers[3])
+				staleLog.BlockHash = common.HexToHash("0xabc")
+				mockLogFetcher.ReturnRangeLogs = []types.Log{staleLog}
+				mockLogFetcher.ReturnError = fakes.FakeError
+
+				err := extractor.ExtractLogs(constants.HeaderUnchecked)
+
+				Expect(err).To(MatchError(fakes.FakeError))
+				Expect(mockCheckedHeadersRepository.MarkHeaderCheckedHeaderID).To(Equal(int64(1)))
+			})
+
+			It("returns error if fetching logs for a span fails", func() {
+				mockLogFetcher.ReturnRangeError = fakes.FakeError
+
+				err := extractor.ExtractLogs(constants.HeaderUnchecked)
+
+				Expect(err).To(MatchError(fakes.FakeError))
+				Expect(mockCheckedHeadersRepository.MarkHeaderCheckedHeaderID).To(BeZero())
+			})
+		})
+
+		Describe("when prefiltering headers with logsBloom", func() {
+			var (
+				mockLogFetcher               *mocks.MockLogFetcher
+				mockCheckedHeadersRepository *fakes.MockCheckedHeadersRepository
+				watchedLog                   types.Log
+			)
+
+			BeforeEach(func() {
+				addTransformerConfig(extractor)
+				extractor.BloomFilter = true
+				extractor.BloomFilterStats = &logs.BloomFilterStats{}
+				mockLogFetcher = &mocks.MockLogFetcher{}
+				extractor.Fetcher = mockLogFetcher
+				mockCheckedHeadersRepository = &fakes.MockCheckedHeadersRepository{}
+				extractor.CheckedHeadersRepository = mockCheckedHeadersRepository
+				watchedLog = types.Log{Address: fakes.FakeAddress, Topics: []common.Hash{fakes.FakeHash}}
+			})
+
+			It("marks a header checked without fetching logs if its bloom has none of the watched logs", func() {
+				otherLog := types.Log{Address: fakes.AnotherFakeAddress, Topics: []common.Hash{fakes.FakeHash}}
+				mockCheckedHeadersRepository.UncheckedHeadersReturnHeaders = []core.Header{headerWithBloom(1, otherLog)}
+
+				err := extractor.ExtractLogs(constants.HeaderUnchecked)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mockLogFetcher.FetchCalled).To(BeFalse())
+				Expect(mockCheckedHeadersRepository.MarkHeaderCheckedHeaderIDs).To(Equal([]int64{1}))
+				Expect(extractor.BloomFilterStats.Tested()).To(Equal(int64(1)))
+				Expect(extractor.BloomFilterStats.Skipped()).To(Equal(int64(1)))
+			})
+
+			It("publishes the number of headers tested and skipped as a metric", func() {
+				otherLog := types.Log{Address: fakes.AnotherFakeAddress, Topics: []common.Hash{fakes.FakeHash}}
+				mockCheckedHeadersRepository.UncheckedHeadersReturnHeaders = []core.Header{headerWithBloom(1, otherLog)}
+				extractor.BloomFilterStats.Publish("testLogsBloomFilter")
+
+				err := extractor.ExtractLogs(constants.HeaderUnchecked)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(expvar.Get("testLogsBloomFilter").String()).To(MatchJSON(`{"tested": 1, "skipped": 1, "skipRate": 1}`))
+			})
+
+			It("fetches logs for a header whose bloom may have a watched log", func() {
+				mockCheckedHeadersRepository.UncheckedHeadersReturnHeaders = []core.Header{headerWithBloom(1, watchedLog)}
+
+				err := extractor.ExtractLogs(constants.HeaderUnchecked)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mockLogFetcher.FetchCalled).To(BeTrue())
+				Expect(extractor.BloomFilterStats.Tested()).To(Equal(int64(1)))
+				Expect(extractor.BloomFilterStats.Skipped()).To(BeZero())
+			})
+
+			It("fetches logs for a header whose bloom has a watched address once an anonymous transformer is added", func() {
+				anonymousConfig := event.TransformerConfig{
+					ContractAddresses: []string{fakes.AnotherFakeAddress.Hex()},
+					Anonymous:         true,
+				}
+				Expect(extractor.AddTransformerConfig(anonymousConfig)).To(Succeed())
+				topiclessLog := types.Log{Address: fakes.AnotherFakeAddress}
+				mockCheckedHeadersRepository.UncheckedHeadersReturnHeaders = []core.Header{headerWithBloom(1, topiclessLog)}
+
+				err := extractor.ExtractLogs(constants.HeaderUnchecked)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mockLogFetcher.FetchCalled).To(BeTrue())
+			})
+
+			It("fetches logs for a header without a logsBloom", func() {
+				mockCheckedHeadersRepository.UncheckedHeadersReturnHeaders = []core.Header{fakeHeader(1, 1)}
+
+				err := extractor.ExtractLogs(constants.HeaderUnchecked)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mockLogFetcher.FetchCalled).To(BeTrue())
+			})
+
+			It("fetches logs regardless of bloom if the prefilter is disabled", func() {
+				extractor.BloomFilter = false
+				mockCheckedHeadersRepository.UncheckedHeadersReturnHeaders = []core.Header{headerWithBloom(1)}
+
+				err := extractor.ExtractLogs(constants.HeaderUnchecked)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mockLogFetcher.FetchCalled).To(BeTrue())
+				Expect(extractor.BloomFilterStats.Tested()).To(BeZero())
+			})
+
+			Describe("when fetching logs by block range", func() {
+				BeforeEach(func() {
+					extractor.LogRangeSize = 4
+					extractor.BlockChain = canonicalBlockChain(headerWithBloom(1), headerWithBloom(2))
+				})
+
+				It("skips the range query if no header in the span may have a watched log", func() {
+					mockCheckedHeadersRepository.UncheckedHeadersReturnHeaders = []core.Header{
+						headerWithBloom(1), headerWithBloom(2),
+					}
+
+					err := extractor.ExtractLogs(constants.HeaderUnchecked)
+
+					Expect(err).NotTo(HaveOccurred())
+					Expect(mockLogFetcher.PassedRanges).To(BeEmpty())
+					Expect(mockCheckedHeadersRepository.MarkHeaderCheckedHeaderIDs).To(Equal([]int64{1, 2}))
+					Expect(extractor.BloomFilterStats.Skipped()).To(Equal(int64(2)))
+				})
+
+				It("queries the span if any header may have a watched log", func() {
+					mockCheckedHeadersRepository.UncheckedHeadersReturnHeaders = []core.Header{
+						headerWithBloom(1), headerWithBloom(2, watchedLog),
+					}
+
+					err := extractor.ExtractLogs(constants.HeaderUnchecked)
+
+					Expect(err).NotTo(HaveOccurred())
+					Expect(len(mockLogFetcher.PassedRanges)).To(Equal(1))
+					Expect(mockCheckedHeadersRepository.MarkHeaderCheckedHeaderIDs).To(Equal([]int64{1, 2}))
+				})
+			})
+		})
 	})
 
 	Describe("BackFillLogs", func() {
@@ -499,6 +1186,26 @@ var _ = Describe("Log extractor", func() {
 			Expect(mockTransactionSyncer.SyncTransactionsCalled).To(BeFalse())
 		})
 
+		It("fetches logs for spans of headers when fetching by block range", func() {
+			startingBlock := addTransformerConfig(extractor)
+			extractor.LogRangeSize = 2
+			mockLogFetcher := &mocks.MockLogFetcher{}
+			extractor.Fetcher = mockLogFetcher
+			mockHeaderRepository := &fakes.MockHeaderRepository{}
+			mockHeaderRepository.AllHeaders = []core.Header{
+				fakeHeader(1, startingBlock), fakeHeader(2, startingBlock+1), fakeHeader(3, startingBlock+2),
+			}
+			extractor.HeaderRepository = mockHeaderRepository
+			extractor.BlockChain = canonicalBlockChain(mockHeaderRepository.AllHeaders...)
+
+			err := extractor.BackFillLogs(startingBlock + 2)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(mockLogFetcher.PassedRanges).To(Equal([][2]int64{
+				{startingBlock, startingBlock + 1}, {startingBlock + 2, startingBlock + 2},
+			}))
+		})
+
 		Describe("when there are fetched logs", func() {
 			It("syncs transactions", func() {
 				addHeaderInRange(extractor)
@@ -616,6 +1323,70 @@ func addHeaderInRange(extractor *logs.LogExtractor) {
 	extractor.HeaderRepository = mockHeadersRepository
 }
 
+// concurrentLogFetcher fails its first failures calls, and if expected is set, blocks each call until that many
+// calls are in progress at once
+type concurrentLogFetcher struct {
+	mocks.MockLogFetcher
+	mu       sync.Mutex
+	failures int
+	arrived  chan struct{}
+	expected int
+}
+
+func (fetcher *concurrentLogFetcher) FetchLogs([]common.Address, [][]common.Hash, core.Header) ([]types.Log, error) {
+	fetcher.mu.Lock()
+	if fetcher.failures > 0 {
+		fetcher.failures--
+		fetcher.mu.Unlock()
+		return nil, fakes.FakeError
+	}
+	fetcher.mu.Unlock()
+
+	if fetcher.expected > 0 {
+		fetcher.arrived <- struct{}{}
+		Eventually(func() int { return len(fetcher.arrived) }).Should(Equal(fetcher.expected))
+	}
+	return nil, nil
+}
+
+func fakeHeader(id, blockNumber int64) core.Header {
+	return core.Header{
+		Id:          id,
+		BlockNumber: blockNumber,
+		Hash:        common.BigToHash(big.NewInt(blockNumber)).Hex(),
+	}
+}
+
+// headerWithBloom returns a header whose raw JSON has a logsBloom of the given logs
+func headerWithBloom(blockNumber int64, logs ...types.Log) core.Header {
+	var bloomLogs []*types.Log
+	for i := range logs {
+		bloomLogs = append(bloomLogs, &logs[i])
+	}
+	bloom := types.BytesToBloom(types.LogsBloom(bloomLogs).Bytes())
+	header := fakeHeader(blockNumber, blockNumber)
+	header.Raw = []byte(fmt.Sprintf(`{"logsBloom":"%s"}`, hexutil.Encode(bloom.Bytes())))
+	return header
+}
+
+// canonicalBlockChain returns a mock blockchain with the given headers as its blocks at their heights
+func canonicalBlockChain(headers ...core.Header) *fakes.MockBlockChain {
+	blockChain := fakes.NewMockBlockChain()
+	blockChain.HeadersByNumber = make(map[int64]core.Header)
+	for _, header := range headers {
+		blockChain.HeadersByNumber[header.BlockNumber] = header
+	}
+	return blockChain
+}
+
+func fakeLogAt(header core.Header) types.Log {
+	return types.Log{
+		BlockNumber: uint64(header.BlockNumber),
+		BlockHash:   common.HexToHash(header.Hash),
+		Data:        []byte{},
+	}
+}
+
 func addFetchedLog(extractor *logs.LogExtractor) {
 	mockLogFetcher := &mocks.MockLogFetcher{}
 	mockLogFetcher.ReturnLogs = []types.Log{{}}
@@ -0,0 +1,96 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package mocks
+
+import (
+	"github.com/makerdao/vulcanizedb/libraries/shared/storage"
+	"github.com/makerdao/vulcanizedb/libraries/shared/storage/types"
+)
+
+type MockPruneRepository struct {
+	HeadBlockNumber                             int64
+	GetHeadBlockNumberError                     error
+	DiffsToPrune                                []types.ArchivedDiff
+	GetDiffsToPruneError                        error
+	GetDiffsToPrunePassedFilters                []storage.PruneFilter
+	DeleteDiffsPassedIDs                        [][]int64
+	DeleteDiffsError                            error
+	MoveDiffsToArchiveTablePassedIDs            [][]int64
+	MoveDiffsToArchiveTableError                error
+	InsertArchivedDiffsPassedDiffs              []types.ArchivedDiff
+	InsertArchivedDiffsError                    error
+	RestoreDiffsFromArchiveTablePassedFromBlock int64
+	RestoreDiffsFromArchiveTablePassedToBlock   int64
+	RestoreDiffsFromArchiveTableError           error
+}
+
+func (repository *MockPruneRepository) GetHeadBlockNumber() (int64, error) {
+	return repository.HeadBlockNumber, repository.GetHeadBlockNumberError
+}
+
+// GetDiffsToPrune pages through DiffsToPrune like the real query, returning diffs with IDs above the filter's MinID
+func (repository *MockPruneRepository) GetDiffsToPrune(filter storage.PruneFilter) ([]types.ArchivedDiff, error) {
+	repository.GetDiffsToPrunePassedFilters = append(repository.GetDiffsToPrunePassedFilters, filter)
+	var result []types.ArchivedDiff
+	for _, diff := range repository.DiffsToPrune {
+		if diff.ID > filter.MinID && len(result) < filter.Limit {
+			result = append(result, diff)
+		}
+	}
+	return result, repository.GetDiffsToPruneError
+}
+
+func (repository *MockPruneRepository) DeleteDiffs(ids []int64) (int64, error) {
+	repository.DeleteDiffsPassedIDs = append(repository.DeleteDiffsPassedIDs, ids)
+	return int64(len(ids)), repository.DeleteDiffsError
+}
+
+func (repository *MockPruneRepository) MoveDiffsToArchiveTable(ids []int64) (int64, error) {
+	repository.MoveDiffsToArchiveTablePassedIDs = append(repository.MoveDiffsToArchiveTablePassedIDs, ids)
+	return int64(len(ids)), repository.MoveDiffsToArchiveTableError
+}
+
+func (repository *MockPruneRepository) InsertArchivedDiffs(diffs []types.ArchivedDiff) (int64, error) {
+	repository.InsertArchivedDiffsPassedDiffs = diffs
+	return int64(len(diffs)), repository.InsertArchivedDiffsError
+}
+
+func (repository *MockPruneRepository) RestoreDiffsFromArchiveTable(fromBlock, toBlock int64) (int64, error) {
+	repository.RestoreDiffsFromArchiveTablePassedFromBlock = fromBlock
+	repository.RestoreDiffsFromArchiveTablePassedToBlock = toBlock
+	return 0, repository.RestoreDiffsFromArchiveTableError
+}
+
+type MockDiffArchive struct {
+	WritePassedDiffs    [][]types.ArchivedDiff
+	WriteError          error
+	ReadPassedFromBlock int64
+	ReadPassedToBlock   int64
+	ReadDiffs           []types.ArchivedDiff
+	ReadError           error
+}
+
+func (archive *MockDiffArchive) Write(diffs []types.ArchivedDiff) error {
+	archive.WritePassedDiffs = append(archive.WritePassedDiffs, diffs)
+	return archive.WriteError
+}
+
+func (archive *MockDiffArchive) Read(fromBlock, toBlock int64) ([]types.ArchivedDiff, error) {
+	archive.ReadPassedFromBlock = fromBlock
+	archive.ReadPassedToBlock = toBlock
+	return archive.ReadDiffs, archive.ReadError
+}
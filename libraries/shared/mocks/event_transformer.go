@@ -26,19 +26,34 @@ import (
 type MockEventTransformer struct {
 	ExecuteWasCalled bool
 	ExecuteError     error
+	FailingLogIDs    map[int64]bool // when set, ExecuteError is only returned for logs including one of these
 	PassedLogs       []core.EventLog
+	TransformedLogs  []core.EventLog
 	config           event.TransformerConfig
 }
 
 func (t *MockEventTransformer) Execute(logs []core.EventLog) error {
-	if t.ExecuteError != nil {
+	if t.ExecuteError != nil && t.failsOn(logs) {
 		return t.ExecuteError
 	}
 	t.ExecuteWasCalled = true
 	t.PassedLogs = logs
+	t.TransformedLogs = append(t.TransformedLogs, logs...)
 	return nil
 }
 
+func (t *MockEventTransformer) failsOn(logs []core.EventLog) bool {
+	if t.FailingLogIDs == nil {
+		return true
+	}
+	for _, log := range logs {
+		if t.FailingLogIDs[log.ID] {
+			return true
+		}
+	}
+	return false
+}
+
 func (t *MockEventTransformer) GetConfig() event.TransformerConfig {
 	return t.config
 }
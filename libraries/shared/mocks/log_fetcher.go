@@ -28,13 +28,37 @@ type MockLogFetcher struct {
 	MissingHeader     core.Header
 	ReturnError       error
 	ReturnLogs        []types.Log
-	Topics            []common.Hash
+	Topics            []common.Hash // topic0s passed in the most recent query
+	QueryTopics       [][]common.Hash
+	PassedAddresses   [][]common.Address // addresses passed in each query
+	PassedQueryTopics [][][]common.Hash  // topics passed in each query
+	PassedRanges      [][2]int64
+	PassedHeaders     []core.Header
+	ReturnRangeError  error
+	ReturnRangeLogs   []types.Log
 }
 
-func (fetcher *MockLogFetcher) FetchLogs(contractAddresses []common.Address, topics []common.Hash, missingHeader core.Header) ([]types.Log, error) {
+func (fetcher *MockLogFetcher) FetchLogs(contractAddresses []common.Address, topics [][]common.Hash, missingHeader core.Header) ([]types.Log, error) {
 	fetcher.FetchCalled = true
-	fetcher.ContractAddresses = contractAddresses
-	fetcher.Topics = topics
+	fetcher.recordQuery(contractAddresses, topics)
 	fetcher.MissingHeader = missingHeader
+	fetcher.PassedHeaders = append(fetcher.PassedHeaders, missingHeader)
 	return fetcher.ReturnLogs, fetcher.ReturnError
 }
+
+func (fetcher *MockLogFetcher) FetchLogsInRange(contractAddresses []common.Address, topics [][]common.Hash, fromBlock, toBlock int64) ([]types.Log, error) {
+	fetcher.recordQuery(contractAddresses, topics)
+	fetcher.PassedRanges = append(fetcher.PassedRanges, [2]int64{fromBlock, toBlock})
+	return fetcher.ReturnRangeLogs, fetcher.ReturnRangeError
+}
+
+func (fetcher *MockLogFetcher) recordQuery(contractAddresses []common.Address, topics [][]common.Hash) {
+	fetcher.ContractAddresses = contractAddresses
+	fetcher.PassedAddresses = append(fetcher.PassedAddresses, contractAddresses)
+	fetcher.PassedQueryTopics = append(fetcher.PassedQueryTopics, topics)
+	fetcher.QueryTopics = topics
+	fetcher.Topics = nil
+	if len(topics) > 0 {
+		fetcher.Topics = topics[0]
+	}
+}
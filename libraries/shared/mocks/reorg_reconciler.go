@@ -0,0 +1,52 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package mocks
+
+import (
+	"sync"
+
+	"github.com/makerdao/vulcanizedb/libraries/shared/storage/types"
+	"github.com/makerdao/vulcanizedb/pkg/core"
+)
+
+type MockReorgReconciler struct {
+	ReconcilePassedBlockHeights []int64
+	ReconcileError              error
+	mutex                       sync.Mutex
+}
+
+func (reconciler *MockReorgReconciler) Reconcile(blockHeight int64) (types.Reconciliation, error) {
+	reconciler.mutex.Lock()
+	defer reconciler.mutex.Unlock()
+	reconciler.ReconcilePassedBlockHeights = append(reconciler.ReconcilePassedBlockHeights, blockHeight)
+	return types.Reconciliation{BlockHeight: blockHeight}, reconciler.ReconcileError
+}
+
+type MockReconciliationRepository struct {
+	ReconcileBlockPassedHeader    core.Header
+	ReconcileBlockStaleHeaderHash string
+	ReconcileBlockError           error
+}
+
+func (repository *MockReconciliationRepository) ReconcileBlock(canonicalHeader core.Header) (types.Reconciliation, error) {
+	repository.ReconcileBlockPassedHeader = canonicalHeader
+	return types.Reconciliation{
+		BlockHeight:     canonicalHeader.BlockNumber,
+		CanonicalHash:   canonicalHeader.Hash,
+		StaleHeaderHash: repository.ReconcileBlockStaleHeaderHash,
+	}, repository.ReconcileBlockError
+}
@@ -17,6 +17,8 @@
 package mocks
 
 import (
+	"sync"
+
 	"github.com/makerdao/vulcanizedb/libraries/shared/storage/types"
 )
 
@@ -37,13 +39,19 @@ type MockStorageDiffRepository struct {
 	GetUnrecognizedDiffsPassedMinIDs           []int
 	GetUnrecognizedDiffsPassedLimits           []int
 	MarkTransformedPassedID                    int64
+	MarkTransformedPassedIDs                   []int64
 	MarkUnrecognizedPassedID                   int64
 	MarkNoncanonicalPassedID                   int64
 	MarkPendingPassedID                        int64
 	MarkUnwatchedPassedID                      int64
+	RecordFailedAttemptPassedIDs               []int64
+	RecordFailedAttemptPassedErrors            []error
+	RecordFailedAttemptPassedMaxAttempts       int
+	RecordFailedAttemptError                   error
 	GetFirstDiffIDToReturn                     int64
 	GetFirstDiffIDErr                          error
 	GetFirstDiffBlockHeightPassed              int64
+	mutex                                      sync.Mutex
 }
 
 func (repository *MockStorageDiffRepository) CreateStorageDiff(rawDiff types.RawDiff) (int64, error) {
@@ -87,11 +95,16 @@ func (repository *MockStorageDiffRepository) GetPendingDiffs(minID, limit int) (
 }
 
 func (repository *MockStorageDiffRepository) MarkTransformed(id int64) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
 	repository.MarkTransformedPassedID = id
+	repository.MarkTransformedPassedIDs = append(repository.MarkTransformedPassedIDs, id)
 	return nil
 }
 
 func (repository *MockStorageDiffRepository) MarkNoncanonical(id int64) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
 	repository.MarkNoncanonicalPassedID = id
 	return nil
 }
@@ -101,20 +114,35 @@ func (repository *MockStorageDiffRepository) MarkNoncanonicalDiffsAsNew(blockNum
 }
 
 func (repository *MockStorageDiffRepository) MarkUnrecognized(id int64) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
 	repository.MarkUnrecognizedPassedID = id
 	return nil
 }
 
 func (repository *MockStorageDiffRepository) MarkUnwatched(id int64) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
 	repository.MarkUnwatchedPassedID = id
 	return nil
 }
 
 func (repository *MockStorageDiffRepository) MarkPending(id int64) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
 	repository.MarkPendingPassedID = id
 	return nil
 }
 
+func (repository *MockStorageDiffRepository) RecordFailedAttempt(id int64, attemptErr error, maxAttempts int) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+	repository.RecordFailedAttemptPassedIDs = append(repository.RecordFailedAttemptPassedIDs, id)
+	repository.RecordFailedAttemptPassedErrors = append(repository.RecordFailedAttemptPassedErrors, attemptErr)
+	repository.RecordFailedAttemptPassedMaxAttempts = maxAttempts
+	return repository.RecordFailedAttemptError
+}
+
 func (repository *MockStorageDiffRepository) GetFirstDiffIDForBlockHeight(blockHeight int64) (int64, error) {
 	repository.GetFirstDiffBlockHeightPassed = blockHeight
 	return repository.GetFirstDiffIDToReturn, repository.GetFirstDiffIDErr
@@ -0,0 +1,38 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package mocks
+
+import (
+	"github.com/makerdao/vulcanizedb/libraries/shared/storage/layout"
+	"github.com/makerdao/vulcanizedb/pkg/datastore/postgres"
+)
+
+type MockKeySource struct {
+	GetKeysPassedMappings []string
+	GetKeysError          error
+	Keys                  map[string][][]string
+	SetDBCalled           bool
+}
+
+func (source *MockKeySource) GetKeys(mapping layout.MappingTemplate) ([][]string, error) {
+	source.GetKeysPassedMappings = append(source.GetKeysPassedMappings, mapping.Name)
+	return source.Keys[mapping.Name], source.GetKeysError
+}
+
+func (source *MockKeySource) SetDB(db *postgres.DB) {
+	source.SetDBCalled = true
+}
@@ -0,0 +1,54 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package storage
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/makerdao/vulcanizedb/pkg/datastore/postgres"
+	"github.com/makerdao/vulcanizedb/pkg/fs"
+)
+
+// CheckpointRepository saves the position in a storage diffs csv to public.storage_diff_checkpoints
+type CheckpointRepository struct {
+	db     *postgres.DB
+	source string
+}
+
+// NewCheckpointRepository returns a repository for the checkpoint of the csv at source
+func NewCheckpointRepository(db *postgres.DB, source string) CheckpointRepository {
+	return CheckpointRepository{db: db, source: source}
+}
+
+func (repository CheckpointRepository) Load() (fs.Checkpoint, error) {
+	var checkpoint fs.Checkpoint
+	err := repository.db.Get(&checkpoint, `SELECT fingerprint, byte_offset FROM public.storage_diff_checkpoints
+		WHERE source = $1`, repository.source)
+	if errors.Is(err, sql.ErrNoRows) {
+		return checkpoint, nil
+	}
+	return checkpoint, err
+}
+
+func (repository CheckpointRepository) Save(checkpoint fs.Checkpoint) error {
+	_, err := repository.db.Exec(`INSERT INTO public.storage_diff_checkpoints (source, fingerprint, byte_offset)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (source) DO UPDATE SET fingerprint = $2, byte_offset = $3, updated = NOW()`,
+		repository.source, checkpoint.Fingerprint, checkpoint.Offset)
+	return err
+}
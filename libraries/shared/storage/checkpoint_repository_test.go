@@ -0,0 +1,64 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package storage_test
+
+import (
+	"github.com/makerdao/vulcanizedb/libraries/shared/storage"
+	"github.com/makerdao/vulcanizedb/pkg/fs"
+	"github.com/makerdao/vulcanizedb/test_config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Checkpoint repository", func() {
+	var (
+		db   = test_config.NewTestDB(test_config.NewTestNode())
+		repo storage.CheckpointRepository
+	)
+
+	BeforeEach(func() {
+		test_config.CleanTestDB(db)
+		repo = storage.NewCheckpointRepository(db, "/data/diffs.csv")
+	})
+
+	It("returns an empty checkpoint if none has been saved", func() {
+		checkpoint, err := repo.Load()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(checkpoint).To(Equal(fs.Checkpoint{}))
+	})
+
+	It("loads the last saved checkpoint", func() {
+		Expect(repo.Save(fs.Checkpoint{Fingerprint: "first", Offset: 10})).To(Succeed())
+		Expect(repo.Save(fs.Checkpoint{Fingerprint: "second", Offset: 20})).To(Succeed())
+
+		checkpoint, err := repo.Load()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(checkpoint).To(Equal(fs.Checkpoint{Fingerprint: "second", Offset: 20}))
+	})
+
+	It("keeps checkpoints for different sources apart", func() {
+		Expect(repo.Save(fs.Checkpoint{Fingerprint: "first", Offset: 10})).To(Succeed())
+		otherRepo := storage.NewCheckpointRepository(db, "/data/other.csv")
+
+		checkpoint, err := otherRepo.Load()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(checkpoint).To(Equal(fs.Checkpoint{}))
+	})
+})
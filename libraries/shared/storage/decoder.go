@@ -19,13 +19,16 @@ package storage
 import (
 	"fmt"
 	"math/big"
+	"strconv"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/makerdao/vulcanizedb/libraries/shared/storage/types"
 )
 
 const (
-	bitsPerByte = 8
+	bitsPerByte  = 8
+	bytesPerSlot = 32
 )
 
 func Decode(diff types.PersistedDiff, metadata types.ValueMetadata) interface{} {
@@ -56,8 +59,142 @@ func Decode(diff types.PersistedDiff, metadata types.ValueMetadata) interface{}
 			return err
 		}
 		return decodedSlotData
+	case types.String, types.Bytes:
+		decodedValue, err := decodeShortDynamicBytes(diff.StorageValue.Bytes(), metadata.Type)
+		if err != nil {
+			return err
+		}
+		return decodedValue
+	case types.DynamicArray:
+		return decodeInteger(diff.StorageValue.Bytes())
+	default:
+		// remaining types occupy the lowest-order bytes of their slot, as they would if packed
+		numberOfBytes := getNumberOfBytes(metadata.Type)
+		if numberOfBytes == 0 {
+			return fmt.Errorf("can't decode unknown type: %d", metadata.Type)
+		}
+		raw := diff.StorageValue.Bytes()
+		decodedValue, err := decodeIndividualItem(raw[len(raw)-numberOfBytes:], metadata.Type)
+		if err != nil {
+			return err
+		}
+		return decodedValue
+	}
+}
+
+// DecodeDynamicBytes reassembles a string or bytes value from the diff to its length-tagged slot and the diffs to its
+// data slots from the same block. Short values (31 bytes or fewer) are decoded from the length slot alone.
+func DecodeDynamicBytes(lengthDiff types.PersistedDiff, dataDiffs []types.PersistedDiff, valueType types.ValueType) (string, error) {
+	lengthSlot := lengthDiff.StorageValue.Bytes()
+	if !isLongDynamicBytes(lengthSlot) {
+		return decodeShortDynamicBytes(lengthSlot, valueType)
+	}
+
+	length := new(big.Int).Rsh(new(big.Int).SetBytes(lengthSlot), 1)
+	numberOfSlots, slotsErr := getNumberOfDataSlots(length, bytesPerSlot, len(dataDiffs))
+	if slotsErr != nil {
+		return "", slotsErr
+	}
+	data, dataErr := collectDataSlots(lengthDiff, dataDiffs, numberOfSlots)
+	if dataErr != nil {
+		return "", dataErr
+	}
+	return formatDynamicBytes(data[:length.Int64()], valueType)
+}
+
+// DecodeDynamicArray reassembles the elements of a dynamic array from the diff to its length slot and the diffs to its
+// element slots from the same block. Elements narrower than a slot are packed together, starting from the lowest-order bytes.
+func DecodeDynamicArray(lengthDiff types.PersistedDiff, elementDiffs []types.PersistedDiff, elementType types.ValueType) ([]string, error) {
+	elementSize := getNumberOfBytes(elementType)
+	if elementSize == 0 {
+		return nil, fmt.Errorf("can't decode dynamic array of unknown type: %d", elementType)
+	}
+	elementsPerSlot := int64(bytesPerSlot / elementSize)
+	numberOfSlots, slotsErr := getNumberOfDataSlots(lengthDiff.StorageValue.Big(), elementsPerSlot, len(elementDiffs))
+	if slotsErr != nil {
+		return nil, slotsErr
+	}
+	length := lengthDiff.StorageValue.Big().Int64()
+	data, dataErr := collectDataSlots(lengthDiff, elementDiffs, numberOfSlots)
+	if dataErr != nil {
+		return nil, dataErr
+	}
+
+	var result []string
+	for i := int64(0); i < length; i++ {
+		slotEnd := (i/elementsPerSlot + 1) * bytesPerSlot
+		itemEnd := slotEnd - (i%elementsPerSlot)*int64(elementSize)
+		itemBytes := data[itemEnd-int64(elementSize) : itemEnd]
+		decoded := Decode(types.PersistedDiff{RawDiff: types.RawDiff{StorageValue: common.BytesToHash(itemBytes)}},
+			types.ValueMetadata{Type: elementType})
+		if decodeErr, ok := decoded.(error); ok {
+			return nil, fmt.Errorf("error decoding dynamic array element %d: %w", i, decodeErr)
+		}
+		result = append(result, decoded.(string))
+	}
+	return result, nil
+}
+
+// getNumberOfDataSlots returns how many data slots hold a value of the given length, and an error if that's more slots
+// than were supplied, so a corrupt or malicious length can't make decoding allocate or slice out of range
+func getNumberOfDataSlots(length *big.Int, itemsPerSlot int64, suppliedSlots int) (int64, error) {
+	numberOfSlots := new(big.Int).Add(length, big.NewInt(itemsPerSlot-1))
+	numberOfSlots.Div(numberOfSlots, big.NewInt(itemsPerSlot))
+	if numberOfSlots.Cmp(big.NewInt(int64(suppliedSlots))) > 0 {
+		return 0, fmt.Errorf("%w: length %s needs %s data slots, but only %d were supplied", types.ErrMultiSlotValue,
+			length.String(), numberOfSlots.String(), suppliedSlots)
+	}
+	return numberOfSlots.Int64(), nil
+}
+
+// collectDataSlots concatenates the values of the data slots that begin at the keccak hash of the length slot's key
+func collectDataSlots(lengthDiff types.PersistedDiff, dataDiffs []types.PersistedDiff, numberOfSlots int64) ([]byte, error) {
+	valuesByKey := make(map[common.Hash]common.Hash)
+	for _, dataDiff := range dataDiffs {
+		if dataDiff.BlockHash != lengthDiff.BlockHash || dataDiff.Address != lengthDiff.Address {
+			continue
+		}
+		valuesByKey[dataDiff.StorageKey] = dataDiff.StorageValue
+	}
+
+	var data []byte
+	for _, key := range GetKeysForDynamicData(lengthDiff.StorageKey, numberOfSlots) {
+		value, ok := valuesByKey[key]
+		if !ok {
+			return nil, fmt.Errorf("%w: missing data slot %s in block %s", types.ErrMultiSlotValue, key.Hex(), lengthDiff.BlockHash.Hex())
+		}
+		data = append(data, value.Bytes()...)
+	}
+	return data, nil
+}
+
+// the lowest bit of a string or bytes slot is set when the value is stored in separate data slots
+func isLongDynamicBytes(lengthSlot []byte) bool {
+	return len(lengthSlot) > 0 && lengthSlot[len(lengthSlot)-1]&1 == 1
+}
+
+// short values are stored left-aligned in the slot, with twice their length in the lowest-order byte
+func decodeShortDynamicBytes(raw []byte, valueType types.ValueType) (string, error) {
+	if isLongDynamicBytes(raw) {
+		return "", fmt.Errorf("%w: length slot for value longer than 31 bytes", types.ErrMultiSlotValue)
+	}
+	slot := common.BytesToHash(raw).Bytes()
+	length := int(slot[bytesPerSlot-1] / 2)
+	if length > bytesPerSlot-1 {
+		return "", fmt.Errorf("%w: short value length %d exceeds %d bytes", types.ErrInvalidLength, length,
+			bytesPerSlot-1)
+	}
+	return formatDynamicBytes(slot[:length], valueType)
+}
+
+func formatDynamicBytes(data []byte, valueType types.ValueType) (string, error) {
+	switch valueType {
+	case types.String:
+		return string(data), nil
+	case types.Bytes:
+		return hexutil.Encode(data), nil
 	default:
-		return fmt.Errorf("can't decode unknown type: %d", metadata.Type)
+		return "", fmt.Errorf("can't decode unknown dynamic type: %d", valueType)
 	}
 }
 
@@ -66,6 +203,19 @@ func decodeInteger(raw []byte) string {
 	return n.String()
 }
 
+// decodeSignedInteger interprets raw as a two's complement integer as wide as raw
+func decodeSignedInteger(raw []byte) string {
+	n := big.NewInt(0).SetBytes(raw)
+	if len(raw) > 0 && raw[0]&0x80 != 0 {
+		n.Sub(n, big.NewInt(0).Lsh(big.NewInt(1), uint(len(raw)*bitsPerByte)))
+	}
+	return n.String()
+}
+
+func decodeBool(raw []byte) string {
+	return strconv.FormatBool(big.NewInt(0).SetBytes(raw).Sign() != 0)
+}
+
 func decodeAddress(raw []byte) string {
 	return common.BytesToAddress(raw).Hex()
 }
@@ -85,6 +235,9 @@ func decodePackedSlot(raw []byte, packedTypes map[int]types.ValueType) (map[int]
 		if lengthOfItem == 0 {
 			return nil, fmt.Errorf("invalid number of bytes at packed position")
 		}
+		if lengthOfItem > lengthOfStorageData {
+			return nil, fmt.Errorf("packed items exceed slot size at position %d", position)
+		}
 		itemStartingIndex := lengthOfStorageData - lengthOfItem
 		itemValueBytes := storageSlotData[itemStartingIndex:]
 
@@ -103,31 +256,50 @@ func decodePackedSlot(raw []byte, packedTypes map[int]types.ValueType) (map[int]
 }
 
 func decodeIndividualItem(itemBytes []byte, valueType types.ValueType) (string, error) {
-	switch valueType {
-	case types.Uint32, types.Uint48, types.Uint64, types.Uint128, types.Uint192:
+	switch {
+	case isUnsignedInteger(valueType):
 		return decodeInteger(itemBytes), nil
-	case types.Address:
+	case isSignedInteger(valueType):
+		return decodeSignedInteger(itemBytes), nil
+	case valueType == types.Bool:
+		return decodeBool(itemBytes), nil
+	case valueType == types.Address:
 		return decodeAddress(itemBytes), nil
+	case isFixedBytes(valueType):
+		return hexutil.Encode(itemBytes), nil
 	default:
 		return "", fmt.Errorf("can't decode unknown type: %d", valueType)
 	}
 }
 
+func isUnsignedInteger(valueType types.ValueType) bool {
+	bits, signed := types.IntegerBits(valueType)
+	return bits > 0 && !signed
+}
+
+func isSignedInteger(valueType types.ValueType) bool {
+	bits, signed := types.IntegerBits(valueType)
+	return bits > 0 && signed
+}
+
+func isFixedBytes(valueType types.ValueType) bool {
+	return valueType == types.Bytes32 || (valueType >= types.Bytes1 && valueType <= types.Bytes31)
+}
+
 func getNumberOfBytes(valueType types.ValueType) int {
+	if bits, _ := types.IntegerBits(valueType); bits > 0 {
+		return bits / bitsPerByte
+	}
 	switch valueType {
-	case types.Uint32:
-		return 32 / bitsPerByte
-	case types.Uint48:
-		return 48 / bitsPerByte
-	case types.Uint64:
-		return 64 / bitsPerByte
-	case types.Uint128:
-		return 128 / bitsPerByte
-	case types.Uint192:
-		return 192 / bitsPerByte
+	case types.Bool:
+		return 1
+	case types.Bytes32:
+		return bytesPerSlot
 	case types.Address:
 		return 20
-	default:
-		return 0
 	}
+	if valueType >= types.Bytes1 && valueType <= types.Bytes31 {
+		return int(valueType-types.Bytes1) + 1
+	}
+	return 0
 }
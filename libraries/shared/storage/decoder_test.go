@@ -108,7 +108,133 @@ var _ = Describe("Storage decoder", func() {
 		Expect(result).To(Equal(fakeAddress.Hex()))
 	})
 
+	It("decodes a positive int256", func() {
+		fakeInt := common.HexToHash("0000000000000000000000000000000000000000000000000000000000000539")
+		diff := types.PersistedDiff{RawDiff: types.RawDiff{StorageValue: fakeInt}}
+		metadata := types.ValueMetadata{Type: types.Int256}
+
+		result := storage.Decode(diff, metadata)
+
+		Expect(result).To(Equal("1337"))
+	})
+
+	It("decodes a negative int256", func() {
+		fakeInt := common.HexToHash("fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffac7")
+		diff := types.PersistedDiff{RawDiff: types.RawDiff{StorageValue: fakeInt}}
+		metadata := types.ValueMetadata{Type: types.Int256}
+
+		result := storage.Decode(diff, metadata)
+
+		Expect(result).To(Equal("-1337"))
+	})
+
+	It("decodes a negative int128 from the lowest-order bytes of the slot", func() {
+		fakeInt := common.HexToHash("00000000000000000000000000000000ffffffffffffffffffffffffffffffff")
+		diff := types.PersistedDiff{RawDiff: types.RawDiff{StorageValue: fakeInt}}
+		metadata := types.ValueMetadata{Type: types.Int128}
+
+		result := storage.Decode(diff, metadata)
+
+		Expect(result).To(Equal("-1"))
+	})
+
+	It("decodes a uint112 from the lowest-order bytes of the slot", func() {
+		fakeUint := common.HexToHash("ffff" + "000000000000000000000000000000000000000000000539")
+		diff := types.PersistedDiff{RawDiff: types.RawDiff{StorageValue: fakeUint}}
+		metadata := types.ValueMetadata{Type: types.Uint112}
+
+		result := storage.Decode(diff, metadata)
+
+		Expect(result).To(Equal("1337"))
+	})
+
+	It("decodes bool", func() {
+		trueDiff := types.PersistedDiff{RawDiff: types.RawDiff{StorageValue: common.HexToHash("01")}}
+		falseDiff := types.PersistedDiff{RawDiff: types.RawDiff{StorageValue: common.HexToHash("00")}}
+		metadata := types.ValueMetadata{Type: types.Bool}
+
+		Expect(storage.Decode(trueDiff, metadata)).To(Equal("true"))
+		Expect(storage.Decode(falseDiff, metadata)).To(Equal("false"))
+	})
+
+	It("decodes bytes4", func() {
+		fakeBytes := common.HexToHash("0000000000000000000000000000000000000000000000000000000012345678")
+		diff := types.PersistedDiff{RawDiff: types.RawDiff{StorageValue: fakeBytes}}
+		metadata := types.ValueMetadata{Type: types.Bytes4}
+
+		result := storage.Decode(diff, metadata)
+
+		Expect(result).To(Equal("0x12345678"))
+	})
+
 	Describe("when there are multiple items packed in the storage slot", func() {
+		It("decodes int128 + bool items", func() {
+			packedStorage := common.HexToHash("01" + "fffffffffffffffffffffffffffffac7")
+			diff := types.PersistedDiff{RawDiff: types.RawDiff{StorageValue: packedStorage}}
+			packedTypes := map[int]types.ValueType{0: types.Int128, 1: types.Bool}
+
+			metadata := types.ValueMetadata{
+				Type:        types.PackedSlot,
+				PackedTypes: packedTypes,
+			}
+
+			result := storage.Decode(diff, metadata)
+			decodedValues := result.(map[int]string)
+
+			Expect(decodedValues[0]).To(Equal("-1337"))
+			Expect(decodedValues[1]).To(Equal("true"))
+		})
+
+		It("decodes uint24 + int40 + uint160 items", func() {
+			packedStorage := common.HexToHash("00000000000000000000000000000000000000ff" + "fffffffac7" + "000539")
+			diff := types.PersistedDiff{RawDiff: types.RawDiff{StorageValue: packedStorage}}
+			packedTypes := map[int]types.ValueType{0: types.Uint24, 1: types.Int40, 2: types.Uint160}
+
+			metadata := types.ValueMetadata{
+				Type:        types.PackedSlot,
+				PackedTypes: packedTypes,
+			}
+
+			result := storage.Decode(diff, metadata)
+			decodedValues := result.(map[int]string)
+
+			Expect(decodedValues[0]).To(Equal("1337"))
+			Expect(decodedValues[1]).To(Equal("-1337"))
+			Expect(decodedValues[2]).To(Equal("255"))
+		})
+
+		It("decodes uint8 + uint96 + bytes2 items", func() {
+			packedStorage := common.HexToHash("abcd" + "000000000000000000000539" + "07")
+			diff := types.PersistedDiff{RawDiff: types.RawDiff{StorageValue: packedStorage}}
+			packedTypes := map[int]types.ValueType{0: types.Uint8, 1: types.Uint96, 2: types.Bytes2}
+
+			metadata := types.ValueMetadata{
+				Type:        types.PackedSlot,
+				PackedTypes: packedTypes,
+			}
+
+			result := storage.Decode(diff, metadata)
+			decodedValues := result.(map[int]string)
+
+			Expect(decodedValues[0]).To(Equal("7"))
+			Expect(decodedValues[1]).To(Equal("1337"))
+			Expect(decodedValues[2]).To(Equal("0xabcd"))
+		})
+
+		It("returns an error if packed items are wider than the slot", func() {
+			diff := types.PersistedDiff{RawDiff: types.RawDiff{StorageValue: common.HexToHash("01")}}
+			packedTypes := map[int]types.ValueType{0: types.Uint256, 1: types.Bool}
+
+			metadata := types.ValueMetadata{
+				Type:        types.PackedSlot,
+				PackedTypes: packedTypes,
+			}
+
+			result := storage.Decode(diff, metadata)
+
+			Expect(result).To(MatchError(ContainSubstring("packed items exceed slot size")))
+		})
+
 		It("decodes uint32 items", func() {
 			//TODO: this packedStorage was generated by hand, it would be nice to test this against
 			//real storage data that has several items packed into it
@@ -246,4 +372,195 @@ var _ = Describe("Storage decoder", func() {
 			Expect(decodedValues[1]).To(Equal(big.NewInt(0).SetBytes(common.HexToHash("348C771B1DE11359F9EE9B8D0C93800000000000").Bytes()).String()))
 		})
 	})
+
+	Describe("dynamic types", func() {
+		var lengthKey = common.HexToHash(storage.IndexThree)
+
+		It("decodes a short string from its length-tagged slot", func() {
+			// "hello" left-aligned, with 2 * length in the lowest-order byte
+			shortString := common.HexToHash("68656c6c6f00000000000000000000000000000000000000000000000000000a")
+			diff := types.PersistedDiff{RawDiff: types.RawDiff{StorageValue: shortString}}
+			metadata := types.ValueMetadata{Type: types.String}
+
+			result := storage.Decode(diff, metadata)
+
+			Expect(result).To(Equal("hello"))
+		})
+
+		It("decodes short bytes as hex", func() {
+			shortBytes := common.HexToHash("0102030000000000000000000000000000000000000000000000000000000006")
+			diff := types.PersistedDiff{RawDiff: types.RawDiff{StorageValue: shortBytes}}
+			metadata := types.ValueMetadata{Type: types.Bytes}
+
+			result := storage.Decode(diff, metadata)
+
+			Expect(result).To(Equal("0x010203"))
+		})
+
+		It("returns an error if a string's value is stored in separate data slots", func() {
+			diff := types.PersistedDiff{RawDiff: types.RawDiff{StorageValue: common.BigToHash(big.NewInt(81))}}
+			metadata := types.ValueMetadata{Type: types.String}
+
+			result := storage.Decode(diff, metadata)
+
+			Expect(result).To(MatchError(types.ErrMultiSlotValue))
+		})
+
+		It("returns an error if a short value's length doesn't fit in its slot", func() {
+			diff := types.PersistedDiff{RawDiff: types.RawDiff{StorageValue: common.BigToHash(big.NewInt(0xfe))}}
+			metadata := types.ValueMetadata{Type: types.Bytes}
+
+			result := storage.Decode(diff, metadata)
+
+			Expect(result).To(MatchError(types.ErrInvalidLength))
+		})
+
+		It("decodes a dynamic array's length", func() {
+			diff := types.PersistedDiff{RawDiff: types.RawDiff{StorageValue: common.BigToHash(big.NewInt(3))}}
+			metadata := types.ValueMetadata{Type: types.DynamicArray}
+
+			result := storage.Decode(diff, metadata)
+
+			Expect(result).To(Equal("3"))
+		})
+
+		Describe("DecodeDynamicBytes", func() {
+			var (
+				blockHash  common.Hash
+				lengthDiff types.PersistedDiff
+				dataDiffs  []types.PersistedDiff
+				longString = "a string value that needs two data slots"
+			)
+
+			BeforeEach(func() {
+				blockHash = common.HexToHash("0x123")
+				// 2 * length + 1 flags the value as stored in data slots
+				lengthDiff = types.PersistedDiff{RawDiff: types.RawDiff{
+					BlockHash:    blockHash,
+					StorageKey:   lengthKey,
+					StorageValue: common.BigToHash(big.NewInt(int64(len(longString)*2 + 1))),
+				}}
+				dataKeys := storage.GetKeysForDynamicData(lengthKey, 2)
+				dataDiffs = []types.PersistedDiff{
+					{RawDiff: types.RawDiff{
+						BlockHash:    blockHash,
+						StorageKey:   dataKeys[1],
+						StorageValue: common.BytesToHash(common.RightPadBytes([]byte(longString[32:]), 32)),
+					}},
+					{RawDiff: types.RawDiff{
+						BlockHash:    blockHash,
+						StorageKey:   dataKeys[0],
+						StorageValue: common.BytesToHash([]byte(longString[:32])),
+					}},
+				}
+			})
+
+			It("reassembles a long string from its data slots", func() {
+				result, err := storage.DecodeDynamicBytes(lengthDiff, dataDiffs, types.String)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result).To(Equal(longString))
+			})
+
+			It("decodes a short value without data slots", func() {
+				shortDiff := types.PersistedDiff{RawDiff: types.RawDiff{
+					StorageValue: common.HexToHash("68656c6c6f00000000000000000000000000000000000000000000000000000a"),
+				}}
+
+				result, err := storage.DecodeDynamicBytes(shortDiff, nil, types.String)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result).To(Equal("hello"))
+			})
+
+			It("returns an error if a data slot is missing", func() {
+				_, err := storage.DecodeDynamicBytes(lengthDiff, dataDiffs[:1], types.String)
+
+				Expect(err).To(MatchError(types.ErrMultiSlotValue))
+			})
+
+			It("ignores data slot diffs from other blocks", func() {
+				dataDiffs[0].BlockHash = common.HexToHash("0x456")
+
+				_, err := storage.DecodeDynamicBytes(lengthDiff, dataDiffs, types.String)
+
+				Expect(err).To(MatchError(types.ErrMultiSlotValue))
+			})
+
+			It("returns an error if the length needs more data slots than were supplied", func() {
+				lengthDiff.StorageValue = common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+
+				_, err := storage.DecodeDynamicBytes(lengthDiff, dataDiffs, types.String)
+
+				Expect(err).To(MatchError(types.ErrMultiSlotValue))
+			})
+		})
+
+		Describe("DecodeDynamicArray", func() {
+			It("decodes full slot elements", func() {
+				lengthDiff := types.PersistedDiff{RawDiff: types.RawDiff{
+					StorageKey:   lengthKey,
+					StorageValue: common.BigToHash(big.NewInt(2)),
+				}}
+				elementDiffs := []types.PersistedDiff{
+					{RawDiff: types.RawDiff{
+						StorageKey:   storage.GetKeyForDynamicArrayElement(lengthKey, 0, 1),
+						StorageValue: common.BigToHash(big.NewInt(1337)),
+					}},
+					{RawDiff: types.RawDiff{
+						StorageKey:   storage.GetKeyForDynamicArrayElement(lengthKey, 1, 1),
+						StorageValue: common.BigToHash(big.NewInt(42)),
+					}},
+				}
+
+				result, err := storage.DecodeDynamicArray(lengthDiff, elementDiffs, types.Uint256)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result).To(Equal([]string{"1337", "42"}))
+			})
+
+			It("decodes elements packed several to a slot", func() {
+				lengthDiff := types.PersistedDiff{RawDiff: types.RawDiff{
+					StorageKey:   lengthKey,
+					StorageValue: common.BigToHash(big.NewInt(3)),
+				}}
+				elementDiffs := []types.PersistedDiff{
+					{RawDiff: types.RawDiff{
+						StorageKey:   storage.GetKeyForDynamicArrayElement(lengthKey, 0, 1),
+						StorageValue: common.HexToHash("000000030000000200000001"),
+					}},
+				}
+
+				result, err := storage.DecodeDynamicArray(lengthDiff, elementDiffs, types.Uint32)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result).To(Equal([]string{"1", "2", "3"}))
+			})
+
+			It("returns an error if the length needs more element slots than were supplied", func() {
+				lengthDiff := types.PersistedDiff{RawDiff: types.RawDiff{
+					StorageKey:   lengthKey,
+					StorageValue: common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"),
+				}}
+				elementDiffs := []types.PersistedDiff{
+					{RawDiff: types.RawDiff{
+						StorageKey:   storage.GetKeyForDynamicArrayElement(lengthKey, 0, 1),
+						StorageValue: common.BigToHash(big.NewInt(1337)),
+					}},
+				}
+
+				_, err := storage.DecodeDynamicArray(lengthDiff, elementDiffs, types.Uint256)
+
+				Expect(err).To(MatchError(types.ErrMultiSlotValue))
+			})
+
+			It("returns an error for element types of unknown width", func() {
+				lengthDiff := types.PersistedDiff{RawDiff: types.RawDiff{StorageValue: common.BigToHash(big.NewInt(1))}}
+
+				_, err := storage.DecodeDynamicArray(lengthDiff, nil, types.PackedSlot)
+
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
 })
@@ -0,0 +1,152 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package storage
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/makerdao/vulcanizedb/libraries/shared/storage/types"
+)
+
+const archiveFilePattern = "storage_diffs_%d-%d_%d.ndjson.gz"
+
+// DiffArchive stores pruned diffs outside of the database
+type DiffArchive interface {
+	Write(diffs []types.ArchivedDiff) error
+	Read(fromBlock, toBlock int64) ([]types.ArchivedDiff, error)
+}
+
+// fileArchive writes each batch of diffs to a gzipped file of newline-delimited JSON. Files are named for the
+// batch's block range and first diff ID, so restoring a block range only reads the files that overlap it.
+type fileArchive struct {
+	dir string
+}
+
+func NewFileArchive(dir string) fileArchive {
+	return fileArchive{dir: dir}
+}
+
+func (archive fileArchive) Write(diffs []types.ArchivedDiff) error {
+	if len(diffs) == 0 {
+		return nil
+	}
+	minBlock, maxBlock := diffs[0].BlockHeight, diffs[0].BlockHeight
+	for _, diff := range diffs {
+		if diff.BlockHeight < minBlock {
+			minBlock = diff.BlockHeight
+		}
+		if diff.BlockHeight > maxBlock {
+			maxBlock = diff.BlockHeight
+		}
+	}
+
+	mkdirErr := os.MkdirAll(archive.dir, 0755)
+	if mkdirErr != nil {
+		return fmt.Errorf("error creating archive directory %s: %w", archive.dir, mkdirErr)
+	}
+	path := filepath.Join(archive.dir, fmt.Sprintf(archiveFilePattern, minBlock, maxBlock, diffs[0].ID))
+	// write to a temporary file and rename it, so an interrupted write never leaves a truncated archive
+	tmpPath := path + ".tmp"
+	writeErr := writeArchiveFile(tmpPath, diffs)
+	if writeErr != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("error writing archive file %s: %w", path, writeErr)
+	}
+	return os.Rename(tmpPath, path)
+}
+
+func writeArchiveFile(path string, diffs []types.ArchivedDiff) error {
+	file, createErr := os.Create(path)
+	if createErr != nil {
+		return createErr
+	}
+	defer file.Close()
+
+	gzipWriter := gzip.NewWriter(file)
+	encoder := json.NewEncoder(gzipWriter)
+	for _, diff := range diffs {
+		encodeErr := encoder.Encode(diff)
+		if encodeErr != nil {
+			return encodeErr
+		}
+	}
+	closeErr := gzipWriter.Close()
+	if closeErr != nil {
+		return closeErr
+	}
+	// the diffs are deleted once this returns, so make sure they're on disk
+	return file.Sync()
+}
+
+// Read returns archived diffs in the block range, ordered by ID
+func (archive fileArchive) Read(fromBlock, toBlock int64) ([]types.ArchivedDiff, error) {
+	files, readDirErr := ioutil.ReadDir(archive.dir)
+	if readDirErr != nil {
+		return nil, fmt.Errorf("error reading archive directory %s: %w", archive.dir, readDirErr)
+	}
+
+	var result []types.ArchivedDiff
+	for _, file := range files {
+		var minBlock, maxBlock, firstID int64
+		_, scanErr := fmt.Sscanf(file.Name(), archiveFilePattern, &minBlock, &maxBlock, &firstID)
+		if scanErr != nil || filepath.Ext(file.Name()) != ".gz" || maxBlock < fromBlock || minBlock > toBlock {
+			continue
+		}
+		diffs, readErr := readArchiveFile(filepath.Join(archive.dir, file.Name()), fromBlock, toBlock)
+		if readErr != nil {
+			return nil, fmt.Errorf("error reading archive file %s: %w", file.Name(), readErr)
+		}
+		result = append(result, diffs...)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result, nil
+}
+
+func readArchiveFile(path string, fromBlock, toBlock int64) ([]types.ArchivedDiff, error) {
+	file, openErr := os.Open(path)
+	if openErr != nil {
+		return nil, openErr
+	}
+	defer file.Close()
+
+	gzipReader, gzipErr := gzip.NewReader(file)
+	if gzipErr != nil {
+		return nil, gzipErr
+	}
+	defer gzipReader.Close()
+
+	var result []types.ArchivedDiff
+	decoder := json.NewDecoder(bufio.NewReader(gzipReader))
+	for decoder.More() {
+		var diff types.ArchivedDiff
+		decodeErr := decoder.Decode(&diff)
+		if decodeErr != nil {
+			return nil, decodeErr
+		}
+		if diff.BlockHeight >= fromBlock && diff.BlockHeight <= toBlock {
+			result = append(result, diff)
+		}
+	}
+	return result, nil
+}
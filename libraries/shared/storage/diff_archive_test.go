@@ -0,0 +1,103 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package storage_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/makerdao/vulcanizedb/libraries/shared/storage"
+	"github.com/makerdao/vulcanizedb/libraries/shared/storage/types"
+	"github.com/makerdao/vulcanizedb/libraries/shared/test_data"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("File diff archive", func() {
+	var (
+		dir     string
+		archive storage.DiffArchive
+	)
+
+	BeforeEach(func() {
+		var dirErr error
+		dir, dirErr = ioutil.TempDir("", "diff_archive")
+		Expect(dirErr).NotTo(HaveOccurred())
+		archive = storage.NewFileArchive(filepath.Join(dir, "archive"))
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	fakeArchivedDiff := func(id, blockHeight int64) types.ArchivedDiff {
+		lastError := "error"
+		return types.ArchivedDiff{
+			ID:           id,
+			Address:      test_data.FakeAddress(),
+			BlockHeight:  blockHeight,
+			BlockHash:    test_data.FakeHash(),
+			StorageKey:   test_data.FakeHash(),
+			StorageValue: test_data.FakeHash(),
+			EthNodeID:    1,
+			Status:       storage.Transformed,
+			Created:      time.Unix(1600000000, 0).UTC(),
+			Updated:      time.Unix(1600000001, 0).UTC(),
+			Attempts:     2,
+			LastError:    &lastError,
+		}
+	}
+
+	It("writes a gzipped file named for the batch's block range", func() {
+		writeErr := archive.Write([]types.ArchivedDiff{fakeArchivedDiff(3, 12), fakeArchivedDiff(4, 10)})
+
+		Expect(writeErr).NotTo(HaveOccurred())
+		files, readDirErr := ioutil.ReadDir(filepath.Join(dir, "archive"))
+		Expect(readDirErr).NotTo(HaveOccurred())
+		Expect(len(files)).To(Equal(1))
+		Expect(files[0].Name()).To(Equal("storage_diffs_10-12_3.ndjson.gz"))
+	})
+
+	It("reads back diffs in a block range across files", func() {
+		first, second, third := fakeArchivedDiff(1, 10), fakeArchivedDiff(2, 15), fakeArchivedDiff(3, 20)
+		Expect(archive.Write([]types.ArchivedDiff{first, second})).To(Succeed())
+		Expect(archive.Write([]types.ArchivedDiff{third})).To(Succeed())
+
+		diffs, readErr := archive.Read(15, 20)
+
+		Expect(readErr).NotTo(HaveOccurred())
+		Expect(diffs).To(Equal([]types.ArchivedDiff{second, third}))
+	})
+
+	It("ignores other files in the directory", func() {
+		Expect(archive.Write([]types.ArchivedDiff{fakeArchivedDiff(1, 10)})).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "archive", "notes.txt"), []byte("notes"), 0644)).To(Succeed())
+
+		diffs, readErr := archive.Read(0, 100)
+
+		Expect(readErr).NotTo(HaveOccurred())
+		Expect(len(diffs)).To(Equal(1))
+	})
+
+	It("returns an error if the directory doesn't exist", func() {
+		_, readErr := archive.Read(0, 100)
+
+		Expect(readErr).To(HaveOccurred())
+	})
+})
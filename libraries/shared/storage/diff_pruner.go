@@ -0,0 +1,143 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/makerdao/vulcanizedb/libraries/shared/storage/types"
+	"github.com/makerdao/vulcanizedb/pkg/datastore/postgres"
+	"github.com/sirupsen/logrus"
+)
+
+var ErrInvalidBatchSize = errors.New("prune batch size must be positive")
+
+// DefaultPruneStatuses are the statuses of diffs the storage watcher never revisits and transformer tables don't
+// reference. Transformed diffs can also be pruned, but those referenced by a transformer table's diff_id are always
+// kept, since deleting them would cascade to the transformed data.
+var DefaultPruneStatuses = []string{Unwatched, Noncanonical}
+
+// DiffPruner archives and deletes diffs more than Depth blocks below the head of the chain. Diffs are moved to
+// public.storage_diff_archive unless an Archive is set.
+type DiffPruner struct {
+	Repository PruneRepository
+	Archive    DiffArchive
+	Depth      int64
+	Statuses   []string
+	BatchSize  int
+}
+
+func NewDiffPruner(db *postgres.DB, archive DiffArchive, depth int64, statuses []string, batchSize int) DiffPruner {
+	return DiffPruner{
+		Repository: NewPruneRepository(db),
+		Archive:    archive,
+		Depth:      depth,
+		Statuses:   statuses,
+		BatchSize:  batchSize,
+	}
+}
+
+// Prune archives and deletes matching diffs in batches, returning how many were pruned. Diffs referenced by
+// transformer tables are kept.
+func (pruner DiffPruner) Prune() (int64, error) {
+	if pruner.BatchSize <= 0 {
+		return 0, ErrInvalidBatchSize
+	}
+	head, headErr := pruner.Repository.GetHeadBlockNumber()
+	if headErr != nil {
+		return 0, fmt.Errorf("error getting head block number: %w", headErr)
+	}
+	filter := PruneFilter{
+		MaxBlockHeight: head - pruner.Depth,
+		Statuses:       pruner.Statuses,
+		Limit:          pruner.BatchSize,
+	}
+	if filter.MaxBlockHeight < 0 {
+		return 0, nil
+	}
+
+	var total int64
+	for {
+		diffs, getErr := pruner.Repository.GetDiffsToPrune(filter)
+		if getErr != nil {
+			return total, fmt.Errorf("error getting diffs to prune: %w", getErr)
+		}
+		if len(diffs) == 0 {
+			break
+		}
+		pruned, pruneErr := pruner.pruneBatch(diffs)
+		if pruneErr != nil {
+			return total, pruneErr
+		}
+		total += pruned
+		logrus.Debugf("pruned %d storage diffs up to id %d", pruned, diffs[len(diffs)-1].ID)
+		if len(diffs) < pruner.BatchSize {
+			break
+		}
+		filter.MinID = diffs[len(diffs)-1].ID
+	}
+	return total, nil
+}
+
+func (pruner DiffPruner) pruneBatch(diffs []types.ArchivedDiff) (int64, error) {
+	ids := make([]int64, len(diffs))
+	for i, diff := range diffs {
+		ids[i] = diff.ID
+	}
+	if pruner.Archive == nil {
+		return pruner.Repository.MoveDiffsToArchiveTable(ids)
+	}
+	writeErr := pruner.Archive.Write(diffs)
+	if writeErr != nil {
+		return 0, fmt.Errorf("error archiving storage diffs: %w", writeErr)
+	}
+	return pruner.Repository.DeleteDiffs(ids)
+}
+
+// Restore returns archived diffs in the block range to public.storage_diff with their original IDs and statuses
+func (pruner DiffPruner) Restore(fromBlock, toBlock int64) (int64, error) {
+	if pruner.Archive == nil {
+		return pruner.Repository.RestoreDiffsFromArchiveTable(fromBlock, toBlock)
+	}
+	diffs, readErr := pruner.Archive.Read(fromBlock, toBlock)
+	if readErr != nil {
+		return 0, fmt.Errorf("error reading archived storage diffs: %w", readErr)
+	}
+	return pruner.Repository.InsertArchivedDiffs(diffs)
+}
+
+// PruneContinuously prunes diffs every interval until quit is closed, logging rather than returning errors
+func (pruner DiffPruner) PruneContinuously(interval time.Duration, quit <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		pruned, pruneErr := pruner.Prune()
+		if pruned > 0 {
+			logrus.Infof("pruned %d storage diffs", pruned)
+		}
+		if pruneErr != nil {
+			logrus.Errorf("error pruning storage diffs: %s", pruneErr.Error())
+		}
+		select {
+		case <-quit:
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,150 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package storage_test
+
+import (
+	"github.com/makerdao/vulcanizedb/libraries/shared/mocks"
+	"github.com/makerdao/vulcanizedb/libraries/shared/storage"
+	"github.com/makerdao/vulcanizedb/libraries/shared/storage/types"
+	"github.com/makerdao/vulcanizedb/pkg/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Diff pruner", func() {
+	var (
+		repository *mocks.MockPruneRepository
+		archive    *mocks.MockDiffArchive
+		pruner     storage.DiffPruner
+		diffs      = []types.ArchivedDiff{
+			{ID: 1, BlockHeight: 10}, {ID: 2, BlockHeight: 11}, {ID: 5, BlockHeight: 12},
+		}
+	)
+
+	BeforeEach(func() {
+		repository = &mocks.MockPruneRepository{HeadBlockNumber: 100, DiffsToPrune: diffs}
+		archive = &mocks.MockDiffArchive{}
+		pruner = storage.DiffPruner{
+			Repository: repository,
+			Depth:      50,
+			Statuses:   storage.DefaultPruneStatuses,
+			BatchSize:  2,
+		}
+	})
+
+	Describe("Prune", func() {
+		It("selects diffs at or below the configured depth from the head", func() {
+			_, err := pruner.Prune()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(repository.GetDiffsToPrunePassedFilters[0]).To(Equal(storage.PruneFilter{
+				MaxBlockHeight: 50,
+				Statuses:       storage.DefaultPruneStatuses,
+				Limit:          2,
+			}))
+		})
+
+		It("doesn't prune if the chain is shorter than the depth", func() {
+			repository.HeadBlockNumber = 10
+
+			pruned, err := pruner.Prune()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pruned).To(BeZero())
+			Expect(repository.GetDiffsToPrunePassedFilters).To(BeEmpty())
+		})
+
+		It("moves diffs to the archive table in batches when no archive is set", func() {
+			pruned, err := pruner.Prune()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pruned).To(Equal(int64(3)))
+			Expect(repository.MoveDiffsToArchiveTablePassedIDs).To(Equal([][]int64{{1, 2}, {5}}))
+			Expect(repository.GetDiffsToPrunePassedFilters[1].MinID).To(Equal(int64(2)))
+			Expect(repository.DeleteDiffsPassedIDs).To(BeEmpty())
+		})
+
+		It("writes each batch to the archive before deleting it", func() {
+			pruner.Archive = archive
+
+			pruned, err := pruner.Prune()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pruned).To(Equal(int64(3)))
+			Expect(archive.WritePassedDiffs).To(Equal([][]types.ArchivedDiff{diffs[:2], diffs[2:]}))
+			Expect(repository.DeleteDiffsPassedIDs).To(Equal([][]int64{{1, 2}, {5}}))
+		})
+
+		It("doesn't delete diffs if archiving them fails", func() {
+			pruner.Archive = archive
+			archive.WriteError = fakes.FakeError
+
+			_, err := pruner.Prune()
+
+			Expect(err).To(MatchError(fakes.FakeError))
+			Expect(repository.DeleteDiffsPassedIDs).To(BeEmpty())
+		})
+
+		It("returns an error if getting diffs fails", func() {
+			repository.GetDiffsToPruneError = fakes.FakeError
+
+			_, err := pruner.Prune()
+
+			Expect(err).To(MatchError(fakes.FakeError))
+		})
+
+		It("returns an error if the batch size isn't positive", func() {
+			pruner.BatchSize = 0
+
+			_, err := pruner.Prune()
+
+			Expect(err).To(MatchError(storage.ErrInvalidBatchSize))
+		})
+	})
+
+	Describe("Restore", func() {
+		It("restores from the archive table when no archive is set", func() {
+			_, err := pruner.Restore(10, 20)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(repository.RestoreDiffsFromArchiveTablePassedFromBlock).To(Equal(int64(10)))
+			Expect(repository.RestoreDiffsFromArchiveTablePassedToBlock).To(Equal(int64(20)))
+		})
+
+		It("inserts diffs read from the archive", func() {
+			pruner.Archive = archive
+			archive.ReadDiffs = diffs
+
+			restored, err := pruner.Restore(10, 20)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(restored).To(Equal(int64(3)))
+			Expect(archive.ReadPassedFromBlock).To(Equal(int64(10)))
+			Expect(archive.ReadPassedToBlock).To(Equal(int64(20)))
+			Expect(repository.InsertArchivedDiffsPassedDiffs).To(Equal(diffs))
+		})
+
+		It("returns an error if reading the archive fails", func() {
+			pruner.Archive = archive
+			archive.ReadError = fakes.FakeError
+
+			_, err := pruner.Restore(10, 20)
+
+			Expect(err).To(MatchError(fakes.FakeError))
+		})
+	})
+})
@@ -18,6 +18,10 @@ package storage
 
 import (
 	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lib/pq"
 
 	"github.com/makerdao/vulcanizedb/libraries/shared/storage/types"
 	"github.com/makerdao/vulcanizedb/pkg/datastore/postgres"
@@ -35,6 +39,7 @@ type DiffRepository interface {
 	MarkUnrecognized(id int64) error
 	MarkUnwatched(id int64) error
 	MarkPending(id int64) error
+	RecordFailedAttempt(id int64, attemptErr error, maxAttempts int) error
 	GetFirstDiffIDForBlockHeight(blockHeight int64) (int64, error)
 }
 
@@ -45,8 +50,44 @@ var (
 	Transformed  = `transformed`
 	Unrecognized = `unrecognized`
 	Unwatched    = `unwatched`
+	Failed       = `failed`
 )
 
+// DiffFilter narrows which failed or unrecognized diffs are listed or reset to new; zero values match everything
+type DiffFilter struct {
+	Statuses      []string
+	Address       common.Address
+	StorageKey    common.Hash
+	ErrorContains string
+}
+
+func (filter DiffFilter) statuses() []string {
+	if len(filter.Statuses) == 0 {
+		return []string{Failed, Unrecognized}
+	}
+	return filter.Statuses
+}
+
+// whereClause returns the filter's conditions on storage_diff, numbering its placeholders after the passed args, and
+// the args with the filter's values appended
+func (filter DiffFilter) whereClause(args []interface{}) (string, []interface{}) {
+	args = append(args, pq.Array(filter.statuses()))
+	conditions := []string{fmt.Sprintf("status = ANY($%d::diff_status[])", len(args))}
+	if filter.Address != (common.Address{}) {
+		args = append(args, filter.Address.Bytes())
+		conditions = append(conditions, fmt.Sprintf("address = $%d", len(args)))
+	}
+	if filter.StorageKey != (common.Hash{}) {
+		args = append(args, filter.StorageKey.Bytes())
+		conditions = append(conditions, fmt.Sprintf("storage_key = $%d", len(args)))
+	}
+	if filter.ErrorContains != "" {
+		args = append(args, "%"+filter.ErrorContains+"%")
+		conditions = append(conditions, fmt.Sprintf("last_error LIKE $%d", len(args)))
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
 type diffRepository struct {
 	db *postgres.DB
 }
@@ -151,6 +192,55 @@ func (repository diffRepository) MarkPending(id int64) error {
 	return nil
 }
 
+// RecordFailedAttempt notes an error transforming the diff, marking it failed once it has been attempted maxAttempts
+// times. A maxAttempts below one records the attempt without ever marking the diff failed.
+func (repository diffRepository) RecordFailedAttempt(id int64, attemptErr error, maxAttempts int) error {
+	_, err := repository.db.Exec(`UPDATE public.storage_diff
+		SET attempts = attempts + 1,
+			last_error = $2,
+			last_attempted = NOW(),
+			status = CASE WHEN $3 > 0 AND attempts + 1 >= $3 THEN $4::diff_status ELSE status END
+		WHERE id = $1`, id, attemptErr.Error(), maxAttempts, Failed)
+	if err != nil {
+		return fmt.Errorf("error recording failed attempt for diff %d: %w", id, err)
+	}
+	return nil
+}
+
+// GetDiffSummaries groups diffs matching the filter by address, storage key, status, and last error
+func (repository diffRepository) GetDiffSummaries(filter DiffFilter) ([]types.DiffSummary, error) {
+	where, args := filter.whereClause(nil)
+	var result []types.DiffSummary
+	err := repository.db.Select(&result,
+		`SELECT address, storage_key, status, COALESCE(last_error, '') AS last_error, COUNT(*) AS count,
+				MIN(block_height) AS min_block_height, MAX(block_height) AS max_block_height, MAX(attempts) AS max_attempts
+			FROM public.storage_diff
+			WHERE `+where+`
+			GROUP BY address, storage_key, status, last_error
+			ORDER BY count DESC`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error getting summaries of %v storage diffs: %w", filter.statuses(), err)
+	}
+	return result, nil
+}
+
+// RequeueDiffs resets matching failed or unrecognized diffs to new with a fresh attempt count
+func (repository diffRepository) RequeueDiffs(filter DiffFilter) (int64, error) {
+	for _, status := range filter.statuses() {
+		if status != Failed && status != Unrecognized {
+			return 0, fmt.Errorf("can only requeue %s or %s diffs, got %s", Failed, Unrecognized, status)
+		}
+	}
+
+	where, args := filter.whereClause([]interface{}{New})
+	query := `UPDATE public.storage_diff SET status = $1, attempts = 0 WHERE ` + where
+	result, err := repository.db.Exec(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("error requeueing storage diffs: %w", err)
+	}
+	return result.RowsAffected()
+}
+
 func (repository diffRepository) GetFirstDiffIDForBlockHeight(blockHeight int64) (int64, error) {
 	var diffID int64
 	err := repository.db.Get(&diffID,
@@ -25,6 +25,7 @@ import (
 	"github.com/makerdao/vulcanizedb/libraries/shared/storage/types"
 	"github.com/makerdao/vulcanizedb/libraries/shared/test_data"
 	"github.com/makerdao/vulcanizedb/pkg/datastore/postgres"
+	"github.com/makerdao/vulcanizedb/pkg/fakes"
 	"github.com/makerdao/vulcanizedb/test_config"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/ginkgo/extensions/table"
@@ -418,6 +419,153 @@ var _ = Describe("Storage diffs repository", func() {
 		})
 	})
 
+	Describe("RecordFailedAttempt", func() {
+		var fakePersistedDiff types.PersistedDiff
+
+		type attemptedDiff struct {
+			Status    string
+			Attempts  int
+			LastError sql.NullString `db:"last_error"`
+			Attempted sql.NullString `db:"last_attempted"`
+		}
+
+		BeforeEach(func() {
+			fakePersistedDiff = createFakePersistedDiff(fakeStorageDiff, storage.New, db.NodeID)
+			insertTestDiff(fakePersistedDiff, db)
+		})
+
+		It("increments the attempt count and records the error", func() {
+			err := repo.RecordFailedAttempt(fakePersistedDiff.ID, fakes.FakeError, 3)
+
+			Expect(err).NotTo(HaveOccurred())
+			var diff attemptedDiff
+			getErr := db.Get(&diff, `SELECT status, attempts, last_error, last_attempted FROM public.storage_diff WHERE id = $1`, fakePersistedDiff.ID)
+			Expect(getErr).NotTo(HaveOccurred())
+			Expect(diff.Status).To(Equal(storage.New))
+			Expect(diff.Attempts).To(Equal(1))
+			Expect(diff.LastError.String).To(Equal(fakes.FakeError.Error()))
+			Expect(diff.Attempted.Valid).To(BeTrue())
+		})
+
+		It("marks the diff as failed once it reaches the max attempts", func() {
+			for i := 0; i < 3; i++ {
+				err := repo.RecordFailedAttempt(fakePersistedDiff.ID, fakes.FakeError, 3)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			var diff attemptedDiff
+			getErr := db.Get(&diff, `SELECT status, attempts, last_error, last_attempted FROM public.storage_diff WHERE id = $1`, fakePersistedDiff.ID)
+			Expect(getErr).NotTo(HaveOccurred())
+			Expect(diff.Status).To(Equal(storage.Failed))
+			Expect(diff.Attempts).To(Equal(3))
+		})
+
+		It("never marks the diff as failed if max attempts is less than one", func() {
+			for i := 0; i < 3; i++ {
+				err := repo.RecordFailedAttempt(fakePersistedDiff.ID, fakes.FakeError, 0)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			var diff attemptedDiff
+			getErr := db.Get(&diff, `SELECT status, attempts, last_error, last_attempted FROM public.storage_diff WHERE id = $1`, fakePersistedDiff.ID)
+			Expect(getErr).NotTo(HaveOccurred())
+			Expect(diff.Status).To(Equal(storage.New))
+			Expect(diff.Attempts).To(Equal(3))
+		})
+	})
+
+	Describe("Inspecting failed and unrecognized diffs", func() {
+		var (
+			concreteRepo                 = storage.NewDiffRepository(db)
+			failedDiffOne, failedDiffTwo types.PersistedDiff
+			unrecognizedDiff             types.PersistedDiff
+		)
+
+		BeforeEach(func() {
+			failedRawDiff := createFakeRawDiff(1)
+			failedDiffOne = createFakePersistedDiff(failedRawDiff, storage.Failed, db.NodeID)
+			insertTestDiff(failedDiffOne, db)
+			failedRawDiff.BlockHeight = 2
+			failedRawDiff.BlockHash = test_data.FakeHash()
+			failedDiffTwo = createFakePersistedDiff(failedRawDiff, storage.Failed, db.NodeID)
+			insertTestDiff(failedDiffTwo, db)
+			_, updateErr := db.Exec(`UPDATE public.storage_diff SET last_error = $1, attempts = 3`, fakes.FakeError.Error())
+			Expect(updateErr).NotTo(HaveOccurred())
+
+			unrecognizedDiff = createFakePersistedDiff(createFakeRawDiff(3), storage.Unrecognized, db.NodeID)
+			insertTestDiff(unrecognizedDiff, db)
+			insertTestDiff(createFakePersistedDiff(createFakeRawDiff(4), storage.Transformed, db.NodeID), db)
+		})
+
+		It("summarizes diffs with the given statuses", func() {
+			summaries, err := concreteRepo.GetDiffSummaries(storage.DiffFilter{Statuses: []string{storage.Failed, storage.Unrecognized}})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(summaries).To(ConsistOf(types.DiffSummary{
+				Address:          failedDiffOne.Address,
+				StorageKey:       failedDiffOne.StorageKey,
+				Status:           storage.Failed,
+				LastError:        fakes.FakeError.Error(),
+				Count:            2,
+				MinBlockHeight:   1,
+				MaxBlockHeight:   2,
+				MaxAttemptsCount: 3,
+			}, types.DiffSummary{
+				Address:        unrecognizedDiff.Address,
+				StorageKey:     unrecognizedDiff.StorageKey,
+				Status:         storage.Unrecognized,
+				Count:          1,
+				MinBlockHeight: 3,
+				MaxBlockHeight: 3,
+			}))
+		})
+
+		It("summarizes only diffs matching the filter", func() {
+			summaries, err := concreteRepo.GetDiffSummaries(storage.DiffFilter{
+				Address:       failedDiffOne.Address,
+				StorageKey:    failedDiffOne.StorageKey,
+				ErrorContains: fakes.FakeError.Error(),
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(summaries)).To(Equal(1))
+			Expect(summaries[0].Status).To(Equal(storage.Failed))
+			Expect(summaries[0].Count).To(Equal(int64(2)))
+		})
+
+		It("requeues diffs matching the filter as new", func() {
+			requeued, err := concreteRepo.RequeueDiffs(storage.DiffFilter{
+				Statuses:      []string{storage.Failed},
+				Address:       failedDiffOne.Address,
+				ErrorContains: fakes.FakeError.Error(),
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requeued).To(Equal(int64(2)))
+			var newDiffIDs []int64
+			getErr := db.Select(&newDiffIDs, `SELECT id FROM public.storage_diff WHERE status = $1 AND attempts = 0`, storage.New)
+			Expect(getErr).NotTo(HaveOccurred())
+			Expect(newDiffIDs).To(ConsistOf(failedDiffOne.ID, failedDiffTwo.ID))
+		})
+
+		It("requeues failed and unrecognized diffs when no statuses are given", func() {
+			requeued, err := concreteRepo.RequeueDiffs(storage.DiffFilter{})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requeued).To(Equal(int64(3)))
+		})
+
+		It("returns an error when asked to requeue diffs with another status", func() {
+			_, err := concreteRepo.RequeueDiffs(storage.DiffFilter{Statuses: []string{storage.Transformed}})
+
+			Expect(err).To(HaveOccurred())
+			var count int
+			getErr := db.Get(&count, `SELECT COUNT(*) FROM public.storage_diff WHERE status = $1`, storage.Transformed)
+			Expect(getErr).NotTo(HaveOccurred())
+			Expect(count).To(Equal(1))
+		})
+	})
+
 	Describe("GetFirstDiffIDForBlockHeight", func() {
 		It("sends first diff for a given block height", func() {
 			blockHeight := fakeStorageDiff.BlockHeight
@@ -17,40 +17,239 @@
 package fetcher
 
 import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/makerdao/vulcanizedb/libraries/shared/storage/types"
 	"github.com/makerdao/vulcanizedb/pkg/fs"
+	"github.com/sirupsen/logrus"
 )
 
+// DefaultCheckpointInterval is how often the position in the csv is saved. Rows read since the last save are
+// fetched again after a restart, and persisting them again is a no-op.
+const DefaultCheckpointInterval = time.Second
+
 type CsvTailStorageFetcher struct {
-	tailer       fs.Tailer
-	statusWriter fs.StatusWriter
+	tailer             fs.Tailer
+	statusWriter       fs.StatusWriter
+	checkpointer       fs.Checkpointer
+	rejectWriter       fs.RejectWriter
+	checkpointInterval time.Duration
 }
 
-func NewCsvTailStorageFetcher(tailer fs.Tailer, statusWriter fs.StatusWriter) CsvTailStorageFetcher {
+func NewCsvTailStorageFetcher(tailer fs.Tailer, statusWriter fs.StatusWriter, checkpointer fs.Checkpointer, rejectWriter fs.RejectWriter) CsvTailStorageFetcher {
 	return CsvTailStorageFetcher{
-		tailer:       tailer,
-		statusWriter: statusWriter,
+		tailer:             tailer,
+		statusWriter:       statusWriter,
+		checkpointer:       checkpointer,
+		rejectWriter:       rejectWriter,
+		checkpointInterval: DefaultCheckpointInterval,
 	}
 }
 
+// FetchStorageDiffs resumes from the saved checkpoint, first finishing any rotated copy of the csv that the
+// checkpoint points into. Rows that can't be parsed are written to the reject file rather than sent to errs.
 func (storageFetcher CsvTailStorageFetcher) FetchStorageDiffs(out chan<- types.RawDiff, errs chan<- error) {
-	t, tailErr := storageFetcher.tailer.Tail()
-	if tailErr != nil {
-		errs <- tailErr
+	checkpoint, loadErr := storageFetcher.checkpointer.Load()
+	if loadErr != nil {
+		errs <- fmt.Errorf("error loading csv checkpoint: %w", loadErr)
+		return
 	}
 	writeErr := storageFetcher.statusWriter.Write()
 	if writeErr != nil {
 		errs <- writeErr
 	}
 
+	reader := csvReader{CsvTailStorageFetcher: storageFetcher, out: out, checkpoint: checkpoint}
+	for {
+		catchUpErr := reader.catchUp()
+		if catchUpErr != nil {
+			errs <- catchUpErr
+			return
+		}
+		tailErr := reader.tail()
+		if tailErr != nil {
+			errs <- tailErr
+			return
+		}
+		logrus.Info("storage diffs csv was rotated, finishing rotated copy")
+	}
+}
+
+type csvReader struct {
+	CsvTailStorageFetcher
+	out        chan<- types.RawDiff
+	checkpoint fs.Checkpoint
+	lastSaved  time.Time
+}
+
+// tail follows the current csv from the checkpoint until the file is rotated
+func (reader *csvReader) tail() error {
+	t, tailErr := reader.tailer.Tail(reader.checkpoint.Offset)
+	if tailErr != nil {
+		return tailErr
+	}
 	for line := range t.Lines {
-		diff, parseErr := types.FromParityCsvRow(strings.Split(line.Text, ","))
-		if parseErr != nil {
-			errs <- parseErr
-		} else {
-			out <- diff
+		if line.Err != nil {
+			stopErr := t.Stop()
+			if stopErr != nil {
+				logrus.Warnf("error stopping csv tail: %s", stopErr.Error())
+			}
+			return line.Err
+		}
+		readErr := reader.readLine(line.Text)
+		if readErr != nil {
+			stopErr := t.Stop()
+			if stopErr != nil {
+				logrus.Warnf("error stopping csv tail: %s", stopErr.Error())
+			}
+			return readErr
 		}
 	}
+	return fs.WaitForTail(t)
+}
+
+// catchUp reads the rest of the rotated copy the checkpoint points into, along with any copies rotated after it,
+// and resets the checkpoint to the start of the current csv
+func (reader *csvReader) catchUp() error {
+	if reader.checkpoint.Fingerprint == "" {
+		return nil
+	}
+	files, filesErr := reader.tailer.Files()
+	if filesErr != nil {
+		return filesErr
+	}
+	current := files[len(files)-1]
+	currentFingerprint, fingerprintErr := reader.fingerprint(current)
+	if fingerprintErr != nil {
+		return fingerprintErr
+	}
+	if currentFingerprint == reader.checkpoint.Fingerprint {
+		return nil
+	}
+
+	found := false
+	lastFingerprint := ""
+	for _, path := range files[:len(files)-1] {
+		fingerprint, readErr := reader.readRotated(path, &found, lastFingerprint)
+		if readErr != nil {
+			return readErr
+		}
+		if found {
+			lastFingerprint = fingerprint
+		}
+	}
+	if !found {
+		logrus.Warnf("no storage diffs csv matches the checkpoint, reading %s from the start", current)
+	}
+	reader.checkpoint = fs.Checkpoint{}
+	return nil
+}
+
+// readRotated reads a rotated copy from the checkpoint if it's the copy the checkpoint points into, or from the start
+// if that copy was already found. A copy with the same first line as the last one read is skipped, since a file may
+// briefly exist both compressed and uncompressed.
+func (reader *csvReader) readRotated(path string, found *bool, lastFingerprint string) (string, error) {
+	file, openErr := reader.tailer.Open(path)
+	if openErr != nil {
+		return "", fmt.Errorf("error opening rotated csv %s: %w", path, openErr)
+	}
+	defer file.Close()
+
+	lines := bufio.NewReader(file)
+	var offset, skipTo int64
+	fingerprint := ""
+	for {
+		line, readErr := lines.ReadString('\n')
+		if readErr != nil && readErr != io.EOF {
+			return fingerprint, fmt.Errorf("error reading rotated csv %s: %w", path, readErr)
+		}
+		if line == "" {
+			return fingerprint, nil
+		}
+		text := strings.TrimSuffix(line, "\n")
+		if offset == 0 {
+			fingerprint = fs.Fingerprint(text)
+			switch {
+			case fingerprint == lastFingerprint:
+				return fingerprint, nil
+			case *found:
+				reader.checkpoint = fs.Checkpoint{}
+			case fingerprint == reader.checkpoint.Fingerprint:
+				*found = true
+				skipTo = reader.checkpoint.Offset
+				logrus.Infof("resuming storage diffs from rotated csv %s at byte %d", path, skipTo)
+			default:
+				return fingerprint, nil
+			}
+		}
+		if offset >= skipTo {
+			processErr := reader.readLine(text)
+			if processErr != nil {
+				return fingerprint, processErr
+			}
+		}
+		offset += int64(len(line))
+		if readErr == io.EOF {
+			return fingerprint, nil
+		}
+	}
+}
+
+func (reader *csvReader) fingerprint(path string) (string, error) {
+	file, openErr := reader.tailer.Open(path)
+	if openErr != nil {
+		if os.IsNotExist(openErr) {
+			return "", nil
+		}
+		return "", fmt.Errorf("error opening csv %s: %w", path, openErr)
+	}
+	defer file.Close()
+	line, readErr := bufio.NewReader(file).ReadString('\n')
+	if readErr == io.EOF {
+		return "", nil
+	}
+	if readErr != nil {
+		return "", fmt.Errorf("error reading csv %s: %w", path, readErr)
+	}
+	return fs.Fingerprint(strings.TrimSuffix(line, "\n")), nil
+}
+
+// readLine sends the diff in a csv row and advances the checkpoint past it. Once the diff is received the checkpoint
+// may be saved at the start of the row, since the extractor has persisted every diff sent before it.
+func (reader *csvReader) readLine(text string) error {
+	start := reader.checkpoint.Offset
+	if start == 0 {
+		reader.checkpoint.Fingerprint = fs.Fingerprint(text)
+	}
+	reader.checkpoint.Offset += int64(len(text)) + 1
+
+	diff, parseErr := types.FromParityCsvRow(strings.Split(text, ","))
+	if parseErr != nil {
+		logrus.Warnf("rejecting storage diff csv row at byte %d: %s", start, parseErr.Error())
+		rejectErr := reader.rejectWriter.Write(text)
+		if rejectErr != nil {
+			return fmt.Errorf("error rejecting csv row: %w", rejectErr)
+		}
+		return nil
+	}
+	reader.out <- diff
+	reader.saveCheckpoint(fs.Checkpoint{Fingerprint: reader.checkpoint.Fingerprint, Offset: start})
+	return nil
+}
+
+func (reader *csvReader) saveCheckpoint(checkpoint fs.Checkpoint) {
+	if time.Since(reader.lastSaved) < reader.checkpointInterval {
+		return
+	}
+	saveErr := reader.checkpointer.Save(checkpoint)
+	if saveErr != nil {
+		logrus.Warnf("error saving csv checkpoint: %s", saveErr.Error())
+		return
+	}
+	reader.lastSaved = time.Now()
 }
@@ -26,6 +26,7 @@ import (
 	"github.com/makerdao/vulcanizedb/libraries/shared/storage/fetcher"
 	"github.com/makerdao/vulcanizedb/libraries/shared/storage/types"
 	"github.com/makerdao/vulcanizedb/pkg/fakes"
+	"github.com/makerdao/vulcanizedb/pkg/fs"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -35,6 +36,8 @@ var _ = Describe("Csv Tail Storage Fetcher", func() {
 		errorsChannel    chan error
 		mockTailer       *fakes.MockTailer
 		mockStatusWriter fakes.MockStatusWriter
+		mockCheckpointer *fakes.MockCheckpointer
+		mockRejectWriter *fakes.MockRejectWriter
 		diffsChannel     chan types.RawDiff
 		storageFetcher   fetcher.CsvTailStorageFetcher
 	)
@@ -44,7 +47,9 @@ var _ = Describe("Csv Tail Storage Fetcher", func() {
 		diffsChannel = make(chan types.RawDiff)
 		mockTailer = fakes.NewMockTailer()
 		mockStatusWriter = fakes.MockStatusWriter{}
-		storageFetcher = fetcher.NewCsvTailStorageFetcher(mockTailer, &mockStatusWriter)
+		mockCheckpointer = &fakes.MockCheckpointer{}
+		mockRejectWriter = &fakes.MockRejectWriter{}
+		storageFetcher = fetcher.NewCsvTailStorageFetcher(mockTailer, &mockStatusWriter, mockCheckpointer, mockRejectWriter)
 	})
 
 	It("adds error to errors channel if tailing file fails", func(done Done) {
@@ -67,7 +72,7 @@ var _ = Describe("Csv Tail Storage Fetcher", func() {
 		})
 
 		It("adds parsed csv row to rows channel for storage diff", func(done Done) {
-			line := getFakeLine()
+			line := getFakeLine(789)
 
 			go storageFetcher.FetchStorageDiffs(diffsChannel, errorsChannel)
 			mockTailer.Lines <- line
@@ -78,28 +83,124 @@ var _ = Describe("Csv Tail Storage Fetcher", func() {
 			close(done)
 		})
 
-		It("adds error to errors channel if parsing csv fails", func(done Done) {
-			line := &tail.Line{Text: "invalid"}
+		It("writes rows that can't be parsed to the reject file and keeps reading", func(done Done) {
+			line := getFakeLine(789)
 
 			go storageFetcher.FetchStorageDiffs(diffsChannel, errorsChannel)
+			mockTailer.Lines <- &tail.Line{Text: "invalid"}
 			mockTailer.Lines <- line
 
-			Expect(<-errorsChannel).To(HaveOccurred())
-			select {
-			case <-diffsChannel:
-				Fail("value passed to rows channel on error")
-			default:
-				Succeed()
+			Expect(<-diffsChannel).To(Equal(parseLine(line.Text)))
+			Expect(mockRejectWriter.RejectedLines).To(Equal([]string{"invalid"}))
+			close(done)
+		})
+
+		It("adds error to errors channel if writing a rejected row fails", func(done Done) {
+			mockRejectWriter.WriteErr = fakes.FakeError
+
+			go storageFetcher.FetchStorageDiffs(diffsChannel, errorsChannel)
+			mockTailer.Lines <- &tail.Line{Text: "invalid"}
+
+			Expect(<-errorsChannel).To(MatchError(fakes.FakeError))
+			close(done)
+		})
+
+		It("adds error to errors channel if the tail stops with an error", func(done Done) {
+			mockTailer.WaitErr = fakes.FakeError
+			close(mockTailer.Lines)
+
+			go storageFetcher.FetchStorageDiffs(diffsChannel, errorsChannel)
+
+			Expect(<-errorsChannel).To(MatchError(fakes.FakeError))
+			close(done)
+		})
+	})
+
+	Describe("checkpoints", func() {
+		It("adds error to errors channel if loading the checkpoint fails", func(done Done) {
+			mockCheckpointer.LoadErr = fakes.FakeError
+
+			go storageFetcher.FetchStorageDiffs(diffsChannel, errorsChannel)
+
+			Expect(<-errorsChannel).To(MatchError(fakes.FakeError))
+			close(done)
+		})
+
+		It("saves the checkpoint at the start of a row once its diff is received", func(done Done) {
+			first, second := getFakeLine(1), getFakeLine(2)
+
+			go storageFetcher.FetchStorageDiffs(diffsChannel, errorsChannel)
+			mockTailer.Lines <- first
+			Expect(<-diffsChannel).To(Equal(parseLine(first.Text)))
+
+			Eventually(func() fs.Checkpoint {
+				return mockCheckpointer.SavedCheckpoint
+			}).Should(Equal(fs.Checkpoint{Fingerprint: fs.Fingerprint(first.Text), Offset: 0}))
+			mockTailer.Lines <- second
+			Expect(<-diffsChannel).To(Equal(parseLine(second.Text)))
+			close(done)
+		})
+
+		It("resumes tailing the file from the checkpoint", func(done Done) {
+			first := getFakeLine(1)
+			mockTailer.FileContents["diffs.csv"] = first.Text + "\n"
+			mockCheckpointer.Checkpoint = fs.Checkpoint{Fingerprint: fs.Fingerprint(first.Text), Offset: 100}
+
+			go storageFetcher.FetchStorageDiffs(diffsChannel, errorsChannel)
+
+			Eventually(func() int64 {
+				return mockTailer.TailPassedOffset
+			}).Should(Equal(int64(100)))
+			close(done)
+		})
+
+		It("finishes the rotated copy the checkpoint is in before tailing the file from the start", func(done Done) {
+			first, second, third, fourth := getFakeLine(1), getFakeLine(2), getFakeLine(3), getFakeLine(4)
+			mockTailer.FilePaths = []string{"diffs.csv.2.gz", "diffs.csv.1.gz", "diffs.csv.1", "diffs.csv"}
+			mockTailer.FileContents["diffs.csv.2.gz"] = first.Text + "\n" + second.Text + "\n"
+			mockTailer.FileContents["diffs.csv.1.gz"] = third.Text + "\n"
+			mockTailer.FileContents["diffs.csv.1"] = third.Text + "\n"
+			mockTailer.FileContents["diffs.csv"] = fourth.Text + "\n"
+			mockCheckpointer.Checkpoint = fs.Checkpoint{
+				Fingerprint: fs.Fingerprint(first.Text),
+				Offset:      int64(len(first.Text) + 1),
 			}
+
+			go storageFetcher.FetchStorageDiffs(diffsChannel, errorsChannel)
+
+			Expect(<-diffsChannel).To(Equal(parseLine(second.Text)))
+			Expect(<-diffsChannel).To(Equal(parseLine(third.Text)))
+			mockTailer.Lines <- fourth
+			Expect(<-diffsChannel).To(Equal(parseLine(fourth.Text)))
+			Expect(mockTailer.TailPassedOffset).To(BeZero())
+			close(done)
+		})
+
+		It("tails the file from the start if no copy matches the checkpoint", func(done Done) {
+			mockTailer.FilePaths = []string{"diffs.csv.1", "diffs.csv"}
+			mockTailer.FileContents["diffs.csv.1"] = getFakeLine(1).Text + "\n"
+			mockCheckpointer.Checkpoint = fs.Checkpoint{Fingerprint: "unknown", Offset: 100}
+			line := getFakeLine(2)
+
+			go storageFetcher.FetchStorageDiffs(diffsChannel, errorsChannel)
+			mockTailer.Lines <- line
+
+			Expect(<-diffsChannel).To(Equal(parseLine(line.Text)))
+			Expect(mockTailer.TailPassedOffset).To(BeZero())
 			close(done)
 		})
 	})
 })
 
-func getFakeLine() *tail.Line {
+func parseLine(text string) types.RawDiff {
+	diff, err := types.FromParityCsvRow(strings.Split(text, ","))
+	Expect(err).NotTo(HaveOccurred())
+	return diff
+}
+
+func getFakeLine(blockHeight int64) *tail.Line {
 	address := common.HexToAddress("0x1234567890abcdef")
 	blockHash := []byte{4, 5, 6}
-	blockHeight := int64(789)
 	storageKey := []byte{9, 8, 7}
 	storageValue := []byte{6, 5, 4}
 	return &tail.Line{
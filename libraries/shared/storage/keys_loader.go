@@ -54,3 +54,26 @@ func GetIncrementedKey(original common.Hash, incrementBy int64) common.Hash {
 	incremented := big.NewInt(0).Add(originalMappingAsInt, big.NewInt(incrementBy))
 	return common.BytesToHash(incremented.Bytes())
 }
+
+// GetKeyForDynamicData returns the first data slot for a long string or bytes value, or the first element slot for a
+// dynamic array, whose length is stored at lengthKey
+func GetKeyForDynamicData(lengthKey common.Hash) common.Hash {
+	return crypto.Keccak256Hash(lengthKey.Bytes())
+}
+
+// GetKeysForDynamicData returns the consecutive data slots that follow from GetKeyForDynamicData
+func GetKeysForDynamicData(lengthKey common.Hash, numberOfSlots int64) []common.Hash {
+	firstKey := GetKeyForDynamicData(lengthKey)
+	var keys []common.Hash
+	for i := int64(0); i < numberOfSlots; i++ {
+		keys = append(keys, GetIncrementedKey(firstKey, i))
+	}
+	return keys
+}
+
+// GetKeyForDynamicArrayElement returns the slot holding the element at elementIndex of a dynamic array whose length is
+// stored at lengthKey. Pass slotsPerElement > 1 for struct elements (the key is then for the struct's first member).
+// Elements packed several to a slot share a key; divide elementIndex by the number per slot before calling.
+func GetKeyForDynamicArrayElement(lengthKey common.Hash, elementIndex, slotsPerElement int64) common.Hash {
+	return GetIncrementedKey(GetKeyForDynamicData(lengthKey), elementIndex*slotsPerElement)
+}
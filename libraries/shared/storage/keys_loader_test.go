@@ -88,4 +88,46 @@ var _ = Describe("Storage keys loader utils", func() {
 			Expect(storageKey).To(Equal(expectedStorageKey))
 		})
 	})
+
+	Describe("GetKeyForDynamicData", func() {
+		It("returns the keccak hash of the slot holding the value's length", func() {
+			// ex. solidity:
+			//    	string public name;
+			// when name is longer than 31 bytes, its data begins at keccak256(slot of name)
+			storageKey := storage.GetKeyForDynamicData(common.HexToHash(storage.IndexZero))
+
+			expectedStorageKey := common.HexToHash("0x290decd9548b62a8d60345a988386fc84ba6bc95484008f6362f93160ef3e563")
+			Expect(storageKey).To(Equal(expectedStorageKey))
+		})
+	})
+
+	Describe("GetKeysForDynamicData", func() {
+		It("returns consecutive slots starting at the data key", func() {
+			lengthKey := common.HexToHash(storage.IndexZero)
+
+			storageKeys := storage.GetKeysForDynamicData(lengthKey, 2)
+
+			expectedFirstKey := common.HexToHash("0x290decd9548b62a8d60345a988386fc84ba6bc95484008f6362f93160ef3e563")
+			expectedSecondKey := common.HexToHash("0x290decd9548b62a8d60345a988386fc84ba6bc95484008f6362f93160ef3e564")
+			Expect(storageKeys).To(Equal([]common.Hash{expectedFirstKey, expectedSecondKey}))
+		})
+	})
+
+	Describe("GetKeyForDynamicArrayElement", func() {
+		It("returns the key for an element, accounting for elements spanning multiple slots", func() {
+			// ex. solidity:
+			//    	struct Data {
+			//        uint256 quantity;
+			//        uint256 quality;
+			//    	}
+			//    	Data[] public items;
+			// items[1].quantity is two slots past the start of the array's data
+			lengthKey := common.HexToHash(storage.IndexZero)
+
+			storageKey := storage.GetKeyForDynamicArrayElement(lengthKey, 1, 2)
+
+			expectedStorageKey := common.HexToHash("0x290decd9548b62a8d60345a988386fc84ba6bc95484008f6362f93160ef3e565")
+			Expect(storageKey).To(Equal(expectedStorageKey))
+		})
+	})
 })
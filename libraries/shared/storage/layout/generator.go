@@ -0,0 +1,157 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package layout
+
+import (
+	"fmt"
+	"sort"
+
+	. "github.com/dave/jennifer/jen"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/makerdao/vulcanizedb/libraries/shared/storage/types"
+)
+
+const (
+	commonPath  = "github.com/ethereum/go-ethereum/common"
+	layoutPath  = "github.com/makerdao/vulcanizedb/libraries/shared/storage/layout"
+	storagePath = "github.com/makerdao/vulcanizedb/libraries/shared/factories/storage"
+	typesPath   = "github.com/makerdao/vulcanizedb/libraries/shared/storage/types"
+)
+
+// KeysFileName is the name of the file written by WriteKeysFile when generating keys at compose time
+const KeysFileName = "storage_layout_keys.go"
+
+var valueTypeNames = map[types.ValueType]string{
+	types.Bytes32:      "Bytes32",
+	types.Address:      "Address",
+	types.PackedSlot:   "PackedSlot",
+	types.String:       "String",
+	types.Bytes:        "Bytes",
+	types.DynamicArray: "DynamicArray",
+	types.Bool:         "Bool",
+}
+
+func init() {
+	for width := 1; width <= 31; width++ {
+		valueTypeNames[types.Bytes1+types.ValueType(width-1)] = fmt.Sprintf("Bytes%d", width)
+	}
+	for bits := 8; bits <= 256; bits += 8 {
+		unsignedType, _ := types.UintType(bits)
+		valueTypeNames[unsignedType] = fmt.Sprintf("Uint%d", bits)
+		signedType, _ := types.IntType(bits)
+		valueTypeNames[signedType] = fmt.Sprintf("Int%d", bits)
+	}
+}
+
+// WriteKeysFile writes Go source declaring the layout's static keys and mapping templates, along with a constructor
+// for a keys loader using them. Regenerating the file when a contract is upgraded keeps the slot math in sync.
+func WriteKeysFile(layout StorageLayout, packageName, source, outputPath string) error {
+	staticKeys, staticErr := layout.StaticKeys()
+	if staticErr != nil {
+		return fmt.Errorf("error getting static keys from storage layout: %w", staticErr)
+	}
+	templates, templatesErr := layout.MappingTemplates()
+	if templatesErr != nil {
+		return fmt.Errorf("error getting mapping templates from storage layout: %w", templatesErr)
+	}
+
+	f := NewFile(packageName)
+	f.HeaderComment(fmt.Sprintf("Code generated by vulcanizedb from %s. DO NOT EDIT.", source))
+
+	f.Comment("StorageLayoutStaticKeys returns metadata for the contract's storage slots at fixed positions")
+	f.Func().Id("StorageLayoutStaticKeys").Params().Map(Qual(commonPath, "Hash")).Qual(typesPath, "ValueMetadata").Block(
+		Return(Map(Qual(commonPath, "Hash")).Qual(typesPath, "ValueMetadata").Values(staticKeysCode(staticKeys)...)),
+	)
+
+	f.Comment("StorageLayoutMappingTemplates returns templates for the storage slots of the contract's mapping entries")
+	f.Func().Id("StorageLayoutMappingTemplates").Params().Index().Qual(layoutPath, "MappingTemplate").Block(
+		Return(Index().Qual(layoutPath, "MappingTemplate").Values(templatesCode(templates)...)),
+	)
+
+	f.Comment("NewStorageLayoutKeysLoader returns a keys loader for the contract, with mapping entries from the key source")
+	f.Func().Id("NewStorageLayoutKeysLoader").Params(Id("keySource").Qual(layoutPath, "KeySource")).Qual(storagePath, "KeysLoader").Block(
+		Return(Qual(layoutPath, "NewKeysLoader").Call(Id("StorageLayoutStaticKeys").Call(), Id("StorageLayoutMappingTemplates").Call(), Id("keySource"))),
+	)
+
+	saveErr := f.Save(outputPath)
+	if saveErr != nil {
+		return fmt.Errorf("error saving generated keys file %s: %w", outputPath, saveErr)
+	}
+	return nil
+}
+
+func staticKeysCode(staticKeys map[common.Hash]types.ValueMetadata) []Code {
+	var keys []common.Hash
+	for key := range staticKeys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Big().Cmp(keys[j].Big()) < 0
+	})
+
+	var result []Code
+	for _, key := range keys {
+		result = append(result, Line().Qual(commonPath, "HexToHash").Call(Lit(key.Hex())).Op(":").Add(metadataCode(staticKeys[key])))
+	}
+	return append(result, Line())
+}
+
+func templatesCode(templates []MappingTemplate) []Code {
+	var result []Code
+	for _, template := range templates {
+		var keyNames, values []Code
+		for _, keyName := range template.KeyNames {
+			keyNames = append(keyNames, Lit(string(keyName)))
+		}
+		for _, value := range template.Values {
+			values = append(values, Values(Dict{
+				Id("SlotOffset"): Lit(value.SlotOffset),
+				Id("Metadata"):   metadataCode(value.Metadata),
+			}))
+		}
+		result = append(result, Line().Values(Dict{
+			Id("Name"): Lit(template.Name),
+			Id("Slot"): Qual(commonPath, "HexToHash").Call(Lit(template.Slot.Hex())),
+			Id("KeyTypes"): Index().String().ValuesFunc(func(g *Group) {
+				for _, keyType := range template.KeyTypes {
+					g.Lit(keyType)
+				}
+			}),
+			Id("KeyNames"): Index().Qual(typesPath, "Key").Values(keyNames...),
+			Id("Values"):   Index().Qual(layoutPath, "TemplateValue").Values(values...),
+		}))
+	}
+	return append(result, Line())
+}
+
+func metadataCode(metadata types.ValueMetadata) Code {
+	fields := Dict{
+		Id("Name"): Lit(metadata.Name),
+		Id("Type"): Qual(typesPath, valueTypeNames[metadata.Type]),
+	}
+	if metadata.Type == types.PackedSlot {
+		packedNames := Dict{}
+		packedTypes := Dict{}
+		for position, name := range metadata.PackedNames {
+			packedNames[Lit(position)] = Lit(name)
+			packedTypes[Lit(position)] = Qual(typesPath, valueTypeNames[metadata.PackedTypes[position]])
+		}
+		fields[Id("PackedNames")] = Map(Int()).String().Values(packedNames)
+		fields[Id("PackedTypes")] = Map(Int()).Qual(typesPath, "ValueType").Values(packedTypes)
+	}
+	return Qual(typesPath, "ValueMetadata").Values(fields)
+}
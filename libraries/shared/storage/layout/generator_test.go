@@ -0,0 +1,95 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package layout_test
+
+import (
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/makerdao/vulcanizedb/libraries/shared/storage/layout"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Storage keys file generation", func() {
+	var (
+		exampleStorageLayout layout.StorageLayout
+		outputDir            string
+		outputPath           string
+	)
+
+	BeforeEach(func() {
+		var parseErr, dirErr error
+		exampleStorageLayout, parseErr = layout.ParseStorageLayout([]byte(exampleLayout), "")
+		Expect(parseErr).NotTo(HaveOccurred())
+		outputDir, dirErr = ioutil.TempDir("", "layout")
+		Expect(dirErr).NotTo(HaveOccurred())
+		outputPath = filepath.Join(outputDir, layout.KeysFileName)
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(outputDir)).To(Succeed())
+	})
+
+	It("writes valid Go source in the given package", func() {
+		err := layout.WriteKeysFile(exampleStorageLayout, "example", "out/Example.json", outputPath)
+
+		Expect(err).NotTo(HaveOccurred())
+		file, parseErr := parser.ParseFile(token.NewFileSet(), outputPath, nil, parser.ParseComments)
+		Expect(parseErr).NotTo(HaveOccurred())
+		Expect(file.Name.Name).To(Equal("example"))
+	})
+
+	It("declares the layout's keys and a keys loader constructor", func() {
+		err := layout.WriteKeysFile(exampleStorageLayout, "example", "out/Example.json", outputPath)
+
+		Expect(err).NotTo(HaveOccurred())
+		source, readErr := ioutil.ReadFile(outputPath)
+		Expect(readErr).NotTo(HaveOccurred())
+		Expect(string(source)).To(ContainSubstring("Code generated by vulcanizedb from out/Example.json. DO NOT EDIT."))
+		Expect(string(source)).To(ContainSubstring("func StorageLayoutStaticKeys() map[common.Hash]types.ValueMetadata"))
+		Expect(string(source)).To(ContainSubstring("func StorageLayoutMappingTemplates() []layout.MappingTemplate"))
+		Expect(string(source)).To(ContainSubstring("func NewStorageLayoutKeysLoader(keySource layout.KeySource) storage.KeysLoader"))
+		Expect(string(source)).To(ContainSubstring(`Name: "ilk.rate"`))
+		Expect(string(source)).To(ContainSubstring("1: types.Uint48"))
+		Expect(string(source)).To(ContainSubstring(`KeyTypes: []string{"address", "address"}`))
+	})
+
+	It("names integer types of any width that's a multiple of 8 bits", func() {
+		exampleStorageLayout.Types["t_uint256"] = layout.TypeInfo{Encoding: "inplace", Label: "uint160", NumberOfBytes: "20"}
+
+		err := layout.WriteKeysFile(exampleStorageLayout, "example", "out/Example.json", outputPath)
+
+		Expect(err).NotTo(HaveOccurred())
+		source, readErr := ioutil.ReadFile(outputPath)
+		Expect(readErr).NotTo(HaveOccurred())
+		Expect(string(source)).To(ContainSubstring("types.Uint160"))
+	})
+
+	It("returns an error if the layout has unsupported types", func() {
+		exampleStorageLayout.Types["t_uint256"] = layout.TypeInfo{Encoding: "inplace", Label: "fixed128x18", NumberOfBytes: "32"}
+
+		err := layout.WriteKeysFile(exampleStorageLayout, "example", "out/Example.json", outputPath)
+
+		Expect(err).To(MatchError(layout.ErrUnsupportedType))
+		_, statErr := os.Stat(outputPath)
+		Expect(os.IsNotExist(statErr)).To(BeTrue())
+	})
+})
@@ -0,0 +1,95 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package layout
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/makerdao/vulcanizedb/libraries/shared/storage/types"
+	"github.com/makerdao/vulcanizedb/pkg/datastore/postgres"
+)
+
+// KeySource supplies the entries known to exist in a contract's mappings, e.g. by reading addresses from event logs
+type KeySource interface {
+	// GetKeys returns the keys of each entry in the mapping, each ordered outermost mapping first
+	GetKeys(mapping MappingTemplate) ([][]string, error)
+	SetDB(db *postgres.DB)
+}
+
+// keysLoader implements the storage transformer's KeysLoader from slot metadata derived from a storage layout
+type keysLoader struct {
+	staticKeys map[common.Hash]types.ValueMetadata
+	templates  []MappingTemplate
+	keySource  KeySource
+}
+
+// NewKeysLoader loads the given static keys, plus keys for each mapping entry supplied by the key source.
+// The key source may be nil if only static keys are needed.
+func NewKeysLoader(staticKeys map[common.Hash]types.ValueMetadata, templates []MappingTemplate, keySource KeySource) *keysLoader {
+	return &keysLoader{
+		staticKeys: staticKeys,
+		templates:  templates,
+		keySource:  keySource,
+	}
+}
+
+// NewKeysLoaderFromLayout derives static keys and mapping templates from a storage layout
+func NewKeysLoaderFromLayout(layout StorageLayout, keySource KeySource) (*keysLoader, error) {
+	staticKeys, staticErr := layout.StaticKeys()
+	if staticErr != nil {
+		return nil, fmt.Errorf("error getting static keys from storage layout: %w", staticErr)
+	}
+	templates, templatesErr := layout.MappingTemplates()
+	if templatesErr != nil {
+		return nil, fmt.Errorf("error getting mapping templates from storage layout: %w", templatesErr)
+	}
+	return NewKeysLoader(staticKeys, templates, keySource), nil
+}
+
+func (loader *keysLoader) LoadMappings() (map[common.Hash]types.ValueMetadata, error) {
+	mappings := make(map[common.Hash]types.ValueMetadata, len(loader.staticKeys))
+	for key, metadata := range loader.staticKeys {
+		mappings[key] = metadata
+	}
+	if loader.keySource == nil {
+		return mappings, nil
+	}
+
+	for _, template := range loader.templates {
+		entries, keysErr := loader.keySource.GetKeys(template)
+		if keysErr != nil {
+			return nil, fmt.Errorf("error getting keys for mapping %s: %w", template.Name, keysErr)
+		}
+		for _, entryKeys := range entries {
+			entryMappings, entryErr := template.StorageKeys(entryKeys)
+			if entryErr != nil {
+				return nil, entryErr
+			}
+			for key, metadata := range entryMappings {
+				mappings[key] = metadata
+			}
+		}
+	}
+	return mappings, nil
+}
+
+func (loader *keysLoader) SetDB(db *postgres.DB) {
+	if loader.keySource != nil {
+		loader.keySource.SetDB(db)
+	}
+}
@@ -0,0 +1,100 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package layout_test
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/makerdao/vulcanizedb/libraries/shared/factories/storage"
+	"github.com/makerdao/vulcanizedb/libraries/shared/mocks"
+	shared "github.com/makerdao/vulcanizedb/libraries/shared/storage"
+	"github.com/makerdao/vulcanizedb/libraries/shared/storage/layout"
+	"github.com/makerdao/vulcanizedb/libraries/shared/storage/types"
+	"github.com/makerdao/vulcanizedb/pkg/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Storage layout keys loader", func() {
+	var (
+		keySource  *mocks.MockKeySource
+		keysLoader storage.KeysLoader
+		owner      = "0x16Fb96a5fa0427Af0C8F7cF1eB4870231c8154B6"
+	)
+
+	BeforeEach(func() {
+		exampleStorageLayout, parseErr := layout.ParseStorageLayout([]byte(exampleLayout), "")
+		Expect(parseErr).NotTo(HaveOccurred())
+		keySource = &mocks.MockKeySource{}
+		var loaderErr error
+		keysLoader, loaderErr = layout.NewKeysLoaderFromLayout(exampleStorageLayout, keySource)
+		Expect(loaderErr).NotTo(HaveOccurred())
+	})
+
+	It("passes the db to the key source", func() {
+		keysLoader.SetDB(nil)
+
+		Expect(keySource.SetDBCalled).To(BeTrue())
+	})
+
+	It("requests keys for each mapping", func() {
+		_, err := keysLoader.LoadMappings()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(keySource.GetKeysPassedMappings).To(Equal([]string{"balances", "ilks", "allowance"}))
+	})
+
+	It("returns static keys along with keys for each mapping entry", func() {
+		keySource.Keys = map[string][][]string{"balances": {{owner}}}
+
+		result, err := keysLoader.LoadMappings()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(len(result)).To(Equal(7))
+		Expect(result[common.HexToHash(shared.IndexZero)]).To(Equal(types.GetValueMetadata("totalSupply", nil, types.Uint256)))
+		balanceKey := shared.GetKeyForMapping(shared.IndexSix, common.HexToHash(owner).Hex())
+		Expect(result[balanceKey]).To(Equal(types.GetValueMetadata("balances", map[types.Key]string{"key0": owner}, types.Uint256)))
+	})
+
+	It("returns only static keys without a key source", func() {
+		keysLoader = layout.NewKeysLoader(map[common.Hash]types.ValueMetadata{
+			common.HexToHash(shared.IndexZero): types.GetValueMetadata("totalSupply", nil, types.Uint256),
+		}, []layout.MappingTemplate{{Name: "balances"}}, nil)
+
+		result, err := keysLoader.LoadMappings()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal(map[common.Hash]types.ValueMetadata{
+			common.HexToHash(shared.IndexZero): types.GetValueMetadata("totalSupply", nil, types.Uint256),
+		}))
+	})
+
+	It("returns an error if getting keys fails", func() {
+		keySource.GetKeysError = fakes.FakeError
+
+		_, err := keysLoader.LoadMappings()
+
+		Expect(err).To(MatchError(fakes.FakeError))
+	})
+
+	It("returns an error if the key source returns invalid keys", func() {
+		keySource.Keys = map[string][][]string{"allowance": {{owner}}}
+
+		_, err := keysLoader.LoadMappings()
+
+		Expect(err).To(HaveOccurred())
+	})
+})
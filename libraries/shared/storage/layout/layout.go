@@ -0,0 +1,189 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package layout
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrContractNotFound   = errors.New("contract not found in compiler output")
+	ErrAmbiguousContract  = errors.New("compiler output has multiple contracts, specify which to use")
+	ErrNoStorageLayout    = errors.New("compiler output has no storage layout")
+	ErrUnsupportedType    = errors.New("unsupported storage type")
+	ErrUnknownStorageType = errors.New("storage layout references unknown type")
+)
+
+// StorageLayout is the storageLayout output of solc for a single contract
+type StorageLayout struct {
+	Storage []Variable          `json:"storage"`
+	Types   map[string]TypeInfo `json:"types"`
+}
+
+// Variable is a state variable, or a member of a struct, and where it is stored
+type Variable struct {
+	Label  string `json:"label"`
+	Offset int    `json:"offset"` // byte offset within the slot, from the lowest-order byte
+	Slot   string `json:"slot"`   // decimal slot number, relative to the enclosing struct for members
+	Type   string `json:"type"`   // identifier of the variable's entry in StorageLayout.Types
+}
+
+// TypeInfo describes how a type referenced by the layout is encoded in storage
+type TypeInfo struct {
+	Encoding      string     `json:"encoding"` // one of inplace, mapping, dynamic_array or bytes
+	Label         string     `json:"label"`
+	NumberOfBytes string     `json:"numberOfBytes"`
+	Key           string     `json:"key,omitempty"`     // mappings only
+	Value         string     `json:"value,omitempty"`   // mappings only
+	Base          string     `json:"base,omitempty"`    // arrays only
+	Members       []Variable `json:"members,omitempty"` // structs only
+}
+
+// compilerOutput covers the shapes compiler artifacts holding a storage layout come in: the output of
+// `solc --combined-json storage-layout` keys contracts by "path:Name", the standard-JSON output keys them by path and
+// then name, and single-contract artifacts hold the layout at the top level.
+type compilerOutput struct {
+	Contracts     map[string]json.RawMessage `json:"contracts"`
+	StorageLayout json.RawMessage            `json:"storageLayout"`
+	Storage       json.RawMessage            `json:"storage"`
+}
+
+type combinedJSONContract struct {
+	StorageLayout json.RawMessage `json:"storage-layout"`
+}
+
+type standardJSONContract struct {
+	StorageLayout json.RawMessage `json:"storageLayout"`
+}
+
+// LoadStorageLayout reads the storage layout of the named contract from a compiler artifact on disk
+func LoadStorageLayout(path, contractName string) (StorageLayout, error) {
+	artifact, readErr := ioutil.ReadFile(path)
+	if readErr != nil {
+		return StorageLayout{}, fmt.Errorf("error reading storage layout artifact %s: %w", path, readErr)
+	}
+	return ParseStorageLayout(artifact, contractName)
+}
+
+// ParseStorageLayout extracts the storage layout of the named contract from the output of
+// `solc --combined-json storage-layout`, from standard-JSON output, or from a single contract's artifact.
+// The contract name may be empty if the output only holds one contract.
+func ParseStorageLayout(artifact []byte, contractName string) (StorageLayout, error) {
+	var output compilerOutput
+	unmarshalErr := json.Unmarshal(artifact, &output)
+	if unmarshalErr != nil {
+		return StorageLayout{}, fmt.Errorf("error parsing compiler output: %w", unmarshalErr)
+	}
+
+	if output.Storage != nil {
+		return decodeStorageLayout(artifact)
+	}
+	if output.StorageLayout != nil {
+		return decodeStorageLayout(output.StorageLayout)
+	}
+	if output.Contracts == nil {
+		return StorageLayout{}, ErrNoStorageLayout
+	}
+
+	rawLayouts, collectErr := collectStorageLayouts(output.Contracts)
+	if collectErr != nil {
+		return StorageLayout{}, collectErr
+	}
+	rawLayout, findErr := findContract(rawLayouts, contractName)
+	if findErr != nil {
+		return StorageLayout{}, findErr
+	}
+	return decodeStorageLayout(rawLayout)
+}
+
+// collectStorageLayouts maps each contract's name to its raw storage layout
+func collectStorageLayouts(contracts map[string]json.RawMessage) (map[string]json.RawMessage, error) {
+	result := make(map[string]json.RawMessage)
+	for key, rawContract := range contracts {
+		// combined JSON keys are "path:Name"
+		if index := strings.LastIndex(key, ":"); index != -1 {
+			var contract combinedJSONContract
+			unmarshalErr := json.Unmarshal(rawContract, &contract)
+			if unmarshalErr != nil {
+				return nil, fmt.Errorf("error parsing contract %s: %w", key, unmarshalErr)
+			}
+			if contract.StorageLayout != nil {
+				result[key[index+1:]] = contract.StorageLayout
+			}
+			continue
+		}
+
+		// standard JSON keys are source paths, each holding contracts keyed by name
+		var contractsByName map[string]standardJSONContract
+		unmarshalErr := json.Unmarshal(rawContract, &contractsByName)
+		if unmarshalErr != nil {
+			return nil, fmt.Errorf("error parsing contracts in %s: %w", key, unmarshalErr)
+		}
+		for name, contract := range contractsByName {
+			if contract.StorageLayout != nil {
+				result[name] = contract.StorageLayout
+			}
+		}
+	}
+	return result, nil
+}
+
+func findContract(rawLayouts map[string]json.RawMessage, contractName string) (json.RawMessage, error) {
+	if contractName != "" {
+		rawLayout, ok := rawLayouts[contractName]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrContractNotFound, contractName)
+		}
+		return rawLayout, nil
+	}
+
+	switch len(rawLayouts) {
+	case 0:
+		return nil, ErrNoStorageLayout
+	case 1:
+		for _, rawLayout := range rawLayouts {
+			return rawLayout, nil
+		}
+	}
+	var names []string
+	for name := range rawLayouts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("%w: %s", ErrAmbiguousContract, strings.Join(names, ", "))
+}
+
+// decodeStorageLayout accepts the layout as an object, or as a string holding the object as older versions of solc
+// emit it in combined JSON
+func decodeStorageLayout(rawLayout json.RawMessage) (StorageLayout, error) {
+	var encodedLayout string
+	if json.Unmarshal(rawLayout, &encodedLayout) == nil {
+		rawLayout = json.RawMessage(encodedLayout)
+	}
+
+	var result StorageLayout
+	unmarshalErr := json.Unmarshal(rawLayout, &result)
+	if unmarshalErr != nil {
+		return StorageLayout{}, fmt.Errorf("error parsing storage layout: %w", unmarshalErr)
+	}
+	return result, nil
+}
@@ -0,0 +1,157 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package layout_test
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/makerdao/vulcanizedb/libraries/shared/storage/layout"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// exampleLayout is solc's storage layout for:
+//
+//	contract Example {
+//		struct Ilk { uint256 Art; uint256 rate; }
+//		uint256 totalSupply;
+//		address owner;
+//		uint48 rho;
+//		bool live;
+//		Ilk ilk;
+//		uint128[3] prices;
+//		mapping(address => uint256) balances;
+//		mapping(bytes32 => Ilk) ilks;
+//		mapping(address => mapping(address => uint256)) allowance;
+//	}
+const exampleLayout = `{
+	"storage": [
+		{"label": "totalSupply", "offset": 0, "slot": "0", "type": "t_uint256"},
+		{"label": "owner", "offset": 0, "slot": "1", "type": "t_address"},
+		{"label": "rho", "offset": 20, "slot": "1", "type": "t_uint48"},
+		{"label": "live", "offset": 26, "slot": "1", "type": "t_bool"},
+		{"label": "ilk", "offset": 0, "slot": "2", "type": "t_struct(Ilk)1_storage"},
+		{"label": "prices", "offset": 0, "slot": "4", "type": "t_array(t_uint128)3_storage"},
+		{"label": "balances", "offset": 0, "slot": "6", "type": "t_mapping(t_address,t_uint256)"},
+		{"label": "ilks", "offset": 0, "slot": "7", "type": "t_mapping(t_bytes32,t_struct(Ilk)1_storage)"},
+		{"label": "allowance", "offset": 0, "slot": "8", "type": "t_mapping(t_address,t_mapping(t_address,t_uint256))"}
+	],
+	"types": {
+		"t_address": {"encoding": "inplace", "label": "address", "numberOfBytes": "20"},
+		"t_array(t_uint128)3_storage": {"base": "t_uint128", "encoding": "inplace", "label": "uint128[3]", "numberOfBytes": "64"},
+		"t_bool": {"encoding": "inplace", "label": "bool", "numberOfBytes": "1"},
+		"t_bytes32": {"encoding": "inplace", "label": "bytes32", "numberOfBytes": "32"},
+		"t_mapping(t_address,t_mapping(t_address,t_uint256))": {"encoding": "mapping", "key": "t_address", "label": "mapping(address => mapping(address => uint256))", "numberOfBytes": "32", "value": "t_mapping(t_address,t_uint256)"},
+		"t_mapping(t_address,t_uint256)": {"encoding": "mapping", "key": "t_address", "label": "mapping(address => uint256)", "numberOfBytes": "32", "value": "t_uint256"},
+		"t_mapping(t_bytes32,t_struct(Ilk)1_storage)": {"encoding": "mapping", "key": "t_bytes32", "label": "mapping(bytes32 => struct Example.Ilk)", "numberOfBytes": "32", "value": "t_struct(Ilk)1_storage"},
+		"t_struct(Ilk)1_storage": {"encoding": "inplace", "label": "struct Example.Ilk", "numberOfBytes": "64", "members": [
+			{"label": "Art", "offset": 0, "slot": "0", "type": "t_uint256"},
+			{"label": "rate", "offset": 0, "slot": "1", "type": "t_uint256"}
+		]},
+		"t_uint128": {"encoding": "inplace", "label": "uint128", "numberOfBytes": "16"},
+		"t_uint256": {"encoding": "inplace", "label": "uint256", "numberOfBytes": "32"},
+		"t_uint48": {"encoding": "inplace", "label": "uint48", "numberOfBytes": "6"}
+	}
+}`
+
+var _ = Describe("Storage layout", func() {
+	var expectedLayout layout.StorageLayout
+
+	BeforeEach(func() {
+		unmarshalErr := json.Unmarshal([]byte(exampleLayout), &expectedLayout)
+		Expect(unmarshalErr).NotTo(HaveOccurred())
+	})
+
+	It("parses a bare storage layout", func() {
+		result, err := layout.ParseStorageLayout([]byte(exampleLayout), "")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal(expectedLayout))
+		Expect(len(result.Storage)).To(Equal(9))
+		Expect(result.Types["t_struct(Ilk)1_storage"].Members[1].Label).To(Equal("rate"))
+	})
+
+	It("parses a layout from a single contract's artifact", func() {
+		artifact := fmt.Sprintf(`{"contractName": "Example", "storageLayout": %s}`, exampleLayout)
+
+		result, err := layout.ParseStorageLayout([]byte(artifact), "")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal(expectedLayout))
+	})
+
+	It("parses a named contract's layout from combined JSON output", func() {
+		artifact := fmt.Sprintf(`{"contracts": {
+			"src/Example.sol:Example": {"storage-layout": %s},
+			"src/Other.sol:Other": {"storage-layout": {"storage": [], "types": null}}
+		}}`, exampleLayout)
+
+		result, err := layout.ParseStorageLayout([]byte(artifact), "Example")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal(expectedLayout))
+	})
+
+	It("parses layouts encoded as strings in combined JSON output", func() {
+		encodedLayout, marshalErr := json.Marshal(exampleLayout)
+		Expect(marshalErr).NotTo(HaveOccurred())
+		artifact := fmt.Sprintf(`{"contracts": {"src/Example.sol:Example": {"storage-layout": %s}}}`, encodedLayout)
+
+		result, err := layout.ParseStorageLayout([]byte(artifact), "")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal(expectedLayout))
+	})
+
+	It("parses a named contract's layout from standard JSON output", func() {
+		artifact := fmt.Sprintf(`{"contracts": {"src/Example.sol": {
+			"Example": {"storageLayout": %s},
+			"Other": {"storageLayout": {"storage": [], "types": null}}
+		}}}`, exampleLayout)
+
+		result, err := layout.ParseStorageLayout([]byte(artifact), "Example")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal(expectedLayout))
+	})
+
+	It("returns an error if the named contract isn't in the output", func() {
+		artifact := fmt.Sprintf(`{"contracts": {"src/Example.sol:Example": {"storage-layout": %s}}}`, exampleLayout)
+
+		_, err := layout.ParseStorageLayout([]byte(artifact), "Missing")
+
+		Expect(err).To(MatchError(layout.ErrContractNotFound))
+	})
+
+	It("returns an error if no contract is named and the output has several", func() {
+		artifact := fmt.Sprintf(`{"contracts": {
+			"src/Example.sol:Example": {"storage-layout": %s},
+			"src/Other.sol:Other": {"storage-layout": %s}
+		}}`, exampleLayout, exampleLayout)
+
+		_, err := layout.ParseStorageLayout([]byte(artifact), "")
+
+		Expect(err).To(MatchError(layout.ErrAmbiguousContract))
+	})
+
+	It("returns an error if the output has no storage layout", func() {
+		_, err := layout.ParseStorageLayout([]byte(`{"contracts": {"src/Example.sol:Example": {"abi": []}}}`), "")
+
+		Expect(err).To(MatchError(layout.ErrNoStorageLayout))
+	})
+})
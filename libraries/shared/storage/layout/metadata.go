@@ -0,0 +1,388 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package layout
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/makerdao/vulcanizedb/libraries/shared/storage"
+	"github.com/makerdao/vulcanizedb/libraries/shared/storage/types"
+)
+
+// Packed is the name given to metadata for slots holding more than one value; the values' names are in PackedNames
+const Packed = "packed_storage_values"
+
+// MappingTemplate describes the values stored for each entry of a mapping. Storage keys for an entry are derived from
+// the entry's keys, which must be supplied by a KeySource.
+type MappingTemplate struct {
+	Name     string
+	Slot     common.Hash
+	KeyTypes []string        // Solidity types of the keys, outermost mapping first
+	KeyNames []types.Key     // names under which each key is recorded in the values' metadata
+	Values   []TemplateValue // values stored under each entry
+}
+
+// TemplateValue is a value stored under a mapping entry, SlotOffset slots after the entry's slot
+type TemplateValue struct {
+	SlotOffset int64
+	Metadata   types.ValueMetadata
+}
+
+// slotItem is a single value at a given position in storage
+type slotItem struct {
+	name      string
+	slot      *big.Int
+	offset    int
+	valueType types.ValueType
+}
+
+// StaticKeys returns metadata for every slot whose position doesn't depend on mapping keys
+func (layout StorageLayout) StaticKeys() (map[common.Hash]types.ValueMetadata, error) {
+	var items []slotItem
+	for _, variable := range layout.Storage {
+		slot, slotErr := parseSlot(variable.Slot)
+		if slotErr != nil {
+			return nil, fmt.Errorf("error parsing slot of %s: %w", variable.Label, slotErr)
+		}
+		variableItems, _, expandErr := layout.expand(variable.Label, variable.Type, slot, variable.Offset)
+		if expandErr != nil {
+			return nil, expandErr
+		}
+		items = append(items, variableItems...)
+	}
+
+	result := make(map[common.Hash]types.ValueMetadata)
+	for _, group := range groupBySlot(items) {
+		result[common.BigToHash(group[0].slot)] = metadataForSlot(group)
+	}
+	return result, nil
+}
+
+// MappingTemplates returns a template for every mapping in the layout, including mappings within structs
+func (layout StorageLayout) MappingTemplates() ([]MappingTemplate, error) {
+	var result []MappingTemplate
+	for _, variable := range layout.Storage {
+		slot, slotErr := parseSlot(variable.Slot)
+		if slotErr != nil {
+			return nil, fmt.Errorf("error parsing slot of %s: %w", variable.Label, slotErr)
+		}
+		_, templates, expandErr := layout.expand(variable.Label, variable.Type, slot, variable.Offset)
+		if expandErr != nil {
+			return nil, expandErr
+		}
+		result = append(result, templates...)
+	}
+	return result, nil
+}
+
+// expand breaks the named value of the given type down into the values stored at fixed positions, and templates for
+// any mappings within it
+func (layout StorageLayout) expand(name, typeID string, slot *big.Int, offset int) ([]slotItem, []MappingTemplate, error) {
+	info, ok := layout.Types[typeID]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnknownStorageType, typeID)
+	}
+
+	switch {
+	case info.Encoding == "mapping":
+		template, templateErr := layout.mappingTemplate(name, info, slot)
+		if templateErr != nil {
+			return nil, nil, templateErr
+		}
+		return nil, []MappingTemplate{template}, nil
+	case len(info.Members) > 0:
+		return layout.expandStruct(name, info, slot)
+	case info.Encoding == "inplace" && info.Base != "":
+		return layout.expandStaticArray(name, info, slot)
+	}
+
+	valueType, valueTypeErr := getValueType(info)
+	if valueTypeErr != nil {
+		return nil, nil, fmt.Errorf("error getting type of %s: %w", name, valueTypeErr)
+	}
+	return []slotItem{{name: name, slot: slot, offset: offset, valueType: valueType}}, nil, nil
+}
+
+func (layout StorageLayout) expandStruct(name string, info TypeInfo, slot *big.Int) ([]slotItem, []MappingTemplate, error) {
+	var (
+		items     []slotItem
+		templates []MappingTemplate
+	)
+	for _, member := range info.Members {
+		memberSlot, slotErr := parseSlot(member.Slot)
+		if slotErr != nil {
+			return nil, nil, fmt.Errorf("error parsing slot of %s.%s: %w", name, member.Label, slotErr)
+		}
+		memberItems, memberTemplates, expandErr := layout.expand(name+"."+member.Label, member.Type,
+			new(big.Int).Add(slot, memberSlot), member.Offset)
+		if expandErr != nil {
+			return nil, nil, expandErr
+		}
+		items = append(items, memberItems...)
+		templates = append(templates, memberTemplates...)
+	}
+	return items, templates, nil
+}
+
+// expandStaticArray lays out fixed-length arrays, whose elements are packed several to a slot if they fit
+func (layout StorageLayout) expandStaticArray(name string, info TypeInfo, slot *big.Int) ([]slotItem, []MappingTemplate, error) {
+	length, lengthErr := getStaticArrayLength(info.Label)
+	if lengthErr != nil {
+		return nil, nil, lengthErr
+	}
+	baseInfo, ok := layout.Types[info.Base]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnknownStorageType, info.Base)
+	}
+	baseBytes, bytesErr := strconv.Atoi(baseInfo.NumberOfBytes)
+	if bytesErr != nil {
+		return nil, nil, fmt.Errorf("error parsing size of %s: %w", baseInfo.Label, bytesErr)
+	}
+
+	var (
+		items     []slotItem
+		templates []MappingTemplate
+	)
+	for i := int64(0); i < length; i++ {
+		elementSlot, elementOffset := getStaticArrayElementPosition(i, baseBytes)
+		elementItems, elementTemplates, expandErr := layout.expand(fmt.Sprintf("%s[%d]", name, i), info.Base,
+			new(big.Int).Add(slot, big.NewInt(elementSlot)), elementOffset)
+		if expandErr != nil {
+			return nil, nil, expandErr
+		}
+		items = append(items, elementItems...)
+		templates = append(templates, elementTemplates...)
+	}
+	return items, templates, nil
+}
+
+func getStaticArrayElementPosition(index int64, elementBytes int) (int64, int) {
+	if elementBytes < 32 {
+		perSlot := int64(32 / elementBytes)
+		return index / perSlot, int(index%perSlot) * elementBytes
+	}
+	slotsPerElement := int64((elementBytes + 31) / 32)
+	return index * slotsPerElement, 0
+}
+
+func getStaticArrayLength(label string) (int64, error) {
+	start := strings.LastIndex(label, "[")
+	if start == -1 || !strings.HasSuffix(label, "]") {
+		return 0, fmt.Errorf("%w: %s", ErrUnsupportedType, label)
+	}
+	return strconv.ParseInt(label[start+1:len(label)-1], 10, 64)
+}
+
+// mappingTemplate collects the keys of nested mappings, and the values stored under each entry of the innermost one
+func (layout StorageLayout) mappingTemplate(name string, info TypeInfo, slot *big.Int) (MappingTemplate, error) {
+	template := MappingTemplate{Name: name, Slot: common.BigToHash(slot)}
+	for info.Encoding == "mapping" {
+		keyInfo, ok := layout.Types[info.Key]
+		if !ok {
+			return MappingTemplate{}, fmt.Errorf("%w: %s", ErrUnknownStorageType, info.Key)
+		}
+		template.KeyTypes = append(template.KeyTypes, keyInfo.Label)
+		template.KeyNames = append(template.KeyNames, types.Key(fmt.Sprintf("key%d", len(template.KeyNames))))
+		valueTypeID := info.Value
+		info, ok = layout.Types[valueTypeID]
+		if !ok {
+			return MappingTemplate{}, fmt.Errorf("%w: %s", ErrUnknownStorageType, valueTypeID)
+		}
+		if info.Encoding != "mapping" {
+			items, nestedTemplates, expandErr := layout.expand(name, valueTypeID, big.NewInt(0), 0)
+			if expandErr != nil {
+				return MappingTemplate{}, expandErr
+			}
+			if len(nestedTemplates) > 0 {
+				return MappingTemplate{}, fmt.Errorf("%w: mapping within a struct stored in mapping %s", ErrUnsupportedType, name)
+			}
+			for _, group := range groupBySlot(items) {
+				template.Values = append(template.Values, TemplateValue{
+					SlotOffset: group[0].slot.Int64(),
+					Metadata:   metadataForSlot(group),
+				})
+			}
+		}
+	}
+	return template, nil
+}
+
+// StorageKeys returns metadata for the values stored under the entry with the given keys, outermost first
+func (template MappingTemplate) StorageKeys(keys []string) (map[common.Hash]types.ValueMetadata, error) {
+	if len(keys) != len(template.KeyTypes) {
+		return nil, fmt.Errorf("mapping %s expects %d keys, got %d", template.Name, len(template.KeyTypes), len(keys))
+	}
+
+	entrySlot := template.Slot
+	metadataKeys := make(map[types.Key]string)
+	for i, key := range keys {
+		encodedKey, encodeErr := encodeMappingKey(template.KeyTypes[i], key)
+		if encodeErr != nil {
+			return nil, fmt.Errorf("error encoding key %s for mapping %s: %w", key, template.Name, encodeErr)
+		}
+		entrySlot = crypto.Keccak256Hash(encodedKey, entrySlot.Bytes())
+		metadataKeys[template.KeyNames[i]] = key
+	}
+
+	result := make(map[common.Hash]types.ValueMetadata)
+	for _, value := range template.Values {
+		metadata := value.Metadata
+		metadata.Keys = metadataKeys
+		result[storage.GetIncrementedKey(entrySlot, value.SlotOffset)] = metadata
+	}
+	return result, nil
+}
+
+// encodeMappingKey pads value types to a full word as Solidity does when hashing mapping keys; strings and bytes are
+// hashed as is
+func encodeMappingKey(keyType, key string) ([]byte, error) {
+	switch {
+	case keyType == "string":
+		return []byte(key), nil
+	case keyType == "bytes":
+		return common.FromHex(key), nil
+	case keyType == "bool":
+		switch key {
+		case "true":
+			return common.LeftPadBytes([]byte{1}, 32), nil
+		case "false":
+			return make([]byte, 32), nil
+		}
+		return nil, fmt.Errorf("invalid bool key: %s", key)
+	case isAddressType(keyType):
+		if !common.IsHexAddress(key) {
+			return nil, fmt.Errorf("invalid address key: %s", key)
+		}
+		return common.LeftPadBytes(common.HexToAddress(key).Bytes(), 32), nil
+	case strings.HasPrefix(keyType, "bytes"):
+		return common.RightPadBytes(common.FromHex(key), 32), nil
+	case strings.HasPrefix(keyType, "uint"), strings.HasPrefix(keyType, "int"), strings.HasPrefix(keyType, "enum "):
+		number, ok := new(big.Int).SetString(key, 0)
+		if !ok {
+			return nil, fmt.Errorf("invalid integer key: %s", key)
+		}
+		if number.Sign() < 0 {
+			// two's complement
+			number.Add(number, new(big.Int).Lsh(big.NewInt(1), 256))
+		}
+		return common.LeftPadBytes(number.Bytes(), 32), nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, keyType)
+}
+
+// groupBySlot collects items sharing a slot, ordered by slot and then by offset within the slot
+func groupBySlot(items []slotItem) [][]slotItem {
+	sort.SliceStable(items, func(i, j int) bool {
+		if comparison := items[i].slot.Cmp(items[j].slot); comparison != 0 {
+			return comparison < 0
+		}
+		return items[i].offset < items[j].offset
+	})
+
+	var result [][]slotItem
+	for _, item := range items {
+		last := len(result) - 1
+		if last >= 0 && result[last][0].slot.Cmp(item.slot) == 0 {
+			result[last] = append(result[last], item)
+		} else {
+			result = append(result, []slotItem{item})
+		}
+	}
+	return result
+}
+
+func metadataForSlot(items []slotItem) types.ValueMetadata {
+	if len(items) == 1 {
+		return types.GetValueMetadata(items[0].name, nil, items[0].valueType)
+	}
+	packedNames := make(map[int]string)
+	packedTypes := make(map[int]types.ValueType)
+	for position, item := range items {
+		packedNames[position] = item.name
+		packedTypes[position] = item.valueType
+	}
+	return types.GetValueMetadataForPackedSlot(Packed, nil, types.PackedSlot, packedNames, packedTypes)
+}
+
+func parseSlot(slot string) (*big.Int, error) {
+	result, ok := new(big.Int).SetString(slot, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid slot: %s", slot)
+	}
+	return result, nil
+}
+
+func isAddressType(label string) bool {
+	return label == "address" || label == "address payable" || strings.HasPrefix(label, "contract ")
+}
+
+var valueTypesByLabel = map[string]types.ValueType{
+	"bool":    types.Bool,
+	"bytes32": types.Bytes32,
+	"string":  types.String,
+	"bytes":   types.Bytes,
+}
+
+func getValueType(info TypeInfo) (types.ValueType, error) {
+	if info.Encoding == "dynamic_array" {
+		return types.DynamicArray, nil
+	}
+	if valueType, ok := valueTypesByLabel[info.Label]; ok {
+		return valueType, nil
+	}
+	if valueType, ok := getIntegerType(info.Label); ok {
+		return valueType, nil
+	}
+	if isAddressType(info.Label) {
+		return types.Address, nil
+	}
+	if strings.HasPrefix(info.Label, "enum ") && info.NumberOfBytes == "1" {
+		return types.Uint8, nil
+	}
+	if strings.HasPrefix(info.Label, "bytes") {
+		width, widthErr := strconv.Atoi(strings.TrimPrefix(info.Label, "bytes"))
+		if widthErr == nil && width >= 1 && width <= 31 {
+			return types.Bytes1 + types.ValueType(width-1), nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %s", ErrUnsupportedType, info.Label)
+}
+
+// getIntegerType returns the type for a uintN or intN label
+func getIntegerType(label string) (types.ValueType, bool) {
+	if strings.HasPrefix(label, "uint") {
+		bits, bitsErr := strconv.Atoi(strings.TrimPrefix(label, "uint"))
+		if bitsErr != nil {
+			return 0, false
+		}
+		return types.UintType(bits)
+	}
+	if strings.HasPrefix(label, "int") {
+		bits, bitsErr := strconv.Atoi(strings.TrimPrefix(label, "int"))
+		if bitsErr != nil {
+			return 0, false
+		}
+		return types.IntType(bits)
+	}
+	return 0, false
+}
@@ -0,0 +1,199 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package layout_test
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/makerdao/vulcanizedb/libraries/shared/storage"
+	"github.com/makerdao/vulcanizedb/libraries/shared/storage/layout"
+	"github.com/makerdao/vulcanizedb/libraries/shared/storage/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Storage layout metadata", func() {
+	var exampleStorageLayout layout.StorageLayout
+
+	BeforeEach(func() {
+		var parseErr error
+		exampleStorageLayout, parseErr = layout.ParseStorageLayout([]byte(exampleLayout), "")
+		Expect(parseErr).NotTo(HaveOccurred())
+	})
+
+	Describe("StaticKeys", func() {
+		It("returns metadata for values at fixed slots, packing values that share a slot", func() {
+			result, err := exampleStorageLayout.StaticKeys()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(map[common.Hash]types.ValueMetadata{
+				common.HexToHash(storage.IndexZero): types.GetValueMetadata("totalSupply", nil, types.Uint256),
+				common.HexToHash(storage.IndexOne): types.GetValueMetadataForPackedSlot(layout.Packed, nil, types.PackedSlot,
+					map[int]string{0: "owner", 1: "rho", 2: "live"},
+					map[int]types.ValueType{0: types.Address, 1: types.Uint48, 2: types.Bool}),
+				common.HexToHash(storage.IndexTwo):   types.GetValueMetadata("ilk.Art", nil, types.Uint256),
+				common.HexToHash(storage.IndexThree): types.GetValueMetadata("ilk.rate", nil, types.Uint256),
+				common.HexToHash(storage.IndexFour): types.GetValueMetadataForPackedSlot(layout.Packed, nil, types.PackedSlot,
+					map[int]string{0: "prices[0]", 1: "prices[1]"},
+					map[int]types.ValueType{0: types.Uint128, 1: types.Uint128}),
+				common.HexToHash(storage.IndexFive): types.GetValueMetadata("prices[2]", nil, types.Uint128),
+			}))
+		})
+
+		It("returns metadata for integers of any width that's a multiple of 8 bits", func() {
+			exampleStorageLayout.Types["t_uint256"] = layout.TypeInfo{Encoding: "inplace", Label: "uint24", NumberOfBytes: "3"}
+			exampleStorageLayout.Types["t_bool"] = layout.TypeInfo{Encoding: "inplace", Label: "int56", NumberOfBytes: "7"}
+
+			result, err := exampleStorageLayout.StaticKeys()
+
+			Expect(err).NotTo(HaveOccurred())
+			var valueTypes []types.ValueType
+			for _, metadata := range result {
+				valueTypes = append(valueTypes, metadata.Type)
+				for _, packedType := range metadata.PackedTypes {
+					valueTypes = append(valueTypes, packedType)
+				}
+			}
+			Expect(valueTypes).To(ContainElement(types.Uint24))
+			Expect(valueTypes).To(ContainElement(types.Int56))
+		})
+
+		It("returns an error for integer widths that aren't a multiple of 8 bits", func() {
+			exampleStorageLayout.Types["t_uint256"] = layout.TypeInfo{Encoding: "inplace", Label: "uint12", NumberOfBytes: "2"}
+
+			_, err := exampleStorageLayout.StaticKeys()
+
+			Expect(err).To(MatchError(layout.ErrUnsupportedType))
+		})
+
+		It("returns an error for types it can't decode", func() {
+			exampleStorageLayout.Types["t_uint256"] = layout.TypeInfo{Encoding: "inplace", Label: "fixed128x18", NumberOfBytes: "32"}
+
+			_, err := exampleStorageLayout.StaticKeys()
+
+			Expect(err).To(MatchError(layout.ErrUnsupportedType))
+		})
+
+		It("returns an error for types missing from the layout", func() {
+			delete(exampleStorageLayout.Types, "t_bool")
+
+			_, err := exampleStorageLayout.StaticKeys()
+
+			Expect(err).To(MatchError(layout.ErrUnknownStorageType))
+		})
+	})
+
+	Describe("MappingTemplates", func() {
+		It("returns a template for each mapping", func() {
+			result, err := exampleStorageLayout.MappingTemplates()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal([]layout.MappingTemplate{
+				{
+					Name:     "balances",
+					Slot:     common.HexToHash(storage.IndexSix),
+					KeyTypes: []string{"address"},
+					KeyNames: []types.Key{"key0"},
+					Values: []layout.TemplateValue{
+						{SlotOffset: 0, Metadata: types.GetValueMetadata("balances", nil, types.Uint256)},
+					},
+				},
+				{
+					Name:     "ilks",
+					Slot:     common.HexToHash(storage.IndexSeven),
+					KeyTypes: []string{"bytes32"},
+					KeyNames: []types.Key{"key0"},
+					Values: []layout.TemplateValue{
+						{SlotOffset: 0, Metadata: types.GetValueMetadata("ilks.Art", nil, types.Uint256)},
+						{SlotOffset: 1, Metadata: types.GetValueMetadata("ilks.rate", nil, types.Uint256)},
+					},
+				},
+				{
+					Name:     "allowance",
+					Slot:     common.HexToHash(storage.IndexEight),
+					KeyTypes: []string{"address", "address"},
+					KeyNames: []types.Key{"key0", "key1"},
+					Values: []layout.TemplateValue{
+						{SlotOffset: 0, Metadata: types.GetValueMetadata("allowance", nil, types.Uint256)},
+					},
+				},
+			}))
+		})
+	})
+
+	Describe("StorageKeys", func() {
+		var (
+			templates []layout.MappingTemplate
+			owner     = "0x16Fb96a5fa0427Af0C8F7cF1eB4870231c8154B6"
+			spender   = "0x5c8fB5847F5D3D5c8B1a30A4C3a3e9c1E4A2D5d1"
+		)
+
+		BeforeEach(func() {
+			var templatesErr error
+			templates, templatesErr = exampleStorageLayout.MappingTemplates()
+			Expect(templatesErr).NotTo(HaveOccurred())
+		})
+
+		It("derives the slot of an address keyed entry", func() {
+			result, err := templates[0].StorageKeys([]string{owner})
+
+			Expect(err).NotTo(HaveOccurred())
+			expectedKey := storage.GetKeyForMapping(storage.IndexSix, common.HexToHash(owner).Hex())
+			Expect(result).To(Equal(map[common.Hash]types.ValueMetadata{
+				expectedKey: types.GetValueMetadata("balances", map[types.Key]string{"key0": owner}, types.Uint256),
+			}))
+		})
+
+		It("derives slots of each member of a struct entry", func() {
+			ilk := "0x4554482d41000000000000000000000000000000000000000000000000000000"
+
+			result, err := templates[1].StorageKeys([]string{ilk})
+
+			Expect(err).NotTo(HaveOccurred())
+			artKey := storage.GetKeyForMapping(storage.IndexSeven, ilk)
+			keys := map[types.Key]string{"key0": ilk}
+			Expect(result).To(Equal(map[common.Hash]types.ValueMetadata{
+				artKey:                               types.GetValueMetadata("ilks.Art", keys, types.Uint256),
+				storage.GetIncrementedKey(artKey, 1): types.GetValueMetadata("ilks.rate", keys, types.Uint256),
+			}))
+		})
+
+		It("derives the slot of a nested mapping entry", func() {
+			result, err := templates[2].StorageKeys([]string{owner, spender})
+
+			Expect(err).NotTo(HaveOccurred())
+			expectedKey := storage.GetKeyForNestedMapping(storage.IndexEight, common.HexToHash(owner).Hex(),
+				common.HexToHash(spender).Hex())
+			Expect(result).To(Equal(map[common.Hash]types.ValueMetadata{
+				expectedKey: types.GetValueMetadata("allowance", map[types.Key]string{"key0": owner, "key1": spender}, types.Uint256),
+			}))
+		})
+
+		It("returns an error if the number of keys doesn't match the mapping", func() {
+			_, err := templates[2].StorageKeys([]string{owner})
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("expects 2 keys"))
+		})
+
+		It("returns an error if a key can't be encoded as the mapping's key type", func() {
+			_, err := templates[0].StorageKeys([]string{"not an address"})
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("invalid address key"))
+		})
+	})
+})
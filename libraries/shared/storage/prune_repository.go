@@ -0,0 +1,198 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package storage
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/makerdao/vulcanizedb/libraries/shared/storage/types"
+	"github.com/makerdao/vulcanizedb/pkg/datastore/postgres"
+)
+
+const archivedDiffColumns = `id, address, block_height, block_hash, storage_key, storage_value, eth_node_id, status,
+	from_backfill, created, updated, attempts, last_error, last_attempted`
+
+// PruneFilter selects diffs to prune: those at or below MaxBlockHeight with one of the statuses, ordered by ID
+// from MinID (exclusive)
+type PruneFilter struct {
+	MaxBlockHeight int64
+	Statuses       []string
+	MinID          int64
+	Limit          int
+}
+
+type PruneRepository interface {
+	GetHeadBlockNumber() (int64, error)
+	GetDiffsToPrune(filter PruneFilter) ([]types.ArchivedDiff, error)
+	DeleteDiffs(ids []int64) (int64, error)
+	MoveDiffsToArchiveTable(ids []int64) (int64, error)
+	InsertArchivedDiffs(diffs []types.ArchivedDiff) (int64, error)
+	RestoreDiffsFromArchiveTable(fromBlock, toBlock int64) (int64, error)
+}
+
+type pruneRepository struct {
+	db *postgres.DB
+}
+
+func NewPruneRepository(db *postgres.DB) pruneRepository {
+	return pruneRepository{db: db}
+}
+
+func (repository pruneRepository) GetHeadBlockNumber() (int64, error) {
+	var head int64
+	err := repository.db.Get(&head, `SELECT COALESCE(MAX(block_number), 0) FROM public.headers`)
+	return head, err
+}
+
+// GetDiffsToPrune skips diffs that transformer output still references, since deleting them would cascade
+func (repository pruneRepository) GetDiffsToPrune(filter PruneFilter) ([]types.ArchivedDiff, error) {
+	unreferenced, referencesErr := repository.unreferencedDiffsCondition()
+	if referencesErr != nil {
+		return nil, referencesErr
+	}
+	var diffs []types.ArchivedDiff
+	query := fmt.Sprintf(`SELECT %s FROM public.storage_diff
+		WHERE id > $1 AND block_height <= $2 AND status = ANY($3::diff_status[])%s
+		ORDER BY id ASC LIMIT $4`, archivedDiffColumns, unreferenced)
+	err := repository.db.Select(&diffs, query, filter.MinID, filter.MaxBlockHeight, pq.Array(filter.Statuses), filter.Limit)
+	return diffs, err
+}
+
+// DeleteDiffs deletes the diffs with the given IDs, except any referenced since they were selected
+func (repository pruneRepository) DeleteDiffs(ids []int64) (int64, error) {
+	unreferenced, referencesErr := repository.unreferencedDiffsCondition()
+	if referencesErr != nil {
+		return 0, referencesErr
+	}
+	result, deleteErr := repository.db.Exec(`DELETE FROM public.storage_diff WHERE id = ANY($1)`+unreferenced,
+		pq.Array(ids))
+	if deleteErr != nil {
+		return 0, fmt.Errorf("error deleting storage diffs: %w", deleteErr)
+	}
+	return result.RowsAffected()
+}
+
+// MoveDiffsToArchiveTable copies the diffs with the given IDs to public.storage_diff_archive, deleting those copied.
+// Diffs whose ID is already archived are left in place, rather than being lost from both tables.
+func (repository pruneRepository) MoveDiffsToArchiveTable(ids []int64) (int64, error) {
+	unreferenced, referencesErr := repository.unreferencedDiffsCondition()
+	if referencesErr != nil {
+		return 0, referencesErr
+	}
+	query := fmt.Sprintf(`WITH moved AS (
+			INSERT INTO public.storage_diff_archive (%s)
+			SELECT %s FROM public.storage_diff WHERE id = ANY($1)%s
+			ON CONFLICT (id) DO NOTHING
+			RETURNING id
+		)
+		DELETE FROM public.storage_diff WHERE id IN (SELECT id FROM moved)`,
+		archivedDiffColumns, archivedDiffColumns, unreferenced)
+	result, moveErr := repository.db.Exec(query, pq.Array(ids))
+	if moveErr != nil {
+		return 0, fmt.Errorf("error moving storage diffs to archive table: %w", moveErr)
+	}
+	return result.RowsAffected()
+}
+
+// InsertArchivedDiffs restores diffs with their original IDs, skipping any already present
+func (repository pruneRepository) InsertArchivedDiffs(diffs []types.ArchivedDiff) (int64, error) {
+	tx, txErr := repository.db.Beginx()
+	if txErr != nil {
+		return 0, txErr
+	}
+	var inserted int64
+	for _, diff := range diffs {
+		result, insertErr := tx.NamedExec(`INSERT INTO public.storage_diff (`+archivedDiffColumns+`)
+			VALUES (:id, :address, :block_height, :block_hash, :storage_key, :storage_value, :eth_node_id, :status,
+				:from_backfill, :created, :updated, :attempts, :last_error, :last_attempted)
+			ON CONFLICT DO NOTHING`, diff)
+		if insertErr != nil {
+			rollbackErr := tx.Rollback()
+			if rollbackErr != nil {
+				return 0, fmt.Errorf("error restoring diff %d: %w (rollback also failed: %s)", diff.ID, insertErr, rollbackErr.Error())
+			}
+			return 0, fmt.Errorf("error restoring diff %d: %w", diff.ID, insertErr)
+		}
+		rows, rowsErr := result.RowsAffected()
+		if rowsErr != nil {
+			_ = tx.Rollback()
+			return 0, rowsErr
+		}
+		inserted += rows
+	}
+	return inserted, tx.Commit()
+}
+
+// RestoreDiffsFromArchiveTable moves archived diffs in the block range back to public.storage_diff. Diffs that
+// conflict with one already in public.storage_diff stay in the archive table.
+func (repository pruneRepository) RestoreDiffsFromArchiveTable(fromBlock, toBlock int64) (int64, error) {
+	query := fmt.Sprintf(`WITH restored AS (
+			INSERT INTO public.storage_diff (%s)
+			SELECT %s FROM public.storage_diff_archive WHERE block_height BETWEEN $1 AND $2
+			ON CONFLICT DO NOTHING
+			RETURNING id
+		)
+		DELETE FROM public.storage_diff_archive WHERE id IN (SELECT id FROM restored)`,
+		archivedDiffColumns, archivedDiffColumns)
+	result, restoreErr := repository.db.Exec(query, fromBlock, toBlock)
+	if restoreErr != nil {
+		return 0, fmt.Errorf("error restoring storage diffs from archive table: %w", restoreErr)
+	}
+	return result.RowsAffected()
+}
+
+type diffReference struct {
+	TableName  string `db:"table_name"`
+	ColumnName string `db:"column_name"`
+}
+
+func (reference diffReference) existsCondition() string {
+	return fmt.Sprintf(`EXISTS (SELECT 1 FROM %s WHERE %s = storage_diff.id)`,
+		reference.TableName, pq.QuoteIdentifier(reference.ColumnName))
+}
+
+// getDiffReferences returns the columns with foreign keys on public.storage_diff, which transformer tables declare
+// with ON DELETE CASCADE
+func (repository pruneRepository) getDiffReferences() ([]diffReference, error) {
+	var references []diffReference
+	err := repository.db.Select(&references, `SELECT c.conrelid::regclass::text AS table_name, a.attname AS column_name
+		FROM pg_constraint c
+			JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = c.conkey[1]
+		WHERE c.contype = 'f' AND c.confrelid = 'public.storage_diff'::regclass
+		ORDER BY table_name, column_name`)
+	if err != nil {
+		return nil, fmt.Errorf("error getting tables referencing storage diffs: %w", err)
+	}
+	return references, nil
+}
+
+// unreferencedDiffsCondition excludes diffs referenced by transformer tables, since deleting them would cascade
+func (repository pruneRepository) unreferencedDiffsCondition() (string, error) {
+	references, err := repository.getDiffReferences()
+	if err != nil {
+		return "", err
+	}
+
+	var conditions strings.Builder
+	for _, reference := range references {
+		conditions.WriteString(`
+			AND NOT ` + reference.existsCondition())
+	}
+	return conditions.String(), nil
+}
@@ -0,0 +1,186 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package storage_test
+
+import (
+	"github.com/makerdao/vulcanizedb/libraries/shared/storage"
+	"github.com/makerdao/vulcanizedb/libraries/shared/storage/types"
+	"github.com/makerdao/vulcanizedb/pkg/datastore/postgres/repositories"
+	"github.com/makerdao/vulcanizedb/pkg/fakes"
+	"github.com/makerdao/vulcanizedb/test_config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Prune repository", func() {
+	var (
+		db   = test_config.NewTestDB(test_config.NewTestNode())
+		repo storage.PruneRepository
+	)
+
+	BeforeEach(func() {
+		test_config.CleanTestDB(db)
+		repo = storage.NewPruneRepository(db)
+	})
+
+	insertDiff := func(id int64, blockHeight int, status string) {
+		diff := createFakePersistedDiff(createFakeRawDiff(blockHeight), status, db.NodeID)
+		diff.ID = id
+		insertTestDiff(diff, db)
+	}
+
+	getDiffIDs := func(table string) []int64 {
+		var ids []int64
+		Expect(db.Select(&ids, `SELECT id FROM public.`+table+` ORDER BY id`)).To(Succeed())
+		return ids
+	}
+
+	Describe("GetHeadBlockNumber", func() {
+		It("returns the highest header's block number", func() {
+			headerRepository := repositories.NewHeaderRepository(db)
+			_, insertErr := headerRepository.CreateOrUpdateHeader(fakes.GetFakeHeader(10))
+			Expect(insertErr).NotTo(HaveOccurred())
+			_, insertErr = headerRepository.CreateOrUpdateHeader(fakes.GetFakeHeader(12))
+			Expect(insertErr).NotTo(HaveOccurred())
+
+			head, err := repo.GetHeadBlockNumber()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(head).To(Equal(int64(12)))
+		})
+	})
+
+	Describe("GetDiffsToPrune", func() {
+		It("returns diffs with the statuses at or below the block height, after the min ID", func() {
+			insertDiff(1, 10, storage.Transformed)
+			insertDiff(2, 10, storage.Unwatched)
+			insertDiff(3, 10, storage.New)
+			insertDiff(4, 20, storage.Transformed)
+			insertDiff(5, 10, storage.Noncanonical)
+
+			diffs, err := repo.GetDiffsToPrune(storage.PruneFilter{
+				MaxBlockHeight: 15,
+				Statuses:       storage.DefaultPruneStatuses,
+				MinID:          1,
+				Limit:          10,
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(diffs)).To(Equal(2))
+			Expect(diffs[0].ID).To(Equal(int64(2)))
+			Expect(diffs[0].Status).To(Equal(storage.Unwatched))
+			Expect(diffs[1].ID).To(Equal(int64(5)))
+		})
+
+		It("skips diffs referenced by other tables", func() {
+			db.MustExec(`CREATE TABLE public.test_storage_values (
+				diff_id BIGINT NOT NULL REFERENCES public.storage_diff (id) ON DELETE CASCADE)`)
+			defer db.MustExec(`DROP TABLE public.test_storage_values`)
+			insertDiff(1, 10, storage.Transformed)
+			insertDiff(2, 10, storage.Transformed)
+			db.MustExec(`INSERT INTO public.test_storage_values (diff_id) VALUES (1)`)
+
+			diffs, err := repo.GetDiffsToPrune(storage.PruneFilter{
+				MaxBlockHeight: 15,
+				Statuses:       []string{storage.Transformed},
+				Limit:          10,
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(diffs)).To(Equal(1))
+			Expect(diffs[0].ID).To(Equal(int64(2)))
+
+			deleted, deleteErr := repo.DeleteDiffs([]int64{1, 2})
+			Expect(deleteErr).NotTo(HaveOccurred())
+			Expect(deleted).To(Equal(int64(1)))
+			Expect(getDiffIDs("storage_diff")).To(Equal([]int64{1}))
+		})
+	})
+
+	Describe("archive table", func() {
+		It("moves diffs to the archive table and restores them by block range", func() {
+			insertDiff(1, 10, storage.Transformed)
+			insertDiff(2, 20, storage.Transformed)
+
+			moved, moveErr := repo.MoveDiffsToArchiveTable([]int64{1, 2})
+			Expect(moveErr).NotTo(HaveOccurred())
+			Expect(moved).To(Equal(int64(2)))
+			Expect(getDiffIDs("storage_diff")).To(BeEmpty())
+			Expect(getDiffIDs("storage_diff_archive")).To(Equal([]int64{1, 2}))
+
+			restored, restoreErr := repo.RestoreDiffsFromArchiveTable(5, 15)
+			Expect(restoreErr).NotTo(HaveOccurred())
+			Expect(restored).To(Equal(int64(1)))
+			Expect(getDiffIDs("storage_diff")).To(Equal([]int64{1}))
+			Expect(getDiffIDs("storage_diff_archive")).To(Equal([]int64{2}))
+		})
+
+		It("leaves diffs in place if they're already archived", func() {
+			insertDiff(1, 10, storage.Transformed)
+			insertDiff(2, 10, storage.Transformed)
+			_, moveErr := repo.MoveDiffsToArchiveTable([]int64{1})
+			Expect(moveErr).NotTo(HaveOccurred())
+			insertDiff(1, 11, storage.Transformed)
+
+			moved, err := repo.MoveDiffsToArchiveTable([]int64{1, 2})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(moved).To(Equal(int64(1)))
+			Expect(getDiffIDs("storage_diff")).To(Equal([]int64{1}))
+			Expect(getDiffIDs("storage_diff_archive")).To(Equal([]int64{1, 2}))
+		})
+
+		It("leaves diffs in the archive table if they conflict with an existing diff", func() {
+			insertDiff(1, 10, storage.Transformed)
+			insertDiff(2, 10, storage.Transformed)
+			_, moveErr := repo.MoveDiffsToArchiveTable([]int64{1, 2})
+			Expect(moveErr).NotTo(HaveOccurred())
+			insertDiff(1, 11, storage.Transformed)
+
+			restored, err := repo.RestoreDiffsFromArchiveTable(5, 15)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(restored).To(Equal(int64(1)))
+			Expect(getDiffIDs("storage_diff")).To(Equal([]int64{1, 2}))
+			Expect(getDiffIDs("storage_diff_archive")).To(Equal([]int64{1}))
+		})
+	})
+
+	Describe("InsertArchivedDiffs", func() {
+		It("restores diffs with their original IDs and statuses, skipping existing diffs", func() {
+			insertDiff(1, 10, storage.Transformed)
+			insertDiff(2, 10, storage.Unwatched)
+			diffs, getErr := repo.GetDiffsToPrune(storage.PruneFilter{
+				MaxBlockHeight: 15,
+				Statuses:       []string{storage.Transformed, storage.Unwatched},
+				Limit:          10,
+			})
+			Expect(getErr).NotTo(HaveOccurred())
+			_, deleteErr := repo.DeleteDiffs([]int64{2})
+			Expect(deleteErr).NotTo(HaveOccurred())
+
+			inserted, err := repo.InsertArchivedDiffs(diffs)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(inserted).To(Equal(int64(1)))
+			var restored []types.ArchivedDiff
+			Expect(db.Select(&restored, `SELECT id, status FROM public.storage_diff ORDER BY id`)).To(Succeed())
+			Expect(restored[1].ID).To(Equal(int64(2)))
+			Expect(restored[1].Status).To(Equal(storage.Unwatched))
+		})
+	})
+})
@@ -0,0 +1,142 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/makerdao/vulcanizedb/libraries/shared/storage/types"
+	"github.com/makerdao/vulcanizedb/pkg/core"
+	"github.com/makerdao/vulcanizedb/pkg/datastore/postgres"
+	"github.com/makerdao/vulcanizedb/utils"
+)
+
+type ReconciliationRepository interface {
+	ReconcileBlock(canonicalHeader core.Header) (types.Reconciliation, error)
+}
+
+type reconciliationRepository struct {
+	db *postgres.DB
+}
+
+func NewReconciliationRepository(db *postgres.DB) reconciliationRepository {
+	return reconciliationRepository{db: db}
+}
+
+// ReconcileBlock replaces the stored header at the block if its hash isn't canonical, requeues noncanonical and
+// pending diffs at the block that match the canonical hash, deletes diffs at the block from any other hash, and
+// records what was done, in a single DB transaction. Deleting a stale header deletes the data synced for it. Nothing
+// is recorded if no header was replaced and no diffs were changed.
+func (repository reconciliationRepository) ReconcileBlock(canonicalHeader core.Header) (types.Reconciliation, error) {
+	blockHeight := canonicalHeader.BlockNumber
+	canonicalHash := common.HexToHash(canonicalHeader.Hash)
+	reconciliation := types.Reconciliation{
+		BlockHeight:   blockHeight,
+		CanonicalHash: canonicalHash.Hex(),
+	}
+
+	tx, txErr := repository.db.Beginx()
+	if txErr != nil {
+		return reconciliation, fmt.Errorf("error beginning reconciliation of block %d: %w", blockHeight, txErr)
+	}
+
+	replaceErr := replaceStaleHeaderInTx(tx, repository.db.NodeID, canonicalHeader, &reconciliation)
+	if replaceErr != nil {
+		utils.RollbackAndLogFailure(tx, replaceErr, "headers")
+		return reconciliation, fmt.Errorf("error replacing stale header at block %d: %w", blockHeight, replaceErr)
+	}
+	reconcileErr := reconcileDiffsInTx(tx, &reconciliation, canonicalHash)
+	if reconcileErr != nil {
+		utils.RollbackAndLogFailure(tx, reconcileErr, "storage_diff")
+		return reconciliation, fmt.Errorf("error reconciling diffs at block %d: %w", blockHeight, reconcileErr)
+	}
+	return reconciliation, tx.Commit()
+}
+
+// replaceStaleHeaderInTx persists the canonical header, setting the reconciliation's StaleHeaderHash if it replaced one
+func replaceStaleHeaderInTx(tx *sqlx.Tx, nodeID int64, canonicalHeader core.Header, reconciliation *types.Reconciliation) error {
+	var storedHash string
+	getErr := tx.Get(&storedHash, `SELECT hash FROM public.headers WHERE block_number = $1 FOR UPDATE`,
+		canonicalHeader.BlockNumber)
+	switch {
+	case errors.Is(getErr, sql.ErrNoRows):
+	case getErr != nil:
+		return fmt.Errorf("error getting stored header: %w", getErr)
+	case storedHash == canonicalHeader.Hash:
+		return nil
+	default:
+		// get_or_create_header won't replace headers outside its validation window, so delete the stale header first
+		_, deleteErr := tx.Exec(`DELETE FROM public.headers WHERE block_number = $1`, canonicalHeader.BlockNumber)
+		if deleteErr != nil {
+			return fmt.Errorf("error deleting stale header: %w", deleteErr)
+		}
+		reconciliation.StaleHeaderHash = storedHash
+	}
+
+	_, insertErr := tx.Exec(`SELECT * FROM public.get_or_create_header($1, $2, $3, $4, $5)`,
+		canonicalHeader.BlockNumber, canonicalHeader.Hash, canonicalHeader.Raw, canonicalHeader.Timestamp, nodeID)
+	if insertErr != nil {
+		return fmt.Errorf("error persisting canonical header: %w", insertErr)
+	}
+	return nil
+}
+
+func reconcileDiffsInTx(tx *sqlx.Tx, reconciliation *types.Reconciliation, canonicalHash common.Hash) error {
+	requeueResult, requeueErr := tx.Exec(`UPDATE public.storage_diff SET status = $1
+		WHERE block_height = $2 AND block_hash = $3 AND status = ANY($4::diff_status[])`,
+		New, reconciliation.BlockHeight, canonicalHash.Bytes(), pq.Array([]string{Noncanonical, Pending}))
+	if requeueErr != nil {
+		return fmt.Errorf("error requeueing canonical diffs: %w", requeueErr)
+	}
+	requeued, requeuedErr := requeueResult.RowsAffected()
+	if requeuedErr != nil {
+		return requeuedErr
+	}
+	reconciliation.RequeuedDiffs = requeued
+
+	deleteResult, deleteErr := tx.Exec(`DELETE FROM public.storage_diff WHERE block_height = $1 AND block_hash != $2`,
+		reconciliation.BlockHeight, canonicalHash.Bytes())
+	if deleteErr != nil {
+		return fmt.Errorf("error deleting orphaned diffs: %w", deleteErr)
+	}
+	deleted, deletedErr := deleteResult.RowsAffected()
+	if deletedErr != nil {
+		return deletedErr
+	}
+	reconciliation.DeletedDiffs = deleted
+
+	if reconciliation.StaleHeaderHash == "" && requeued == 0 && deleted == 0 {
+		return nil
+	}
+	_, insertErr := tx.Exec(`INSERT INTO public.storage_diff_reconciliations
+		(block_height, canonical_hash, stale_header_hash, requeued_diffs, deleted_diffs) VALUES ($1, $2, $3, $4, $5)`,
+		reconciliation.BlockHeight, reconciliation.CanonicalHash, toNullString(reconciliation.StaleHeaderHash),
+		requeued, deleted)
+	if insertErr != nil {
+		return fmt.Errorf("error recording reconciliation: %w", insertErr)
+	}
+	return nil
+}
+
+func toNullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
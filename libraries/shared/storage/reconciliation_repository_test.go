@@ -0,0 +1,191 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package storage_test
+
+import (
+	"math/rand"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/makerdao/vulcanizedb/libraries/shared/storage"
+	"github.com/makerdao/vulcanizedb/libraries/shared/storage/types"
+	"github.com/makerdao/vulcanizedb/libraries/shared/test_data"
+	"github.com/makerdao/vulcanizedb/pkg/core"
+	"github.com/makerdao/vulcanizedb/pkg/datastore/postgres/repositories"
+	"github.com/makerdao/vulcanizedb/pkg/fakes"
+	"github.com/makerdao/vulcanizedb/test_config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Reconciliation repository", func() {
+	var (
+		db            = test_config.NewTestDB(test_config.NewTestNode())
+		repo          storage.ReconciliationRepository
+		blockHeight   int
+		canonicalDiff types.PersistedDiff
+		orphanedDiff  types.PersistedDiff
+	)
+
+	BeforeEach(func() {
+		test_config.CleanTestDB(db)
+		repo = storage.NewReconciliationRepository(db)
+		blockHeight = rand.Int()
+		canonicalDiff = createFakePersistedDiff(createFakeRawDiff(blockHeight), storage.Noncanonical, db.NodeID)
+		insertTestDiff(canonicalDiff, db)
+		orphanedDiff = createFakePersistedDiff(createFakeRawDiff(blockHeight), storage.Noncanonical, db.NodeID)
+		insertTestDiff(orphanedDiff, db)
+	})
+
+	getCanonicalHeader := func(blockHeight int64, hash common.Hash) core.Header {
+		header := fakes.GetFakeHeader(blockHeight)
+		header.Hash = hash.Hex()
+		return header
+	}
+
+	insertHeader := func(blockHeight int64, hash string) int64 {
+		header := fakes.GetFakeHeader(blockHeight)
+		header.Hash = hash
+		headerID, insertErr := repositories.NewHeaderRepository(db).CreateOrUpdateHeader(header)
+		Expect(insertErr).NotTo(HaveOccurred())
+		return headerID
+	}
+
+	getStoredHeaders := func(blockHeight int64) []core.Header {
+		var headers []core.Header
+		Expect(db.Select(&headers, `SELECT id, hash FROM public.headers WHERE block_number = $1`, blockHeight)).To(Succeed())
+		return headers
+	}
+
+	Describe("headers", func() {
+		It("persists the canonical header if none is stored for the block", func() {
+			reconciliation, err := repo.ReconcileBlock(getCanonicalHeader(int64(blockHeight), canonicalDiff.BlockHash))
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reconciliation.StaleHeaderHash).To(BeEmpty())
+			headers := getStoredHeaders(int64(blockHeight))
+			Expect(len(headers)).To(Equal(1))
+			Expect(headers[0].Hash).To(Equal(canonicalDiff.BlockHash.Hex()))
+		})
+
+		It("replaces a stale header with the canonical header, deleting data synced for it", func() {
+			staleHeaderHash := test_data.FakeHash().Hex()
+			staleHeaderID := insertHeader(int64(blockHeight), staleHeaderHash)
+			_, checkErr := db.Exec(`INSERT INTO public.checked_headers (header_id) VALUES ($1)`, staleHeaderID)
+			Expect(checkErr).NotTo(HaveOccurred())
+
+			reconciliation, err := repo.ReconcileBlock(getCanonicalHeader(int64(blockHeight), canonicalDiff.BlockHash))
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reconciliation.StaleHeaderHash).To(Equal(staleHeaderHash))
+			headers := getStoredHeaders(int64(blockHeight))
+			Expect(len(headers)).To(Equal(1))
+			Expect(headers[0].Hash).To(Equal(canonicalDiff.BlockHash.Hex()))
+			var checkedCount int
+			Expect(db.Get(&checkedCount, `SELECT COUNT(*) FROM public.checked_headers`)).To(Succeed())
+			Expect(checkedCount).To(BeZero())
+		})
+
+		It("leaves the stored header alone if it is canonical", func() {
+			headerID := insertHeader(int64(blockHeight), canonicalDiff.BlockHash.Hex())
+
+			reconciliation, err := repo.ReconcileBlock(getCanonicalHeader(int64(blockHeight), canonicalDiff.BlockHash))
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reconciliation.StaleHeaderHash).To(BeEmpty())
+			headers := getStoredHeaders(int64(blockHeight))
+			Expect(len(headers)).To(Equal(1))
+			Expect(headers[0].Id).To(Equal(headerID))
+		})
+	})
+
+	It("requeues noncanonical diffs matching the canonical hash", func() {
+		reconciliation, err := repo.ReconcileBlock(getCanonicalHeader(int64(blockHeight), canonicalDiff.BlockHash))
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reconciliation.RequeuedDiffs).To(Equal(int64(1)))
+		var status string
+		getErr := db.Get(&status, `SELECT status FROM public.storage_diff WHERE id = $1`, canonicalDiff.ID)
+		Expect(getErr).NotTo(HaveOccurred())
+		Expect(status).To(Equal(storage.New))
+	})
+
+	It("requeues pending diffs matching the canonical hash", func() {
+		_, updateErr := db.Exec(`UPDATE public.storage_diff SET status = $1 WHERE id = $2`, storage.Pending, canonicalDiff.ID)
+		Expect(updateErr).NotTo(HaveOccurred())
+
+		reconciliation, err := repo.ReconcileBlock(getCanonicalHeader(int64(blockHeight), canonicalDiff.BlockHash))
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reconciliation.RequeuedDiffs).To(Equal(int64(1)))
+	})
+
+	It("deletes diffs at the block from other hashes", func() {
+		reconciliation, err := repo.ReconcileBlock(getCanonicalHeader(int64(blockHeight), canonicalDiff.BlockHash))
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reconciliation.DeletedDiffs).To(Equal(int64(1)))
+		var ids []int64
+		getErr := db.Select(&ids, `SELECT id FROM public.storage_diff`)
+		Expect(getErr).NotTo(HaveOccurred())
+		Expect(ids).To(ConsistOf(canonicalDiff.ID))
+	})
+
+	It("leaves diffs at other blocks alone", func() {
+		otherDiff := createFakePersistedDiff(createFakeRawDiff(blockHeight-1), storage.Noncanonical, db.NodeID)
+		insertTestDiff(otherDiff, db)
+
+		_, err := repo.ReconcileBlock(getCanonicalHeader(int64(blockHeight), canonicalDiff.BlockHash))
+
+		Expect(err).NotTo(HaveOccurred())
+		var status string
+		getErr := db.Get(&status, `SELECT status FROM public.storage_diff WHERE id = $1`, otherDiff.ID)
+		Expect(getErr).NotTo(HaveOccurred())
+		Expect(status).To(Equal(storage.Noncanonical))
+	})
+
+	It("records the reconciliation", func() {
+		staleHeaderHash := test_data.FakeHash().Hex()
+		insertHeader(int64(blockHeight), staleHeaderHash)
+
+		_, err := repo.ReconcileBlock(getCanonicalHeader(int64(blockHeight), canonicalDiff.BlockHash))
+
+		Expect(err).NotTo(HaveOccurred())
+		var recorded types.Reconciliation
+		getErr := db.Get(&recorded, `SELECT block_height, canonical_hash, stale_header_hash, requeued_diffs, deleted_diffs
+			FROM public.storage_diff_reconciliations`)
+		Expect(getErr).NotTo(HaveOccurred())
+		Expect(recorded).To(Equal(types.Reconciliation{
+			BlockHeight:     int64(blockHeight),
+			CanonicalHash:   canonicalDiff.BlockHash.Hex(),
+			StaleHeaderHash: staleHeaderHash,
+			RequeuedDiffs:   1,
+			DeletedDiffs:    1,
+		}))
+	})
+
+	It("doesn't record a reconciliation that changed nothing", func() {
+		emptyBlockHeight := blockHeight - 1
+
+		_, err := repo.ReconcileBlock(getCanonicalHeader(int64(emptyBlockHeight), test_data.FakeHash()))
+
+		Expect(err).NotTo(HaveOccurred())
+		var count int
+		getErr := db.Get(&count, `SELECT COUNT(*) FROM public.storage_diff_reconciliations`)
+		Expect(getErr).NotTo(HaveOccurred())
+		Expect(count).To(BeZero())
+	})
+})
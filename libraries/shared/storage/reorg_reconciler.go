@@ -0,0 +1,59 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package storage
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/makerdao/vulcanizedb/libraries/shared/storage/types"
+	"github.com/makerdao/vulcanizedb/pkg/core"
+	"github.com/makerdao/vulcanizedb/pkg/datastore/postgres"
+)
+
+type IReorgReconciler interface {
+	Reconcile(blockHeight int64) (types.Reconciliation, error)
+}
+
+// ReorgReconciler resolves diffs whose block hash doesn't match the stored header, replacing the stored header
+// if the node reports a different canonical hash for the block
+type ReorgReconciler struct {
+	BlockChain               core.BlockChain
+	ReconciliationRepository ReconciliationRepository
+	mutex                    *sync.Mutex
+}
+
+func NewReorgReconciler(db *postgres.DB, blockChain core.BlockChain) ReorgReconciler {
+	return ReorgReconciler{
+		BlockChain:               blockChain,
+		ReconciliationRepository: NewReconciliationRepository(db),
+		mutex:                    &sync.Mutex{},
+	}
+}
+
+func (reconciler ReorgReconciler) Reconcile(blockHeight int64) (types.Reconciliation, error) {
+	// storage watcher workers may find diffs at the same stale block concurrently
+	reconciler.mutex.Lock()
+	defer reconciler.mutex.Unlock()
+
+	canonicalHeader, fetchErr := reconciler.BlockChain.GetHeaderByNumber(blockHeight)
+	if fetchErr != nil {
+		return types.Reconciliation{}, fmt.Errorf("error fetching canonical header for block %d: %w", blockHeight, fetchErr)
+	}
+
+	return reconciler.ReconciliationRepository.ReconcileBlock(canonicalHeader)
+}
@@ -0,0 +1,77 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package storage_test
+
+import (
+	"math/rand"
+
+	"github.com/makerdao/vulcanizedb/libraries/shared/mocks"
+	"github.com/makerdao/vulcanizedb/libraries/shared/storage"
+	"github.com/makerdao/vulcanizedb/libraries/shared/test_data"
+	"github.com/makerdao/vulcanizedb/pkg/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Reorg reconciler", func() {
+	var (
+		blockChain               *fakes.MockBlockChain
+		reconciliationRepository *mocks.MockReconciliationRepository
+		reconciler               storage.ReorgReconciler
+		blockHeight              int64
+		canonicalHash            string
+	)
+
+	BeforeEach(func() {
+		blockChain = fakes.NewMockBlockChain()
+		reconciliationRepository = &mocks.MockReconciliationRepository{}
+		reconciler = storage.NewReorgReconciler(nil, blockChain)
+		reconciler.ReconciliationRepository = reconciliationRepository
+		blockHeight = rand.Int63()
+		canonicalHash = test_data.FakeHash().Hex()
+		blockChain.GetHeaderByNumberHash = canonicalHash
+	})
+
+	It("returns an error if fetching the canonical header fails", func() {
+		blockChain.GetHeaderByNumberErr = fakes.FakeError
+
+		_, err := reconciler.Reconcile(blockHeight)
+
+		Expect(err).To(MatchError(fakes.FakeError))
+		Expect(reconciliationRepository.ReconcileBlockPassedHeader).To(BeZero())
+	})
+
+	It("reconciles the block against the canonical header", func() {
+		staleHash := test_data.FakeHash().Hex()
+		reconciliationRepository.ReconcileBlockStaleHeaderHash = staleHash
+
+		reconciliation, err := reconciler.Reconcile(blockHeight)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reconciliationRepository.ReconcileBlockPassedHeader.BlockNumber).To(Equal(blockHeight))
+		Expect(reconciliationRepository.ReconcileBlockPassedHeader.Hash).To(Equal(canonicalHash))
+		Expect(reconciliation.StaleHeaderHash).To(Equal(staleHash))
+	})
+
+	It("returns an error if reconciling the block fails", func() {
+		reconciliationRepository.ReconcileBlockError = fakes.FakeError
+
+		_, err := reconciler.Reconcile(blockHeight)
+
+		Expect(err).To(MatchError(fakes.FakeError))
+	})
+})
@@ -18,6 +18,7 @@ package types
 
 import (
 	"strconv"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -44,6 +45,45 @@ type PersistedDiff struct {
 	EthNodeID    int64 `db:"eth_node_id"`
 }
 
+// DiffSummary groups diffs that share an address, storage key, status, and last error
+type DiffSummary struct {
+	Address          common.Address `db:"address"`
+	StorageKey       common.Hash    `db:"storage_key"`
+	Status           string
+	LastError        string `db:"last_error"`
+	Count            int64
+	MinBlockHeight   int64 `db:"min_block_height"`
+	MaxBlockHeight   int64 `db:"max_block_height"`
+	MaxAttemptsCount int64 `db:"max_attempts"`
+}
+
+// Reconciliation records how diffs at a block were resolved after the block's header was found to be stale
+type Reconciliation struct {
+	BlockHeight     int64  `db:"block_height"`
+	CanonicalHash   string `db:"canonical_hash"`
+	StaleHeaderHash string `db:"stale_header_hash"`
+	RequeuedDiffs   int64  `db:"requeued_diffs"`
+	DeletedDiffs    int64  `db:"deleted_diffs"`
+}
+
+// ArchivedDiff is a pruned diff with everything needed to restore it to public.storage_diff
+type ArchivedDiff struct {
+	ID            int64          `db:"id" json:"id"`
+	Address       common.Address `db:"address" json:"address"`
+	BlockHeight   int64          `db:"block_height" json:"blockHeight"`
+	BlockHash     common.Hash    `db:"block_hash" json:"blockHash"`
+	StorageKey    common.Hash    `db:"storage_key" json:"storageKey"`
+	StorageValue  common.Hash    `db:"storage_value" json:"storageValue"`
+	EthNodeID     int64          `db:"eth_node_id" json:"ethNodeId"`
+	Status        string         `db:"status" json:"status"`
+	FromBackfill  bool           `db:"from_backfill" json:"fromBackfill"`
+	Created       time.Time      `db:"created" json:"created"`
+	Updated       time.Time      `db:"updated" json:"updated"`
+	Attempts      int64          `db:"attempts" json:"attempts"`
+	LastError     *string        `db:"last_error" json:"lastError,omitempty"`
+	LastAttempted *time.Time     `db:"last_attempted" json:"lastAttempted,omitempty"`
+}
+
 func FromParityCsvRow(csvRow []string) (RawDiff, error) {
 	if len(csvRow) != ExpectedRowLength {
 		return RawDiff{}, ErrRowMalformed{Length: len(csvRow)}
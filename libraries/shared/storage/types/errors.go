@@ -45,4 +45,8 @@ func (e ErrRowMalformed) Error() string {
 	return fmt.Sprintf("storage row malformed: length %d, expected %d", e.Length, ExpectedRowLength)
 }
 
-var ErrKeyNotFound = errors.New("unknown storage key")
+var (
+	ErrKeyNotFound    = errors.New("unknown storage key")
+	ErrMultiSlotValue = errors.New("value spans multiple storage slots")
+	ErrInvalidLength  = errors.New("dynamic value length doesn't fit in its storage slot")
+)
@@ -32,8 +32,146 @@ const (
 	Bytes32
 	Address
 	PackedSlot
+	String       // length-tagged slot; values longer than 31 bytes continue in keccak-addressed data slots
+	Bytes        // same layout as String
+	DynamicArray // slot holds the array's length; elements start at the keccak-addressed data slot
+	Uint16
+	Int8
+	Int16
+	Int32
+	Int48
+	Int64
+	Int96
+	Int128
+	Int192
+	Int256
+	Bool
+	// Bytes1 through Bytes31 must stay contiguous: their widths are derived from their offset from Bytes1
+	Bytes1
+	Bytes2
+	Bytes3
+	Bytes4
+	Bytes5
+	Bytes6
+	Bytes7
+	Bytes8
+	Bytes9
+	Bytes10
+	Bytes11
+	Bytes12
+	Bytes13
+	Bytes14
+	Bytes15
+	Bytes16
+	Bytes17
+	Bytes18
+	Bytes19
+	Bytes20
+	Bytes21
+	Bytes22
+	Bytes23
+	Bytes24
+	Bytes25
+	Bytes26
+	Bytes27
+	Bytes28
+	Bytes29
+	Bytes30
+	Bytes31
+	// the remaining 8-bit multiples of integer widths
+	Uint24
+	Uint40
+	Uint56
+	Uint72
+	Uint80
+	Uint88
+	Uint104
+	Uint112
+	Uint120
+	Uint136
+	Uint144
+	Uint152
+	Uint160
+	Uint168
+	Uint176
+	Uint184
+	Uint200
+	Uint208
+	Uint216
+	Uint224
+	Uint232
+	Uint240
+	Uint248
+	Int24
+	Int40
+	Int56
+	Int72
+	Int80
+	Int88
+	Int104
+	Int112
+	Int120
+	Int136
+	Int144
+	Int152
+	Int160
+	Int168
+	Int176
+	Int184
+	Int200
+	Int208
+	Int216
+	Int224
+	Int232
+	Int240
+	Int248
 )
 
+// unsignedIntegerTypes and signedIntegerTypes map each integer width in bits to its type
+var (
+	unsignedIntegerTypes = map[int]ValueType{
+		8: Uint8, 16: Uint16, 24: Uint24, 32: Uint32, 40: Uint40, 48: Uint48, 56: Uint56, 64: Uint64, 72: Uint72,
+		80: Uint80, 88: Uint88, 96: Uint96, 104: Uint104, 112: Uint112, 120: Uint120, 128: Uint128, 136: Uint136,
+		144: Uint144, 152: Uint152, 160: Uint160, 168: Uint168, 176: Uint176, 184: Uint184, 192: Uint192,
+		200: Uint200, 208: Uint208, 216: Uint216, 224: Uint224, 232: Uint232, 240: Uint240, 248: Uint248,
+		256: Uint256,
+	}
+	signedIntegerTypes = map[int]ValueType{
+		8: Int8, 16: Int16, 24: Int24, 32: Int32, 40: Int40, 48: Int48, 56: Int56, 64: Int64, 72: Int72, 80: Int80,
+		88: Int88, 96: Int96, 104: Int104, 112: Int112, 120: Int120, 128: Int128, 136: Int136, 144: Int144,
+		152: Int152, 160: Int160, 168: Int168, 176: Int176, 184: Int184, 192: Int192, 200: Int200, 208: Int208,
+		216: Int216, 224: Int224, 232: Int232, 240: Int240, 248: Int248, 256: Int256,
+	}
+)
+
+// UintType returns the unsigned integer type the given number of bits wide, which must be a multiple of 8 up to 256
+func UintType(bits int) (ValueType, bool) {
+	valueType, ok := unsignedIntegerTypes[bits]
+	return valueType, ok
+}
+
+// IntType returns the signed integer type the given number of bits wide, which must be a multiple of 8 up to 256
+func IntType(bits int) (ValueType, bool) {
+	valueType, ok := signedIntegerTypes[bits]
+	return valueType, ok
+}
+
+// IntegerBits returns the width in bits of an integer type and whether it's signed, or zero bits if the type isn't
+// an integer
+func IntegerBits(valueType ValueType) (int, bool) {
+	for bits, unsignedType := range unsignedIntegerTypes {
+		if unsignedType == valueType {
+			return bits, false
+		}
+	}
+	for bits, signedType := range signedIntegerTypes {
+		if signedType == valueType {
+			return bits, true
+		}
+	}
+	return 0, false
+}
+
 type Key string
 
 type ValueMetadata struct {
@@ -20,6 +20,36 @@ var _ = Describe("Storage value metadata getter", func() {
 		Expect(types.GetValueMetadata(metadataName, metadataKeys, metadataType)).To(Equal(expectedMetadata))
 	})
 
+	Describe("integer types", func() {
+		It("returns the integer type for every width that's a multiple of 8 bits", func() {
+			for bits := 8; bits <= 256; bits += 8 {
+				unsignedType, unsignedOK := types.UintType(bits)
+				signedType, signedOK := types.IntType(bits)
+
+				Expect(unsignedOK).To(BeTrue())
+				Expect(signedOK).To(BeTrue())
+				unsignedBits, unsignedIsSigned := types.IntegerBits(unsignedType)
+				Expect(unsignedBits).To(Equal(bits))
+				Expect(unsignedIsSigned).To(BeFalse())
+				signedBits, signedIsSigned := types.IntegerBits(signedType)
+				Expect(signedBits).To(Equal(bits))
+				Expect(signedIsSigned).To(BeTrue())
+			}
+		})
+
+		It("doesn't return integer types for other widths", func() {
+			_, ok := types.UintType(12)
+			Expect(ok).To(BeFalse())
+			_, ok = types.IntType(264)
+			Expect(ok).To(BeFalse())
+		})
+
+		It("returns zero bits for a type that isn't an integer", func() {
+			bits, _ := types.IntegerBits(types.Bytes4)
+			Expect(bits).To(BeZero())
+		})
+	})
+
 	Describe("metadata for a packed storage slot", func() {
 		It("returns metadata for multiple storage variables", func() {
 			metadataName := "fake_name"
@@ -51,7 +51,11 @@ func (syncer TransactionsSyncer) SyncTransactions(headerID int64, logs []types.L
 	if transactionErr != nil {
 		return transactionErr
 	}
-	writeErr := syncer.Repository.CreateTransactions(headerID, transactions)
+	receipts, receiptErr := syncer.BlockChain.GetTransactionReceipts(logs[0].BlockHash, transactionHashes)
+	if receiptErr != nil {
+		return receiptErr
+	}
+	writeErr := syncer.Repository.CreateTransactionsWithReceipts(headerID, transactions, receipts)
 	if writeErr != nil {
 		return writeErr
 	}
@@ -73,21 +73,42 @@ var _ = Describe("Transaction syncer", func() {
 		Expect(err).To(MatchError(fakes.FakeError))
 	})
 
-	It("passes transactions to repository for persistence", func() {
-		blockChain.Transactions = []core.TransactionModel{{}}
+	It("fetches receipts for the logs' transactions", func() {
+		err := syncer.SyncTransactions(0, []types.Log{{BlockHash: fakes.FakeHash, TxHash: fakes.FakeHash}})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(blockChain.GetTransactionReceiptsCalled).To(BeTrue())
+		Expect(blockChain.GetTransactionReceiptsPassedBlock).To(Equal(fakes.FakeHash))
+		Expect(blockChain.GetTransactionReceiptsPassedHashes).To(ConsistOf(fakes.FakeHash))
+	})
+
+	It("returns error if fetching receipts fails", func() {
+		blockChain.GetTransactionReceiptsError = fakes.FakeError
+
+		err := syncer.SyncTransactions(0, []types.Log{{TxHash: fakes.FakeHash}})
+
+		Expect(err).To(HaveOccurred())
+		Expect(err).To(MatchError(fakes.FakeError))
+	})
+
+	It("passes transactions and receipts to repository for persistence", func() {
+		blockChain.Transactions = []core.TransactionModel{{Hash: fakes.FakeHash.Hex()}}
+		blockChain.Receipts = []core.Receipt{{TxHash: fakes.FakeHash.Hex()}}
 		mockHeaderRepository := fakes.NewMockHeaderRepository()
 		syncer.Repository = mockHeaderRepository
 
 		err := syncer.SyncTransactions(0, []types.Log{{TxHash: fakes.FakeHash}})
 
 		Expect(err).NotTo(HaveOccurred())
-		Expect(mockHeaderRepository.CreateTransactionsCalled).To(BeTrue())
+		Expect(mockHeaderRepository.CreateTransactionsWithReceiptsCalled).To(BeTrue())
+		Expect(mockHeaderRepository.PassedTransactions).To(Equal(blockChain.Transactions))
+		Expect(mockHeaderRepository.PassedReceipts).To(Equal(blockChain.Receipts))
 	})
 
 	It("returns error if persisting transactions fails", func() {
 		blockChain.Transactions = []core.TransactionModel{{}}
 		mockHeaderRepository := fakes.NewMockHeaderRepository()
-		mockHeaderRepository.CreateTransactionsError = fakes.FakeError
+		mockHeaderRepository.CreateTransactionsWithReceiptsError = fakes.FakeError
 		syncer.Repository = mockHeaderRepository
 
 		err := syncer.SyncTransactions(0, []types.Log{{TxHash: fakes.FakeHash}})
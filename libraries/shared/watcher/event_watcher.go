@@ -40,6 +40,8 @@ type EventWatcher struct {
 	MaxConsecutiveUnexpectedErrs int
 	RetryInterval                time.Duration
 	StatusWriter                 fs.StatusWriter
+	ExtractorWakeups             <-chan struct{} // optional: cuts the retry wait short when new headers are inserted
+	DelegatorWakeups             <-chan struct{} // optional: cuts the retry wait short when new logs are inserted
 }
 
 func NewEventWatcher(db *postgres.DB, bc core.BlockChain, extractor logs.ILogExtractor, delegator logs.ILogDelegator, maxConsecutiveUnexpectedErrs int, retryInterval time.Duration, statusWriter fs.StatusWriter) EventWatcher {
@@ -103,15 +105,15 @@ func (watcher *EventWatcher) extractLogs(recheckHeaders constants.TransformerExe
 	call := func() error { return watcher.LogExtractor.ExtractLogs(recheckHeaders) }
 	// io.ErrUnexpectedEOF errors are sometimes returned from fetching logs at the head of the chain when fetching from an uncle or fork block
 	expectedErrors := []error{watcher.ExpectedExtractorError, io.ErrUnexpectedEOF}
-	watcher.withRetry(call, expectedErrors, "extracting", errs, quitChan)
+	watcher.withRetry(call, expectedErrors, "extracting", watcher.ExtractorWakeups, errs, quitChan)
 }
 
 func (watcher *EventWatcher) delegateLogs(errs chan error, quitChan chan bool) {
 	call := func() error { return watcher.LogDelegator.DelegateLogs(ResultsLimit) }
-	watcher.withRetry(call, []error{watcher.ExpectedDelegatorError}, "delegating", errs, quitChan)
+	watcher.withRetry(call, []error{watcher.ExpectedDelegatorError}, "delegating", watcher.DelegatorWakeups, errs, quitChan)
 }
 
-func (watcher *EventWatcher) withRetry(call func() error, expectedErrors []error, operation string, errs chan error, quitChan chan bool) {
+func (watcher *EventWatcher) withRetry(call func() error, expectedErrors []error, operation string, wakeups <-chan struct{}, errs chan error, quitChan chan bool) {
 	defer close(errs)
 	consecutiveUnexpectedErrCount := 0
 	for {
@@ -129,13 +131,25 @@ func (watcher *EventWatcher) withRetry(call func() error, expectedErrors []error
 						errs <- err
 						return
 					}
+					time.Sleep(watcher.RetryInterval)
+				} else {
+					waitForRetry(watcher.RetryInterval, wakeups, quitChan)
 				}
-				time.Sleep(watcher.RetryInterval)
 			}
 		}
 	}
 }
 
+// waitForRetry waits for the retry interval, returning early if new data is signaled or the watcher is quitting.
+// A nil wakeups channel never fires, leaving plain polling in place.
+func waitForRetry(retryInterval time.Duration, wakeups <-chan struct{}, quitChan chan bool) {
+	select {
+	case <-wakeups:
+	case <-quitChan:
+	case <-time.After(retryInterval):
+	}
+}
+
 func isUnexpectedError(currentError error, expectedErrors []error) bool {
 	for _, expectedError := range expectedErrors {
 		if currentError == expectedError {
@@ -139,6 +139,19 @@ var _ = Describe("Event Watcher", func() {
 			Expect(err).To(MatchError(errExecuteClosed))
 		})
 
+		It("extracts logs again without waiting out the retry interval when woken by a header insert", func() {
+			eventWatcher.RetryInterval = time.Hour
+			wakeups := make(chan struct{}, 1)
+			wakeups <- struct{}{}
+			eventWatcher.ExtractorWakeups = wakeups
+			extractor.ExtractLogsErrors = []error{logs.ErrNoUncheckedHeaders, errExecuteClosed}
+
+			err := eventWatcher.Execute(constants.HeaderUnchecked)
+
+			Expect(err).To(MatchError(errExecuteClosed))
+			Expect(extractor.ExtractLogsCount).To(Equal(2))
+		})
+
 		It("does not treat an io.ErrUnexpectedEOF error from the node as an unexpected error", func() {
 			extractor.ExtractLogsErrors = []error{io.ErrUnexpectedEOF, errExecuteClosed}
 
@@ -217,6 +230,19 @@ var _ = Describe("Event Watcher", func() {
 			Expect(err).To(MatchError(errExecuteClosed))
 		})
 
+		It("delegates logs again without waiting out the retry interval when woken by a log insert", func() {
+			eventWatcher.RetryInterval = time.Hour
+			wakeups := make(chan struct{}, 1)
+			wakeups <- struct{}{}
+			eventWatcher.DelegatorWakeups = wakeups
+			delegator.DelegateErrors = []error{logs.ErrNoLogs, errExecuteClosed}
+
+			err := eventWatcher.Execute(constants.HeaderUnchecked)
+
+			Expect(err).To(MatchError(errExecuteClosed))
+			Expect(delegator.DelegateCallCount).To(Equal(2))
+		})
+
 		It("delegates logs again if untransformed logs found", func() {
 			delegator.DelegateErrors = []error{nil, errExecuteClosed}
 
@@ -20,12 +20,15 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	storage2 "github.com/makerdao/vulcanizedb/libraries/shared/factories/storage"
 	"github.com/makerdao/vulcanizedb/libraries/shared/storage"
 	"github.com/makerdao/vulcanizedb/libraries/shared/storage/types"
+	"github.com/makerdao/vulcanizedb/pkg/core"
 	"github.com/makerdao/vulcanizedb/pkg/datastore"
 	"github.com/makerdao/vulcanizedb/pkg/datastore/postgres"
 	"github.com/makerdao/vulcanizedb/pkg/datastore/postgres/repositories"
@@ -35,11 +38,30 @@ import (
 )
 
 var (
-	ErrHeaderMismatch = errors.New("header hash doesn't match between db and diff")
-	ReorgWindow       = 50
-	ResultsLimit      = 500
+	ErrHeaderMismatch       = errors.New("header hash doesn't match between db and diff")
+	ErrTransformerExecution = errors.New("error executing storage transformer")
+	ReorgWindow             = 50
+	ResultsLimit            = 500
 )
 
+// executionError wraps an error returned by a storage transformer, matching both the wrapped error and ErrTransformerExecution
+type executionError struct {
+	watcherName string
+	err         error
+}
+
+func (e executionError) Error() string {
+	return fmt.Sprintf("error executing %s storage transformer: %s", e.watcherName, e.err.Error())
+}
+
+func (e executionError) Unwrap() error {
+	return e.err
+}
+
+func (e executionError) Is(target error) bool {
+	return target == ErrTransformerExecution
+}
+
 type IStorageWatcher interface {
 	AddTransformers(initializers []storage2.TransformerInitializer)
 	Execute() error
@@ -55,6 +77,10 @@ type StorageWatcher struct {
 	StatusWriter              fs.StatusWriter
 	DiffStatus                DiffStatusToWatch
 	Throttler                 utils.ThrottlerFunc
+	DiffWorkers               int                      // the max number of contracts whose diffs are transformed concurrently
+	MaxDiffAttempts           int                      // the number of failed transformer executions before a diff is marked failed; < 1 retries forever
+	ReorgReconciler           storage.IReorgReconciler // if set, resolves diffs outside the reorg window instead of marking them noncanonical
+	FinalizedOnly             bool                     // if set, leaves diffs unprocessed until headerSync marks their header finalized
 	minWaitTime               time.Duration
 }
 
@@ -93,6 +119,7 @@ func createStorageWatcher(db *postgres.DB, backFromHeadOfChain int64, statusWrit
 		StatusWriter:              statusWriter,
 		DiffStatus:                diffStatus,
 		Throttler:                 throttler.Throttle,
+		DiffWorkers:               1,
 		minWaitTime:               minWaitTime,
 	}
 }
@@ -155,11 +182,11 @@ func (watcher StorageWatcher) transformDiffs() error {
 		if extractErr != nil {
 			return fmt.Errorf("error getting new diffs: %w", extractErr)
 		}
-		for _, diff := range diffs {
-			transformErr := watcher.transformDiff(diff)
-			if handleErr := watcher.handleTransformError(transformErr, diff); handleErr != nil {
-				return fmt.Errorf("error transforming diff: %w", handleErr)
-			}
+		// every diff in this page is handled before the next page is requested, so minID only advances
+		// past diffs that have already been processed
+		transformErr := watcher.transformDiffsByAddress(diffs)
+		if transformErr != nil {
+			return transformErr
 		}
 		lenDiffs := len(diffs)
 		if lenDiffs > 0 {
@@ -171,6 +198,71 @@ func (watcher StorageWatcher) transformDiffs() error {
 	}
 }
 
+// transformDiffsByAddress fans diffs out to a bounded pool of workers. Each contract's diffs are handled by a
+// single worker in ID order, while diffs for different contracts are transformed concurrently.
+func (watcher StorageWatcher) transformDiffsByAddress(diffs []types.PersistedDiff) error {
+	diffsByAddress := groupDiffsByAddress(diffs)
+	numWorkers := watcher.DiffWorkers
+	if numWorkers < 1 {
+		numWorkers = 1
+	}
+	if numWorkers > len(diffsByAddress) {
+		numWorkers = len(diffsByAddress)
+	}
+
+	queue := make(chan []types.PersistedDiff, len(diffsByAddress))
+	for _, addressDiffs := range diffsByAddress {
+		queue <- addressDiffs
+	}
+	close(queue)
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+		failed   int32
+	)
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for addressDiffs := range queue {
+				for _, diff := range addressDiffs {
+					if atomic.LoadInt32(&failed) == 1 {
+						return
+					}
+					transformErr := watcher.transformDiff(diff)
+					if handleErr := watcher.handleTransformError(transformErr, diff); handleErr != nil {
+						errOnce.Do(func() {
+							firstErr = fmt.Errorf("error transforming diff: %w", handleErr)
+							atomic.StoreInt32(&failed, 1)
+						})
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// groupDiffsByAddress splits diffs into per-contract slices, preserving ID order within each slice
+func groupDiffsByAddress(diffs []types.PersistedDiff) [][]types.PersistedDiff {
+	var result [][]types.PersistedDiff
+	indexByAddress := make(map[common.Address]int)
+	for _, diff := range diffs {
+		index, ok := indexByAddress[diff.Address]
+		if !ok {
+			index = len(result)
+			indexByAddress[diff.Address] = index
+			result = append(result, nil)
+		}
+		result[index] = append(result[index], diff)
+	}
+	return result
+}
+
 func (watcher StorageWatcher) getMinDiffID() (int, error) {
 	var minID = 0
 	if watcher.DiffBlocksFromHeadOfChain != -1 {
@@ -202,18 +294,21 @@ func (watcher StorageWatcher) transformDiff(diff types.PersistedDiff) error {
 		return nil
 	}
 
-	headerID, headerErr := watcher.getHeaderID(diff)
+	header, headerErr := watcher.getHeader(diff)
 	if headerErr != nil {
 		if errors.Is(headerErr, ErrHeaderMismatch) {
 			return watcher.handleDiffWithInvalidHeaderHash(diff)
 		}
 		return fmt.Errorf("error getting header for diff: %w", headerErr)
 	}
-	diff.HeaderID = headerID
+	if watcher.FinalizedOnly && !header.Finalized {
+		return nil
+	}
+	diff.HeaderID = header.Id
 
 	executeErr := t.Execute(diff)
 	if executeErr != nil {
-		return fmt.Errorf("error executing %s storage transformer: %w", watcher.StorageWatcherName(), executeErr)
+		return executionError{watcherName: watcher.StorageWatcherName(), err: executeErr}
 	}
 
 	markTransformedErr := watcher.StorageDiffRepository.MarkTransformed(diff.ID)
@@ -229,17 +324,17 @@ func (watcher StorageWatcher) getTransformer(diff types.PersistedDiff) (storage2
 	return storageTransformer, ok
 }
 
-func (watcher StorageWatcher) getHeaderID(diff types.PersistedDiff) (int64, error) {
+func (watcher StorageWatcher) getHeader(diff types.PersistedDiff) (core.Header, error) {
 	header, getHeaderErr := watcher.HeaderRepository.GetHeaderByBlockNumber(int64(diff.BlockHeight))
 	if getHeaderErr != nil {
-		return 0, fmt.Errorf("error getting header by block number %d: %w", diff.BlockHeight, getHeaderErr)
+		return core.Header{}, fmt.Errorf("error getting header by block number %d: %w", diff.BlockHeight, getHeaderErr)
 	}
 	if diff.BlockHash != common.HexToHash(header.Hash) {
 		msgToFormat := "diff ID %d, block %d, db hash %s, diff hash %s"
 		details := fmt.Sprintf(msgToFormat, diff.ID, diff.BlockHeight, header.Hash, diff.BlockHash.Hex())
-		return 0, fmt.Errorf("%w: %s", ErrHeaderMismatch, details)
+		return core.Header{}, fmt.Errorf("%w: %s", ErrHeaderMismatch, details)
 	}
-	return header.Id, nil
+	return header, nil
 }
 
 func (watcher StorageWatcher) handleDiffWithInvalidHeaderHash(diff types.PersistedDiff) error {
@@ -249,6 +344,9 @@ func (watcher StorageWatcher) handleDiffWithInvalidHeaderHash(diff types.Persist
 		return fmt.Errorf(msg, diff.ID, maxBlockErr)
 	}
 	if diff.BlockHeight < int(maxBlock)-ReorgWindow {
+		if watcher.ReorgReconciler != nil {
+			return watcher.reconcileDiffWithInvalidHeaderHash(diff)
+		}
 		return watcher.StorageDiffRepository.MarkNoncanonical(diff.ID)
 	} else if diff.Status != storage.Pending {
 		return watcher.StorageDiffRepository.MarkPending(diff.ID)
@@ -256,6 +354,22 @@ func (watcher StorageWatcher) handleDiffWithInvalidHeaderHash(diff types.Persist
 	return nil
 }
 
+func (watcher StorageWatcher) reconcileDiffWithInvalidHeaderHash(diff types.PersistedDiff) error {
+	reconciliation, reconcileErr := watcher.ReorgReconciler.Reconcile(int64(diff.BlockHeight))
+	if reconcileErr != nil {
+		return fmt.Errorf("error reconciling diff %d with invalid header hash: %w", diff.ID, reconcileErr)
+	}
+	if reconciliation.StaleHeaderHash != "" {
+		logrus.Infof("replaced stale header %s at block %d with canonical header %s", reconciliation.StaleHeaderHash,
+			reconciliation.BlockHeight, reconciliation.CanonicalHash)
+	}
+	if reconciliation.RequeuedDiffs > 0 || reconciliation.DeletedDiffs > 0 {
+		logrus.Infof("reconciled diffs at block %d: %d requeued, %d deleted", reconciliation.BlockHeight,
+			reconciliation.RequeuedDiffs, reconciliation.DeletedDiffs)
+	}
+	return nil
+}
+
 func (watcher StorageWatcher) handleTransformError(transformErr error, diff types.PersistedDiff) error {
 	if transformErr != nil {
 		if errors.Is(transformErr, types.ErrKeyNotFound) {
@@ -265,6 +379,10 @@ func (watcher StorageWatcher) handleTransformError(transformErr error, diff type
 			}
 		} else if isCommonTransformError(transformErr) {
 			logrus.Tracef("error transforming diff: %s", transformErr.Error())
+			// only count failures of the transformer itself, since a missing header resolves once headers are synced
+			if errors.Is(transformErr, ErrTransformerExecution) {
+				return watcher.StorageDiffRepository.RecordFailedAttempt(diff.ID, transformErr, watcher.MaxDiffAttempts)
+			}
 		} else {
 			return transformErr
 		}
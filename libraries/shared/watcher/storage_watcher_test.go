@@ -29,6 +29,7 @@ import (
 	"github.com/makerdao/vulcanizedb/libraries/shared/storage/types"
 	"github.com/makerdao/vulcanizedb/libraries/shared/test_data"
 	"github.com/makerdao/vulcanizedb/libraries/shared/watcher"
+	"github.com/makerdao/vulcanizedb/pkg/core"
 	"github.com/makerdao/vulcanizedb/pkg/datastore/postgres"
 	"github.com/makerdao/vulcanizedb/pkg/fakes"
 	"github.com/makerdao/vulcanizedb/test_config"
@@ -110,6 +111,8 @@ func SharedExecuteBehavior(input *ExecuteInput) {
 		storageWatcher.Throttler = mockThrottler.Throttle
 		storageWatcher.HeaderRepository = mockHeaderRepository
 		storageWatcher.StorageDiffRepository = mockDiffsRepository
+		storageWatcher.MaxDiffAttempts = 0
+		storageWatcher.ReorgReconciler = nil
 		storageWatcher.AddTransformers([]storage.TransformerInitializer{mockTransformer.FakeTransformerInitializer})
 	})
 
@@ -224,6 +227,7 @@ func SharedExecuteBehavior(input *ExecuteInput) {
 			Expect(err).To(HaveOccurred())
 			Expect(err).To(MatchError(fakes.FakeError))
 			Expect(mockDiffsRepository.MarkTransformedPassedID).NotTo(Equal(diffWithoutHeader.ID))
+			Expect(mockDiffsRepository.RecordFailedAttemptPassedIDs).To(BeEmpty())
 		})
 
 		It("does not return postgres.ErrHeaderDoesNotExist if header for diff not found", func() {
@@ -302,6 +306,65 @@ func SharedExecuteBehavior(input *ExecuteInput) {
 			Expect(err).To(MatchError(fakes.FakeError))
 		})
 
+		Describe("When the watcher is configured with multiple diff workers", func() {
+			var (
+				otherContractAddress common.Address
+				otherTransformer     *mocks.MockStorageTransformer
+			)
+
+			BeforeEach(func() {
+				otherContractAddress = test_data.FakeAddress()
+				otherTransformer = &mocks.MockStorageTransformer{Address: otherContractAddress}
+				storageWatcher.AddTransformers([]storage.TransformerInitializer{otherTransformer.FakeTransformerInitializer})
+				storageWatcher.DiffWorkers = 2
+				mockHeaderRepository.GetHeaderByBlockNumberReturnHash = common.Hash{}.Hex()
+			})
+
+			AfterEach(func() {
+				storageWatcher.DiffWorkers = 1
+			})
+
+			It("marks diffs for every watched contract transformed", func() {
+				var diffs []types.PersistedDiff
+				for i := 0; i < 10; i++ {
+					address := contractAddress
+					if i%2 == 0 {
+						address = otherContractAddress
+					}
+					diffs = append(diffs, types.PersistedDiff{
+						RawDiff: types.RawDiff{Address: address},
+						ID:      int64(i + 1),
+					})
+				}
+				setDiffsToReturn(storageWatcher.DiffStatus, mockDiffsRepository, diffs)
+				setGetDiffsErrors(storageWatcher.DiffStatus, mockDiffsRepository, []error{nil, fakes.FakeError})
+
+				err := storageWatcher.Execute()
+
+				Expect(err).To(HaveOccurred())
+				Expect(err).To(MatchError(fakes.FakeError))
+				Expect(mockDiffsRepository.MarkTransformedPassedIDs).To(ConsistOf(
+					int64(1), int64(2), int64(3), int64(4), int64(5), int64(6), int64(7), int64(8), int64(9), int64(10)))
+			})
+
+			It("returns an error if transforming a diff fails for any contract", func() {
+				executeErr := errors.New("execute failed")
+				otherTransformer.ExecuteErr = executeErr
+				diffs := []types.PersistedDiff{
+					{RawDiff: types.RawDiff{Address: contractAddress}, ID: 1},
+					{RawDiff: types.RawDiff{Address: otherContractAddress}, ID: 2},
+				}
+				setDiffsToReturn(storageWatcher.DiffStatus, mockDiffsRepository, diffs)
+				setGetDiffsErrors(storageWatcher.DiffStatus, mockDiffsRepository, []error{nil})
+
+				err := storageWatcher.Execute()
+
+				Expect(err).To(HaveOccurred())
+				Expect(err).To(MatchError(executeErr))
+				Expect(mockDiffsRepository.MarkTransformedPassedIDs).NotTo(ContainElement(int64(2)))
+			})
+		})
+
 		Describe("When the watcher is configured to skip old diffs", func() {
 			var diffs []types.PersistedDiff
 			var numberOfBlocksFromHeadOfChain = int64(500)
@@ -478,6 +541,47 @@ func SharedExecuteBehavior(input *ExecuteInput) {
 				Expect(err).To(MatchError(fakes.FakeError))
 				Expect(mockDiffsRepository.MarkPendingPassedID).To(Equal(int64(0)))
 			})
+
+			Describe("When the watcher is configured to reconcile reorgs", func() {
+				var mockReconciler *mocks.MockReorgReconciler
+
+				BeforeEach(func() {
+					mockReconciler = &mocks.MockReorgReconciler{}
+					storageWatcher.ReorgReconciler = mockReconciler
+				})
+
+				It("reconciles the diff's block instead of marking it 'noncanonical' if outside the reorg window", func() {
+					mockHeaderRepository.MostRecentHeaderBlockNumber = int64(blockNumber + watcher.ReorgWindow + 1)
+					setGetDiffsErrors(storageWatcher.DiffStatus, mockDiffsRepository, []error{nil, fakes.FakeError})
+
+					err := storageWatcher.Execute()
+
+					Expect(err).To(MatchError(fakes.FakeError))
+					Expect(mockReconciler.ReconcilePassedBlockHeights).To(ConsistOf(int64(blockNumber)))
+					Expect(mockDiffsRepository.MarkNoncanonicalPassedID).To(BeZero())
+				})
+
+				It("still marks diff as 'pending' if block height is within reorg window", func() {
+					mockHeaderRepository.MostRecentHeaderBlockNumber = int64(blockNumber + watcher.ReorgWindow)
+					setGetDiffsErrors(storageWatcher.DiffStatus, mockDiffsRepository, []error{nil, fakes.FakeError})
+
+					err := storageWatcher.Execute()
+
+					Expect(err).To(MatchError(fakes.FakeError))
+					Expect(mockReconciler.ReconcilePassedBlockHeights).To(BeEmpty())
+					Expect(mockDiffsRepository.MarkPendingPassedID).To(Equal(fakePersistedDiff.ID))
+				})
+
+				It("returns an error if reconciling fails", func() {
+					mockHeaderRepository.MostRecentHeaderBlockNumber = int64(blockNumber + watcher.ReorgWindow + 1)
+					mockReconciler.ReconcileError = fakes.FakeError
+					setGetDiffsErrors(storageWatcher.DiffStatus, mockDiffsRepository, []error{nil})
+
+					err := storageWatcher.Execute()
+
+					Expect(err).To(MatchError(fakes.FakeError))
+				})
+			})
 		})
 
 		Describe("When a header with a matching hash exists", func() {
@@ -512,6 +616,36 @@ func SharedExecuteBehavior(input *ExecuteInput) {
 				Expect(mockDiffsRepository.MarkTransformedPassedID).NotTo(Equal(fakePersistedDiff.ID))
 			})
 
+			It("records a failed attempt when transformer execution returns a common error", func() {
+				fkViolationErr := &pq.Error{
+					Severity: "ERROR",
+					Code:     postgres.ForeignKeyViolationErrorCode,
+				}
+				mockTransformer.ExecuteErr = fkViolationErr
+				storageWatcher.MaxDiffAttempts = 3
+				setGetDiffsErrors(storageWatcher.DiffStatus, mockDiffsRepository, []error{nil, fakes.FakeError})
+
+				err := storageWatcher.Execute()
+
+				Expect(err).To(MatchError(fakes.FakeError))
+				Expect(mockDiffsRepository.RecordFailedAttemptPassedIDs).To(ConsistOf(fakePersistedDiff.ID))
+				Expect(mockDiffsRepository.RecordFailedAttemptPassedErrors[0]).To(MatchError(fkViolationErr))
+				Expect(mockDiffsRepository.RecordFailedAttemptPassedMaxAttempts).To(Equal(3))
+			})
+
+			It("returns an error if recording a failed attempt fails", func() {
+				mockTransformer.ExecuteErr = &pq.Error{
+					Severity: "ERROR",
+					Code:     postgres.ForeignKeyViolationErrorCode,
+				}
+				mockDiffsRepository.RecordFailedAttemptError = fakes.FakeError
+				setGetDiffsErrors(storageWatcher.DiffStatus, mockDiffsRepository, []error{nil})
+
+				err := storageWatcher.Execute()
+
+				Expect(err).To(MatchError(fakes.FakeError))
+			})
+
 			It("marks diff as 'unrecognized' when transforming the diff returns a ErrKeyNotFound error", func() {
 				mockTransformer.ExecuteErr = types.ErrKeyNotFound
 				setGetDiffsErrors(storageWatcher.DiffStatus, mockDiffsRepository, []error{nil, fakes.FakeError})
@@ -543,6 +677,36 @@ func SharedExecuteBehavior(input *ExecuteInput) {
 				Expect(err).To(MatchError(fakes.FakeError))
 				Expect(mockDiffsRepository.MarkTransformedPassedID).To(Equal(fakePersistedDiff.ID))
 			})
+
+			Describe("When the watcher only processes finalized diffs", func() {
+				BeforeEach(func() {
+					storageWatcher.FinalizedOnly = true
+					setGetDiffsErrors(storageWatcher.DiffStatus, mockDiffsRepository, []error{nil, fakes.FakeError})
+				})
+
+				It("leaves the diff unprocessed if its header isn't finalized", func() {
+					err := storageWatcher.Execute()
+
+					Expect(err).To(MatchError(fakes.FakeError))
+					Expect(mockTransformer.PassedDiff).To(Equal(types.PersistedDiff{}))
+					Expect(mockDiffsRepository.MarkTransformedPassedID).NotTo(Equal(fakePersistedDiff.ID))
+				})
+
+				It("transforms the diff if its header is finalized", func() {
+					mockHeaderRepository.HeadersByBlockNumber = map[int64]core.Header{
+						int64(fakePersistedDiff.BlockHeight): {
+							Id:        mockHeaderRepository.GetHeaderByBlockNumberReturnID,
+							Hash:      mockHeaderRepository.GetHeaderByBlockNumberReturnHash,
+							Finalized: true,
+						},
+					}
+
+					err := storageWatcher.Execute()
+
+					Expect(err).To(MatchError(fakes.FakeError))
+					Expect(mockDiffsRepository.MarkTransformedPassedID).To(Equal(fakePersistedDiff.ID))
+				})
+			})
 		})
 
 	})
@@ -134,6 +134,124 @@ var _ = Describe("Plugin Config", func() {
 			Expect(err).To(MatchError(config.MissingMigrationsErr))
 		})
 
+		It("includes the storage layout for generating a storage transformer's keys", func() {
+			viper.Set("exporter.transformer2",
+				map[string]interface{}{
+					"migrations":            "db/migrations",
+					"path":                  "path/to/transformer2",
+					"rank":                  "0",
+					"repository":            "github.com/transformer-repository",
+					"type":                  "eth_storage",
+					"storageLayout":         "out/combined.json",
+					"storageLayoutContract": "Vat",
+					"storageKeys":           "path/to/keys",
+				},
+			)
+			pluginConfig, err := config.PreparePluginConfig(testSubCommand)
+			Expect(err).NotTo(HaveOccurred())
+			transformer := pluginConfig.Transformers["transformer2"]
+			Expect(transformer.StorageLayoutPath).To(Equal("out/combined.json"))
+			Expect(transformer.StorageLayoutContract).To(Equal("Vat"))
+			Expect(transformer.StorageKeysPath).To(Equal("path/to/keys"))
+		})
+
+		It("returns an error if a storage layout is configured for a transformer that isn't a storage transformer", func() {
+			viper.Set("exporter.transformer1",
+				map[string]interface{}{
+					"migrations":    "db/migrations",
+					"path":          "path/to/transformer1",
+					"rank":          "0",
+					"repository":    "github.com/transformer-repository",
+					"type":          "eth_event",
+					"storageLayout": "out/combined.json",
+				},
+			)
+			_, err := config.PreparePluginConfig(testSubCommand)
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(MatchError(config.StorageLayoutTypeErr))
+		})
+
+		It("prepares an event transformer configured with an abi without a path, repository, or migrations", func() {
+			viper.Set("exporter.transformer1",
+				map[string]interface{}{
+					"abi":           "abis/token.json",
+					"event":         "Transfer",
+					"contracts":     []string{"CONTRACT1", "CONTRACT2"},
+					"startingBlock": "100",
+					"table":         "token_transfer",
+					"rank":          "1",
+					"type":          "eth_event",
+				},
+			)
+			pluginConfig, err := config.PreparePluginConfig(testSubCommand)
+			Expect(err).NotTo(HaveOccurred())
+			transformer := pluginConfig.Transformers["transformer1"]
+			Expect(transformer.IsABIEvent()).To(BeTrue())
+			Expect(transformer.AbiPath).To(Equal("abis/token.json"))
+			Expect(transformer.EventName).To(Equal("Transfer"))
+			Expect(transformer.ContractAddresses).To(Equal([]string{"CONTRACT1", "CONTRACT2"}))
+			Expect(transformer.StartingBlock).To(Equal(int64(100)))
+			Expect(transformer.EndingBlock).To(Equal(int64(-1)))
+			Expect(transformer.TableName).To(Equal("token_transfer"))
+			Expect(transformer.MigrationRank).To(Equal(uint64(1)))
+		})
+
+		It("prepares the data selector of an event transformer configured with an abi", func() {
+			viper.Set("exporter.transformer1",
+				map[string]interface{}{
+					"abi":          "abis/token.json",
+					"event":        "Note",
+					"contracts":    []string{"CONTRACT1"},
+					"dataSelector": "0x1a0b287e",
+					"rank":         "1",
+					"type":         "eth_event",
+				},
+			)
+			pluginConfig, err := config.PreparePluginConfig(testSubCommand)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pluginConfig.Transformers["transformer1"].DataSelector).To(Equal("0x1a0b287e"))
+		})
+
+		It("returns an error if a transformer configured with an abi is missing its event", func() {
+			viper.Set("exporter.transformer1",
+				map[string]interface{}{
+					"abi":       "abis/token.json",
+					"contracts": []string{"CONTRACT1"},
+					"rank":      "0",
+					"type":      "eth_event",
+				},
+			)
+			_, err := config.PreparePluginConfig(testSubCommand)
+			Expect(err).To(MatchError(config.MissingEventErr))
+		})
+
+		It("returns an error if a transformer configured with an abi is missing its contracts", func() {
+			viper.Set("exporter.transformer2",
+				map[string]interface{}{
+					"abi":   "abis/token.json",
+					"event": "Transfer",
+					"rank":  "0",
+					"type":  "eth_event",
+				},
+			)
+			_, err := config.PreparePluginConfig(testSubCommand)
+			Expect(err).To(MatchError(config.MissingContractsErr))
+		})
+
+		It("returns an error if an abi is configured for a transformer that isn't an event transformer", func() {
+			viper.Set("exporter.transformer1",
+				map[string]interface{}{
+					"abi":       "abis/token.json",
+					"event":     "Transfer",
+					"contracts": []string{"CONTRACT1"},
+					"rank":      "0",
+					"type":      "eth_storage",
+				},
+			)
+			_, err := config.PreparePluginConfig(testSubCommand)
+			Expect(err).To(MatchError(config.AbiTypeErr))
+		})
+
 		It("returns an error if the transformer's rank is missing", func() {
 			viper.Set("exporter.transformer1",
 				map[string]interface{}{
@@ -220,6 +338,25 @@ var _ = Describe("Plugin Config", func() {
 			Expect(len(migrationsPaths)).To(Equal(1))
 			Expect(migrationsPaths[0]).To(MatchRegexp("db/migrations"))
 		})
+
+		It("uses the generated migrations directory for transformers configured with an abi", func() {
+			viper.Set("exporter.transformer1",
+				map[string]interface{}{
+					"abi":       "abis/token.json",
+					"event":     "Transfer",
+					"contracts": []string{"CONTRACT1"},
+					"rank":      "1",
+					"type":      "eth_event",
+				},
+			)
+			pluginConfig, prepareErr := config.PreparePluginConfig(testSubCommand)
+			Expect(prepareErr).NotTo(HaveOccurred())
+
+			migrationsPaths, getMigrationsErr := pluginConfig.GetMigrationsPaths()
+			Expect(getMigrationsErr).NotTo(HaveOccurred())
+			Expect(len(migrationsPaths)).To(Equal(2))
+			Expect(migrationsPaths[1]).To(MatchRegexp("plugins/migrations/transformer1$"))
+		})
 	})
 
 	Describe("GetRepoPaths", func() {
@@ -230,6 +367,23 @@ var _ = Describe("Plugin Config", func() {
 			repoPaths := pluginConfig.GetRepoPaths()
 			Expect(repoPaths).To(Equal(map[string]bool{"github.com/transformer-repository": true}))
 		})
+
+		It("doesn't include transformers configured with an abi", func() {
+			viper.Set("exporter.transformer1",
+				map[string]interface{}{
+					"abi":       "abis/token.json",
+					"event":     "Transfer",
+					"contracts": []string{"CONTRACT1"},
+					"rank":      "1",
+					"type":      "eth_event",
+				},
+			)
+			pluginConfig, prepareErr := config.PreparePluginConfig(testSubCommand)
+			Expect(prepareErr).NotTo(HaveOccurred())
+
+			repoPaths := pluginConfig.GetRepoPaths()
+			Expect(repoPaths).To(Equal(map[string]bool{"github.com/transformer-repository": true}))
+		})
 	})
 
 	Describe("GetTransformerType", func() {
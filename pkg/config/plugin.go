@@ -43,6 +43,27 @@ type Transformer struct {
 	MigrationPath  string
 	MigrationRank  uint64
 	RepositoryPath string
+	// Optional solc storage layout artifact, relative to RepositoryPath, used to generate a storage keys loader
+	StorageLayoutPath     string
+	StorageLayoutContract string
+	// Relative path from RepositoryPath to the package the keys loader is generated in, defaults to Path
+	StorageKeysPath string
+	// Optional contract ABI file and event name for an eth_event transformer built from the ABI, which doesn't need
+	// a Path, RepositoryPath, or MigrationPath since its converter and table migration are generated
+	AbiPath           string
+	EventName         string
+	ContractAddresses []string
+	StartingBlock     int64
+	EndingBlock       int64
+	// Table in the plugin schema the event is persisted to, defaults to the snake cased event name
+	TableName string
+	// Hex prefix of the log data that identifies an anonymous event, required since its logs have no signature topic
+	DataSelector string
+}
+
+// IsABIEvent returns whether the transformer is an event transformer generated from a contract ABI
+func (transformer Transformer) IsABIEvent() bool {
+	return transformer.AbiPath != ""
 }
 
 var (
@@ -54,6 +75,12 @@ var (
 	RankParsingErr            = errors.New("migration `rank` can't be converted to an unsigned integer")
 	MissingTypeErr            = errors.New("transformer config is missing `type` value")
 	UnknownTransformerTypeErr = errors.New(`unknown transformer type in exporter config accepted types are "eth_event", "eth_storage"`)
+	StorageLayoutTypeErr      = errors.New("transformer config has a `storageLayout` value but is not an `eth_storage` transformer")
+	AbiTypeErr                = errors.New("transformer config has an `abi` value but is not an `eth_event` transformer")
+	MissingEventErr           = errors.New("transformer config has an `abi` value but is missing an `event` value")
+	MissingContractsErr       = errors.New("transformer config has an `abi` value but is missing a `contracts` value")
+	BlockParsingErr           = errors.New("transformer `startingBlock` or `endingBlock` can't be converted to an integer")
+	MissingDataSelectorErr    = errors.New("transformer config's `event` is anonymous but is missing a `dataSelector` value")
 )
 
 func PreparePluginConfig(subCommand string) (Plugin, error) {
@@ -63,6 +90,14 @@ func PreparePluginConfig(subCommand string) (Plugin, error) {
 	transformers := make(map[string]Transformer)
 	for _, name := range names {
 		transformer := viper.GetStringMapString("exporter." + name)
+		if transformer["abi"] != "" {
+			abiTransformer, abiErr := prepareABITransformer(name, transformer)
+			if abiErr != nil {
+				return Plugin{}, abiErr
+			}
+			transformers[name] = abiTransformer
+			continue
+		}
 		p, pOK := transformer["path"]
 		if !pOK || p == "" {
 			return Plugin{}, fmt.Errorf("%w: %s", MissingPathErr, name)
@@ -75,21 +110,18 @@ func PreparePluginConfig(subCommand string) (Plugin, error) {
 		if !mOK || m == "" {
 			return Plugin{}, fmt.Errorf("%w: %s", MissingMigrationsErr, name)
 		}
-		mr, mrOK := transformer["rank"]
-		if !mrOK || mr == "" {
-			return Plugin{}, fmt.Errorf("%w: %s", MissingRankErr, name)
+		rank, rankErr := getRank(name, transformer)
+		if rankErr != nil {
+			return Plugin{}, rankErr
 		}
-		rank, err := strconv.ParseUint(mr, 10, 64)
-		if err != nil {
-			return Plugin{}, fmt.Errorf("%w: %s", RankParsingErr, name)
+		transformerType, typeErr := getTransformerType(name, transformer)
+		if typeErr != nil {
+			return Plugin{}, typeErr
 		}
-		t, tOK := transformer["type"]
-		if !tOK {
-			return Plugin{}, fmt.Errorf("%w: %s", MissingTypeErr, name)
-		}
-		transformerType := GetTransformerType(t)
-		if transformerType == UnknownTransformerType {
-			return Plugin{}, UnknownTransformerTypeErr
+		// viper lowercases map keys
+		storageLayout := transformer["storagelayout"]
+		if storageLayout != "" && transformerType != EthStorage {
+			return Plugin{}, fmt.Errorf("%w: %s", StorageLayoutTypeErr, name)
 		}
 
 		transformers[name] = Transformer{
@@ -98,6 +130,10 @@ func PreparePluginConfig(subCommand string) (Plugin, error) {
 			RepositoryPath: r,
 			MigrationPath:  m,
 			MigrationRank:  rank,
+
+			StorageLayoutPath:     storageLayout,
+			StorageLayoutContract: transformer["storagelayoutcontract"],
+			StorageKeysPath:       transformer["storagekeys"],
 		}
 	}
 
@@ -111,6 +147,78 @@ func PreparePluginConfig(subCommand string) (Plugin, error) {
 	}, nil
 }
 
+// Event transformers generated from an ABI take their converter from the event package and get a generated migration
+func prepareABITransformer(name string, transformer map[string]string) (Transformer, error) {
+	rank, rankErr := getRank(name, transformer)
+	if rankErr != nil {
+		return Transformer{}, rankErr
+	}
+	transformerType, typeErr := getTransformerType(name, transformer)
+	if typeErr != nil {
+		return Transformer{}, typeErr
+	}
+	if transformerType != EthEvent {
+		return Transformer{}, fmt.Errorf("%w: %s", AbiTypeErr, name)
+	}
+	eventName := transformer["event"]
+	if eventName == "" {
+		return Transformer{}, fmt.Errorf("%w: %s", MissingEventErr, name)
+	}
+	contracts := viper.GetStringSlice("exporter." + name + ".contracts")
+	if len(contracts) == 0 {
+		return Transformer{}, fmt.Errorf("%w: %s", MissingContractsErr, name)
+	}
+	// viper lowercases map keys
+	startingBlock, startingErr := getBlockNumber(transformer["startingblock"], 0)
+	endingBlock, endingErr := getBlockNumber(transformer["endingblock"], -1)
+	if startingErr != nil || endingErr != nil {
+		return Transformer{}, fmt.Errorf("%w: %s", BlockParsingErr, name)
+	}
+
+	return Transformer{
+		Type:              transformerType,
+		MigrationRank:     rank,
+		AbiPath:           transformer["abi"],
+		EventName:         eventName,
+		ContractAddresses: contracts,
+		StartingBlock:     startingBlock,
+		EndingBlock:       endingBlock,
+		TableName:         transformer["table"],
+		DataSelector:      transformer["dataselector"],
+	}, nil
+}
+
+func getRank(name string, transformer map[string]string) (uint64, error) {
+	mr, mrOK := transformer["rank"]
+	if !mrOK || mr == "" {
+		return 0, fmt.Errorf("%w: %s", MissingRankErr, name)
+	}
+	rank, err := strconv.ParseUint(mr, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", RankParsingErr, name)
+	}
+	return rank, nil
+}
+
+func getTransformerType(name string, transformer map[string]string) (TransformerType, error) {
+	t, tOK := transformer["type"]
+	if !tOK {
+		return UnknownTransformerType, fmt.Errorf("%w: %s", MissingTypeErr, name)
+	}
+	transformerType := GetTransformerType(t)
+	if transformerType == UnknownTransformerType {
+		return UnknownTransformerType, UnknownTransformerTypeErr
+	}
+	return transformerType, nil
+}
+
+func getBlockNumber(value string, defaultValue int64) (int64, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
 func (pluginConfig *Plugin) GetPluginPaths() (string, string, error) {
 	path, err := helpers.CleanPath(pluginConfig.FilePath)
 	if err != nil {
@@ -132,6 +240,9 @@ func (pluginConfig *Plugin) GetMigrationsPaths() ([]string, error) {
 		repo := transformer.RepositoryPath
 		mig := transformer.MigrationPath
 		path := filepath.Join("$GOPATH/src", pluginConfig.Home, "vendor", repo, mig)
+		if transformer.IsABIEvent() {
+			path = pluginConfig.GetGeneratedMigrationsPath(name)
+		}
 		cleanPath, err := helpers.CleanPath(path)
 		if err != nil {
 			return nil, err
@@ -165,10 +276,18 @@ func (pluginConfig *Plugin) GetMigrationsPaths() ([]string, error) {
 	return sortedPaths, nil
 }
 
+// Returns the directory the table migration for a transformer generated from an ABI is written to
+func (pluginConfig *Plugin) GetGeneratedMigrationsPath(name string) string {
+	return filepath.Join(pluginConfig.FilePath, "migrations", name)
+}
+
 // Removes duplicate repo paths before returning them
 func (pluginConfig *Plugin) GetRepoPaths() map[string]bool {
 	paths := make(map[string]bool)
 	for _, transformer := range pluginConfig.Transformers {
+		if transformer.IsABIEvent() {
+			continue
+		}
 		paths[transformer.RepositoryPath] = true
 	}
 
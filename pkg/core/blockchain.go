@@ -30,7 +30,10 @@ type BlockChain interface {
 	GetHeaderByNumber(blockNumber int64) (Header, error)
 	GetHeadersByNumbers(blockNumbers []int64) ([]Header, error)
 	GetTransactions(transactionHashes []common.Hash) ([]TransactionModel, error)
+	GetTransactionReceipts(blockHash common.Hash, transactionHashes []common.Hash) ([]Receipt, error)
 	ChainHead() (*big.Int, error)
+	GetFinalizedHeader() (Header, error)
+	SubscribeNewHeads(headers chan<- Header) (Subscription, error)
 	BatchGetStorageAt(account common.Address, keys []common.Hash, blockNumber *big.Int) (map[common.Hash][]byte, error)
 	Node() Node
 }
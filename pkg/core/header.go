@@ -17,6 +17,8 @@
 package core
 
 import (
+	"encoding/json"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -28,6 +30,23 @@ type Header struct {
 	Hash        string
 	Raw         []byte
 	Timestamp   string `db:"block_timestamp"`
+	Finalized   bool   // set by headerSync once the block is finalized; not populated from the node
+}
+
+// ParentHash returns the parent hash from the header's raw JSON, and false if the raw JSON has no parentHash
+func (header Header) ParentHash() (common.Hash, bool) {
+	var raw struct {
+		ParentHash *common.Hash `json:"parentHash"`
+	}
+	if len(header.Raw) == 0 || json.Unmarshal(header.Raw, &raw) != nil || raw.ParentHash == nil {
+		return common.Hash{}, false
+	}
+	return *raw.ParentHash, true
+}
+
+// HeaderDecoder converts a header as the node returns it from eth_getBlockByNumber or a newHeads subscription
+type HeaderDecoder interface {
+	Decode(rawHeader json.RawMessage) (Header, error)
 }
 
 type POAHeader struct {
@@ -0,0 +1,33 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import "time"
+
+// QuarantinedLog is an event log a transformer failed to execute on. It's left out of the logs delegated to
+// transformers until it's retried.
+type QuarantinedLog struct {
+	ID              int64
+	LogID           int64  `db:"log_id"`
+	TransformerName string `db:"transformer_name"`
+	Error           string
+	Attempts        int
+	BlockNumber     int64  `db:"block_number"`
+	TxHash          string `db:"tx_hash"`
+	Created         time.Time
+	Updated         time.Time
+}
@@ -0,0 +1,31 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import "time"
+
+// Reorg records the headers replaced after the node switched to a chain that forked below them. OldHashes and
+// NewHashes hold the orphaned and canonical hash of each replaced block, in block order.
+type Reorg struct {
+	ID        int64
+	Depth     int64
+	FromBlock int64 `db:"from_block"`
+	ToBlock   int64 `db:"to_block"`
+	OldHashes []string
+	NewHashes []string
+	Created   time.Time
+}
@@ -0,0 +1,109 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package postgres
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/makerdao/vulcanizedb/pkg/config"
+	"github.com/sirupsen/logrus"
+)
+
+// Channels notified by the insert triggers on public.storage_diff, public.event_logs and public.headers
+const (
+	StorageDiffInsertedChannel = "storage_diff_inserted"
+	EventLogsInsertedChannel   = "event_logs_inserted"
+	HeadersInsertedChannel     = "headers_inserted"
+)
+
+var (
+	minReconnectInterval = 10 * time.Second
+	maxReconnectInterval = time.Minute
+)
+
+// Listener LISTENs on Postgres notification channels and fans each notification out to every subscriber of that
+// channel. Wakeups are coalesced: a subscriber that has not consumed its last wakeup doesn't receive another.
+type Listener struct {
+	listener    *pq.Listener
+	mutex       sync.Mutex
+	subscribers map[string][]chan struct{}
+}
+
+func NewListener(databaseConfig config.Database, channels ...string) (*Listener, error) {
+	l := &Listener{subscribers: make(map[string][]chan struct{})}
+	connectString := config.DbConnectionString(databaseConfig)
+	l.listener = pq.NewListener(connectString, minReconnectInterval, maxReconnectInterval, l.logEvent)
+	for _, channel := range channels {
+		listenErr := l.listener.Listen(channel)
+		if listenErr != nil {
+			closeErr := l.listener.Close()
+			if closeErr != nil {
+				logrus.Warnf("error closing listener: %s", closeErr.Error())
+			}
+			return nil, fmt.Errorf("error listening on channel %s: %w", channel, listenErr)
+		}
+	}
+	go l.dispatch()
+	return l, nil
+}
+
+// Wakeups returns a channel that receives a value whenever rows are inserted into the table behind the given
+// notification channel. Each call returns a new subscription.
+func (l *Listener) Wakeups(channel string) <-chan struct{} {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	wakeups := make(chan struct{}, 1)
+	l.subscribers[channel] = append(l.subscribers[channel], wakeups)
+	return wakeups
+}
+
+func (l *Listener) Close() error {
+	return l.listener.Close()
+}
+
+func (l *Listener) dispatch() {
+	for notification := range l.listener.Notify {
+		l.mutex.Lock()
+		if notification == nil {
+			// pq sends nil after re-establishing a lost connection, so notifications may have been missed
+			for _, subscribers := range l.subscribers {
+				wakeAll(subscribers)
+			}
+		} else {
+			wakeAll(l.subscribers[notification.Channel])
+		}
+		l.mutex.Unlock()
+	}
+}
+
+func (l *Listener) logEvent(event pq.ListenerEventType, err error) {
+	if err != nil {
+		logrus.Warnf("postgres listener event %d: %s", event, err.Error())
+	}
+}
+
+func wakeAll(subscribers []chan struct{}) {
+	for _, subscriber := range subscribers {
+		select {
+		case subscriber <- struct{}{}:
+		default:
+		}
+	}
+}
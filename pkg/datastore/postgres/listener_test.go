@@ -0,0 +1,68 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package postgres_test
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/makerdao/vulcanizedb/pkg/datastore/postgres"
+	"github.com/makerdao/vulcanizedb/pkg/datastore/postgres/repositories"
+	"github.com/makerdao/vulcanizedb/pkg/fakes"
+	"github.com/makerdao/vulcanizedb/test_config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Listener", func() {
+	var (
+		db       *postgres.DB
+		listener *postgres.Listener
+	)
+
+	BeforeEach(func() {
+		db = test_config.NewTestDB(test_config.NewTestNode())
+		test_config.CleanTestDB(db)
+		var listenErr error
+		listener, listenErr = postgres.NewListener(test_config.DBConfig, postgres.HeadersInsertedChannel)
+		Expect(listenErr).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(listener.Close()).To(Succeed())
+	})
+
+	It("wakes every subscriber when a row is inserted into the watched table", func() {
+		firstWakeups := listener.Wakeups(postgres.HeadersInsertedChannel)
+		secondWakeups := listener.Wakeups(postgres.HeadersInsertedChannel)
+
+		_, insertErr := repositories.NewHeaderRepository(db).CreateOrUpdateHeader(fakes.GetFakeHeader(rand.Int63()))
+		Expect(insertErr).NotTo(HaveOccurred())
+
+		Eventually(firstWakeups, 5*time.Second).Should(Receive())
+		Eventually(secondWakeups, 5*time.Second).Should(Receive())
+	})
+
+	It("does not wake subscribers of other channels", func() {
+		diffWakeups := listener.Wakeups(postgres.StorageDiffInsertedChannel)
+
+		_, insertErr := repositories.NewHeaderRepository(db).CreateOrUpdateHeader(fakes.GetFakeHeader(rand.Int63()))
+		Expect(insertErr).NotTo(HaveOccurred())
+
+		Consistently(diffWakeups, time.Second).ShouldNot(Receive())
+	})
+})
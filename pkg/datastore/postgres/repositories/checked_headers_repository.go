@@ -83,13 +83,13 @@ func (repo CheckedHeadersRepository) UncheckedHeaders(startingBlockNumber, endin
 
 	joinQuery := fmt.Sprintf(`
 WITH checked_headers AS (
-	SELECT h.id, h.block_number, h.hash, COALESCE(ch.check_count, 0) AS check_count
+	SELECT h.id, h.block_number, h.hash, h.raw, h.finalized, COALESCE(ch.check_count, 0) AS check_count
 	FROM public.headers h
 	LEFT JOIN %s.checked_headers ch
 	ON ch.header_id = h.id
     WHERE h.block_number >= $1
 )
-SELECT id, block_number, hash
+SELECT id, block_number, hash, raw, finalized
 FROM checked_headers
 WHERE ( check_count < 1
 	OR (check_count < $2
@@ -105,3 +105,14 @@ WHERE ( check_count < 1
 
 	return result, err
 }
+
+// Return the highest block number with a checked header, or -1 if no header has been checked
+func (repo CheckedHeadersRepository) LastCheckedBlockNumber() (int64, error) {
+	var blockNumber int64
+	queryString := fmt.Sprintf(`SELECT COALESCE(MAX(h.block_number), -1)
+		FROM %s.checked_headers ch
+		JOIN public.headers h ON ch.header_id = h.id
+		WHERE ch.check_count > 0`, repo.schemaName)
+	err := repo.db.Get(&blockNumber, queryString)
+	return blockNumber, err
+}
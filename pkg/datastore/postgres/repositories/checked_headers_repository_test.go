@@ -88,6 +88,49 @@ var _ = Describe("Checked Headers repository", func() {
 			})
 		})
 
+		Describe("LastCheckedBlockNumber", func() {
+			It("returns -1 if no headers have been checked", func() {
+				headerRepository := repositories.NewHeaderRepository(db)
+				_, headerErr := headerRepository.CreateOrUpdateHeader(fakes.GetFakeHeader(1))
+				Expect(headerErr).NotTo(HaveOccurred())
+
+				blockNumber, err := repo.LastCheckedBlockNumber()
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(blockNumber).To(Equal(int64(-1)))
+			})
+
+			It("returns the highest block number with a checked header", func() {
+				headerRepository := repositories.NewHeaderRepository(db)
+				lowerHeaderID, lowerHeaderErr := headerRepository.CreateOrUpdateHeader(fakes.GetFakeHeader(1))
+				Expect(lowerHeaderErr).NotTo(HaveOccurred())
+				higherHeaderID, higherHeaderErr := headerRepository.CreateOrUpdateHeader(fakes.GetFakeHeader(2))
+				Expect(higherHeaderErr).NotTo(HaveOccurred())
+				_, uncheckedHeaderErr := headerRepository.CreateOrUpdateHeader(fakes.GetFakeHeader(3))
+				Expect(uncheckedHeaderErr).NotTo(HaveOccurred())
+				Expect(repo.MarkHeaderChecked(lowerHeaderID)).To(Succeed())
+				Expect(repo.MarkHeaderChecked(higherHeaderID)).To(Succeed())
+
+				blockNumber, err := repo.LastCheckedBlockNumber()
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(blockNumber).To(Equal(int64(2)))
+			})
+
+			It("ignores headers that have had their check-count reset", func() {
+				headerRepository := repositories.NewHeaderRepository(db)
+				headerID, headerErr := headerRepository.CreateOrUpdateHeader(fakes.GetFakeHeader(1))
+				Expect(headerErr).NotTo(HaveOccurred())
+				Expect(repo.MarkHeaderChecked(headerID)).To(Succeed())
+				Expect(repo.MarkSingleHeaderUnchecked(1)).To(Succeed())
+
+				blockNumber, err := repo.LastCheckedBlockNumber()
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(blockNumber).To(Equal(int64(-1)))
+			})
+		})
+
 		Describe("MarkSingleHeaderUnchecked", func() {
 			It("marks headers with matching block number as unchecked", func() {
 				blockNumber := rand.Int63()
@@ -161,6 +204,29 @@ var _ = Describe("Checked Headers repository", func() {
 				thirdHeaderID = headerIDs[2]
 			})
 
+			It("includes headers' raw JSON", func() {
+				headers, err := repo.UncheckedHeaders(firstBlock, -1, uncheckedCheckCount)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(len(headers)).To(Equal(len(blockNumbers)))
+				for _, header := range headers {
+					Expect(header.Raw).To(MatchJSON(fakes.GetFakeHeader(header.BlockNumber).Raw))
+				}
+			})
+
+			It("includes whether headers are finalized", func() {
+				finalizeErr := headerRepository.MarkHeadersFinalized(secondBlock)
+				Expect(finalizeErr).NotTo(HaveOccurred())
+
+				headers, err := repo.UncheckedHeaders(firstBlock, -1, uncheckedCheckCount)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(len(headers)).To(Equal(len(blockNumbers)))
+				for _, header := range headers {
+					Expect(header.Finalized).To(Equal(header.BlockNumber <= secondBlock))
+				}
+			})
+
 			Describe("when ending block is specified", func() {
 				It("excludes headers that are out of range", func() {
 					headers, err := repo.UncheckedHeaders(firstBlock, thirdBlock, uncheckedCheckCount)
@@ -17,6 +17,8 @@
 package repositories
 
 import (
+	"github.com/lib/pq"
+	"github.com/makerdao/vulcanizedb/pkg/core"
 	"github.com/makerdao/vulcanizedb/pkg/datastore/postgres"
 	"github.com/sirupsen/logrus"
 )
@@ -67,3 +69,89 @@ func (repository CheckedLogsRepository) MarkLogWatched(addresses []string, topic
 	}
 	return tx.Commit()
 }
+
+// Return which of the given addresses are not yet covered by watched logs for topic0. Logs are fetched for every
+// watched address with every watched topic0, so an address is covered if it and topic0 are both watched.
+func (repository CheckedLogsRepository) UnwatchedAddresses(addresses []string, topic0 string) ([]string, error) {
+	var topicZeroExists bool
+	getTopicZeroExistsErr := repository.db.Get(&topicZeroExists, `SELECT EXISTS(SELECT 1 FROM public.watched_logs WHERE topic_zero = $1)`, topic0)
+	if getTopicZeroExistsErr != nil {
+		return nil, getTopicZeroExistsErr
+	}
+	if !topicZeroExists {
+		return addresses, nil
+	}
+
+	var unwatched []string
+	for _, address := range addresses {
+		var addressExists bool
+		getAddressExistsErr := repository.db.Get(&addressExists, `SELECT EXISTS(SELECT 1 FROM public.watched_logs WHERE contract_address = $1)`, address)
+		if getAddressExistsErr != nil {
+			return nil, getAddressExistsErr
+		}
+		if !addressExists {
+			unwatched = append(unwatched, address)
+		}
+	}
+	return unwatched, nil
+}
+
+// Persist that blocks fromBlock through toBlock must be checked again for logs with the given addresses + topic0
+func (repository CheckedLogsRepository) CreateLogBackfill(addresses []string, topic0 string, fromBlock, toBlock int64) error {
+	tx, txErr := repository.db.Beginx()
+	if txErr != nil {
+		return txErr
+	}
+	for _, address := range addresses {
+		_, insertErr := tx.Exec(`INSERT INTO public.watched_log_backfills (contract_address, topic_zero, from_block, to_block, checked_through)
+			VALUES ($1, $2, $3, $4, $5)`, address, topic0, fromBlock, toBlock, fromBlock-1)
+		if insertErr != nil {
+			rollbackErr := tx.Rollback()
+			if rollbackErr != nil {
+				logrus.Errorf("error rolling back transaction inserting log backfills: %s", rollbackErr.Error())
+			}
+			return insertErr
+		}
+	}
+	return tx.Commit()
+}
+
+// Return backfills that haven't been checked through their last block, grouping addresses that share a topic0 and range
+func (repository CheckedLogsRepository) PendingLogBackfills() ([]core.LogBackfill, error) {
+	var rows []struct {
+		Addresses      pq.StringArray
+		Topic0         string `db:"topic_zero"`
+		FromBlock      int64  `db:"from_block"`
+		ToBlock        int64  `db:"to_block"`
+		CheckedThrough int64  `db:"checked_through"`
+	}
+	selectErr := repository.db.Select(&rows, `SELECT array_agg(contract_address ORDER BY id) AS addresses, topic_zero,
+			from_block, to_block, checked_through
+		FROM public.watched_log_backfills
+		WHERE checked_through < to_block
+		GROUP BY topic_zero, from_block, to_block, checked_through
+		ORDER BY MIN(id)`)
+	if selectErr != nil {
+		return nil, selectErr
+	}
+
+	backfills := make([]core.LogBackfill, len(rows))
+	for i, row := range rows {
+		backfills[i] = core.LogBackfill{
+			Addresses:      row.Addresses,
+			Topic0:         row.Topic0,
+			FromBlock:      row.FromBlock,
+			ToBlock:        row.ToBlock,
+			CheckedThrough: row.CheckedThrough,
+		}
+	}
+	return backfills, nil
+}
+
+// Persist that a backfill's blocks have been checked through the given block number
+func (repository CheckedLogsRepository) UpdateLogBackfill(backfill core.LogBackfill, checkedThrough int64) error {
+	_, updateErr := repository.db.Exec(`UPDATE public.watched_log_backfills SET checked_through = $1
+		WHERE contract_address = ANY($2) AND topic_zero = $3 AND from_block = $4 AND to_block = $5`,
+		checkedThrough, pq.Array(backfill.Addresses), backfill.Topic0, backfill.FromBlock, backfill.ToBlock)
+	return updateErr
+}
@@ -18,6 +18,7 @@ package repositories_test
 
 import (
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/makerdao/vulcanizedb/pkg/core"
 	"github.com/makerdao/vulcanizedb/pkg/datastore"
 	"github.com/makerdao/vulcanizedb/pkg/datastore/postgres"
 	"github.com/makerdao/vulcanizedb/pkg/datastore/postgres/repositories"
@@ -112,4 +113,86 @@ var _ = Describe("Checked logs repository", func() {
 			Expect(comboTwoExists).To(BeTrue())
 		})
 	})
+
+	Describe("UnwatchedAddresses", func() {
+		It("returns all addresses if topic0 is not watched", func() {
+			_, insertErr := db.Exec(`INSERT INTO public.watched_logs (contract_address, topic_zero) VALUES ($1, $2)`, fakeAddress, fakes.RandomString(66))
+			Expect(insertErr).NotTo(HaveOccurred())
+
+			unwatched, err := repository.UnwatchedAddresses(fakeAddresses, fakeTopicZero)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(unwatched).To(Equal(fakeAddresses))
+		})
+
+		It("returns addresses that are not watched if topic0 is watched", func() {
+			anotherFakeAddress := common.HexToAddress("0x" + fakes.RandomString(40)).Hex()
+			_, insertErr := db.Exec(`INSERT INTO public.watched_logs (contract_address, topic_zero) VALUES ($1, $2)`, fakeAddress, fakeTopicZero)
+			Expect(insertErr).NotTo(HaveOccurred())
+
+			unwatched, err := repository.UnwatchedAddresses(append(fakeAddresses, anotherFakeAddress), fakeTopicZero)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(unwatched).To(Equal([]string{anotherFakeAddress}))
+		})
+
+		It("returns no addresses if each address and topic0 are watched", func() {
+			_, insertErr := db.Exec(`INSERT INTO public.watched_logs (contract_address, topic_zero) VALUES ($1, $2)`, fakeAddress, fakeTopicZero)
+			Expect(insertErr).NotTo(HaveOccurred())
+
+			unwatched, err := repository.UnwatchedAddresses(fakeAddresses, fakeTopicZero)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(unwatched).To(BeEmpty())
+		})
+	})
+
+	Describe("log backfills", func() {
+		It("returns created backfills grouped by topic0 and range", func() {
+			anotherFakeAddress := common.HexToAddress("0x" + fakes.RandomString(40)).Hex()
+			addresses := append(fakeAddresses, anotherFakeAddress)
+
+			createErr := repository.CreateLogBackfill(addresses, fakeTopicZero, 100, 200)
+			Expect(createErr).NotTo(HaveOccurred())
+
+			backfills, err := repository.PendingLogBackfills()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(backfills).To(ConsistOf(core.LogBackfill{
+				Addresses:      addresses,
+				Topic0:         fakeTopicZero,
+				FromBlock:      100,
+				ToBlock:        200,
+				CheckedThrough: 99,
+			}))
+		})
+
+		It("records progress on a backfill", func() {
+			createErr := repository.CreateLogBackfill(fakeAddresses, fakeTopicZero, 100, 200)
+			Expect(createErr).NotTo(HaveOccurred())
+			backfills, getErr := repository.PendingLogBackfills()
+			Expect(getErr).NotTo(HaveOccurred())
+
+			err := repository.UpdateLogBackfill(backfills[0], 150)
+
+			Expect(err).NotTo(HaveOccurred())
+			updated, updatedErr := repository.PendingLogBackfills()
+			Expect(updatedErr).NotTo(HaveOccurred())
+			Expect(len(updated)).To(Equal(1))
+			Expect(updated[0].CheckedThrough).To(Equal(int64(150)))
+		})
+
+		It("excludes backfills that have been checked through their last block", func() {
+			createErr := repository.CreateLogBackfill(fakeAddresses, fakeTopicZero, 100, 200)
+			Expect(createErr).NotTo(HaveOccurred())
+			backfills, getErr := repository.PendingLogBackfills()
+			Expect(getErr).NotTo(HaveOccurred())
+
+			err := repository.UpdateLogBackfill(backfills[0], 200)
+
+			Expect(err).NotTo(HaveOccurred())
+			pending, pendingErr := repository.PendingLogBackfills()
+			Expect(pendingErr).NotTo(HaveOccurred())
+			Expect(pending).To(BeEmpty())
+		})
+	})
 })
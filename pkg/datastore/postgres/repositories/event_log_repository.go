@@ -29,6 +29,10 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+const quarantineLogQuery = `INSERT INTO public.quarantined_logs (log_id, transformer_name, error) VALUES ($1, $2, $3)
+		ON CONFLICT (log_id, transformer_name) DO UPDATE
+		SET error = EXCLUDED.error, attempts = quarantined_logs.attempts + 1, updated = NOW()`
+
 const insertEventLogQuery = `INSERT INTO public.event_logs
 		(header_id, address, topics, data, block_number, block_hash, tx_index, tx_hash, log_index, raw)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) ON CONFLICT DO NOTHING`
@@ -58,15 +62,77 @@ type rawEventLog struct {
 	Raw         []byte
 }
 
+// GetUntransformedEventLogs returns logs that haven't been transformed, leaving out any a transformer has quarantined
 func (repo EventLogRepository) GetUntransformedEventLogs(minID, limit int) ([]core.EventLog, error) {
 	var rawLogs []rawEventLog
 	query := fmt.Sprintf("SELECT id, header_id, address, topics, data, block_number, block_hash,"+
 		"tx_hash, tx_index, log_index, transformed, raw FROM public.event_logs "+
-		"WHERE transformed = false AND id > %d ORDER BY id ASC LIMIT %d", minID, limit)
+		"WHERE transformed = false AND id > %d "+
+		"AND NOT EXISTS (SELECT 1 FROM public.quarantined_logs WHERE quarantined_logs.log_id = event_logs.id) "+
+		"ORDER BY id ASC LIMIT %d", minID, limit)
 	err := repo.db.Select(&rawLogs, query)
 	if err != nil {
 		return nil, err
 	}
+	return repo.toEventLogs(rawLogs)
+}
+
+// GetUntransformedFinalizedEventLogs returns the untransformed logs GetUntransformedEventLogs does, leaving out any
+// whose header hasn't been finalized
+func (repo EventLogRepository) GetUntransformedFinalizedEventLogs(minID, limit int) ([]core.EventLog, error) {
+	var rawLogs []rawEventLog
+	err := repo.db.Select(&rawLogs, `SELECT event_logs.id, header_id, address, topics, data, event_logs.block_number,
+		block_hash, tx_hash, tx_index, log_index, transformed, event_logs.raw
+		FROM public.event_logs JOIN public.headers ON headers.id = event_logs.header_id
+		WHERE transformed = false AND event_logs.id > $1 AND headers.finalized = true
+		AND NOT EXISTS (SELECT 1 FROM public.quarantined_logs WHERE quarantined_logs.log_id = event_logs.id)
+		ORDER BY event_logs.id ASC LIMIT $2`, minID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return repo.toEventLogs(rawLogs)
+}
+
+// QuarantineLog records that a transformer failed on a log, counting the attempts if it was already quarantined
+func (repo EventLogRepository) QuarantineLog(logID int64, transformerName string, transformErr error) error {
+	_, err := repo.db.Exec(quarantineLogQuery, logID, transformerName, transformErr.Error())
+	return err
+}
+
+// GetQuarantinedLogs returns the logs quarantined by a transformer, or by every transformer if the name is empty
+func (repo EventLogRepository) GetQuarantinedLogs(transformerName string) ([]core.QuarantinedLog, error) {
+	var quarantinedLogs []core.QuarantinedLog
+	err := repo.db.Select(&quarantinedLogs, `SELECT quarantined_logs.id, log_id, transformer_name, error, attempts,
+		block_number, tx_hash, quarantined_logs.created, quarantined_logs.updated
+		FROM public.quarantined_logs JOIN public.event_logs ON event_logs.id = quarantined_logs.log_id
+		WHERE $1 = '' OR transformer_name = $1
+		ORDER BY transformer_name, log_id`, transformerName)
+	return quarantinedLogs, err
+}
+
+// GetQuarantinedEventLogs returns the event logs a transformer has quarantined fewer than maxAttempts times, so they can
+// be retried. A maxAttempts below one returns every log the transformer has quarantined.
+func (repo EventLogRepository) GetQuarantinedEventLogs(transformerName string, maxAttempts int) ([]core.EventLog, error) {
+	var rawLogs []rawEventLog
+	err := repo.db.Select(&rawLogs, `SELECT event_logs.id, header_id, address, topics, data, block_number, block_hash,
+		tx_hash, tx_index, log_index, transformed, raw
+		FROM public.event_logs JOIN public.quarantined_logs ON quarantined_logs.log_id = event_logs.id
+		WHERE transformer_name = $1 AND ($2 < 1 OR attempts < $2)
+		ORDER BY event_logs.id`, transformerName, maxAttempts)
+	if err != nil {
+		return nil, err
+	}
+	return repo.toEventLogs(rawLogs)
+}
+
+// RemoveQuarantinedLog releases a log from quarantine once its transformer has executed on it
+func (repo EventLogRepository) RemoveQuarantinedLog(logID int64, transformerName string) error {
+	_, err := repo.db.Exec(`DELETE FROM public.quarantined_logs WHERE log_id = $1 AND transformer_name = $2`,
+		logID, transformerName)
+	return err
+}
+
+func (repo EventLogRepository) toEventLogs(rawLogs []rawEventLog) ([]core.EventLog, error) {
 	var results []core.EventLog
 	for _, rawLog := range rawLogs {
 		var logTopics []common.Hash
@@ -17,6 +17,8 @@
 package repositories_test
 
 import (
+	"errors"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/lib/pq"
@@ -225,6 +227,127 @@ var _ = Describe("Event log repository", func() {
 
 				Expect(resultTwo[0].ID > resultOne[0].ID).To(BeTrue())
 			})
+
+			It("excludes logs that have been quarantined", func() {
+				untransformed, getErr := repo.GetUntransformedEventLogs(0, 2)
+				Expect(getErr).NotTo(HaveOccurred())
+				quarantineErr := repo.QuarantineLog(untransformed[0].ID, "transformer", fakes.FakeError)
+				Expect(quarantineErr).NotTo(HaveOccurred())
+
+				result, err := repo.GetUntransformedEventLogs(0, 2)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(len(result)).To(Equal(1))
+				Expect(result[0].ID).To(Equal(untransformed[1].ID))
+			})
+		})
+	})
+
+	Describe("GetUntransformedFinalizedEventLogs", func() {
+		BeforeEach(func() {
+			log := test_data.GenericTestLog()
+			test_data.CreateMatchingTx(log, headerID, headerRepository)
+			logsErr := repo.CreateEventLogs(headerID, []types.Log{log})
+			Expect(logsErr).NotTo(HaveOccurred())
+		})
+
+		It("excludes logs from headers that aren't finalized", func() {
+			result, err := repo.GetUntransformedFinalizedEventLogs(0, 1)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(BeEmpty())
+		})
+
+		It("returns logs from finalized headers", func() {
+			finalizeErr := headerRepository.MarkHeadersFinalized(fakes.FakeHeader.BlockNumber)
+			Expect(finalizeErr).NotTo(HaveOccurred())
+
+			result, err := repo.GetUntransformedFinalizedEventLogs(0, 1)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(result)).To(Equal(1))
+			Expect(result[0].HeaderID).To(Equal(headerID))
+		})
+	})
+
+	Describe("quarantined logs", func() {
+		var (
+			log        types.Log
+			logID      int64
+			otherLogID int64
+		)
+
+		BeforeEach(func() {
+			log = test_data.GenericTestLog()
+			otherLog := test_data.GenericTestLog()
+			test_data.CreateMatchingTx(log, headerID, headerRepository)
+			test_data.CreateMatchingTx(otherLog, headerID, headerRepository)
+			logsErr := repo.CreateEventLogs(headerID, []types.Log{log, otherLog})
+			Expect(logsErr).NotTo(HaveOccurred())
+			Expect(db.Get(&logID, `SELECT id FROM public.event_logs WHERE tx_hash = $1`, log.TxHash.Hex())).To(Succeed())
+			Expect(db.Get(&otherLogID, `SELECT id FROM public.event_logs WHERE tx_hash = $1`, otherLog.TxHash.Hex())).To(Succeed())
+		})
+
+		It("records the transformer, error and attempts for a quarantined log", func() {
+			Expect(repo.QuarantineLog(logID, "transformer", fakes.FakeError)).To(Succeed())
+			Expect(repo.QuarantineLog(logID, "transformer", errors.New("second error"))).To(Succeed())
+
+			result, err := repo.GetQuarantinedLogs("")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(result)).To(Equal(1))
+			Expect(result[0].LogID).To(Equal(logID))
+			Expect(result[0].TransformerName).To(Equal("transformer"))
+			Expect(result[0].Error).To(Equal("second error"))
+			Expect(result[0].Attempts).To(Equal(2))
+			Expect(result[0].BlockNumber).To(Equal(int64(log.BlockNumber)))
+			Expect(result[0].TxHash).To(Equal(log.TxHash.Hex()))
+		})
+
+		It("filters quarantined logs by transformer", func() {
+			Expect(repo.QuarantineLog(logID, "transformer", fakes.FakeError)).To(Succeed())
+			Expect(repo.QuarantineLog(otherLogID, "otherTransformer", fakes.FakeError)).To(Succeed())
+
+			result, err := repo.GetQuarantinedLogs("otherTransformer")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(result)).To(Equal(1))
+			Expect(result[0].LogID).To(Equal(otherLogID))
+		})
+
+		It("returns a transformer's quarantined event logs", func() {
+			Expect(repo.QuarantineLog(logID, "transformer", fakes.FakeError)).To(Succeed())
+			Expect(repo.QuarantineLog(otherLogID, "otherTransformer", fakes.FakeError)).To(Succeed())
+
+			result, err := repo.GetQuarantinedEventLogs("transformer", 0)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(result)).To(Equal(1))
+			Expect(result[0].ID).To(Equal(logID))
+			Expect(result[0].Log).To(Equal(log))
+		})
+
+		It("leaves out event logs quarantined at least max attempts times", func() {
+			Expect(repo.QuarantineLog(logID, "transformer", fakes.FakeError)).To(Succeed())
+			Expect(repo.QuarantineLog(logID, "transformer", fakes.FakeError)).To(Succeed())
+			Expect(repo.QuarantineLog(otherLogID, "transformer", fakes.FakeError)).To(Succeed())
+
+			result, err := repo.GetQuarantinedEventLogs("transformer", 2)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(result)).To(Equal(1))
+			Expect(result[0].ID).To(Equal(otherLogID))
+		})
+
+		It("removes a log from quarantine", func() {
+			Expect(repo.QuarantineLog(logID, "transformer", fakes.FakeError)).To(Succeed())
+
+			removeErr := repo.RemoveQuarantinedLog(logID, "transformer")
+
+			Expect(removeErr).NotTo(HaveOccurred())
+			result, err := repo.GetQuarantinedLogs("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(BeEmpty())
 		})
 	})
 })
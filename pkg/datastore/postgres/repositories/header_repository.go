@@ -20,13 +20,24 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/makerdao/vulcanizedb/pkg/core"
 	"github.com/makerdao/vulcanizedb/pkg/datastore/postgres"
+	"github.com/makerdao/vulcanizedb/utils"
 	"github.com/sirupsen/logrus"
 )
 
+// ErrReceiptWithoutTransaction is returned when a receipt's transaction isn't among the transactions being persisted
+var ErrReceiptWithoutTransaction = errors.New("receipt's transaction not included")
+
+// maxHeadersPerQuery keeps multi-row header inserts, with four bind parameters per header plus the node ID, within
+// postgres' limit of 65535 bind parameters
+const maxHeadersPerQuery = (65535 - 1) / 4
+
 type headerRepository struct {
 	db *postgres.DB
 }
@@ -45,6 +56,65 @@ func (repo headerRepository) CreateOrUpdateHeader(header core.Header) (int64, er
 	return headerID, nil
 }
 
+// CreateOrUpdateHeaders passes headers to get_or_create_header with multi-row queries, so a batch of headers takes a
+// single round trip to the DB instead of one per header
+func (repo headerRepository) CreateOrUpdateHeaders(headers []core.Header) error {
+	for start := 0; start < len(headers); start += maxHeadersPerQuery {
+		end := start + maxHeadersPerQuery
+		if end > len(headers) {
+			end = len(headers)
+		}
+		args := []interface{}{repo.db.NodeID}
+		valueRows := make([]string, 0, end-start)
+		for _, header := range headers[start:end] {
+			n := len(args)
+			valueRows = append(valueRows, fmt.Sprintf("($%d::BIGINT, $%d::VARCHAR, $%d::JSONB, $%d::NUMERIC)",
+				n+1, n+2, n+3, n+4))
+			args = append(args, header.BlockNumber, header.Hash, header.Raw, header.Timestamp)
+		}
+		query := fmt.Sprintf(`SELECT public.get_or_create_header(v.block_number, v.hash, v.raw, v.block_timestamp, $1)
+			FROM (VALUES %s) AS v (block_number, hash, raw, block_timestamp)`, strings.Join(valueRows, ", "))
+		_, err := repo.db.Exec(query, args...)
+		if err != nil {
+			return fmt.Errorf("error inserting headers for blocks %d-%d: %w", headers[start].BlockNumber,
+				headers[end-1].BlockNumber, err)
+		}
+	}
+	return nil
+}
+
+// ReplaceReorgedHeaders replaces the headers orphaned by a reorg with the canonical headers at their heights, and records
+// the reorg, in a single DB transaction. Deleting an orphaned header deletes the logs, transactions and receipts
+// synced for it.
+func (repo headerRepository) ReplaceReorgedHeaders(reorg core.Reorg, headers []core.Header) error {
+	tx, txErr := repo.db.Beginx()
+	if txErr != nil {
+		return txErr
+	}
+	for _, header := range headers {
+		_, deleteErr := tx.Exec(`DELETE FROM public.headers WHERE block_number = $1 AND hash != $2`,
+			header.BlockNumber, header.Hash)
+		if deleteErr != nil {
+			utils.RollbackAndLogFailure(tx, deleteErr, "headers")
+			return fmt.Errorf("error deleting orphaned header for block %d: %w", header.BlockNumber, deleteErr)
+		}
+		_, insertErr := tx.Exec(`SELECT * FROM public.get_or_create_header($1, $2, $3, $4, $5)`,
+			header.BlockNumber, header.Hash, header.Raw, header.Timestamp, repo.db.NodeID)
+		if insertErr != nil {
+			utils.RollbackAndLogFailure(tx, insertErr, "headers")
+			return fmt.Errorf("error inserting header for block %d: %w", header.BlockNumber, insertErr)
+		}
+	}
+	_, reorgErr := tx.Exec(`INSERT INTO public.reorgs (depth, from_block, to_block, old_hashes, new_hashes)
+		VALUES ($1, $2, $3, $4, $5)`, reorg.Depth, reorg.FromBlock, reorg.ToBlock, pq.Array(reorg.OldHashes),
+		pq.Array(reorg.NewHashes))
+	if reorgErr != nil {
+		utils.RollbackAndLogFailure(tx, reorgErr, "reorgs")
+		return fmt.Errorf("error recording reorg of blocks %d-%d: %w", reorg.FromBlock, reorg.ToBlock, reorgErr)
+	}
+	return tx.Commit()
+}
+
 func (repo headerRepository) CreateTransactions(headerID int64, transactions []core.TransactionModel) error {
 	for _, transaction := range transactions {
 		_, err := repo.db.Exec(`INSERT INTO public.transactions
@@ -60,6 +130,38 @@ func (repo headerRepository) CreateTransactions(headerID int64, transactions []c
 	return nil
 }
 
+// CreateTransactionsWithReceipts persists transactions and their receipts in a single DB transaction
+func (repo headerRepository) CreateTransactionsWithReceipts(headerID int64, transactions []core.TransactionModel, receipts []core.Receipt) error {
+	tx, txErr := repo.db.Beginx()
+	if txErr != nil {
+		return txErr
+	}
+	transactionIDs := make(map[common.Hash]int64, len(transactions))
+	for _, transaction := range transactions {
+		transactionID, createErr := repo.CreateTransactionInTx(tx, headerID, transaction)
+		if createErr != nil {
+			utils.RollbackAndLogFailure(tx, createErr, "transactions")
+			return fmt.Errorf("error creating transactions: %w", createErr)
+		}
+		transactionIDs[common.HexToHash(transaction.Hash)] = transactionID
+	}
+	receiptRepository := ReceiptRepository{}
+	for _, receipt := range receipts {
+		transactionID, ok := transactionIDs[common.HexToHash(receipt.TxHash)]
+		if !ok {
+			missingErr := fmt.Errorf("%w: %s", ErrReceiptWithoutTransaction, receipt.TxHash)
+			utils.RollbackAndLogFailure(tx, missingErr, "receipts")
+			return missingErr
+		}
+		_, createErr := receiptRepository.CreateReceiptInTx(headerID, transactionID, receipt, tx)
+		if createErr != nil {
+			utils.RollbackAndLogFailure(tx, createErr, "receipts")
+			return fmt.Errorf("error creating receipts: %w", createErr)
+		}
+	}
+	return tx.Commit()
+}
+
 func (repo headerRepository) CreateTransactionInTx(tx *sqlx.Tx, headerID int64, transaction core.TransactionModel) (int64, error) {
 	var txId int64
 	err := tx.QueryRowx(`INSERT INTO public.transactions
@@ -93,7 +195,7 @@ func (repo headerRepository) DeleteHeader(blockNumber int64) error {
 func (repo headerRepository) GetHeaderByBlockNumber(blockNumber int64) (core.Header, error) {
 	var header core.Header
 	err := repo.db.Get(&header,
-		`SELECT id, block_number, hash, raw, block_timestamp FROM headers WHERE block_number = $1`, blockNumber)
+		`SELECT id, block_number, hash, raw, block_timestamp, finalized FROM headers WHERE block_number = $1`, blockNumber)
 	if err == sql.ErrNoRows {
 		return header, postgres.ErrHeaderDoesNotExist
 	}
@@ -102,14 +204,14 @@ func (repo headerRepository) GetHeaderByBlockNumber(blockNumber int64) (core.Hea
 
 func (repo headerRepository) GetHeaderByID(id int64) (core.Header, error) {
 	var header core.Header
-	headerErr := repo.db.Get(&header, `SELECT id, block_number, hash, raw, block_timestamp FROM headers WHERE id = $1`, id)
+	headerErr := repo.db.Get(&header, `SELECT id, block_number, hash, raw, block_timestamp, finalized FROM headers WHERE id = $1`, id)
 	return header, headerErr
 }
 
 func (repo headerRepository) GetHeadersInRange(startingBlock, endingBlock int64) ([]core.Header, error) {
 	var headers []core.Header
 	err := repo.db.Select(&headers,
-		`SELECT id, block_number, hash, raw, block_timestamp FROM headers WHERE block_number BETWEEN $1 AND $2 ORDER BY block_number ASC`,
+		`SELECT id, block_number, hash, raw, block_timestamp, finalized FROM headers WHERE block_number BETWEEN $1 AND $2 ORDER BY block_number ASC`,
 		startingBlock, endingBlock)
 	return headers, err
 }
@@ -130,6 +232,16 @@ func (repo headerRepository) MissingBlockNumbers(startingBlockNumber, endingBloc
 	return numbers, err
 }
 
+// MarkHeadersFinalized marks every header at or below the block number finalized
+func (repo headerRepository) MarkHeadersFinalized(blockNumber int64) error {
+	_, err := repo.db.Exec(`UPDATE public.headers SET finalized = true WHERE block_number <= $1 AND finalized = false`,
+		blockNumber)
+	if err != nil {
+		return fmt.Errorf("error marking headers through block %d finalized: %w", blockNumber, err)
+	}
+	return nil
+}
+
 func (repo headerRepository) GetMostRecentHeaderBlockNumber() (int64, error) {
 	var blockNumber int64
 	err := repo.db.Get(&blockNumber,
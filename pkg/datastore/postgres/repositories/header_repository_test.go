@@ -18,11 +18,14 @@ package repositories_test
 
 import (
 	"database/sql"
+	"errors"
 	"math/big"
 	"math/rand"
 	"strconv"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/lib/pq"
+	"github.com/makerdao/vulcanizedb/libraries/shared/test_data"
 	"github.com/makerdao/vulcanizedb/pkg/core"
 	"github.com/makerdao/vulcanizedb/pkg/datastore"
 	"github.com/makerdao/vulcanizedb/pkg/datastore/postgres"
@@ -202,6 +205,67 @@ var _ = Describe("Block header repository", func() {
 		})
 	})
 
+	Describe("creating transactions with receipts", func() {
+		var (
+			headerID    int64
+			transaction core.TransactionModel
+			receipt     core.Receipt
+		)
+
+		BeforeEach(func() {
+			var err error
+			headerID, err = repo.CreateOrUpdateHeader(header)
+			Expect(err).NotTo(HaveOccurred())
+			transaction = core.TransactionModel{
+				Data:    []byte{},
+				From:    common.HexToAddress("0x1234").Hex(),
+				Hash:    common.HexToHash("0x9876").Hex(),
+				Raw:     []byte{},
+				To:      common.HexToAddress("0x5678").Hex(),
+				TxIndex: 1,
+				Value:   "0",
+			}
+			receipt = core.Receipt{
+				ContractAddress:   common.HexToAddress("0xabcd").Hex(),
+				CumulativeGasUsed: 42000,
+				GasUsed:           21000,
+				Rlp:               []byte{1, 2, 3},
+				Status:            1,
+				TxHash:            transaction.Hash,
+			}
+		})
+
+		It("adds transactions and their receipts", func() {
+			err := repo.CreateTransactionsWithReceipts(headerID, []core.TransactionModel{transaction}, []core.Receipt{receipt})
+			Expect(err).NotTo(HaveOccurred())
+
+			var dbReceipt core.Receipt
+			readErr := db.Get(&dbReceipt,
+				`SELECT cumulative_gas_used, gas_used, rlp, status, receipts.tx_hash
+				FROM public.receipts JOIN public.transactions ON receipts.transaction_id = transactions.id
+				WHERE receipts.header_id = $1 AND transactions.hash = $2`, headerID, transaction.Hash)
+			Expect(readErr).NotTo(HaveOccurred())
+			Expect(dbReceipt.CumulativeGasUsed).To(Equal(receipt.CumulativeGasUsed))
+			Expect(dbReceipt.GasUsed).To(Equal(receipt.GasUsed))
+			Expect(dbReceipt.Rlp).To(Equal(receipt.Rlp))
+			Expect(dbReceipt.Status).To(Equal(receipt.Status))
+			Expect(dbReceipt.TxHash).To(Equal(receipt.TxHash))
+		})
+
+		It("rolls back the transactions if a receipt has no matching transaction", func() {
+			receipt.TxHash = common.HexToHash("0x5432").Hex()
+
+			err := repo.CreateTransactionsWithReceipts(headerID, []core.TransactionModel{transaction}, []core.Receipt{receipt})
+
+			Expect(err).To(HaveOccurred())
+			Expect(errors.Is(err, repositories.ErrReceiptWithoutTransaction)).To(BeTrue())
+			var transactionCount int
+			readErr := db.Get(&transactionCount, `SELECT count(*) FROM public.transactions WHERE header_id = $1`, headerID)
+			Expect(readErr).NotTo(HaveOccurred())
+			Expect(transactionCount).To(BeZero())
+		})
+	})
+
 	Describe("creating a transaction in a sqlx tx", func() {
 		It("adds a transaction", func() {
 			headerID, err := repo.CreateOrUpdateHeader(header)
@@ -274,6 +338,111 @@ var _ = Describe("Block header repository", func() {
 		})
 	})
 
+	Describe("creating or updating multiple headers", func() {
+		It("inserts every header", func() {
+			headers := []core.Header{fakes.GetFakeHeader(1), fakes.GetFakeHeader(2), fakes.GetFakeHeader(3)}
+
+			err := repo.CreateOrUpdateHeaders(headers)
+
+			Expect(err).NotTo(HaveOccurred())
+			var dbHeaders []core.Header
+			readErr := db.Select(&dbHeaders, `SELECT block_number, hash, raw, block_timestamp FROM public.headers
+				ORDER BY block_number`)
+			Expect(readErr).NotTo(HaveOccurred())
+			Expect(len(dbHeaders)).To(Equal(len(headers)))
+			for i, header := range headers {
+				Expect(dbHeaders[i].BlockNumber).To(Equal(header.BlockNumber))
+				Expect(dbHeaders[i].Hash).To(Equal(header.Hash))
+				Expect(dbHeaders[i].Raw).To(MatchJSON(header.Raw))
+				Expect(dbHeaders[i].Timestamp).To(Equal(header.Timestamp))
+			}
+		})
+
+		It("does not duplicate headers that already exist", func() {
+			_, createErr := repo.CreateOrUpdateHeader(header)
+			Expect(createErr).NotTo(HaveOccurred())
+
+			err := repo.CreateOrUpdateHeaders([]core.Header{header, fakes.GetFakeHeader(header.BlockNumber + 1)})
+
+			Expect(err).NotTo(HaveOccurred())
+			var count int
+			readErr := db.Get(&count, `SELECT count(*) FROM public.headers`)
+			Expect(readErr).NotTo(HaveOccurred())
+			Expect(count).To(Equal(2))
+		})
+	})
+
+	Describe("replacing reorged headers", func() {
+		var (
+			orphanedHeaders  []core.Header
+			canonicalHeaders []core.Header
+			reorg            core.Reorg
+		)
+
+		BeforeEach(func() {
+			orphanedHeaders, canonicalHeaders = nil, nil
+			reorg = core.Reorg{Depth: 2, FromBlock: header.BlockNumber, ToBlock: header.BlockNumber + 1}
+			for i := int64(0); i < 2; i++ {
+				orphaned := fakes.GetFakeHeader(header.BlockNumber + i)
+				_, createErr := repo.CreateOrUpdateHeader(orphaned)
+				Expect(createErr).NotTo(HaveOccurred())
+				orphanedHeaders = append(orphanedHeaders, orphaned)
+				canonical := fakes.GetFakeHeader(header.BlockNumber + i)
+				canonicalHeaders = append(canonicalHeaders, canonical)
+				reorg.OldHashes = append(reorg.OldHashes, orphaned.Hash)
+				reorg.NewHashes = append(reorg.NewHashes, canonical.Hash)
+			}
+			// headers more than 15 blocks below the latest header aren't replaced by CreateOrUpdateHeader
+			_, createErr := repo.CreateOrUpdateHeader(fakes.GetFakeHeader(header.BlockNumber + 100))
+			Expect(createErr).NotTo(HaveOccurred())
+		})
+
+		It("replaces orphaned headers with the canonical headers", func() {
+			err := repo.ReplaceReorgedHeaders(reorg, canonicalHeaders)
+
+			Expect(err).NotTo(HaveOccurred())
+			var hashes []string
+			readErr := db.Select(&hashes, `SELECT hash FROM public.headers WHERE block_number BETWEEN $1 AND $2
+				ORDER BY block_number`, reorg.FromBlock, reorg.ToBlock)
+			Expect(readErr).NotTo(HaveOccurred())
+			Expect(hashes).To(Equal([]string{canonicalHeaders[0].Hash, canonicalHeaders[1].Hash}))
+		})
+
+		It("deletes logs synced for orphaned headers", func() {
+			orphanedHeaderID, getErr := repo.GetHeaderByBlockNumber(orphanedHeaders[0].BlockNumber)
+			Expect(getErr).NotTo(HaveOccurred())
+			test_data.CreateTestLog(orphanedHeaderID.Id, db)
+
+			err := repo.ReplaceReorgedHeaders(reorg, canonicalHeaders)
+
+			Expect(err).NotTo(HaveOccurred())
+			var logCount int
+			readErr := db.Get(&logCount, `SELECT count(*) FROM public.event_logs WHERE header_id = $1`, orphanedHeaderID.Id)
+			Expect(readErr).NotTo(HaveOccurred())
+			Expect(logCount).To(BeZero())
+		})
+
+		It("records the reorg", func() {
+			err := repo.ReplaceReorgedHeaders(reorg, canonicalHeaders)
+
+			Expect(err).NotTo(HaveOccurred())
+			var dbReorg struct {
+				Depth     int64
+				FromBlock int64          `db:"from_block"`
+				ToBlock   int64          `db:"to_block"`
+				OldHashes pq.StringArray `db:"old_hashes"`
+				NewHashes pq.StringArray `db:"new_hashes"`
+			}
+			readErr := db.Get(&dbReorg, `SELECT depth, from_block, to_block, old_hashes, new_hashes FROM public.reorgs`)
+			Expect(readErr).NotTo(HaveOccurred())
+			Expect(dbReorg.Depth).To(Equal(reorg.Depth))
+			Expect(dbReorg.FromBlock).To(Equal(reorg.FromBlock))
+			Expect(dbReorg.ToBlock).To(Equal(reorg.ToBlock))
+			Expect([]string(dbReorg.OldHashes)).To(Equal(reorg.OldHashes))
+			Expect([]string(dbReorg.NewHashes)).To(Equal(reorg.NewHashes))
+		})
+	})
+
 	Describe("deleting a header", func() {
 		It("returns error if header does not exist", func() {
 			err := repo.DeleteHeader(rand.Int63())
@@ -424,6 +593,26 @@ var _ = Describe("Block header repository", func() {
 		})
 	})
 
+	Describe("marking headers finalized", func() {
+		It("marks headers at or below the block number finalized", func() {
+			_, createErr := repo.CreateOrUpdateHeader(header)
+			Expect(createErr).NotTo(HaveOccurred())
+			laterHeader := fakes.GetFakeHeader(header.BlockNumber + 1)
+			_, createLaterErr := repo.CreateOrUpdateHeader(laterHeader)
+			Expect(createLaterErr).NotTo(HaveOccurred())
+
+			err := repo.MarkHeadersFinalized(header.BlockNumber)
+
+			Expect(err).NotTo(HaveOccurred())
+			finalizedHeader, getErr := repo.GetHeaderByBlockNumber(header.BlockNumber)
+			Expect(getErr).NotTo(HaveOccurred())
+			Expect(finalizedHeader.Finalized).To(BeTrue())
+			unfinalizedHeader, getLaterErr := repo.GetHeaderByBlockNumber(laterHeader.BlockNumber)
+			Expect(getLaterErr).NotTo(HaveOccurred())
+			Expect(unfinalizedHeader.Finalized).To(BeFalse())
+		})
+	})
+
 	Describe("GetMostRecentHeaderBlockNumber", func() {
 		It("gets the most recent header block number", func() {
 			_, createHeader1Err := repo.CreateOrUpdateHeader(header)
@@ -27,6 +27,7 @@ type AddressRepository interface {
 }
 
 type CheckedHeadersRepository interface {
+	LastCheckedBlockNumber() (int64, error)
 	MarkHeaderChecked(headerID int64) error
 	MarkSingleHeaderUnchecked(blockNumber int64) error
 	UncheckedHeaders(startingBlockNumber, endingBlockNumber, checkCount int64) ([]core.Header, error)
@@ -34,12 +35,18 @@ type CheckedHeadersRepository interface {
 
 type CheckedLogsRepository interface {
 	AlreadyWatchingLog(addresses []string, topic0 string) (bool, error)
+	CreateLogBackfill(addresses []string, topic0 string, fromBlock, toBlock int64) error
 	MarkLogWatched(addresses []string, topic0 string) error
+	PendingLogBackfills() ([]core.LogBackfill, error)
+	UnwatchedAddresses(addresses []string, topic0 string) ([]string, error)
+	UpdateLogBackfill(backfill core.LogBackfill, checkedThrough int64) error
 }
 
 type HeaderRepository interface {
 	CreateOrUpdateHeader(header core.Header) (int64, error)
+	CreateOrUpdateHeaders(headers []core.Header) error
 	CreateTransactions(headerID int64, transactions []core.TransactionModel) error
+	CreateTransactionsWithReceipts(headerID int64, transactions []core.TransactionModel, receipts []core.Receipt) error
 	CreateTransactionInTx(tx *sqlx.Tx, headerID int64, transaction core.TransactionModel) (int64, error)
 	DeleteHeader(blockNumber int64) error
 	GetHeaderByBlockNumber(blockNumber int64) (core.Header, error)
@@ -47,9 +54,16 @@ type HeaderRepository interface {
 	GetHeadersInRange(startingBlock, endingBlock int64) ([]core.Header, error)
 	MissingBlockNumbers(startingBlockNumber, endingBlockNumber int64) ([]int64, error)
 	GetMostRecentHeaderBlockNumber() (int64, error)
+	MarkHeadersFinalized(blockNumber int64) error
+	ReplaceReorgedHeaders(reorg core.Reorg, headers []core.Header) error
 }
 
 type EventLogRepository interface {
 	GetUntransformedEventLogs(minID, limit int) ([]core.EventLog, error)
+	GetUntransformedFinalizedEventLogs(minID, limit int) ([]core.EventLog, error)
 	CreateEventLogs(headerID int64, logs []types.Log) error
+	QuarantineLog(logID int64, transformerName string, transformErr error) error
+	GetQuarantinedLogs(transformerName string) ([]core.QuarantinedLog, error)
+	GetQuarantinedEventLogs(transformerName string, maxAttempts int) ([]core.EventLog, error)
+	RemoveQuarantinedLog(logID int64, transformerName string) error
 }
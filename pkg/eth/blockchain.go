@@ -17,35 +17,50 @@
 package eth
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"math/big"
-	"strconv"
+	"sync"
+	"sync/atomic"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/makerdao/vulcanizedb/pkg/core"
 	"github.com/makerdao/vulcanizedb/pkg/eth/converters"
+	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 )
 
-var ErrEmptyHeader = errors.New("empty header returned over RPC")
+var (
+	ErrEmptyHeader          = converters.ErrEmptyHeader
+	ErrMissingReceipt       = errors.New("receipt not returned over RPC")
+	ErrFinalizedUnsupported = errors.New("node doesn't support the finalized block tag")
+	methodNotFoundCode      = -32601
+	invalidParamsCode       = -32602
+)
 
+// MAX_BATCH_SIZE is the default number of requests sent to the node in a single batch call
 const MAX_BATCH_SIZE = 100
 
 type BlockChain struct {
 	ethClient            core.EthClient
-	headerConverter      converters.HeaderConverter
+	headerDecoder        core.HeaderDecoder
 	node                 core.Node
+	receiptConverter     converters.ReceiptConverter
 	rpcClient            core.RpcClient
 	transactionConverter converters.TransactionConverter
+	// Set once the node has rejected eth_getBlockReceipts, so receipts are fetched per transaction from then on
+	blockReceiptsUnsupported int32
 }
 
-func NewBlockChain(ethClient core.EthClient, rpcClient core.RpcClient, node core.Node, converter converters.TransactionConverter) *BlockChain {
+func NewBlockChain(ethClient core.EthClient, rpcClient core.RpcClient, node core.Node, converter converters.TransactionConverter, headerDecoder core.HeaderDecoder) *BlockChain {
 	return &BlockChain{
 		ethClient:            ethClient,
-		headerConverter:      converters.HeaderConverter{},
+		headerDecoder:        headerDecoder,
 		node:                 node,
 		rpcClient:            rpcClient,
 		transactionConverter: converter,
@@ -61,17 +76,35 @@ func (blockChain *BlockChain) GetEthLogsWithCustomQuery(query ethereum.FilterQue
 }
 
 func (blockChain *BlockChain) GetHeaderByNumber(blockNumber int64) (header core.Header, err error) {
-	if blockChain.node.NetworkID == core.KOVAN_NETWORK_ID {
-		return blockChain.getPOAHeader(blockNumber)
+	var rawHeader json.RawMessage
+	blockNumberArg := hexutil.EncodeBig(big.NewInt(blockNumber))
+	includeTransactions := false
+	err = blockChain.rpcClient.CallContext(context.Background(), &rawHeader, "eth_getBlockByNumber", blockNumberArg, includeTransactions)
+	if err != nil {
+		return header, err
 	}
-	return blockChain.getPOWHeader(blockNumber)
+	return blockChain.headerDecoder.Decode(rawHeader)
 }
 
-func (blockChain *BlockChain) GetHeadersByNumbers(blockNumbers []int64) (header []core.Header, err error) {
-	if blockChain.node.NetworkID == core.KOVAN_NETWORK_ID {
-		return blockChain.getPOAHeaders(blockNumbers)
+// GetHeadersByNumbers fetches the headers in a single batch request, so callers should limit how many block numbers
+// they pass (see MAX_BATCH_SIZE). Block numbers the node has no header for are omitted from the result.
+func (blockChain *BlockChain) GetHeadersByNumbers(blockNumbers []int64) ([]core.Header, error) {
+	rawHeaders, err := blockChain.getRawHeaders(blockNumbers)
+	if err != nil {
+		return nil, err
+	}
+	var headers []core.Header
+	for index, rawHeader := range rawHeaders {
+		header, decodeErr := blockChain.headerDecoder.Decode(rawHeader)
+		if errors.Is(decodeErr, ErrEmptyHeader) {
+			continue
+		}
+		if decodeErr != nil {
+			return nil, fmt.Errorf("error decoding header for block %d: %w", blockNumbers[index], decodeErr)
+		}
+		headers = append(headers, header)
 	}
-	return blockChain.getPOWHeaders(blockNumbers)
+	return headers, nil
 }
 
 func (blockChain *BlockChain) GetTransactions(transactionHashes []common.Hash) ([]core.TransactionModel, error) {
@@ -96,169 +129,220 @@ func (blockChain *BlockChain) GetTransactions(transactionHashes []common.Hash) (
 	return blockChain.transactionConverter.ConvertRpcTransactionsToModels(transactions)
 }
 
-func (blockChain *BlockChain) ChainHead() (*big.Int, error) {
-	block, err := blockChain.ethClient.HeaderByNumber(context.Background(), nil)
-	if err != nil {
-		return big.NewInt(0), err
+// GetTransactionReceipts fetches the receipts for transactions in a block, with a single eth_getBlockReceipts call if
+// the node supports it and otherwise with a batch of eth_getTransactionReceipt calls
+func (blockChain *BlockChain) GetTransactionReceipts(blockHash common.Hash, transactionHashes []common.Hash) ([]core.Receipt, error) {
+	if len(transactionHashes) == 0 {
+		return nil, nil
 	}
-	return block.Number, err
+	if atomic.LoadInt32(&blockChain.blockReceiptsUnsupported) == 0 {
+		receipts, err := blockChain.getBlockReceipts(blockHash, transactionHashes)
+		if !isMethodNotFound(err) {
+			return receipts, err
+		}
+		logrus.Info("node doesn't support eth_getBlockReceipts, fetching receipts per transaction")
+		atomic.StoreInt32(&blockChain.blockReceiptsUnsupported, 1)
+	}
+	return blockChain.getTransactionReceipts(transactionHashes)
 }
 
-func (blockChain *BlockChain) BatchGetStorageAt(account common.Address, keys []common.Hash, blockNumber *big.Int) (map[common.Hash][]byte, error) {
-	numStorageValues := len(keys)
-	var batch []core.BatchElem
-	storageValues := make([]hexutil.Bytes, numStorageValues)
-
-	for index, key := range keys {
-		batchElem := core.BatchElem{
-			Method: "eth_getStorageAt",
-			Args:   []interface{}{account.Hex(), key.Hex(), hexutil.EncodeBig(blockNumber)},
-			Result: &storageValues[index],
-			Error:  nil,
+func (blockChain *BlockChain) getBlockReceipts(blockHash common.Hash, transactionHashes []common.Hash) ([]core.Receipt, error) {
+	var gethReceipts []*types.Receipt
+	rpcErr := blockChain.rpcClient.CallContext(context.Background(), &gethReceipts, "eth_getBlockReceipts", blockHash.Hex())
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	receiptsByHash := make(map[common.Hash]*types.Receipt, len(gethReceipts))
+	for _, gethReceipt := range gethReceipts {
+		if gethReceipt != nil {
+			receiptsByHash[gethReceipt.TxHash] = gethReceipt
 		}
-		batch = append(batch, batchElem)
 	}
+	wantedReceipts := make([]*types.Receipt, len(transactionHashes))
+	for i, transactionHash := range transactionHashes {
+		wantedReceipts[i] = receiptsByHash[transactionHash]
+	}
+	return blockChain.convertReceipts(transactionHashes, wantedReceipts)
+}
 
+func (blockChain *BlockChain) getTransactionReceipts(transactionHashes []common.Hash) ([]core.Receipt, error) {
+	var batch []core.BatchElem
+	gethReceipts := make([]*types.Receipt, len(transactionHashes))
+	for index, transactionHash := range transactionHashes {
+		batch = append(batch, core.BatchElem{
+			Method: "eth_getTransactionReceipt",
+			Result: &gethReceipts[index],
+			Args:   []interface{}{transactionHash},
+		})
+	}
 	rpcErr := blockChain.rpcClient.BatchCall(batch)
 	if rpcErr != nil {
 		return nil, rpcErr
 	}
+	return blockChain.convertReceipts(transactionHashes, gethReceipts)
+}
 
-	result := make(map[common.Hash][]byte)
-	for index, key := range keys {
-		result[key] = storageValues[index]
+func (blockChain *BlockChain) convertReceipts(transactionHashes []common.Hash, gethReceipts []*types.Receipt) ([]core.Receipt, error) {
+	receipts := make([]core.Receipt, len(gethReceipts))
+	for i, gethReceipt := range gethReceipts {
+		if gethReceipt == nil {
+			return nil, fmt.Errorf("%w: %s", ErrMissingReceipt, transactionHashes[i].Hex())
+		}
+		receipt, convertErr := blockChain.receiptConverter.Convert(gethReceipt)
+		if convertErr != nil {
+			return nil, convertErr
+		}
+		receipts[i] = receipt
 	}
-	return result, nil
+	return receipts, nil
 }
 
-func (blockChain *BlockChain) Node() core.Node {
-	return blockChain.node
+func isMethodNotFound(err error) bool {
+	var rpcErr rpc.Error
+	return errors.As(err, &rpcErr) && rpcErr.ErrorCode() == methodNotFoundCode
 }
 
-func (blockChain *BlockChain) getPOAHeader(blockNumber int64) (header core.Header, err error) {
-	var POAHeader core.POAHeader
-	blockNumberArg := hexutil.EncodeBig(big.NewInt(blockNumber))
-	includeTransactions := false
-	err = blockChain.rpcClient.CallContext(context.Background(), &POAHeader, "eth_getBlockByNumber", blockNumberArg, includeTransactions)
+func isInvalidParams(err error) bool {
+	var rpcErr rpc.Error
+	return errors.As(err, &rpcErr) && rpcErr.ErrorCode() == invalidParamsCode
+}
+
+// SubscribeNewHeads streams the node's new chain heads to headers over a newHeads subscription, which requires a
+// websocket or IPC connection. The subscription's Err channel receives an error if the subscription drops.
+func (blockChain *BlockChain) SubscribeNewHeads(headers chan<- core.Header) (core.Subscription, error) {
+	rawHeaders := make(chan json.RawMessage)
+	rpcSubscription, err := blockChain.rpcClient.Subscribe("eth", rawHeaders, "newHeads")
 	if err != nil {
-		return header, err
+		return nil, err
 	}
-	if POAHeader.Number == nil {
-		return header, ErrEmptyHeader
+	subscription := &headerSubscription{
+		rpcSubscription: rpcSubscription,
+		err:             make(chan error, 1),
+		quit:            make(chan struct{}),
 	}
-	return blockChain.headerConverter.Convert(&types.Header{
-		ParentHash:  POAHeader.ParentHash,
-		UncleHash:   POAHeader.UncleHash,
-		Coinbase:    POAHeader.Coinbase,
-		Root:        POAHeader.Root,
-		TxHash:      POAHeader.TxHash,
-		ReceiptHash: POAHeader.ReceiptHash,
-		Bloom:       POAHeader.Bloom,
-		Difficulty:  POAHeader.Difficulty.ToInt(),
-		Number:      POAHeader.Number.ToInt(),
-		GasLimit:    uint64(POAHeader.GasLimit),
-		GasUsed:     uint64(POAHeader.GasUsed),
-		Time:        uint64(POAHeader.Time),
-		Extra:       POAHeader.Extra,
-	}, POAHeader.Hash.String()), nil
+	go blockChain.forwardHeaders(rawHeaders, headers, subscription)
+	return subscription, nil
 }
 
-func (blockChain *BlockChain) getPOAHeaders(blockNumbers []int64) (headers []core.Header, err error) {
+func (blockChain *BlockChain) forwardHeaders(rawHeaders <-chan json.RawMessage, headers chan<- core.Header, subscription *headerSubscription) {
+	for {
+		select {
+		case rawHeader := <-rawHeaders:
+			header, convertErr := blockChain.headerDecoder.Decode(rawHeader)
+			if convertErr != nil {
+				subscription.rpcSubscription.Unsubscribe()
+				subscription.err <- fmt.Errorf("error converting streamed header: %w", convertErr)
+				return
+			}
+			select {
+			case headers <- header:
+			case <-subscription.quit:
+				return
+			}
+		case rpcErr := <-subscription.rpcSubscription.Err():
+			if rpcErr != nil {
+				subscription.err <- rpcErr
+			}
+			return
+		case <-subscription.quit:
+			return
+		}
+	}
+}
 
-	var batch []core.BatchElem
-	var POAHeaders [MAX_BATCH_SIZE]core.POAHeader
-	includeTransactions := false
+// headerSubscription wraps a newHeads subscription, so that an error converting a streamed header is reported like
+// the subscription dropping
+type headerSubscription struct {
+	rpcSubscription core.Subscription
+	err             chan error
+	quit            chan struct{}
+	quitOnce        sync.Once
+}
 
-	for index, blockNumber := range blockNumbers {
+func (subscription *headerSubscription) Err() <-chan error {
+	return subscription.err
+}
 
-		if index >= MAX_BATCH_SIZE {
-			break
+func (subscription *headerSubscription) Unsubscribe() {
+	subscription.quitOnce.Do(func() {
+		close(subscription.quit)
+		subscription.rpcSubscription.Unsubscribe()
+	})
+}
+
+// GetFinalizedHeader fetches the latest header the node considers finalized. Only a node rejecting the method or the
+// finalized tag itself is reported as ErrFinalizedUnsupported; other errors (e.g. rate limits) may be transient.
+func (blockChain *BlockChain) GetFinalizedHeader() (core.Header, error) {
+	var rawHeader json.RawMessage
+	err := blockChain.rpcClient.CallContext(context.Background(), &rawHeader, "eth_getBlockByNumber", "finalized", false)
+	if err != nil {
+		if isMethodNotFound(err) || isInvalidParams(err) {
+			return core.Header{}, fmt.Errorf("%w: %s", ErrFinalizedUnsupported, err.Error())
 		}
+		return core.Header{}, err
+	}
+	if len(rawHeader) == 0 || string(rawHeader) == "null" {
+		return core.Header{}, ErrEmptyHeader
+	}
+	return blockChain.headerDecoder.Decode(rawHeader)
+}
 
-		blockNumberArg := hexutil.EncodeBig(big.NewInt(blockNumber))
+func (blockChain *BlockChain) ChainHead() (*big.Int, error) {
+	block, err := blockChain.ethClient.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		return big.NewInt(0), err
+	}
+	return block.Number, err
+}
+
+func (blockChain *BlockChain) BatchGetStorageAt(account common.Address, keys []common.Hash, blockNumber *big.Int) (map[common.Hash][]byte, error) {
+	numStorageValues := len(keys)
+	var batch []core.BatchElem
+	storageValues := make([]hexutil.Bytes, numStorageValues)
 
+	for index, key := range keys {
 		batchElem := core.BatchElem{
-			Method: "eth_getBlockByNumber",
-			Result: &POAHeaders[index],
-			Args:   []interface{}{blockNumberArg, includeTransactions},
+			Method: "eth_getStorageAt",
+			Args:   []interface{}{account.Hex(), key.Hex(), hexutil.EncodeBig(blockNumber)},
+			Result: &storageValues[index],
+			Error:  nil,
 		}
-
 		batch = append(batch, batchElem)
 	}
 
-	err = blockChain.rpcClient.BatchCall(batch)
-	if err != nil {
-		return headers, err
+	rpcErr := blockChain.rpcClient.BatchCall(batch)
+	if rpcErr != nil {
+		return nil, rpcErr
 	}
 
-	for _, POAHeader := range POAHeaders {
-		var header core.Header
-		//Header.Number of the newest block will return nil.
-		if _, err := strconv.ParseUint(POAHeader.Number.ToInt().String(), 16, 64); err == nil {
-			header = blockChain.headerConverter.Convert(&types.Header{
-				ParentHash:  POAHeader.ParentHash,
-				UncleHash:   POAHeader.UncleHash,
-				Coinbase:    POAHeader.Coinbase,
-				Root:        POAHeader.Root,
-				TxHash:      POAHeader.TxHash,
-				ReceiptHash: POAHeader.ReceiptHash,
-				Bloom:       POAHeader.Bloom,
-				Difficulty:  POAHeader.Difficulty.ToInt(),
-				Number:      POAHeader.Number.ToInt(),
-				GasLimit:    uint64(POAHeader.GasLimit),
-				GasUsed:     uint64(POAHeader.GasUsed),
-				Time:        uint64(POAHeader.Time),
-				Extra:       POAHeader.Extra,
-			}, POAHeader.Hash.String())
-
-			headers = append(headers, header)
-		}
+	result := make(map[common.Hash][]byte)
+	for index, key := range keys {
+		result[key] = storageValues[index]
 	}
-
-	return headers, err
+	return result, nil
 }
 
-func (blockChain *BlockChain) getPOWHeader(blockNumber int64) (header core.Header, err error) {
-	gethHeader, err := blockChain.ethClient.HeaderByNumber(context.Background(), big.NewInt(blockNumber))
-	if err != nil {
-		return header, err
-	}
-	return blockChain.headerConverter.Convert(gethHeader, gethHeader.Hash().String()), nil
+func (blockChain *BlockChain) Node() core.Node {
+	return blockChain.node
 }
 
-func (blockChain *BlockChain) getPOWHeaders(blockNumbers []int64) (headers []core.Header, err error) {
+func (blockChain *BlockChain) getRawHeaders(blockNumbers []int64) ([]json.RawMessage, error) {
 	var batch []core.BatchElem
-	var POWHeaders [MAX_BATCH_SIZE]types.Header
+	rawHeaders := make([]json.RawMessage, len(blockNumbers))
 	includeTransactions := false
 
 	for index, blockNumber := range blockNumbers {
-
-		if index >= MAX_BATCH_SIZE {
-			break
-		}
-
 		blockNumberArg := hexutil.EncodeBig(big.NewInt(blockNumber))
 
 		batchElem := core.BatchElem{
 			Method: "eth_getBlockByNumber",
-			Result: &POWHeaders[index],
+			Result: &rawHeaders[index],
 			Args:   []interface{}{blockNumberArg, includeTransactions},
 		}
 
 		batch = append(batch, batchElem)
 	}
 
-	err = blockChain.rpcClient.BatchCall(batch)
-	if err != nil {
-		return headers, err
-	}
-
-	for _, POWHeader := range POWHeaders {
-		if POWHeader.Number != nil {
-			header := blockChain.headerConverter.Convert(&POWHeader, POWHeader.Hash().String())
-			headers = append(headers, header)
-		}
-	}
-
-	return headers, err
+	err := blockChain.rpcClient.BatchCall(batch)
+	return rawHeaders, err
 }
@@ -18,6 +18,8 @@ package eth_test
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"math/big"
 	"math/rand"
 
@@ -28,6 +30,7 @@ import (
 	"github.com/makerdao/vulcanizedb/libraries/shared/test_data"
 	"github.com/makerdao/vulcanizedb/pkg/core"
 	"github.com/makerdao/vulcanizedb/pkg/eth"
+	"github.com/makerdao/vulcanizedb/pkg/eth/converters"
 	"github.com/makerdao/vulcanizedb/pkg/fakes"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -47,81 +50,91 @@ var _ = Describe("Geth blockchain", func() {
 		mockRpcClient = fakes.NewMockRpcClient()
 		mockTransactionConverter = fakes.NewMockTransactionConverter()
 		node = core.Node{}
-		blockChain = eth.NewBlockChain(mockClient, mockRpcClient, node, mockTransactionConverter)
+		blockChain = eth.NewBlockChain(mockClient, mockRpcClient, node, mockTransactionConverter, converters.POWHeaderDecoder{})
 	})
 
 	Describe("getting a header", func() {
-		Describe("default/mainnet", func() {
-			It("fetches header from ethClient", func() {
-				blockNumber := int64(100)
-				mockClient.SetHeaderByNumberReturnHeader(&types.Header{Number: big.NewInt(blockNumber)})
+		var gethHeader types.Header
 
-				_, err := blockChain.GetHeaderByNumber(blockNumber)
+		BeforeEach(func() {
+			gethHeader = types.Header{Number: big.NewInt(100), Difficulty: big.NewInt(1), Time: 123}
+			rawHeader, marshalErr := json.Marshal(&gethHeader)
+			Expect(marshalErr).NotTo(HaveOccurred())
+			mockRpcClient.RawHeaderToReturn = rawHeader
+		})
 
-				Expect(err).NotTo(HaveOccurred())
-				mockClient.AssertHeaderByNumberCalledWith(context.Background(), big.NewInt(blockNumber))
-			})
+		It("fetches the block by number from rpcClient", func() {
+			_, err := blockChain.GetHeaderByNumber(100)
 
-			It("returns err if ethClient returns err", func() {
-				mockClient.SetHeaderByNumberErr(fakes.FakeError)
+			Expect(err).NotTo(HaveOccurred())
+			mockRpcClient.AssertCallContextCalledWithArgs("eth_getBlockByNumber", hexutil.EncodeBig(big.NewInt(100)), false)
+		})
 
-				_, err := blockChain.GetHeaderByNumber(100)
+		It("decodes the header with the configured decoder", func() {
+			header, err := blockChain.GetHeaderByNumber(100)
 
-				Expect(err).To(HaveOccurred())
-				Expect(err).To(MatchError(fakes.FakeError))
-			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(header.BlockNumber).To(Equal(int64(100)))
+			Expect(header.Hash).To(Equal(gethHeader.Hash().Hex()))
+		})
 
-			It("fetches headers with multiple blocks", func() {
-				_, err := blockChain.GetHeadersByNumbers([]int64{100, 99})
+		It("trusts the reported hash when configured with the POA decoder", func() {
+			reportedHash := test_data.FakeHash()
+			rawHeader := json.RawMessage(`{"number":"0x64","hash":"` + reportedHash.Hex() + `","timestamp":"0x7b"}`)
+			mockRpcClient.RawHeaderToReturn = rawHeader
+			blockChain = eth.NewBlockChain(mockClient, mockRpcClient, node, mockTransactionConverter, converters.POAHeaderDecoder{})
 
-				Expect(err).NotTo(HaveOccurred())
-				mockRpcClient.AssertBatchCalledWith("eth_getBlockByNumber", 2)
-			})
+			header, err := blockChain.GetHeaderByNumber(100)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(header.Hash).To(Equal(reportedHash.Hex()))
+			Expect(header.Timestamp).To(Equal("123"))
 		})
 
-		Describe("POA/Kovan", func() {
-			It("fetches header from rpcClient", func() {
-				node.NetworkID = core.KOVAN_NETWORK_ID
-				blockNumber := hexutil.Big(*big.NewInt(100))
-				mockRpcClient.SetReturnPOAHeader(core.POAHeader{Number: &blockNumber})
-				blockChain = eth.NewBlockChain(mockClient, mockRpcClient, node, fakes.NewMockTransactionConverter())
+		It("returns err if rpcClient returns err", func() {
+			mockRpcClient.SetCallContextErr(fakes.FakeError)
 
-				_, err := blockChain.GetHeaderByNumber(100)
+			_, err := blockChain.GetHeaderByNumber(100)
 
-				Expect(err).NotTo(HaveOccurred())
-				mockRpcClient.AssertCallContextCalledWith(context.Background(), &core.POAHeader{}, "eth_getBlockByNumber")
-			})
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(MatchError(fakes.FakeError))
+		})
 
-			It("returns err if rpcClient returns err", func() {
-				node.NetworkID = core.KOVAN_NETWORK_ID
-				mockRpcClient.SetCallContextErr(fakes.FakeError)
-				blockChain = eth.NewBlockChain(mockClient, mockRpcClient, node, fakes.NewMockTransactionConverter())
+		It("returns error if returned header is empty", func() {
+			mockRpcClient.RawHeaderToReturn = json.RawMessage("null")
 
-				_, err := blockChain.GetHeaderByNumber(100)
+			_, err := blockChain.GetHeaderByNumber(100)
 
-				Expect(err).To(HaveOccurred())
-				Expect(err).To(MatchError(fakes.FakeError))
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(MatchError(eth.ErrEmptyHeader))
+		})
+
+		Describe("with multiple block numbers", func() {
+			It("fetches headers in a batch", func() {
+				_, err := blockChain.GetHeadersByNumbers([]int64{100, 99})
+
+				Expect(err).NotTo(HaveOccurred())
+				mockRpcClient.AssertBatchCalledWith("eth_getBlockByNumber", 2)
 			})
 
-			It("returns error if returned header is empty", func() {
-				node.NetworkID = core.KOVAN_NETWORK_ID
-				blockChain = eth.NewBlockChain(mockClient, mockRpcClient, node, fakes.NewMockTransactionConverter())
+			It("decodes each returned header and skips empty ones", func() {
+				rawHeader, marshalErr := json.Marshal(&gethHeader)
+				Expect(marshalErr).NotTo(HaveOccurred())
+				mockRpcClient.RawHeadersToReturn = []json.RawMessage{rawHeader, json.RawMessage("null")}
 
-				_, err := blockChain.GetHeaderByNumber(100)
+				headers, err := blockChain.GetHeadersByNumbers([]int64{100, 101})
 
-				Expect(err).To(HaveOccurred())
-				Expect(err).To(MatchError(eth.ErrEmptyHeader))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(len(headers)).To(Equal(1))
+				Expect(headers[0].BlockNumber).To(Equal(int64(100)))
 			})
 
-			It("returns multiple headers with multiple blocknumbers", func() {
-				node.NetworkID = core.KOVAN_NETWORK_ID
-				blockNumber := hexutil.Big(*big.NewInt(100))
-				mockRpcClient.SetReturnPOAHeaders([]core.POAHeader{{Number: &blockNumber}})
+			It("returns an error if a header can't be decoded", func() {
+				mockRpcClient.RawHeadersToReturn = []json.RawMessage{json.RawMessage(`{"number":[]}`)}
 
-				_, err := blockChain.GetHeadersByNumbers([]int64{100, 99})
+				_, err := blockChain.GetHeadersByNumbers([]int64{100})
 
-				Expect(err).NotTo(HaveOccurred())
-				mockRpcClient.AssertBatchCalledWith("eth_getBlockByNumber", 2)
+				Expect(err).To(HaveOccurred())
 			})
 		})
 	})
@@ -181,6 +194,141 @@ var _ = Describe("Geth blockchain", func() {
 		})
 	})
 
+	Describe("getting transaction receipts", func() {
+		var (
+			blockHash        = common.HexToHash("0xb1")
+			transactionHashA = common.HexToHash("0xa1")
+			transactionHashB = common.HexToHash("0xa2")
+		)
+
+		BeforeEach(func() {
+			mockRpcClient.ReceiptsToReturn = []*types.Receipt{
+				{TxHash: transactionHashA, Status: types.ReceiptStatusSuccessful, GasUsed: 21000, Logs: []*types.Log{}},
+				{TxHash: transactionHashB, Status: types.ReceiptStatusFailed, GasUsed: 30000, Logs: []*types.Log{}},
+			}
+		})
+
+		It("fetches receipts for the block with eth_getBlockReceipts", func() {
+			receipts, err := blockChain.GetTransactionReceipts(blockHash, []common.Hash{transactionHashB})
+
+			Expect(err).NotTo(HaveOccurred())
+			mockRpcClient.AssertCallContextCalledWith(context.Background(), &[]*types.Receipt{}, "eth_getBlockReceipts")
+			Expect(len(receipts)).To(Equal(1))
+			Expect(receipts[0].TxHash).To(Equal(transactionHashB.Hex()))
+			Expect(receipts[0].Status).To(Equal(0))
+			Expect(receipts[0].GasUsed).To(Equal(uint64(30000)))
+			Expect(receipts[0].Rlp).NotTo(BeEmpty())
+		})
+
+		It("falls back to a batch of eth_getTransactionReceipt calls if eth_getBlockReceipts is unsupported", func() {
+			mockRpcClient.BlockReceiptsErr = methodNotFoundError{}
+
+			receipts, err := blockChain.GetTransactionReceipts(blockHash, []common.Hash{transactionHashA, transactionHashB})
+
+			Expect(err).NotTo(HaveOccurred())
+			mockRpcClient.AssertBatchCalledWith("eth_getTransactionReceipt", 2)
+			Expect(len(receipts)).To(Equal(2))
+			Expect(receipts[0].TxHash).To(Equal(transactionHashA.Hex()))
+			Expect(receipts[1].TxHash).To(Equal(transactionHashB.Hex()))
+		})
+
+		It("keeps fetching receipts per transaction once eth_getBlockReceipts is found unsupported", func() {
+			mockRpcClient.BlockReceiptsErr = methodNotFoundError{}
+			_, err := blockChain.GetTransactionReceipts(blockHash, []common.Hash{transactionHashA})
+			Expect(err).NotTo(HaveOccurred())
+			mockRpcClient.BlockReceiptsErr = nil
+
+			_, err = blockChain.GetTransactionReceipts(blockHash, []common.Hash{transactionHashA, transactionHashB})
+
+			Expect(err).NotTo(HaveOccurred())
+			mockRpcClient.AssertBatchCalledWith("eth_getTransactionReceipt", 2)
+		})
+
+		It("returns other errors from eth_getBlockReceipts", func() {
+			mockRpcClient.BlockReceiptsErr = fakes.FakeError
+
+			_, err := blockChain.GetTransactionReceipts(blockHash, []common.Hash{transactionHashA})
+
+			Expect(err).To(MatchError(fakes.FakeError))
+		})
+
+		It("returns an error if a receipt is missing", func() {
+			_, err := blockChain.GetTransactionReceipts(blockHash, []common.Hash{common.HexToHash("0xa3")})
+
+			Expect(err).To(HaveOccurred())
+			Expect(errors.Is(err, eth.ErrMissingReceipt)).To(BeTrue())
+		})
+	})
+
+	Describe("subscribing to new heads", func() {
+		var (
+			headers         chan core.Header
+			rpcSubscription *fakes.MockSubscription
+		)
+
+		BeforeEach(func() {
+			headers = make(chan core.Header)
+			rpcSubscription = &fakes.MockSubscription{Errs: make(chan error)}
+			mockRpcClient.SubscriptionToReturn = rpcSubscription
+		})
+
+		It("subscribes to newHeads and streams converted headers", func() {
+			gethHeader := types.Header{Number: big.NewInt(100), Difficulty: big.NewInt(1), Time: 123}
+			rawHeader, marshalErr := json.Marshal(&gethHeader)
+			Expect(marshalErr).NotTo(HaveOccurred())
+
+			subscription, err := blockChain.SubscribeNewHeads(headers)
+
+			Expect(err).NotTo(HaveOccurred())
+			defer subscription.Unsubscribe()
+			mockRpcClient.AssertSubscribeCalledWith("eth", nil, []interface{}{"newHeads"})
+			mockRpcClient.PassedRawPayloadChan <- rawHeader
+			var header core.Header
+			Eventually(headers).Should(Receive(&header))
+			Expect(header.BlockNumber).To(Equal(int64(100)))
+			Expect(header.Hash).To(Equal(gethHeader.Hash().Hex()))
+			Expect(header.Timestamp).To(Equal("123"))
+		})
+
+		It("reports the subscription dropping", func() {
+			subscription, err := blockChain.SubscribeNewHeads(headers)
+			Expect(err).NotTo(HaveOccurred())
+			defer subscription.Unsubscribe()
+
+			rpcSubscription.Errs <- fakes.FakeError
+
+			Eventually(subscription.Err()).Should(Receive(MatchError(fakes.FakeError)))
+		})
+
+		It("reports an error and unsubscribes if a streamed header can't be converted", func() {
+			subscription, err := blockChain.SubscribeNewHeads(headers)
+			Expect(err).NotTo(HaveOccurred())
+			defer subscription.Unsubscribe()
+
+			mockRpcClient.PassedRawPayloadChan <- json.RawMessage(`"not a header"`)
+
+			Eventually(subscription.Err()).Should(Receive(HaveOccurred()))
+			Expect(rpcSubscription.UnsubscribeCalled).To(BeTrue())
+		})
+
+		It("unsubscribes from the node", func() {
+			subscription, err := blockChain.SubscribeNewHeads(headers)
+			Expect(err).NotTo(HaveOccurred())
+
+			subscription.Unsubscribe()
+
+			Expect(rpcSubscription.UnsubscribeCalled).To(BeTrue())
+		})
+
+		It("returns error if subscribing fails", func() {
+			mockRpcClient.SubscribeErr = fakes.FakeError
+
+			_, err := blockChain.SubscribeNewHeads(headers)
+
+			Expect(err).To(MatchError(fakes.FakeError))
+		})
+	})
+
 	Describe("getting the most recent block number", func() {
 		It("fetches latest header from ethClient", func() {
 			blockNumber := int64(100)
@@ -194,6 +342,64 @@ var _ = Describe("Geth blockchain", func() {
 		})
 	})
 
+	Describe("getting the finalized header", func() {
+		It("fetches the block by the finalized tag", func() {
+			gethHeader := types.Header{Number: big.NewInt(100), Difficulty: big.NewInt(1), Time: 123}
+			rawHeader, marshalErr := json.Marshal(&gethHeader)
+			Expect(marshalErr).NotTo(HaveOccurred())
+			mockRpcClient.RawHeaderToReturn = rawHeader
+
+			header, err := blockChain.GetFinalizedHeader()
+
+			Expect(err).NotTo(HaveOccurred())
+			mockRpcClient.AssertCallContextCalledWithArgs("eth_getBlockByNumber", "finalized", false)
+			Expect(header.BlockNumber).To(Equal(int64(100)))
+			Expect(header.Hash).To(Equal(gethHeader.Hash().Hex()))
+		})
+
+		It("returns an error if the node rejects the finalized tag", func() {
+			mockRpcClient.SetCallContextErr(methodNotFoundError{})
+
+			_, err := blockChain.GetFinalizedHeader()
+
+			Expect(err).To(MatchError(eth.ErrFinalizedUnsupported))
+		})
+
+		It("returns an error if the node rejects the finalized tag as an invalid param", func() {
+			mockRpcClient.SetCallContextErr(rpcError{code: -32602, message: "invalid argument 0: hex string without 0x prefix"})
+
+			_, err := blockChain.GetFinalizedHeader()
+
+			Expect(err).To(MatchError(eth.ErrFinalizedUnsupported))
+		})
+
+		It("returns other errors from the node as is, since they may be transient", func() {
+			nodeErr := rpcError{code: -32000, message: "finalized block not found"}
+			mockRpcClient.SetCallContextErr(nodeErr)
+
+			_, err := blockChain.GetFinalizedHeader()
+
+			Expect(err).To(MatchError(nodeErr))
+			Expect(errors.Is(err, eth.ErrFinalizedUnsupported)).To(BeFalse())
+		})
+
+		It("returns other errors as is", func() {
+			mockRpcClient.SetCallContextErr(fakes.FakeError)
+
+			_, err := blockChain.GetFinalizedHeader()
+
+			Expect(err).To(MatchError(fakes.FakeError))
+		})
+
+		It("returns an error if the node has no finalized block", func() {
+			mockRpcClient.RawHeaderToReturn = json.RawMessage("null")
+
+			_, err := blockChain.GetFinalizedHeader()
+
+			Expect(err).To(MatchError(eth.ErrEmptyHeader))
+		})
+	})
+
 	Describe("getting batched storage at a given block", func() {
 		var (
 			account     = fakes.FakeAddress
@@ -219,3 +425,18 @@ var _ = Describe("Geth blockchain", func() {
 		})
 	})
 })
+
+type methodNotFoundError struct{}
+
+func (methodNotFoundError) Error() string {
+	return "the method eth_getBlockReceipts does not exist/is not available"
+}
+func (methodNotFoundError) ErrorCode() int { return -32601 }
+
+type rpcError struct {
+	code    int
+	message string
+}
+
+func (err rpcError) Error() string  { return err.message }
+func (err rpcError) ErrorCode() int { return err.code }
@@ -0,0 +1,151 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package converters
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/makerdao/vulcanizedb/pkg/core"
+)
+
+const (
+	AutoHeaderDecoderName = "auto"
+	POAHeaderDecoderName  = "poa"
+	POWHeaderDecoderName  = "pow"
+)
+
+var (
+	ErrEmptyHeader          = errors.New("empty header returned over RPC")
+	ErrUnknownHeaderDecoder = errors.New("unknown header decoder")
+)
+
+var headerDecoders = map[string]core.HeaderDecoder{
+	AutoHeaderDecoderName: AutoHeaderDecoder{},
+	POAHeaderDecoderName:  POAHeaderDecoder{},
+	POWHeaderDecoderName:  POWHeaderDecoder{},
+}
+
+// RegisterHeaderDecoder makes a decoder available by name, e.g. for a chain whose headers neither the pow nor the poa
+// decoder can read. It isn't safe to call concurrently, so should be called from an init function.
+func RegisterHeaderDecoder(name string, decoder core.HeaderDecoder) {
+	headerDecoders[name] = decoder
+}
+
+// GetHeaderDecoder returns the decoder registered with the name
+func GetHeaderDecoder(name string) (core.HeaderDecoder, error) {
+	decoder, ok := headerDecoders[name]
+	if !ok {
+		var names []string
+		for registeredName := range headerDecoders {
+			names = append(names, registeredName)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("%w %q, expected one of %v", ErrUnknownHeaderDecoder, name, names)
+	}
+	return decoder, nil
+}
+
+// POWHeaderDecoder decodes headers with go-ethereum's header type, which requires the fields of a proof of work
+// header, and recomputes the header's hash locally
+type POWHeaderDecoder struct{}
+
+func (decoder POWHeaderDecoder) Decode(rawHeader json.RawMessage) (core.Header, error) {
+	if isEmptyHeader(rawHeader) {
+		return core.Header{}, ErrEmptyHeader
+	}
+	var gethHeader types.Header
+	err := json.Unmarshal(rawHeader, &gethHeader)
+	if err != nil {
+		return core.Header{}, fmt.Errorf("error decoding header: %w", err)
+	}
+	return HeaderConverter{}.Convert(&gethHeader, gethHeader.Hash().String()), nil
+}
+
+// blockBodyFields are returned by eth_getBlockByNumber alongside a header's fields, but aren't part of the header
+var blockBodyFields = []string{"size", "totalDifficulty", "transactions", "uncles", "withdrawals"}
+
+// POAHeaderDecoder decodes the fields headers share across consensus engines (e.g. Clique, Aura and Bor), and trusts
+// the hash the node returns, so it works for chains whose header hash can't be recomputed locally. The header's raw
+// JSON is kept as the node returned it, including any fields specific to the chain, less the block body fields.
+type POAHeaderDecoder struct{}
+
+func (decoder POAHeaderDecoder) Decode(rawHeader json.RawMessage) (core.Header, error) {
+	if isEmptyHeader(rawHeader) {
+		return core.Header{}, ErrEmptyHeader
+	}
+	var POAHeader core.POAHeader
+	err := json.Unmarshal(rawHeader, &POAHeader)
+	if err != nil {
+		return core.Header{}, fmt.Errorf("error decoding header: %w", err)
+	}
+	if POAHeader.Number == nil {
+		return core.Header{}, ErrEmptyHeader
+	}
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(rawHeader, &fields)
+	if err != nil {
+		return core.Header{}, fmt.Errorf("error decoding header: %w", err)
+	}
+	for _, field := range blockBodyFields {
+		delete(fields, field)
+	}
+	headerJSON, err := json.Marshal(fields)
+	if err != nil {
+		return core.Header{}, fmt.Errorf("error encoding header: %w", err)
+	}
+	return core.Header{
+		Hash:        POAHeader.Hash.String(),
+		BlockNumber: POAHeader.Number.ToInt().Int64(),
+		Raw:         headerJSON,
+		Timestamp:   strconv.FormatUint(uint64(POAHeader.Time), 10),
+	}, nil
+}
+
+// AutoHeaderDecoder picks a decoder from each header's fields. Headers with proof of work fields are decoded with
+// POWHeaderDecoder if the hash it recomputes matches the node's, and every other header with POAHeaderDecoder.
+type AutoHeaderDecoder struct{}
+
+func (decoder AutoHeaderDecoder) Decode(rawHeader json.RawMessage) (core.Header, error) {
+	var fields struct {
+		Hash    *common.Hash      `json:"hash"`
+		MixHash *common.Hash      `json:"mixHash"`
+		Nonce   *types.BlockNonce `json:"nonce"`
+	}
+	if !isEmptyHeader(rawHeader) {
+		err := json.Unmarshal(rawHeader, &fields)
+		if err != nil {
+			return core.Header{}, fmt.Errorf("error decoding header: %w", err)
+		}
+	}
+	if fields.MixHash != nil && fields.Nonce != nil {
+		header, err := POWHeaderDecoder{}.Decode(rawHeader)
+		if err == nil && (fields.Hash == nil || common.HexToHash(header.Hash) == *fields.Hash) {
+			return header, nil
+		}
+	}
+	return POAHeaderDecoder{}.Decode(rawHeader)
+}
+
+func isEmptyHeader(rawHeader json.RawMessage) bool {
+	return len(rawHeader) == 0 || string(rawHeader) == "null"
+}
@@ -0,0 +1,152 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package converters_test
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/makerdao/vulcanizedb/pkg/core"
+	"github.com/makerdao/vulcanizedb/pkg/eth/converters"
+	"github.com/makerdao/vulcanizedb/pkg/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Header decoders", func() {
+	var (
+		gethHeader   types.Header
+		rawPOWHeader json.RawMessage
+		// an Aura style header, without the mixHash and nonce of a proof of work header
+		rawPOAHeader = json.RawMessage(`{"number":"0x64","hash":"` + fakes.FakeHash.Hex() + `","parentHash":"` +
+			fakes.AnotherFakeHash.Hex() + `","timestamp":"0x7b","step":"0x2","transactions":["0x3"]}`)
+	)
+
+	BeforeEach(func() {
+		gethHeader = types.Header{Number: big.NewInt(100), Difficulty: big.NewInt(1), Time: 123}
+		var err error
+		rawPOWHeader, err = json.Marshal(&gethHeader)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Describe("POWHeaderDecoder", func() {
+		It("decodes the header and recomputes its hash", func() {
+			header, err := converters.POWHeaderDecoder{}.Decode(rawPOWHeader)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(header).To(Equal(converters.HeaderConverter{}.Convert(&gethHeader, gethHeader.Hash().String())))
+		})
+
+		It("returns an error if the header is empty", func() {
+			_, err := converters.POWHeaderDecoder{}.Decode(json.RawMessage("null"))
+
+			Expect(err).To(MatchError(converters.ErrEmptyHeader))
+		})
+	})
+
+	Describe("POAHeaderDecoder", func() {
+		It("trusts the hash the node reports", func() {
+			header, err := converters.POAHeaderDecoder{}.Decode(rawPOAHeader)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(header.BlockNumber).To(Equal(int64(100)))
+			Expect(header.Hash).To(Equal(fakes.FakeHash.Hex()))
+			Expect(header.Timestamp).To(Equal("123"))
+		})
+
+		It("keeps the chain specific fields of the raw header, but not the block body", func() {
+			header, err := converters.POAHeaderDecoder{}.Decode(rawPOAHeader)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(header.Raw).To(MatchJSON(`{"number":"0x64","hash":"` + fakes.FakeHash.Hex() + `","parentHash":"` +
+				fakes.AnotherFakeHash.Hex() + `","timestamp":"0x7b","step":"0x2"}`))
+		})
+
+		It("returns an error if the header is empty", func() {
+			_, err := converters.POAHeaderDecoder{}.Decode(json.RawMessage("null"))
+
+			Expect(err).To(MatchError(converters.ErrEmptyHeader))
+		})
+	})
+
+	Describe("AutoHeaderDecoder", func() {
+		It("decodes a proof of work header with a matching hash as proof of work", func() {
+			header, err := converters.AutoHeaderDecoder{}.Decode(rawPOWHeader)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(header.Hash).To(Equal(gethHeader.Hash().Hex()))
+			Expect(header.Raw).To(MatchJSON(rawPOWHeader))
+		})
+
+		It("falls back to the reported hash if the recomputed hash doesn't match", func() {
+			var fields map[string]interface{}
+			Expect(json.Unmarshal(rawPOWHeader, &fields)).To(Succeed())
+			fields["hash"] = fakes.FakeHash.Hex()
+			rawHeader, err := json.Marshal(fields)
+			Expect(err).NotTo(HaveOccurred())
+
+			header, err := converters.AutoHeaderDecoder{}.Decode(rawHeader)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(header.Hash).To(Equal(fakes.FakeHash.Hex()))
+		})
+
+		It("decodes a header without proof of work fields as proof of authority", func() {
+			header, err := converters.AutoHeaderDecoder{}.Decode(rawPOAHeader)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(header.Hash).To(Equal(fakes.FakeHash.Hex()))
+		})
+
+		It("returns an error if the header is empty", func() {
+			_, err := converters.AutoHeaderDecoder{}.Decode(json.RawMessage("null"))
+
+			Expect(err).To(MatchError(converters.ErrEmptyHeader))
+		})
+	})
+
+	Describe("registry", func() {
+		It("returns the built in decoders by name", func() {
+			decoder, err := converters.GetHeaderDecoder(converters.POAHeaderDecoderName)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decoder).To(Equal(converters.POAHeaderDecoder{}))
+		})
+
+		It("returns registered decoders", func() {
+			converters.RegisterHeaderDecoder("custom", customHeaderDecoder{})
+
+			decoder, err := converters.GetHeaderDecoder("custom")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decoder).To(Equal(customHeaderDecoder{}))
+		})
+
+		It("returns an error for an unknown name", func() {
+			_, err := converters.GetHeaderDecoder("kovan")
+
+			Expect(err).To(MatchError(ContainSubstring(converters.ErrUnknownHeaderDecoder.Error())))
+		})
+	})
+})
+
+type customHeaderDecoder struct{}
+
+func (customHeaderDecoder) Decode(json.RawMessage) (core.Header, error) {
+	return core.Header{}, nil
+}
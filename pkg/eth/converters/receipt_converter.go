@@ -0,0 +1,66 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package converters
+
+import (
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/makerdao/vulcanizedb/pkg/core"
+)
+
+type ReceiptConverter struct{}
+
+// Convert converts a receipt returned over RPC to a core.Receipt, with the consensus RLP encoding of the receipt
+func (converter ReceiptConverter) Convert(gethReceipt *types.Receipt) (core.Receipt, error) {
+	receiptRLP, rlpErr := rlp.EncodeToBytes(gethReceipt)
+	if rlpErr != nil {
+		return core.Receipt{}, rlpErr
+	}
+	var logs []core.ReceiptLog
+	for _, log := range gethReceipt.Logs {
+		var topics core.Topics
+		for i, topic := range log.Topics {
+			if i < len(topics) {
+				topics[i] = topic.Hex()
+			}
+		}
+		logs = append(logs, core.ReceiptLog{
+			BlockNumber: int64(log.BlockNumber),
+			TxHash:      log.TxHash.Hex(),
+			Address:     log.Address.Hex(),
+			Topics:      topics,
+			Index:       int64(log.Index),
+			Data:        hexutil.Encode(log.Data),
+		})
+	}
+	var stateRoot string
+	if len(gethReceipt.PostState) > 0 {
+		stateRoot = hexutil.Encode(gethReceipt.PostState)
+	}
+	return core.Receipt{
+		Bloom:             hexutil.Encode(gethReceipt.Bloom.Bytes()),
+		ContractAddress:   gethReceipt.ContractAddress.Hex(),
+		CumulativeGasUsed: gethReceipt.CumulativeGasUsed,
+		GasUsed:           gethReceipt.GasUsed,
+		Logs:              logs,
+		StateRoot:         stateRoot,
+		Status:            int(gethReceipt.Status),
+		TxHash:            gethReceipt.TxHash.Hex(),
+		Rlp:               receiptRLP,
+	}, nil
+}
@@ -0,0 +1,88 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package converters_test
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/makerdao/vulcanizedb/pkg/eth/converters"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Receipt converter", func() {
+	var (
+		converter   converters.ReceiptConverter
+		gethReceipt *types.Receipt
+	)
+
+	BeforeEach(func() {
+		gethReceipt = &types.Receipt{
+			Status:            types.ReceiptStatusSuccessful,
+			CumulativeGasUsed: 43000,
+			GasUsed:           21000,
+			TxHash:            common.HexToHash("0xa1"),
+			ContractAddress:   common.HexToAddress("0xc1"),
+			Logs: []*types.Log{{
+				Address:     common.HexToAddress("0xd1"),
+				Topics:      []common.Hash{common.HexToHash("0xe1"), common.HexToHash("0xe2")},
+				Data:        []byte{1, 2},
+				BlockNumber: 10,
+				TxHash:      common.HexToHash("0xa1"),
+				Index:       3,
+			}},
+		}
+	})
+
+	It("copies status, gas used, contract address and transaction hash", func() {
+		receipt, err := converter.Convert(gethReceipt)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(receipt.Status).To(Equal(1))
+		Expect(receipt.CumulativeGasUsed).To(Equal(uint64(43000)))
+		Expect(receipt.GasUsed).To(Equal(uint64(21000)))
+		Expect(receipt.ContractAddress).To(Equal(common.HexToAddress("0xc1").Hex()))
+		Expect(receipt.TxHash).To(Equal(common.HexToHash("0xa1").Hex()))
+		Expect(receipt.StateRoot).To(BeEmpty())
+	})
+
+	It("converts the receipt's logs", func() {
+		receipt, err := converter.Convert(gethReceipt)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(len(receipt.Logs)).To(Equal(1))
+		Expect(receipt.Logs[0].Address).To(Equal(common.HexToAddress("0xd1").Hex()))
+		Expect(receipt.Logs[0].Topics[0]).To(Equal(common.HexToHash("0xe1").Hex()))
+		Expect(receipt.Logs[0].Topics[1]).To(Equal(common.HexToHash("0xe2").Hex()))
+		Expect(receipt.Logs[0].Topics[2]).To(BeEmpty())
+		Expect(receipt.Logs[0].Data).To(Equal("0x0102"))
+		Expect(receipt.Logs[0].BlockNumber).To(Equal(int64(10)))
+		Expect(receipt.Logs[0].Index).To(Equal(int64(3)))
+	})
+
+	It("includes the consensus RLP encoding of the receipt", func() {
+		receipt, err := converter.Convert(gethReceipt)
+
+		Expect(err).NotTo(HaveOccurred())
+		var decoded types.Receipt
+		Expect(rlp.DecodeBytes(receipt.Rlp, &decoded)).To(Succeed())
+		Expect(decoded.Status).To(Equal(gethReceipt.Status))
+		Expect(decoded.CumulativeGasUsed).To(Equal(gethReceipt.CumulativeGasUsed))
+		Expect(len(decoded.Logs)).To(Equal(1))
+	})
+})
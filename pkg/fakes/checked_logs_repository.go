@@ -16,14 +16,30 @@
 
 package fakes
 
+import (
+	"sync"
+
+	"github.com/makerdao/vulcanizedb/pkg/core"
+)
+
 type MockCheckedLogsRepository struct {
-	AlreadyWatchingLogAddresses []string
-	AlreadyWatchingLogError     error
-	AlreadyWatchingLogReturn    bool
-	AlreadyWatchingLogTopicZero string
-	MarkLogWatchedAddresses     []string
-	MarkLogWatchedError         error
-	MarkLogWatchedTopicZero     string
+	mu                               sync.Mutex
+	AlreadyWatchingLogAddresses      []string
+	AlreadyWatchingLogError          error
+	AlreadyWatchingLogReturn         bool
+	AlreadyWatchingLogTopicZero      string
+	CreatedLogBackfills              []core.LogBackfill
+	CreateLogBackfillError           error
+	MarkLogWatchedAddresses          []string
+	MarkLogWatchedError              error
+	MarkLogWatchedTopicZero          string
+	PendingLogBackfillsError         error
+	PendingLogBackfillsReturn        []core.LogBackfill
+	UnwatchedAddressesError          error
+	UnwatchedAddressesReturn         []string
+	UpdatedLogBackfills              []core.LogBackfill
+	UpdatedLogBackfillCheckedThrough []int64
+	UpdateLogBackfillError           error
 }
 
 func (repository *MockCheckedLogsRepository) AlreadyWatchingLog(addresses []string, topic0 string) (bool, error) {
@@ -37,3 +53,30 @@ func (repository *MockCheckedLogsRepository) MarkLogWatched(addresses []string,
 	repository.MarkLogWatchedTopicZero = topic0
 	return repository.MarkLogWatchedError
 }
+
+func (repository *MockCheckedLogsRepository) CreateLogBackfill(addresses []string, topic0 string, fromBlock, toBlock int64) error {
+	repository.CreatedLogBackfills = append(repository.CreatedLogBackfills, core.LogBackfill{
+		Addresses:      addresses,
+		Topic0:         topic0,
+		FromBlock:      fromBlock,
+		ToBlock:        toBlock,
+		CheckedThrough: fromBlock - 1,
+	})
+	return repository.CreateLogBackfillError
+}
+
+func (repository *MockCheckedLogsRepository) PendingLogBackfills() ([]core.LogBackfill, error) {
+	return repository.PendingLogBackfillsReturn, repository.PendingLogBackfillsError
+}
+
+func (repository *MockCheckedLogsRepository) UnwatchedAddresses(addresses []string, topic0 string) ([]string, error) {
+	return repository.UnwatchedAddressesReturn, repository.UnwatchedAddressesError
+}
+
+func (repository *MockCheckedLogsRepository) UpdateLogBackfill(backfill core.LogBackfill, checkedThrough int64) error {
+	repository.mu.Lock()
+	defer repository.mu.Unlock()
+	repository.UpdatedLogBackfills = append(repository.UpdatedLogBackfills, backfill)
+	repository.UpdatedLogBackfillCheckedThrough = append(repository.UpdatedLogBackfillCheckedThrough, checkedThrough)
+	return repository.UpdateLogBackfillError
+}
@@ -18,6 +18,7 @@ package fakes
 
 import (
 	"math/big"
+	"sync"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
@@ -33,7 +34,25 @@ type MockBlockChain struct {
 	GetTransactionsError               error
 	GetTransactionsPassedHashes        []common.Hash
 	Transactions                       []core.TransactionModel
+	GetTransactionReceiptsCalled       bool
+	GetTransactionReceiptsError        error
+	GetTransactionReceiptsPassedBlock  common.Hash
+	GetTransactionReceiptsPassedHashes []common.Hash
+	Receipts                           []core.Receipt
 	GetHeadersByNumbersErr             error
+	FinalizedHeader                    core.Header
+	GetFinalizedHeaderCalled           bool
+	GetFinalizedHeaderError            error
+	GetHeadersByNumbersPassedNumbers   [][]int64
+	getHeadersByNumbersMutex           sync.Mutex
+	NewHeads                           []core.Header // streamed to a newHeads subscriber before NewHeadsDropError
+	NewHeadsDropError                  error
+	NewHeadsSubscription               *MockSubscription
+	SubscribeNewHeadsCalled            bool
+	SubscribeNewHeadsError             error
+	GetHeaderByNumberErr               error
+	GetHeaderByNumberHash              string
+	HeadersByNumber                    map[int64]core.Header // optional: canonical headers to return by block number
 	fetchContractDataErr               error
 	fetchContractDataPassedAbi         string
 	fetchContractDataPassedAddress     string
@@ -46,6 +65,8 @@ type MockBlockChain struct {
 	logQuery                           ethereum.FilterQuery
 	logQueryErr                        error
 	logQueryReturnLogs                 []types.Log
+	LogQueries                         []ethereum.FilterQuery
+	LogQueryFunc                       func(query ethereum.FilterQuery) ([]types.Log, error) // optional: overrides the set logs and error
 	node                               core.Node
 	storageValuesToReturn              map[common.Address]map[int64][]byte
 }
@@ -89,32 +110,73 @@ func (blockChain *MockBlockChain) FetchContractData(abiJSON string, address stri
 
 func (blockChain *MockBlockChain) GetEthLogsWithCustomQuery(query ethereum.FilterQuery) ([]types.Log, error) {
 	blockChain.logQuery = query
+	blockChain.LogQueries = append(blockChain.LogQueries, query)
+	if blockChain.LogQueryFunc != nil {
+		return blockChain.LogQueryFunc(query)
+	}
 	return blockChain.logQueryReturnLogs, blockChain.logQueryErr
 }
 
 func (blockChain *MockBlockChain) GetHeaderByNumber(blockNumber int64) (core.Header, error) {
-	return core.Header{BlockNumber: blockNumber}, nil
+	if canonical, ok := blockChain.HeadersByNumber[blockNumber]; ok {
+		return canonical, blockChain.GetHeaderByNumberErr
+	}
+	return core.Header{BlockNumber: blockNumber, Hash: blockChain.GetHeaderByNumberHash}, blockChain.GetHeaderByNumberErr
 }
 
 func (blockChain *MockBlockChain) GetHeadersByNumbers(blockNumbers []int64) ([]core.Header, error) {
+	blockChain.getHeadersByNumbersMutex.Lock()
+	blockChain.GetHeadersByNumbersPassedNumbers = append(blockChain.GetHeadersByNumbersPassedNumbers, blockNumbers)
+	blockChain.getHeadersByNumbersMutex.Unlock()
 	var headers []core.Header
 	for _, blockNumber := range blockNumbers {
 		var header = core.Header{BlockNumber: blockNumber}
+		if canonical, ok := blockChain.HeadersByNumber[blockNumber]; ok {
+			header = canonical
+		}
 		headers = append(headers, header)
 	}
 	return headers, blockChain.GetHeadersByNumbersErr
 }
 
+func (blockChain *MockBlockChain) SubscribeNewHeads(headers chan<- core.Header) (core.Subscription, error) {
+	blockChain.SubscribeNewHeadsCalled = true
+	if blockChain.SubscribeNewHeadsError != nil {
+		return nil, blockChain.SubscribeNewHeadsError
+	}
+	subscription := &MockSubscription{Errs: make(chan error, 1)}
+	blockChain.NewHeadsSubscription = subscription
+	go func() {
+		for _, header := range blockChain.NewHeads {
+			headers <- header
+		}
+		subscription.Errs <- blockChain.NewHeadsDropError
+	}()
+	return subscription, nil
+}
+
 func (blockChain *MockBlockChain) GetTransactions(transactionHashes []common.Hash) ([]core.TransactionModel, error) {
 	blockChain.GetTransactionsCalled = true
 	blockChain.GetTransactionsPassedHashes = transactionHashes
 	return blockChain.Transactions, blockChain.GetTransactionsError
 }
 
+func (blockChain *MockBlockChain) GetTransactionReceipts(blockHash common.Hash, transactionHashes []common.Hash) ([]core.Receipt, error) {
+	blockChain.GetTransactionReceiptsCalled = true
+	blockChain.GetTransactionReceiptsPassedBlock = blockHash
+	blockChain.GetTransactionReceiptsPassedHashes = transactionHashes
+	return blockChain.Receipts, blockChain.GetTransactionReceiptsError
+}
+
 func (blockChain *MockBlockChain) CallContract(contractHash string, input []byte, blockNumber *big.Int) ([]byte, error) {
 	return []byte{}, nil
 }
 
+func (blockChain *MockBlockChain) GetFinalizedHeader() (core.Header, error) {
+	blockChain.GetFinalizedHeaderCalled = true
+	return blockChain.FinalizedHeader, blockChain.GetFinalizedHeaderError
+}
+
 func (blockChain *MockBlockChain) ChainHead() (*big.Int, error) {
 	return blockChain.chainHead, blockChain.chainHeadErr
 }
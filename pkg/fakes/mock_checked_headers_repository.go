@@ -17,11 +17,17 @@
 package fakes
 
 import (
+	"sync"
+
 	"github.com/makerdao/vulcanizedb/pkg/core"
 )
 
 type MockCheckedHeadersRepository struct {
+	mu                                  sync.Mutex
+	LastCheckedBlockNumberReturn        int64
+	LastCheckedBlockNumberReturnError   error
 	MarkHeaderCheckedHeaderID           int64
+	MarkHeaderCheckedHeaderIDs          []int64
 	MarkHeaderCheckedReturnError        error
 	UncheckedHeadersCheckCount          int64
 	UncheckedHeadersEndingBlockNumber   int64
@@ -30,12 +36,19 @@ type MockCheckedHeadersRepository struct {
 	UncheckedHeadersStartingBlockNumber int64
 }
 
+func (repository *MockCheckedHeadersRepository) LastCheckedBlockNumber() (int64, error) {
+	return repository.LastCheckedBlockNumberReturn, repository.LastCheckedBlockNumberReturnError
+}
+
 func (repository *MockCheckedHeadersRepository) MarkSingleHeaderUnchecked(blockNumber int64) error {
 	panic("implement me")
 }
 
 func (repository *MockCheckedHeadersRepository) MarkHeaderChecked(headerID int64) error {
+	repository.mu.Lock()
+	defer repository.mu.Unlock()
 	repository.MarkHeaderCheckedHeaderID = headerID
+	repository.MarkHeaderCheckedHeaderIDs = append(repository.MarkHeaderCheckedHeaderIDs, headerID)
 	return repository.MarkHeaderCheckedReturnError
 }
 
@@ -0,0 +1,46 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fakes
+
+import "github.com/makerdao/vulcanizedb/pkg/fs"
+
+type MockCheckpointer struct {
+	Checkpoint      fs.Checkpoint
+	LoadErr         error
+	SavedCheckpoint fs.Checkpoint
+	SaveCalled      bool
+}
+
+func (c *MockCheckpointer) Load() (fs.Checkpoint, error) {
+	return c.Checkpoint, c.LoadErr
+}
+
+func (c *MockCheckpointer) Save(checkpoint fs.Checkpoint) error {
+	c.SaveCalled = true
+	c.SavedCheckpoint = checkpoint
+	return nil
+}
+
+type MockRejectWriter struct {
+	RejectedLines []string
+	WriteErr      error
+}
+
+func (w *MockRejectWriter) Write(line string) error {
+	w.RejectedLines = append(w.RejectedLines, line)
+	return w.WriteErr
+}
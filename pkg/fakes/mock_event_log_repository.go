@@ -30,6 +30,20 @@ type MockEventLogRepository struct {
 	PassedHeaderID int64
 	PassedLogs     []types.Log
 	ReturnLogs     []core.EventLog
+	CreatedLogs    map[int64][]types.Log
+
+	GetFinalizedCalled bool
+
+	QuarantineError              error
+	QuarantinedLogIDs            map[string][]int64
+	QuarantinedErrors            map[string][]error
+	GetQuarantinedError          error
+	GetQuarantinedLogsReturn     []core.QuarantinedLog
+	PassedQuarantineTransformers []string
+	PassedQuarantineMaxAttempts  []int
+	QuarantinedEventLogsReturn   map[string][]core.EventLog
+	RemoveQuarantinedError       error
+	RemovedQuarantinedLogIDs     map[string][]int64
 }
 
 func (repository *MockEventLogRepository) GetUntransformedEventLogs(minID, limit int) ([]core.EventLog, error) {
@@ -51,8 +65,46 @@ func (repository *MockEventLogRepository) GetUntransformedEventLogs(minID, limit
 	return returnLogs, repository.GetError
 }
 
+func (repository *MockEventLogRepository) GetUntransformedFinalizedEventLogs(minID, limit int) ([]core.EventLog, error) {
+	repository.GetFinalizedCalled = true
+	return repository.GetUntransformedEventLogs(minID, limit)
+}
+
 func (repository *MockEventLogRepository) CreateEventLogs(headerID int64, logs []types.Log) error {
 	repository.PassedHeaderID = headerID
 	repository.PassedLogs = logs
+	if repository.CreatedLogs == nil {
+		repository.CreatedLogs = make(map[int64][]types.Log)
+	}
+	repository.CreatedLogs[headerID] = append(repository.CreatedLogs[headerID], logs...)
 	return repository.CreateError
 }
+
+func (repository *MockEventLogRepository) QuarantineLog(logID int64, transformerName string, transformErr error) error {
+	if repository.QuarantinedLogIDs == nil {
+		repository.QuarantinedLogIDs = make(map[string][]int64)
+		repository.QuarantinedErrors = make(map[string][]error)
+	}
+	repository.QuarantinedLogIDs[transformerName] = append(repository.QuarantinedLogIDs[transformerName], logID)
+	repository.QuarantinedErrors[transformerName] = append(repository.QuarantinedErrors[transformerName], transformErr)
+	return repository.QuarantineError
+}
+
+func (repository *MockEventLogRepository) GetQuarantinedLogs(transformerName string) ([]core.QuarantinedLog, error) {
+	repository.PassedQuarantineTransformers = append(repository.PassedQuarantineTransformers, transformerName)
+	return repository.GetQuarantinedLogsReturn, repository.GetQuarantinedError
+}
+
+func (repository *MockEventLogRepository) GetQuarantinedEventLogs(transformerName string, maxAttempts int) ([]core.EventLog, error) {
+	repository.PassedQuarantineTransformers = append(repository.PassedQuarantineTransformers, transformerName)
+	repository.PassedQuarantineMaxAttempts = append(repository.PassedQuarantineMaxAttempts, maxAttempts)
+	return repository.QuarantinedEventLogsReturn[transformerName], repository.GetQuarantinedError
+}
+
+func (repository *MockEventLogRepository) RemoveQuarantinedLog(logID int64, transformerName string) error {
+	if repository.RemovedQuarantinedLogIDs == nil {
+		repository.RemovedQuarantinedLogIDs = make(map[string][]int64)
+	}
+	repository.RemovedQuarantinedLogIDs[transformerName] = append(repository.RemovedQuarantinedLogIDs[transformerName], logID)
+	return repository.RemoveQuarantinedError
+}
@@ -17,15 +17,27 @@
 package fakes
 
 import (
+	"sync"
+
 	"github.com/jmoiron/sqlx"
 	"github.com/makerdao/vulcanizedb/pkg/core"
+	"github.com/makerdao/vulcanizedb/pkg/datastore/postgres"
 	. "github.com/onsi/gomega"
 )
 
 type MockHeaderRepository struct {
 	AllHeaders                             []core.Header
+	CreateOrUpdateHeadersError             error
+	CreateOrUpdateHeadersPassedHeaders     []core.Header
 	CreateTransactionsCalled               bool
 	CreateTransactionsError                error
+	CreateTransactionsWithReceiptsCalled   bool
+	CreateTransactionsWithReceiptsError    error
+	PassedTransactions                     []core.TransactionModel
+	PassedReceipts                         []core.Receipt
+	DeleteHeaderCalled                     bool
+	DeleteHeaderError                      error
+	DeleteHeaderPassedBlockNumber          int64
 	GetHeaderByBlockNumberError            error
 	GetHeaderByBlockNumberReturnHash       string
 	GetHeaderByBlockNumberReturnID         int64
@@ -35,14 +47,22 @@ type MockHeaderRepository struct {
 	GetHeadersInRangeEndingBlocks          []int64
 	GetHeadersInRangeError                 error
 	GetHeadersInRangeStartingBlocks        []int64
+	HeadersByBlockNumber                   map[int64]core.Header // optional: stored headers to get by block number
+	MarkHeadersFinalizedError              error
+	MarkHeadersFinalizedPassedBlockNumbers []int64
 	MissingBlockNumbersPassedEndingBlock   int64
 	MissingBlockNumbersPassedStartingBlock int64
 	MostRecentHeaderBlockNumber            int64
 	MostRecentHeaderBlockNumberErr         error
+	ReplaceReorgedHeadersCalled            bool
+	ReplaceReorgedHeadersError             error
+	ReplaceReorgedHeadersPassedHeaders     []core.Header
+	ReplaceReorgedHeadersPassedReorg       core.Reorg
 	createOrUpdateHeaderCallCount          int
 	createOrUpdateHeaderErr                error
 	createOrUpdateHeaderPassedBlockNumbers []int64
 	createOrUpdateHeaderReturnID           int64
+	createOrUpdateHeadersMutex             sync.Mutex
 	headerExists                           bool
 	missingBlockNumbers                    []int64
 }
@@ -63,6 +83,13 @@ func (mock *MockHeaderRepository) SetMissingBlockNumbers(blockNumbers []int64) {
 	mock.missingBlockNumbers = blockNumbers
 }
 
+func (mock *MockHeaderRepository) CreateOrUpdateHeaders(headers []core.Header) error {
+	mock.createOrUpdateHeadersMutex.Lock()
+	defer mock.createOrUpdateHeadersMutex.Unlock()
+	mock.CreateOrUpdateHeadersPassedHeaders = append(mock.CreateOrUpdateHeadersPassedHeaders, headers...)
+	return mock.CreateOrUpdateHeadersError
+}
+
 func (mock *MockHeaderRepository) CreateOrUpdateHeader(header core.Header) (int64, error) {
 	mock.createOrUpdateHeaderCallCount++
 	mock.createOrUpdateHeaderPassedBlockNumbers = append(mock.createOrUpdateHeaderPassedBlockNumbers, header.BlockNumber)
@@ -74,16 +101,32 @@ func (mock *MockHeaderRepository) CreateTransactions(headerID int64, transaction
 	return mock.CreateTransactionsError
 }
 
+func (mock *MockHeaderRepository) CreateTransactionsWithReceipts(headerID int64, transactions []core.TransactionModel, receipts []core.Receipt) error {
+	mock.CreateTransactionsWithReceiptsCalled = true
+	mock.PassedTransactions = transactions
+	mock.PassedReceipts = receipts
+	return mock.CreateTransactionsWithReceiptsError
+}
+
 func (mock *MockHeaderRepository) CreateTransactionInTx(tx *sqlx.Tx, headerID int64, transaction core.TransactionModel) (int64, error) {
 	panic("implement me")
 }
 
 func (mock *MockHeaderRepository) DeleteHeader(blockNumber int64) error {
-	panic("implement me")
+	mock.DeleteHeaderCalled = true
+	mock.DeleteHeaderPassedBlockNumber = blockNumber
+	return mock.DeleteHeaderError
 }
 
 func (mock *MockHeaderRepository) GetHeaderByBlockNumber(blockNumber int64) (core.Header, error) {
 	mock.GetHeaderPassedBlockNumber = blockNumber
+	if mock.HeadersByBlockNumber != nil {
+		header, ok := mock.HeadersByBlockNumber[blockNumber]
+		if !ok {
+			return core.Header{}, postgres.ErrHeaderDoesNotExist
+		}
+		return header, mock.GetHeaderByBlockNumberError
+	}
 	return core.Header{
 		Id:          mock.GetHeaderByBlockNumberReturnID,
 		BlockNumber: blockNumber,
@@ -98,6 +141,15 @@ func (mock *MockHeaderRepository) GetHeaderByID(id int64) (core.Header, error) {
 func (mock *MockHeaderRepository) GetHeadersInRange(startingBlock, endingBlock int64) ([]core.Header, error) {
 	mock.GetHeadersInRangeStartingBlocks = append(mock.GetHeadersInRangeStartingBlocks, startingBlock)
 	mock.GetHeadersInRangeEndingBlocks = append(mock.GetHeadersInRangeEndingBlocks, endingBlock)
+	if mock.HeadersByBlockNumber != nil {
+		var headers []core.Header
+		for blockNumber := startingBlock; blockNumber <= endingBlock; blockNumber++ {
+			if header, ok := mock.HeadersByBlockNumber[blockNumber]; ok {
+				headers = append(headers, header)
+			}
+		}
+		return headers, mock.GetHeadersInRangeError
+	}
 	return mock.AllHeaders, mock.GetHeadersInRangeError
 }
 
@@ -111,7 +163,19 @@ func (mock *MockHeaderRepository) GetMostRecentHeaderBlockNumber() (int64, error
 	return mock.MostRecentHeaderBlockNumber, mock.MostRecentHeaderBlockNumberErr
 }
 
+func (mock *MockHeaderRepository) ReplaceReorgedHeaders(reorg core.Reorg, headers []core.Header) error {
+	mock.ReplaceReorgedHeadersCalled = true
+	mock.ReplaceReorgedHeadersPassedReorg = reorg
+	mock.ReplaceReorgedHeadersPassedHeaders = headers
+	return mock.ReplaceReorgedHeadersError
+}
+
 func (mock *MockHeaderRepository) AssertCreateOrUpdateHeaderCallCountAndPassedBlockNumbers(times int, blockNumbers []int64) {
 	Expect(mock.createOrUpdateHeaderCallCount).To(Equal(times))
 	Expect(mock.createOrUpdateHeaderPassedBlockNumbers).To(Equal(blockNumbers))
 }
+
+func (mock *MockHeaderRepository) MarkHeadersFinalized(blockNumber int64) error {
+	mock.MarkHeadersFinalizedPassedBlockNumbers = append(mock.MarkHeadersFinalizedPassedBlockNumbers, blockNumber)
+	return mock.MarkHeadersFinalizedError
+}
@@ -18,8 +18,8 @@ package fakes
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
-	"math/big"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -46,11 +46,16 @@ type MockRpcClient struct {
 	passedBatch          []core.BatchElem
 	passedNamespace      string
 	passedPayloadChan    chan filters.Payload
+	PassedRawPayloadChan chan json.RawMessage
+	SubscribeErr         error
+	SubscriptionToReturn core.Subscription
 	passedSubscribeArgs  []interface{}
 	lengthOfBatch        int
-	returnPOAHeader      core.POAHeader
-	returnPOAHeaders     []core.POAHeader
-	returnPOWHeaders     []*types.Header
+	BlockReceiptsErr     error
+	RawHeaderToReturn    json.RawMessage
+	RawHeadersToReturn   []json.RawMessage
+	passedArgs           []interface{}
+	ReceiptsToReturn     []*types.Receipt
 	StorageValueToReturn []byte
 }
 
@@ -61,16 +66,25 @@ func NewMockRpcClient() *MockRpcClient {
 func (c *MockRpcClient) Subscribe(namespace string, payloadChan interface{}, args ...interface{}) (core.Subscription, error) {
 	c.passedNamespace = namespace
 
-	passedPayloadChan, ok := payloadChan.(chan filters.Payload)
-	if !ok {
+	switch passedPayloadChan := payloadChan.(type) {
+	case chan filters.Payload:
+		c.passedPayloadChan = passedPayloadChan
+	case chan json.RawMessage:
+		c.PassedRawPayloadChan = passedPayloadChan
+	default:
 		return nil, errors.New("passed in channel is not of the correct type")
 	}
-	c.passedPayloadChan = passedPayloadChan
 
 	for _, arg := range args {
 		c.passedSubscribeArgs = append(c.passedSubscribeArgs, arg)
 	}
 
+	if c.SubscribeErr != nil {
+		return nil, c.SubscribeErr
+	}
+	if c.SubscriptionToReturn != nil {
+		return c.SubscriptionToReturn, nil
+	}
 	subscription := rpc.ClientSubscription{}
 	return client.Subscription{RpcSubscription: &subscription}, nil
 }
@@ -90,19 +104,19 @@ func (c *MockRpcClient) BatchCall(batch []core.BatchElem) error {
 	c.passedMethod = batch[0].Method
 	c.lengthOfBatch = len(batch)
 
-	for _, batchElem := range batch {
+	for index, batchElem := range batch {
 		c.passedContext = context.Background()
 		c.passedResult = &batchElem.Result
 		c.passedMethod = batchElem.Method
-		if p, ok := batchElem.Result.(*types.Header); ok {
-			*p = types.Header{Number: big.NewInt(100)}
-		}
-		if p, ok := batchElem.Result.(*core.POAHeader); ok {
-			*p = c.returnPOAHeader
+		if p, ok := batchElem.Result.(*json.RawMessage); ok && index < len(c.RawHeadersToReturn) {
+			*p = c.RawHeadersToReturn[index]
 		}
 		if p, ok := batchElem.Result.(*hexutil.Bytes); ok {
 			*p = c.StorageValueToReturn
 		}
+		if p, ok := batchElem.Result.(**types.Receipt); ok {
+			*p = c.receiptFor(batchElem.Args[0])
+		}
 	}
 
 	return nil
@@ -112,18 +126,22 @@ func (c *MockRpcClient) CallContext(ctx context.Context, result interface{}, met
 	c.passedContext = ctx
 	c.passedResult = result
 	c.passedMethod = method
+	c.passedArgs = args
 	switch method {
 	case "eth_getBlockByNumber":
-		if p, ok := result.(*types.Header); ok {
-			*p = types.Header{Number: big.NewInt(100)}
-		}
-		if p, ok := result.(*core.POAHeader); ok {
-
-			*p = c.returnPOAHeader
+		if p, ok := result.(*json.RawMessage); ok {
+			*p = c.RawHeaderToReturn
 		}
 		if c.callContextErr != nil {
 			return c.callContextErr
 		}
+	case "eth_getBlockReceipts":
+		if c.BlockReceiptsErr != nil {
+			return c.BlockReceiptsErr
+		}
+		if p, ok := result.(*[]*types.Receipt); ok {
+			*p = c.ReceiptsToReturn
+		}
 	case "parity_versionInfo":
 		if p, ok := result.(*core.ParityNodeInfo); ok {
 			*p = c.ParityNodeInfo
@@ -144,24 +162,26 @@ func (c *MockRpcClient) CallContext(ctx context.Context, result interface{}, met
 	return nil
 }
 
-func (c *MockRpcClient) IpcPath() string {
-	return c.ipcPath
-}
-
-func (c *MockRpcClient) SetCallContextErr(err error) {
-	c.callContextErr = err
+func (c *MockRpcClient) receiptFor(transactionHash interface{}) *types.Receipt {
+	for _, receipt := range c.ReceiptsToReturn {
+		if receipt.TxHash == transactionHash {
+			return receipt
+		}
+	}
+	return nil
 }
 
-func (c *MockRpcClient) SetReturnPOAHeader(header core.POAHeader) {
-	c.returnPOAHeader = header
+func (c *MockRpcClient) IpcPath() string {
+	return c.ipcPath
 }
 
-func (c *MockRpcClient) SetReturnPOWHeaders(headers []*types.Header) {
-	c.returnPOWHeaders = headers
+func (c *MockRpcClient) AssertCallContextCalledWithArgs(method string, args ...interface{}) {
+	Expect(c.passedMethod).To(Equal(method))
+	Expect(c.passedArgs).To(Equal(args))
 }
 
-func (c *MockRpcClient) SetReturnPOAHeaders(headers []core.POAHeader) {
-	c.returnPOAHeaders = headers
+func (c *MockRpcClient) SetCallContextErr(err error) {
+	c.callContextErr = err
 }
 
 func (c *MockRpcClient) AssertCallContextCalledWith(ctx context.Context, result interface{}, method string) {
@@ -17,27 +17,53 @@
 package fakes
 
 import (
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
 	"github.com/hpcloud/tail"
 	"gopkg.in/tomb.v1"
 )
 
 type MockTailer struct {
-	Lines   chan *tail.Line
-	TailErr error
+	Lines            chan *tail.Line
+	TailErr          error
+	TailPassedOffset int64
+	WaitErr          error
+	FilePaths        []string
+	FileContents     map[string]string
 }
 
 func NewMockTailer() *MockTailer {
 	return &MockTailer{
-		Lines: make(chan *tail.Line, 1),
+		Lines:        make(chan *tail.Line, 1),
+		FilePaths:    []string{"diffs.csv"},
+		FileContents: make(map[string]string),
 	}
 }
 
-func (mock *MockTailer) Tail() (*tail.Tail, error) {
+func (mock *MockTailer) Tail(offset int64) (*tail.Tail, error) {
+	mock.TailPassedOffset = offset
 	fakeTail := &tail.Tail{
 		Filename: "",
 		Lines:    mock.Lines,
 		Config:   tail.Config{},
 		Tomb:     tomb.Tomb{},
 	}
+	fakeTail.Kill(mock.WaitErr)
+	fakeTail.Done()
 	return fakeTail, mock.TailErr
 }
+
+func (mock *MockTailer) Files() ([]string, error) {
+	return mock.FilePaths, nil
+}
+
+func (mock *MockTailer) Open(path string) (io.ReadCloser, error) {
+	contents, ok := mock.FileContents[path]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: path, Err: os.ErrNotExist}
+	}
+	return ioutil.NopCloser(strings.NewReader(contents)), nil
+}
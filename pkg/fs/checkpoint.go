@@ -0,0 +1,94 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Checkpoint is a byte offset into a file, identified by a fingerprint of its first line so that it can still be
+// found after the file is rotated
+type Checkpoint struct {
+	Fingerprint string `json:"fingerprint" db:"fingerprint"`
+	Offset      int64  `json:"offset" db:"byte_offset"`
+}
+
+type Checkpointer interface {
+	// Load returns the saved checkpoint, or an empty checkpoint if none has been saved
+	Load() (Checkpoint, error)
+	Save(checkpoint Checkpoint) error
+}
+
+// Fingerprint identifies a file by its first line
+func Fingerprint(firstLine string) string {
+	sum := sha256.Sum256([]byte(firstLine))
+	return hex.EncodeToString(sum[:])
+}
+
+type FileCheckpointer struct {
+	Path string
+}
+
+func NewFileCheckpointer(path string) FileCheckpointer {
+	return FileCheckpointer{Path: path}
+}
+
+func (checkpointer FileCheckpointer) Load() (Checkpoint, error) {
+	var checkpoint Checkpoint
+	contents, readErr := ioutil.ReadFile(checkpointer.Path)
+	if os.IsNotExist(readErr) {
+		return checkpoint, nil
+	}
+	if readErr != nil {
+		return checkpoint, fmt.Errorf("error reading checkpoint %s: %w", checkpointer.Path, readErr)
+	}
+	unmarshalErr := json.Unmarshal(contents, &checkpoint)
+	if unmarshalErr != nil {
+		return checkpoint, fmt.Errorf("error parsing checkpoint %s: %w", checkpointer.Path, unmarshalErr)
+	}
+	return checkpoint, nil
+}
+
+// Save replaces the checkpoint file atomically, so a crash never leaves a partially written checkpoint
+func (checkpointer FileCheckpointer) Save(checkpoint Checkpoint) error {
+	contents, marshalErr := json.Marshal(checkpoint)
+	if marshalErr != nil {
+		return fmt.Errorf("error encoding checkpoint: %w", marshalErr)
+	}
+	tmp, createErr := ioutil.TempFile(filepath.Dir(checkpointer.Path), filepath.Base(checkpointer.Path)+".tmp")
+	if createErr != nil {
+		return fmt.Errorf("error creating checkpoint %s: %w", checkpointer.Path, createErr)
+	}
+	_, writeErr := tmp.Write(contents)
+	if writeErr != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("error writing checkpoint %s: %w", checkpointer.Path, writeErr)
+	}
+	closeErr := tmp.Close()
+	if closeErr != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("error closing checkpoint %s: %w", checkpointer.Path, closeErr)
+	}
+	return os.Rename(tmp.Name(), checkpointer.Path)
+}
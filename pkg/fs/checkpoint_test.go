@@ -0,0 +1,90 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fs_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/makerdao/vulcanizedb/pkg/fs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FileCheckpointer", func() {
+	var (
+		dir          string
+		checkpointer fs.FileCheckpointer
+	)
+
+	BeforeEach(func() {
+		var dirErr error
+		dir, dirErr = ioutil.TempDir("", "checkpoint")
+		Expect(dirErr).NotTo(HaveOccurred())
+		checkpointer = fs.NewFileCheckpointer(filepath.Join(dir, "diffs.checkpoint"))
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("returns an empty checkpoint if none has been saved", func() {
+		checkpoint, err := checkpointer.Load()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(checkpoint).To(Equal(fs.Checkpoint{}))
+	})
+
+	It("loads the last saved checkpoint", func() {
+		Expect(checkpointer.Save(fs.Checkpoint{Fingerprint: "first", Offset: 10})).To(Succeed())
+		Expect(checkpointer.Save(fs.Checkpoint{Fingerprint: "second", Offset: 20})).To(Succeed())
+
+		checkpoint, err := checkpointer.Load()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(checkpoint).To(Equal(fs.Checkpoint{Fingerprint: "second", Offset: 20}))
+		files, readDirErr := ioutil.ReadDir(dir)
+		Expect(readDirErr).NotTo(HaveOccurred())
+		Expect(len(files)).To(Equal(1))
+	})
+
+	It("returns an error if the checkpoint is corrupt", func() {
+		Expect(ioutil.WriteFile(checkpointer.Path, []byte("{"), 0644)).To(Succeed())
+
+		_, err := checkpointer.Load()
+
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("rejectWriter", func() {
+	It("appends each rejected line to the file", func() {
+		dir, dirErr := ioutil.TempDir("", "rejects")
+		Expect(dirErr).NotTo(HaveOccurred())
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "diffs.csv.rejected")
+		writer := fs.NewRejectWriter(path)
+
+		Expect(writer.Write("first")).To(Succeed())
+		Expect(writer.Write("second")).To(Succeed())
+
+		contents, readErr := ioutil.ReadFile(path)
+		Expect(readErr).NotTo(HaveOccurred())
+		Expect(string(contents)).To(Equal("first\nsecond\n"))
+	})
+})
@@ -0,0 +1,53 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fs
+
+import (
+	"fmt"
+	"os"
+)
+
+// RejectWriter records lines that couldn't be processed
+type RejectWriter interface {
+	Write(line string) error
+}
+
+type rejectWriter struct {
+	file string
+}
+
+// NewRejectWriter appends rejected lines to file as they were read, so that they can be fixed and replayed
+func NewRejectWriter(file string) rejectWriter {
+	return rejectWriter{file: file}
+}
+
+func (w rejectWriter) Write(line string) error {
+	file, openErr := os.OpenFile(w.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if openErr != nil {
+		return fmt.Errorf("error opening file %s: %w", w.file, openErr)
+	}
+
+	_, writeErr := file.WriteString(line + "\n")
+	if writeErr != nil {
+		closeErr := file.Close()
+		if closeErr != nil {
+			return fmt.Errorf("error closing file %s: %w", w.file, closeErr)
+		}
+		return fmt.Errorf("error writing file %s: %w", w.file, writeErr)
+	}
+	return file.Close()
+}
@@ -1,15 +1,104 @@
 package fs
 
-import "github.com/hpcloud/tail"
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hpcloud/tail"
+)
+
+var rotatedSuffix = regexp.MustCompile(`^\.(\d+)(\.gz)?$`)
 
 type Tailer interface {
-	Tail() (*tail.Tail, error)
+	// Tail follows the file from the byte offset until it is moved or deleted, at which point Lines is closed
+	Tail(offset int64) (*tail.Tail, error)
+	// Files returns rotated copies of the file (e.g. diffs.csv.2.gz, diffs.csv.1), oldest first, followed by the
+	// file itself
+	Files() ([]string, error)
+	// Open returns the contents of one of Files, decompressing gzipped copies
+	Open(path string) (io.ReadCloser, error)
+}
+
+// WaitForTail waits for a tail to stop, returning nil if it stopped because the file was moved or deleted. A file
+// moved before the tail starts watching it for changes stops the tail with a not exist error, rather than cleanly.
+func WaitForTail(t *tail.Tail) error {
+	waitErr := t.Wait()
+	if errors.Is(waitErr, os.ErrNotExist) {
+		return nil
+	}
+	return waitErr
 }
 
 type FileTailer struct {
 	Path string
 }
 
-func (tailer FileTailer) Tail() (*tail.Tail, error) {
-	return tail.TailFile(tailer.Path, tail.Config{Follow: true})
+func (tailer FileTailer) Tail(offset int64) (*tail.Tail, error) {
+	return tail.TailFile(tailer.Path, tail.Config{
+		Follow:   true,
+		Location: &tail.SeekInfo{Offset: offset, Whence: io.SeekStart},
+	})
+}
+
+func (tailer FileTailer) Files() ([]string, error) {
+	matches, globErr := filepath.Glob(tailer.Path + ".*")
+	if globErr != nil {
+		return nil, fmt.Errorf("error listing rotated copies of %s: %w", tailer.Path, globErr)
+	}
+	generations := make(map[string]int)
+	var rotated []string
+	for _, match := range matches {
+		suffix := rotatedSuffix.FindStringSubmatch(strings.TrimPrefix(match, tailer.Path))
+		if suffix == nil {
+			continue
+		}
+		generation, _ := strconv.Atoi(suffix[1])
+		generations[match] = generation
+		rotated = append(rotated, match)
+	}
+	sort.SliceStable(rotated, func(i, j int) bool {
+		return generations[rotated[i]] > generations[rotated[j]]
+	})
+	return append(rotated, tailer.Path), nil
+}
+
+func (FileTailer) Open(path string) (io.ReadCloser, error) {
+	file, openErr := os.Open(path)
+	if openErr != nil {
+		return nil, openErr
+	}
+	if !strings.HasSuffix(path, ".gz") {
+		return file, nil
+	}
+	reader, gzipErr := gzip.NewReader(file)
+	if gzipErr != nil {
+		closeErr := file.Close()
+		if closeErr != nil {
+			return nil, fmt.Errorf("error closing file %s: %w", path, closeErr)
+		}
+		return nil, fmt.Errorf("error decompressing file %s: %w", path, gzipErr)
+	}
+	return gzipFile{Reader: reader, file: file}, nil
+}
+
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (f gzipFile) Close() error {
+	readerErr := f.Reader.Close()
+	fileErr := f.file.Close()
+	if readerErr != nil {
+		return readerErr
+	}
+	return fileErr
 }
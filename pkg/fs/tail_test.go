@@ -0,0 +1,132 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package fs_test
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/hpcloud/tail"
+	"github.com/makerdao/vulcanizedb/pkg/fs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FileTailer", func() {
+	var (
+		dir    string
+		path   string
+		tailer fs.FileTailer
+	)
+
+	BeforeEach(func() {
+		var dirErr error
+		dir, dirErr = ioutil.TempDir("", "tailer")
+		Expect(dirErr).NotTo(HaveOccurred())
+		path = filepath.Join(dir, "diffs.csv")
+		tailer = fs.FileTailer{Path: path}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	writeGzip := func(path, contents string) {
+		file, createErr := os.Create(path)
+		Expect(createErr).NotTo(HaveOccurred())
+		writer := gzip.NewWriter(file)
+		_, writeErr := writer.Write([]byte(contents))
+		Expect(writeErr).NotTo(HaveOccurred())
+		Expect(writer.Close()).To(Succeed())
+		Expect(file.Close()).To(Succeed())
+	}
+
+	Describe("Tail", func() {
+		It("reads lines from the offset until the file is moved", func() {
+			Expect(ioutil.WriteFile(path, []byte("first\nsecond\n"), 0644)).To(Succeed())
+
+			t, tailErr := tailer.Tail(int64(len("first\n")))
+			Expect(tailErr).NotTo(HaveOccurred())
+
+			Expect((<-t.Lines).Text).To(Equal("second"))
+			Expect(os.Rename(path, path+".1")).To(Succeed())
+			Eventually(t.Lines).Should(BeClosed())
+			Expect(fs.WaitForTail(t)).To(Succeed())
+		})
+	})
+
+	Describe("WaitForTail", func() {
+		It("treats a not exist error as the file being moved", func() {
+			t := &tail.Tail{}
+			t.Kill(syscall.ENOENT)
+			t.Done()
+
+			Expect(fs.WaitForTail(t)).To(Succeed())
+		})
+
+		It("returns other errors stopping the tail", func() {
+			t := &tail.Tail{}
+			t.Kill(syscall.EACCES)
+			t.Done()
+
+			Expect(fs.WaitForTail(t)).To(MatchError(syscall.EACCES))
+		})
+	})
+
+	Describe("Files", func() {
+		It("lists rotated copies oldest first, followed by the file", func() {
+			for _, name := range []string{"diffs.csv.1", "diffs.csv.10.gz", "diffs.csv.2.gz", "diffs.csv.rejected"} {
+				Expect(ioutil.WriteFile(filepath.Join(dir, name), nil, 0644)).To(Succeed())
+			}
+
+			files, err := tailer.Files()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(Equal([]string{path + ".10.gz", path + ".2.gz", path + ".1", path}))
+		})
+
+		It("includes the file even if it doesn't exist yet", func() {
+			files, err := tailer.Files()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(Equal([]string{path}))
+		})
+	})
+
+	Describe("Open", func() {
+		It("decompresses gzipped copies", func() {
+			writeGzip(path+".1.gz", "first\n")
+
+			file, openErr := tailer.Open(path + ".1.gz")
+			Expect(openErr).NotTo(HaveOccurred())
+			contents, readErr := ioutil.ReadAll(file)
+
+			Expect(readErr).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("first\n"))
+			Expect(file.Close()).To(Succeed())
+		})
+
+		It("returns a not exist error for missing files", func() {
+			_, openErr := tailer.Open(path)
+
+			Expect(os.IsNotExist(openErr)).To(BeTrue())
+		})
+	})
+})
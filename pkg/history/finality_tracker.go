@@ -0,0 +1,104 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package history
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/makerdao/vulcanizedb/pkg/core"
+	"github.com/makerdao/vulcanizedb/pkg/datastore"
+	"github.com/makerdao/vulcanizedb/pkg/datastore/postgres"
+	"github.com/makerdao/vulcanizedb/pkg/eth"
+	"github.com/sirupsen/logrus"
+)
+
+var ErrFinalizedHeaderMismatch = errors.New("stored header doesn't match the node's finalized header")
+
+// FinalityTracker marks stored headers finalized, using the node's finalized block where it supports the "finalized"
+// block tag, or the block a fixed number of confirmations below the chain head otherwise
+type FinalityTracker struct {
+	blockChain        core.BlockChain
+	headerRepository  datastore.HeaderRepository
+	confirmationDepth int64
+	tagUnsupported    bool
+}
+
+func NewFinalityTracker(blockChain core.BlockChain, repository datastore.HeaderRepository, confirmationDepth int64) *FinalityTracker {
+	return &FinalityTracker{
+		blockChain:        blockChain,
+		headerRepository:  repository,
+		confirmationDepth: confirmationDepth,
+	}
+}
+
+// UpdateFinalizedHeaders marks the headers at or below the finalized block finalized, and returns its block number,
+// or -1 if no block can be treated as finalized yet
+func (tracker *FinalityTracker) UpdateFinalizedHeaders() (int64, error) {
+	blockNumber, err := tracker.finalizedBlockNumber()
+	if err != nil || blockNumber < 0 {
+		return -1, err
+	}
+	err = tracker.headerRepository.MarkHeadersFinalized(blockNumber)
+	if err != nil {
+		return -1, err
+	}
+	return blockNumber, nil
+}
+
+func (tracker *FinalityTracker) finalizedBlockNumber() (int64, error) {
+	if !tracker.tagUnsupported {
+		finalizedHeader, err := tracker.blockChain.GetFinalizedHeader()
+		if err == nil {
+			return finalizedHeader.BlockNumber, tracker.checkStoredHeader(finalizedHeader)
+		}
+		if !errors.Is(err, eth.ErrFinalizedUnsupported) {
+			return -1, fmt.Errorf("error getting finalized header: %w", err)
+		}
+		tracker.tagUnsupported = true
+		if tracker.confirmationDepth < 1 {
+			logrus.Warnf("%s and no confirmation depth is set, so headers won't be marked finalized", err.Error())
+		} else {
+			logrus.Infof("%s, treating blocks %d below the chain head as finalized", err.Error(), tracker.confirmationDepth)
+		}
+	}
+	if tracker.confirmationDepth < 1 {
+		return -1, nil
+	}
+	chainHead, err := tracker.blockChain.ChainHead()
+	if err != nil {
+		return -1, fmt.Errorf("error getting chain head: %w", err)
+	}
+	return chainHead.Int64() - tracker.confirmationDepth, nil
+}
+
+// checkStoredHeader returns an error if the header stored at the finalized block's height is a different block, so
+// headers aren't marked finalized until the reorg is handled
+func (tracker *FinalityTracker) checkStoredHeader(finalizedHeader core.Header) error {
+	storedHeader, err := tracker.headerRepository.GetHeaderByBlockNumber(finalizedHeader.BlockNumber)
+	if errors.Is(err, postgres.ErrHeaderDoesNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error getting header for block %d: %w", finalizedHeader.BlockNumber, err)
+	}
+	if !sameHash(storedHeader.Hash, finalizedHeader.Hash) {
+		return fmt.Errorf("%w at block %d: stored %s, finalized %s", ErrFinalizedHeaderMismatch,
+			finalizedHeader.BlockNumber, storedHeader.Hash, finalizedHeader.Hash)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package history_test
+
+import (
+	"math/big"
+
+	"github.com/makerdao/vulcanizedb/pkg/core"
+	"github.com/makerdao/vulcanizedb/pkg/eth"
+	"github.com/makerdao/vulcanizedb/pkg/fakes"
+	"github.com/makerdao/vulcanizedb/pkg/history"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Finality tracker", func() {
+	var (
+		blockChain       *fakes.MockBlockChain
+		headerRepository *fakes.MockHeaderRepository
+		tracker          *history.FinalityTracker
+		finalizedHash    = "0xab"
+	)
+
+	BeforeEach(func() {
+		blockChain = fakes.NewMockBlockChain()
+		blockChain.FinalizedHeader = core.Header{BlockNumber: 100, Hash: finalizedHash}
+		blockChain.SetChainHead(big.NewInt(200))
+		headerRepository = fakes.NewMockHeaderRepository()
+		headerRepository.HeadersByBlockNumber = map[int64]core.Header{100: {BlockNumber: 100, Hash: finalizedHash}}
+		tracker = history.NewFinalityTracker(blockChain, headerRepository, 10)
+	})
+
+	It("marks headers through the node's finalized block finalized", func() {
+		finalized, err := tracker.UpdateFinalizedHeaders()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(finalized).To(Equal(int64(100)))
+		Expect(headerRepository.MarkHeadersFinalizedPassedBlockNumbers).To(Equal([]int64{100}))
+	})
+
+	It("marks headers finalized if the finalized block isn't stored yet", func() {
+		headerRepository.HeadersByBlockNumber = map[int64]core.Header{}
+
+		_, err := tracker.UpdateFinalizedHeaders()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(headerRepository.MarkHeadersFinalizedPassedBlockNumbers).To(Equal([]int64{100}))
+	})
+
+	It("doesn't mark headers finalized if the stored header isn't the finalized block", func() {
+		headerRepository.HeadersByBlockNumber = map[int64]core.Header{100: {BlockNumber: 100, Hash: "0xcd"}}
+
+		finalized, err := tracker.UpdateFinalizedHeaders()
+
+		Expect(err).To(MatchError(history.ErrFinalizedHeaderMismatch))
+		Expect(finalized).To(Equal(int64(-1)))
+		Expect(headerRepository.MarkHeadersFinalizedPassedBlockNumbers).To(BeEmpty())
+	})
+
+	It("returns an error if getting the finalized header fails", func() {
+		blockChain.GetFinalizedHeaderError = fakes.FakeError
+
+		_, err := tracker.UpdateFinalizedHeaders()
+
+		Expect(err).To(MatchError(fakes.FakeError))
+		Expect(headerRepository.MarkHeadersFinalizedPassedBlockNumbers).To(BeEmpty())
+	})
+
+	It("asks the node for its finalized block again after other errors", func() {
+		blockChain.GetFinalizedHeaderError = fakes.FakeError
+		_, err := tracker.UpdateFinalizedHeaders()
+		Expect(err).To(HaveOccurred())
+		blockChain.GetFinalizedHeaderError = nil
+		blockChain.GetFinalizedHeaderCalled = false
+
+		_, err = tracker.UpdateFinalizedHeaders()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(blockChain.GetFinalizedHeaderCalled).To(BeTrue())
+	})
+
+	Describe("when the node doesn't support the finalized tag", func() {
+		BeforeEach(func() {
+			blockChain.GetFinalizedHeaderError = eth.ErrFinalizedUnsupported
+		})
+
+		It("treats the block the confirmation depth below the chain head as finalized", func() {
+			finalized, err := tracker.UpdateFinalizedHeaders()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(finalized).To(Equal(int64(190)))
+			Expect(headerRepository.MarkHeadersFinalizedPassedBlockNumbers).To(Equal([]int64{190}))
+		})
+
+		It("stops asking the node for its finalized block", func() {
+			_, err := tracker.UpdateFinalizedHeaders()
+			Expect(err).NotTo(HaveOccurred())
+			blockChain.GetFinalizedHeaderCalled = false
+
+			_, err = tracker.UpdateFinalizedHeaders()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(blockChain.GetFinalizedHeaderCalled).To(BeFalse())
+		})
+
+		It("doesn't mark headers finalized without a confirmation depth", func() {
+			tracker = history.NewFinalityTracker(blockChain, headerRepository, 0)
+
+			finalized, err := tracker.UpdateFinalizedHeaders()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(finalized).To(Equal(int64(-1)))
+			Expect(headerRepository.MarkHeadersFinalizedPassedBlockNumbers).To(BeEmpty())
+		})
+
+		It("doesn't mark headers finalized before the chain is deeper than the confirmation depth", func() {
+			blockChain.SetChainHead(big.NewInt(5))
+
+			finalized, err := tracker.UpdateFinalizedHeaders()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(finalized).To(Equal(int64(-1)))
+			Expect(headerRepository.MarkHeadersFinalizedPassedBlockNumbers).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,145 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package history
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/makerdao/vulcanizedb/pkg/core"
+	"github.com/makerdao/vulcanizedb/pkg/datastore"
+	"github.com/makerdao/vulcanizedb/pkg/eth"
+	"github.com/sirupsen/logrus"
+)
+
+var ErrSubscriptionDropped = errors.New("newHeads subscription dropped")
+
+// HeaderSubscriber inserts headers as the node streams them over a newHeads subscription, filling any gap between the
+// last stored header (or the validation window below the first streamed header, whichever is later) and each streamed
+// header so that none are missed. Stored headers orphaned by the streamed headers are replaced as the HeaderValidator
+// replaces them.
+type HeaderSubscriber struct {
+	blockChain       core.BlockChain
+	headerRepository datastore.HeaderRepository
+	validator        HeaderValidator
+	windowSize       int
+}
+
+func NewHeaderSubscriber(blockChain core.BlockChain, repository datastore.HeaderRepository, windowSize int) HeaderSubscriber {
+	return HeaderSubscriber{
+		blockChain:       blockChain,
+		headerRepository: repository,
+		validator:        NewHeaderValidator(blockChain, repository, windowSize),
+		windowSize:       windowSize,
+	}
+}
+
+// SyncNewHeads subscribes to newHeads and inserts headers from startingBlockNumber onward until the subscription
+// drops, returning an error wrapping ErrSubscriptionDropped, or inserting a header fails
+func (subscriber HeaderSubscriber) SyncNewHeads(startingBlockNumber int64) error {
+	headers := make(chan core.Header)
+	subscription, subscribeErr := subscriber.blockChain.SubscribeNewHeads(headers)
+	if subscribeErr != nil {
+		return fmt.Errorf("error subscribing to newHeads: %w", subscribeErr)
+	}
+	defer subscription.Unsubscribe()
+
+	lastBlockNumber, lastBlockErr := subscriber.lastStoredBlockNumber()
+	if lastBlockErr != nil {
+		return fmt.Errorf("error getting most recent header: %w", lastBlockErr)
+	}
+	firstHeader := true
+	for {
+		select {
+		case header := <-headers:
+			if header.BlockNumber < startingBlockNumber {
+				continue
+			}
+			fromBlock := lastBlockNumber + 1
+			if firstHeader {
+				fromBlock = subscriber.firstGapBlock(fromBlock, header.BlockNumber, startingBlockNumber)
+				firstHeader = false
+			}
+			gapHeaders, gapErr := subscriber.getGapHeaders(fromBlock, header.BlockNumber-1)
+			if gapErr != nil {
+				return fmt.Errorf("error filling headers before block %d: %w", header.BlockNumber, gapErr)
+			}
+			insertErr := subscriber.insertHeaders(append(gapHeaders, header))
+			if insertErr != nil {
+				return fmt.Errorf("error inserting streamed header for block %d: %w", header.BlockNumber, insertErr)
+			}
+			lastBlockNumber = header.BlockNumber
+		case subscriptionErr := <-subscription.Err():
+			return fmt.Errorf("%w: %v", ErrSubscriptionDropped, subscriptionErr)
+		}
+	}
+}
+
+// lastStoredBlockNumber returns the most recent header's block number, or -1 if there are no headers
+func (subscriber HeaderSubscriber) lastStoredBlockNumber() (int64, error) {
+	blockNumber, err := subscriber.headerRepository.GetMostRecentHeaderBlockNumber()
+	if errors.Is(err, sql.ErrNoRows) {
+		return -1, nil
+	}
+	return blockNumber, err
+}
+
+// firstGapBlock bounds the gap before the first streamed header by the validation window, since headers further back
+// are left to the backfill of missing headers
+func (subscriber HeaderSubscriber) firstGapBlock(fromBlock, headBlock, startingBlockNumber int64) int64 {
+	windowStart := headBlock - int64(subscriber.windowSize)
+	if fromBlock < windowStart {
+		fromBlock = windowStart
+	}
+	if fromBlock < startingBlockNumber {
+		fromBlock = startingBlockNumber
+	}
+	return fromBlock
+}
+
+func (subscriber HeaderSubscriber) getGapHeaders(fromBlock, toBlock int64) ([]core.Header, error) {
+	var headers []core.Header
+	for fromBlock <= toBlock {
+		chunkEnd := fromBlock + eth.MAX_BATCH_SIZE - 1
+		if chunkEnd > toBlock {
+			chunkEnd = toBlock
+		}
+		logrus.Debugf("filling headers for blocks %d-%d before streamed header", fromBlock, chunkEnd)
+		chunk, err := subscriber.blockChain.GetHeadersByNumbers(MakeRange(fromBlock, chunkEnd))
+		if err != nil {
+			return nil, err
+		}
+		headers = append(headers, chunk...)
+		fromBlock = chunkEnd + 1
+	}
+	return headers, nil
+}
+
+func (subscriber HeaderSubscriber) insertHeaders(headers []core.Header) error {
+	replaceErr := subscriber.validator.ReplaceOrphanedHeaders(headers)
+	if replaceErr != nil {
+		return fmt.Errorf("error replacing orphaned headers: %w", replaceErr)
+	}
+	for _, header := range headers {
+		_, createErr := subscriber.headerRepository.CreateOrUpdateHeader(header)
+		if createErr != nil {
+			return createErr
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package history_test
+
+import (
+	"database/sql"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/makerdao/vulcanizedb/pkg/core"
+	"github.com/makerdao/vulcanizedb/pkg/fakes"
+	"github.com/makerdao/vulcanizedb/pkg/history"
+)
+
+var _ = Describe("Header subscriber", func() {
+	var (
+		headerRepository *fakes.MockHeaderRepository
+		blockChain       *fakes.MockBlockChain
+		subscriber       history.HeaderSubscriber
+	)
+
+	BeforeEach(func() {
+		headerRepository = fakes.NewMockHeaderRepository()
+		blockChain = fakes.NewMockBlockChain()
+		blockChain.NewHeadsDropError = fakes.FakeError
+		subscriber = history.NewHeaderSubscriber(blockChain, headerRepository, 15)
+	})
+
+	It("inserts streamed headers until the subscription drops", func() {
+		headerRepository.MostRecentHeaderBlockNumber = 99
+		blockChain.NewHeads = []core.Header{{BlockNumber: 100}, {BlockNumber: 101}}
+
+		err := subscriber.SyncNewHeads(0)
+
+		Expect(err).To(HaveOccurred())
+		Expect(errors.Is(err, history.ErrSubscriptionDropped)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring(fakes.FakeError.Error()))
+		headerRepository.AssertCreateOrUpdateHeaderCallCountAndPassedBlockNumbers(2, []int64{100, 101})
+		Expect(blockChain.NewHeadsSubscription.UnsubscribeCalled).To(BeTrue())
+	})
+
+	It("fills the gap between the last stored header and the first streamed header", func() {
+		headerRepository.MostRecentHeaderBlockNumber = 97
+		blockChain.NewHeads = []core.Header{{BlockNumber: 100}}
+
+		err := subscriber.SyncNewHeads(0)
+
+		Expect(errors.Is(err, history.ErrSubscriptionDropped)).To(BeTrue())
+		headerRepository.AssertCreateOrUpdateHeaderCallCountAndPassedBlockNumbers(3, []int64{98, 99, 100})
+	})
+
+	It("only fills the validation window before the first streamed header", func() {
+		headerRepository.MostRecentHeaderBlockNumber = 10
+		blockChain.NewHeads = []core.Header{{BlockNumber: 100}}
+		subscriber = history.NewHeaderSubscriber(blockChain, headerRepository, 2)
+
+		err := subscriber.SyncNewHeads(0)
+
+		Expect(errors.Is(err, history.ErrSubscriptionDropped)).To(BeTrue())
+		headerRepository.AssertCreateOrUpdateHeaderCallCountAndPassedBlockNumbers(3, []int64{98, 99, 100})
+	})
+
+	It("fills from the starting block if there are no stored headers", func() {
+		headerRepository.MostRecentHeaderBlockNumber = 0
+		headerRepository.MostRecentHeaderBlockNumberErr = sql.ErrNoRows
+		blockChain.NewHeads = []core.Header{{BlockNumber: 100}}
+
+		err := subscriber.SyncNewHeads(98)
+
+		Expect(errors.Is(err, history.ErrSubscriptionDropped)).To(BeTrue())
+		headerRepository.AssertCreateOrUpdateHeaderCallCountAndPassedBlockNumbers(3, []int64{98, 99, 100})
+	})
+
+	It("fills headers skipped between streamed headers", func() {
+		headerRepository.MostRecentHeaderBlockNumber = 99
+		blockChain.NewHeads = []core.Header{{BlockNumber: 100}, {BlockNumber: 102}}
+
+		err := subscriber.SyncNewHeads(0)
+
+		Expect(errors.Is(err, history.ErrSubscriptionDropped)).To(BeTrue())
+		headerRepository.AssertCreateOrUpdateHeaderCallCountAndPassedBlockNumbers(3, []int64{100, 101, 102})
+	})
+
+	It("replaces the header at a streamed header's height after a reorg", func() {
+		headerRepository.MostRecentHeaderBlockNumber = 99
+		blockChain.NewHeads = []core.Header{{BlockNumber: 100}, {BlockNumber: 100, Hash: "0xreorged"}}
+
+		err := subscriber.SyncNewHeads(0)
+
+		Expect(errors.Is(err, history.ErrSubscriptionDropped)).To(BeTrue())
+		headerRepository.AssertCreateOrUpdateHeaderCallCountAndPassedBlockNumbers(2, []int64{100, 100})
+	})
+
+	It("returns error if subscribing fails", func() {
+		blockChain.SubscribeNewHeadsError = fakes.FakeError
+
+		err := subscriber.SyncNewHeads(0)
+
+		Expect(err).To(HaveOccurred())
+		Expect(errors.Is(err, fakes.FakeError)).To(BeTrue())
+		Expect(errors.Is(err, history.ErrSubscriptionDropped)).To(BeFalse())
+	})
+
+	It("returns error if getting the most recent header fails", func() {
+		headerRepository.MostRecentHeaderBlockNumberErr = fakes.FakeError
+
+		err := subscriber.SyncNewHeads(0)
+
+		Expect(errors.Is(err, fakes.FakeError)).To(BeTrue())
+	})
+
+	It("returns error if filling a gap fails", func() {
+		headerRepository.MostRecentHeaderBlockNumber = 97
+		blockChain.NewHeads = []core.Header{{BlockNumber: 100}}
+		blockChain.GetHeadersByNumbersErr = fakes.FakeError
+
+		err := subscriber.SyncNewHeads(0)
+
+		Expect(errors.Is(err, fakes.FakeError)).To(BeTrue())
+		Expect(errors.Is(err, history.ErrSubscriptionDropped)).To(BeFalse())
+	})
+
+	It("returns error if inserting a streamed header fails", func() {
+		headerRepository.MostRecentHeaderBlockNumber = 99
+		blockChain.NewHeads = []core.Header{{BlockNumber: 100}}
+		headerRepository.SetCreateOrUpdateHeaderReturnErr(fakes.FakeError)
+
+		err := subscriber.SyncNewHeads(0)
+
+		Expect(errors.Is(err, fakes.FakeError)).To(BeTrue())
+	})
+})
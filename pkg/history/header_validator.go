@@ -17,12 +17,19 @@
 package history
 
 import (
+	"errors"
 	"fmt"
+	"sort"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/makerdao/vulcanizedb/pkg/core"
 	"github.com/makerdao/vulcanizedb/pkg/datastore"
+	"github.com/makerdao/vulcanizedb/pkg/datastore/postgres"
+	"github.com/sirupsen/logrus"
 )
 
+var ErrCanonicalChainChanged = errors.New("node's canonical chain changed while validating headers")
+
 type HeaderValidator struct {
 	blockChain       core.BlockChain
 	headerRepository datastore.HeaderRepository
@@ -37,15 +44,136 @@ func NewHeaderValidator(blockChain core.BlockChain, repository datastore.HeaderR
 	}
 }
 
+// ValidateHeaders stores the node's canonical headers in the validation window, first replacing any stored headers
+// they orphan, however far below the window those are
 func (validator HeaderValidator) ValidateHeaders() (ValidationWindow, error) {
 	window, err := MakeValidationWindow(validator.blockChain, validator.windowSize)
 	if err != nil {
 		return ValidationWindow{}, fmt.Errorf("error creating validation window: %s", err.Error())
 	}
 	blockNumbers := MakeRange(window.LowerBound, window.UpperBound)
-	_, err = RetrieveAndUpdateHeaders(validator.blockChain, validator.headerRepository, blockNumbers)
+	headers, err := validator.blockChain.GetHeadersByNumbers(blockNumbers)
 	if err != nil {
 		return ValidationWindow{}, fmt.Errorf("error getting/updating headers: %s", err.Error())
 	}
+	err = validator.ReplaceOrphanedHeaders(headers)
+	if err != nil {
+		return ValidationWindow{}, fmt.Errorf("error replacing orphaned headers: %w", err)
+	}
+	for _, header := range headers {
+		_, err = validator.headerRepository.CreateOrUpdateHeader(header)
+		if err != nil {
+			return ValidationWindow{}, fmt.Errorf("error getting/updating headers: %s", err.Error())
+		}
+	}
 	return window, nil
 }
+
+// ReplaceOrphanedHeaders compares canonical headers with the stored headers at their heights, and walks the parent
+// hashes back from the lowest canonical header until it reaches a stored header that's its ancestor (or a height
+// without a stored header). Every stored header that isn't canonical is replaced in one DB transaction, and the reorg
+// is recorded.
+func (validator HeaderValidator) ReplaceOrphanedHeaders(canonicalHeaders []core.Header) error {
+	if len(canonicalHeaders) == 0 {
+		return nil
+	}
+	headers := make([]core.Header, len(canonicalHeaders))
+	copy(headers, canonicalHeaders)
+	sort.Slice(headers, func(i, j int) bool {
+		return headers[i].BlockNumber < headers[j].BlockNumber
+	})
+	linkErr := checkParentHashes(headers)
+	if linkErr != nil {
+		return linkErr
+	}
+
+	lowest, highest := headers[0].BlockNumber, headers[len(headers)-1].BlockNumber
+	storedHeaders, storedErr := validator.headerRepository.GetHeadersInRange(lowest, highest)
+	if storedErr != nil {
+		return fmt.Errorf("error getting stored headers for blocks %d-%d: %w", lowest, highest, storedErr)
+	}
+	storedHashes := make(map[int64]string, len(storedHeaders))
+	for _, stored := range storedHeaders {
+		storedHashes[stored.BlockNumber] = stored.Hash
+	}
+
+	orphaned, replacements, walkErr := validator.walkBackToAncestor(headers[0])
+	if walkErr != nil {
+		return walkErr
+	}
+	for _, header := range headers {
+		storedHash, ok := storedHashes[header.BlockNumber]
+		if ok && !sameHash(storedHash, header.Hash) {
+			orphaned = append(orphaned, core.Header{BlockNumber: header.BlockNumber, Hash: storedHash})
+			replacements = append(replacements, header)
+		}
+	}
+	if len(replacements) == 0 {
+		return nil
+	}
+
+	reorg := core.Reorg{
+		Depth:     int64(len(replacements)),
+		FromBlock: replacements[0].BlockNumber,
+		ToBlock:   replacements[len(replacements)-1].BlockNumber,
+	}
+	for i := range replacements {
+		reorg.OldHashes = append(reorg.OldHashes, orphaned[i].Hash)
+		reorg.NewHashes = append(reorg.NewHashes, replacements[i].Hash)
+	}
+	logrus.Warnf("reorg of depth %d detected, replacing headers for blocks %d-%d", reorg.Depth, reorg.FromBlock,
+		reorg.ToBlock)
+	return validator.headerRepository.ReplaceReorgedHeaders(reorg, replacements)
+}
+
+// walkBackToAncestor returns the stored headers below a canonical header that aren't its ancestors, and the canonical
+// headers at their heights, in block order
+func (validator HeaderValidator) walkBackToAncestor(header core.Header) ([]core.Header, []core.Header, error) {
+	var orphaned, replacements []core.Header
+	parentHash, ok := header.ParentHash()
+	for blockNumber := header.BlockNumber - 1; ok && blockNumber >= 0; blockNumber-- {
+		stored, storedErr := validator.headerRepository.GetHeaderByBlockNumber(blockNumber)
+		if errors.Is(storedErr, postgres.ErrHeaderDoesNotExist) {
+			break
+		}
+		if storedErr != nil {
+			return nil, nil, fmt.Errorf("error getting stored header for block %d: %w", blockNumber, storedErr)
+		}
+		if sameHash(stored.Hash, parentHash.Hex()) {
+			break
+		}
+
+		canonical, canonicalErr := validator.blockChain.GetHeaderByNumber(blockNumber)
+		if canonicalErr != nil {
+			return nil, nil, fmt.Errorf("error getting canonical header for block %d: %w", blockNumber, canonicalErr)
+		}
+		if !sameHash(canonical.Hash, parentHash.Hex()) {
+			return nil, nil, fmt.Errorf("%w: header for block %d isn't parent of block %d", ErrCanonicalChainChanged,
+				blockNumber, blockNumber+1)
+		}
+		orphaned = append([]core.Header{stored}, orphaned...)
+		replacements = append([]core.Header{canonical}, replacements...)
+		parentHash, ok = canonical.ParentHash()
+	}
+	return orphaned, replacements, nil
+}
+
+// checkParentHashes checks that consecutive headers are linked by their parent hashes, which they may not be if the
+// node reorged while they were fetched
+func checkParentHashes(headers []core.Header) error {
+	for i := 1; i < len(headers); i++ {
+		if headers[i].BlockNumber != headers[i-1].BlockNumber+1 {
+			continue
+		}
+		parentHash, ok := headers[i].ParentHash()
+		if ok && !sameHash(headers[i-1].Hash, parentHash.Hex()) {
+			return fmt.Errorf("%w: header for block %d isn't parent of block %d", ErrCanonicalChainChanged,
+				headers[i-1].BlockNumber, headers[i].BlockNumber)
+		}
+	}
+	return nil
+}
+
+func sameHash(a, b string) bool {
+	return common.HexToHash(a) == common.HexToHash(b)
+}
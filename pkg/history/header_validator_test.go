@@ -18,11 +18,14 @@ package history_test
 
 import (
 	"errors"
+	"fmt"
 	"math/big"
 
+	"github.com/ethereum/go-ethereum/common"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	"github.com/makerdao/vulcanizedb/pkg/core"
 	"github.com/makerdao/vulcanizedb/pkg/fakes"
 	"github.com/makerdao/vulcanizedb/pkg/history"
 )
@@ -58,4 +61,105 @@ var _ = Describe("Header validator", func() {
 		_, err := validator.ValidateHeaders()
 		Expect(err.Error()).To(ContainSubstring(headerRepositoryError.Error()))
 	})
+
+	Describe("when stored headers aren't ancestors of the canonical head", func() {
+		var validator history.HeaderValidator
+
+		BeforeEach(func() {
+			blockChain.SetChainHead(big.NewInt(10))
+			blockChain.HeadersByNumber = map[int64]core.Header{
+				6:  linkedHeader(6, "0xc6", "0xa5"),
+				7:  linkedHeader(7, "0xc7", "0xc6"),
+				8:  linkedHeader(8, "0xc8", "0xc7"),
+				9:  linkedHeader(9, "0xc9", "0xc8"),
+				10: linkedHeader(10, "0xc10", "0xc9"),
+			}
+			headerRepository.HeadersByBlockNumber = map[int64]core.Header{
+				5: linkedHeader(5, "0xa5", "0xa4"),
+				6: linkedHeader(6, "0xb6", "0xa5"),
+				7: linkedHeader(7, "0xb7", "0xb6"),
+				8: linkedHeader(8, "0xb8", "0xb7"),
+			}
+			validator = history.NewHeaderValidator(blockChain, headerRepository, 2)
+		})
+
+		It("replaces orphaned headers back to the common ancestor, below the validation window", func() {
+			_, err := validator.ValidateHeaders()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(headerRepository.ReplaceReorgedHeadersCalled).To(BeTrue())
+			Expect(headerRepository.ReplaceReorgedHeadersPassedHeaders).To(Equal([]core.Header{
+				blockChain.HeadersByNumber[6], blockChain.HeadersByNumber[7], blockChain.HeadersByNumber[8],
+			}))
+			headerRepository.AssertCreateOrUpdateHeaderCallCountAndPassedBlockNumbers(3, []int64{8, 9, 10})
+		})
+
+		It("records the reorg's depth, block range and hashes", func() {
+			_, err := validator.ValidateHeaders()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(headerRepository.ReplaceReorgedHeadersPassedReorg).To(Equal(core.Reorg{
+				Depth:     3,
+				FromBlock: 6,
+				ToBlock:   8,
+				OldHashes: []string{hash("0xb6"), hash("0xb7"), hash("0xb8")},
+				NewHashes: []string{hash("0xc6"), hash("0xc7"), hash("0xc8")},
+			}))
+		})
+
+		It("stops walking back at a height without a stored header", func() {
+			delete(headerRepository.HeadersByBlockNumber, 6)
+
+			_, err := validator.ValidateHeaders()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(headerRepository.ReplaceReorgedHeadersPassedReorg.FromBlock).To(Equal(int64(7)))
+			Expect(headerRepository.ReplaceReorgedHeadersPassedReorg.Depth).To(Equal(int64(2)))
+		})
+
+		It("does not replace headers if the stored headers are canonical", func() {
+			headerRepository.HeadersByBlockNumber = map[int64]core.Header{
+				7: blockChain.HeadersByNumber[7],
+				8: blockChain.HeadersByNumber[8],
+			}
+
+			_, err := validator.ValidateHeaders()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(headerRepository.ReplaceReorgedHeadersCalled).To(BeFalse())
+			headerRepository.AssertCreateOrUpdateHeaderCallCountAndPassedBlockNumbers(3, []int64{8, 9, 10})
+		})
+
+		It("returns error if the canonical headers aren't linked by their parent hashes", func() {
+			blockChain.HeadersByNumber[9] = linkedHeader(9, "0xc9", "0xd8")
+
+			_, err := validator.ValidateHeaders()
+
+			Expect(err).To(HaveOccurred())
+			Expect(errors.Is(err, history.ErrCanonicalChainChanged)).To(BeTrue())
+			Expect(headerRepository.ReplaceReorgedHeadersCalled).To(BeFalse())
+		})
+
+		It("returns error if replacing orphaned headers fails", func() {
+			headerRepository.ReplaceReorgedHeadersError = fakes.FakeError
+
+			_, err := validator.ValidateHeaders()
+
+			Expect(err).To(HaveOccurred())
+			Expect(errors.Is(err, fakes.FakeError)).To(BeTrue())
+			headerRepository.AssertCreateOrUpdateHeaderCallCountAndPassedBlockNumbers(0, nil)
+		})
+	})
 })
+
+func linkedHeader(blockNumber int64, blockHash, parentHash string) core.Header {
+	return core.Header{
+		BlockNumber: blockNumber,
+		Hash:        hash(blockHash),
+		Raw:         []byte(fmt.Sprintf(`{"parentHash": "%s"}`, hash(parentHash))),
+	}
+}
+
+func hash(hex string) string {
+	return common.HexToHash(hex).Hex()
+}
@@ -18,13 +18,32 @@ package history
 
 import (
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/makerdao/vulcanizedb/pkg/core"
 	"github.com/makerdao/vulcanizedb/pkg/datastore"
+	"github.com/makerdao/vulcanizedb/pkg/eth"
 	"github.com/sirupsen/logrus"
 )
 
-func PopulateMissingHeaders(blockChain core.BlockChain, headerRepository datastore.HeaderRepository, startingBlockNumber, validationWindowSize int64) (int, error) {
+// BackfillConfig sets how missing headers are fetched from the node and persisted
+type BackfillConfig struct {
+	// BatchSize is the number of headers fetched in each batch call to the node and inserted with each query
+	BatchSize int
+	// Workers is the number of batches fetched and persisted at once
+	Workers int
+	// ProgressInterval is the minimum time between progress logs
+	ProgressInterval time.Duration
+}
+
+var DefaultBackfillConfig = BackfillConfig{
+	BatchSize:        eth.MAX_BATCH_SIZE,
+	Workers:          1,
+	ProgressInterval: 30 * time.Second,
+}
+
+func PopulateMissingHeaders(blockChain core.BlockChain, headerRepository datastore.HeaderRepository, startingBlockNumber, validationWindowSize int64, config BackfillConfig) (int, error) {
 	chainHead, err := blockChain.ChainHead()
 	if err != nil {
 		return 0, fmt.Errorf("error getting last block: %w", err)
@@ -39,28 +58,125 @@ func PopulateMissingHeaders(blockChain core.BlockChain, headerRepository datasto
 	}
 
 	logrus.Debug(getBlockRangeString(blockNumbers))
-	_, err = RetrieveAndUpdateHeaders(blockChain, headerRepository, blockNumbers)
+	_, err = RetrieveAndUpdateHeadersConcurrently(blockChain, headerRepository, blockNumbers, config)
 	if err != nil {
 		return 0, fmt.Errorf("error getting/updating headers: %s", err.Error())
 	}
 	return len(blockNumbers), nil
 }
 
+// RetrieveAndUpdateHeadersConcurrently splits the block numbers into batches of config.BatchSize, and fetches and
+// persists them on a pool of config.Workers, logging progress at most every config.ProgressInterval. If a batch
+// fails, no further batches are started and its error is returned once the other workers finish.
+func RetrieveAndUpdateHeadersConcurrently(blockChain core.BlockChain, headerRepository datastore.HeaderRepository, blockNumbers []int64, config BackfillConfig) (int, error) {
+	if config.BatchSize < 1 {
+		config.BatchSize = DefaultBackfillConfig.BatchSize
+	}
+	if config.Workers < 1 {
+		config.Workers = DefaultBackfillConfig.Workers
+	}
+
+	progress := newBackfillProgress(len(blockNumbers), config.ProgressInterval)
+	pending := make(chan []int64)
+	quit := make(chan struct{})
+	var (
+		failure     error
+		failureOnce sync.Once
+		wg          sync.WaitGroup
+	)
+
+	for worker := 0; worker < config.Workers; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range pending {
+				_, err := RetrieveAndUpdateHeaders(blockChain, headerRepository, batch)
+				if err != nil {
+					failureOnce.Do(func() {
+						failure = fmt.Errorf("error backfilling blocks %d-%d: %w", batch[0], batch[len(batch)-1], err)
+						close(quit)
+					})
+					return
+				}
+				progress.add(len(batch))
+			}
+		}()
+	}
+
+SendBatches:
+	for start := 0; start < len(blockNumbers); start += config.BatchSize {
+		end := start + config.BatchSize
+		if end > len(blockNumbers) {
+			end = len(blockNumbers)
+		}
+		select {
+		case pending <- blockNumbers[start:end]:
+		case <-quit:
+			break SendBatches
+		}
+	}
+	close(pending)
+	wg.Wait()
+	if failure != nil {
+		return 0, failure
+	}
+	return len(blockNumbers), nil
+}
+
+// RetrieveAndUpdateHeaders fetches the headers in a single batch call and persists them with a multi-row insert
 func RetrieveAndUpdateHeaders(blockChain core.BlockChain, headerRepository datastore.HeaderRepository, blockNumbers []int64) (int, error) {
 	headers, getHeadersErr := blockChain.GetHeadersByNumbers(blockNumbers)
 	if getHeadersErr != nil {
 		return 0, getHeadersErr
 	}
 
-	for _, header := range headers {
-		_, createOrUpdateHeaderErr := headerRepository.CreateOrUpdateHeader(header)
-		if createOrUpdateHeaderErr != nil {
-			return 0, createOrUpdateHeaderErr
-		}
+	createErr := headerRepository.CreateOrUpdateHeaders(headers)
+	if createErr != nil {
+		return 0, createErr
 	}
 	return len(blockNumbers), nil
 }
 
+// FormatBackfillProgress describes how many of the total headers have been backfilled, the rate they've been
+// backfilled at, and the estimated time until the rest are
+func FormatBackfillProgress(done, total int, elapsed time.Duration) string {
+	if done == 0 || elapsed <= 0 {
+		return fmt.Sprintf("Backfilled 0/%d headers", total)
+	}
+	rate := float64(done) / elapsed.Seconds()
+	eta := time.Duration(float64(total-done) / rate * float64(time.Second)).Round(time.Second)
+	return fmt.Sprintf("Backfilled %d/%d headers (%.1f blocks/s, ETA %s)", done, total, rate, eta.String())
+}
+
+type backfillProgress struct {
+	sync.Mutex
+	done     int
+	total    int
+	interval time.Duration
+	started  time.Time
+	logged   time.Time
+}
+
+func newBackfillProgress(total int, interval time.Duration) *backfillProgress {
+	now := time.Now()
+	return &backfillProgress{total: total, interval: interval, started: now, logged: now}
+}
+
+func (progress *backfillProgress) add(n int) {
+	progress.Lock()
+	defer progress.Unlock()
+	progress.done += n
+	now := time.Now()
+	message := FormatBackfillProgress(progress.done, progress.total, now.Sub(progress.started))
+	if now.Sub(progress.logged) >= progress.interval {
+		progress.logged = now
+		logrus.Info(message)
+	} else if progress.done == progress.total {
+		// backfills that finish within an interval happen every few blocks once synced, so aren't worth an info log
+		logrus.Debug(message)
+	}
+}
+
 func getBlockRangeString(blockRange []int64) string {
 	return fmt.Sprintf("Backfilling |%v| blocks", len(blockRange))
 }
@@ -18,6 +18,7 @@ package history_test
 
 import (
 	"math/big"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -46,7 +47,7 @@ var _ = Describe("Populating headers", func() {
 		blockChain.SetChainHead(big.NewInt(startingBlock + 1))
 		headerRepository.SetMissingBlockNumbers([]int64{startingBlock + 1})
 
-		numHeadersAdded, err := history.PopulateMissingHeaders(blockChain, headerRepository, startingBlock, validationWindowSize)
+		numHeadersAdded, err := history.PopulateMissingHeaders(blockChain, headerRepository, startingBlock, validationWindowSize, history.DefaultBackfillConfig)
 
 		Expect(err).NotTo(HaveOccurred())
 		Expect(numHeadersAdded).To(Equal(1))
@@ -57,16 +58,17 @@ var _ = Describe("Populating headers", func() {
 		blockChain.SetChainHead(big.NewInt(startingBlock + 1))
 		headerRepository.SetMissingBlockNumbers([]int64{startingBlock + 1})
 
-		_, err := history.PopulateMissingHeaders(blockChain, headerRepository, startingBlock, validationWindowSize)
+		_, err := history.PopulateMissingHeaders(blockChain, headerRepository, startingBlock, validationWindowSize, history.DefaultBackfillConfig)
 
 		Expect(err).NotTo(HaveOccurred())
-		headerRepository.AssertCreateOrUpdateHeaderCallCountAndPassedBlockNumbers(1, []int64{2})
+		Expect(len(headerRepository.CreateOrUpdateHeadersPassedHeaders)).To(Equal(1))
+		Expect(headerRepository.CreateOrUpdateHeadersPassedHeaders[0].BlockNumber).To(Equal(startingBlock + 1))
 	})
 
 	It("queries headers table for missing headers until beginning validation window (not chain head)", func() {
 		blockChain := fakes.NewMockBlockChain()
 		blockChain.SetChainHead(big.NewInt(startingBlock + validationWindowSize))
-		_, err := history.PopulateMissingHeaders(blockChain, headerRepository, startingBlock, validationWindowSize)
+		_, err := history.PopulateMissingHeaders(blockChain, headerRepository, startingBlock, validationWindowSize, history.DefaultBackfillConfig)
 
 		Expect(err).NotTo(HaveOccurred())
 		Expect(headerRepository.MissingBlockNumbersPassedStartingBlock).To(Equal(startingBlock))
@@ -76,7 +78,7 @@ var _ = Describe("Populating headers", func() {
 	It("doesn't query for numbers less than starting block", func() {
 		blockChain := fakes.NewMockBlockChain()
 		blockChain.SetChainHead(big.NewInt(startingBlock))
-		_, err := history.PopulateMissingHeaders(blockChain, headerRepository, startingBlock, validationWindowSize)
+		_, err := history.PopulateMissingHeaders(blockChain, headerRepository, startingBlock, validationWindowSize, history.DefaultBackfillConfig)
 
 		Expect(err).NotTo(HaveOccurred())
 		Expect(headerRepository.MissingBlockNumbersPassedStartingBlock).To(Equal(startingBlock))
@@ -86,7 +88,7 @@ var _ = Describe("Populating headers", func() {
 	It("returns early if the db is already synced up to the beginning of the validation window", func() {
 		blockChain := fakes.NewMockBlockChain()
 		blockChain.SetChainHead(big.NewInt(startingBlock))
-		headersAdded, err := history.PopulateMissingHeaders(blockChain, headerRepository, startingBlock, validationWindowSize)
+		headersAdded, err := history.PopulateMissingHeaders(blockChain, headerRepository, startingBlock, validationWindowSize, history.DefaultBackfillConfig)
 
 		Expect(err).NotTo(HaveOccurred())
 		Expect(headersAdded).To(Equal(0))
@@ -96,13 +98,79 @@ var _ = Describe("Populating headers", func() {
 		blockChain := fakes.NewMockBlockChain()
 		blockChain.SetChainHeadError(fakes.FakeError)
 
-		_, err := history.PopulateMissingHeaders(blockChain, headerRepository, startingBlock, validationWindowSize)
+		_, err := history.PopulateMissingHeaders(blockChain, headerRepository, startingBlock, validationWindowSize, history.DefaultBackfillConfig)
 
 		Expect(err).To(HaveOccurred())
 		Expect(err).To(MatchError(fakes.FakeError))
 		Expect(statusWriter.WriteCalled).To(BeFalse())
 	})
 
+	Describe("RetrieveAndUpdateHeadersConcurrently", func() {
+		var (
+			blockChain   *fakes.MockBlockChain
+			blockNumbers []int64
+			config       history.BackfillConfig
+		)
+
+		BeforeEach(func() {
+			blockChain = fakes.NewMockBlockChain()
+			blockNumbers = history.MakeRange(1, 10)
+			config = history.BackfillConfig{BatchSize: 3, Workers: 2, ProgressInterval: time.Minute}
+		})
+
+		It("fetches headers in batches of the configured size", func() {
+			_, err := history.RetrieveAndUpdateHeadersConcurrently(blockChain, headerRepository, blockNumbers, config)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(blockChain.GetHeadersByNumbersPassedNumbers).To(ConsistOf(
+				[]int64{1, 2, 3}, []int64{4, 5, 6}, []int64{7, 8, 9}, []int64{10}))
+		})
+
+		It("persists every fetched header", func() {
+			numHeadersAdded, err := history.RetrieveAndUpdateHeadersConcurrently(blockChain, headerRepository, blockNumbers, config)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(numHeadersAdded).To(Equal(len(blockNumbers)))
+			var persistedBlockNumbers []int64
+			for _, header := range headerRepository.CreateOrUpdateHeadersPassedHeaders {
+				persistedBlockNumbers = append(persistedBlockNumbers, header.BlockNumber)
+			}
+			Expect(persistedBlockNumbers).To(ConsistOf(blockNumbers))
+		})
+
+		It("defaults a non-positive batch size and worker count", func() {
+			config = history.BackfillConfig{}
+
+			_, err := history.RetrieveAndUpdateHeadersConcurrently(blockChain, headerRepository, blockNumbers, config)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(blockChain.GetHeadersByNumbersPassedNumbers).To(Equal([][]int64{blockNumbers}))
+		})
+
+		It("returns an error if a batch fails", func() {
+			blockChain.GetHeadersByNumbersErr = fakes.FakeError
+
+			_, err := history.RetrieveAndUpdateHeadersConcurrently(blockChain, headerRepository, blockNumbers, config)
+
+			Expect(err).To(MatchError(fakes.FakeError))
+			Expect(headerRepository.CreateOrUpdateHeadersPassedHeaders).To(BeEmpty())
+		})
+	})
+
+	Describe("FormatBackfillProgress", func() {
+		It("includes the rate and estimated time remaining", func() {
+			progress := history.FormatBackfillProgress(100, 300, 10*time.Second)
+
+			Expect(progress).To(Equal("Backfilled 100/300 headers (10.0 blocks/s, ETA 20s)"))
+		})
+
+		It("omits the rate before any headers are backfilled", func() {
+			progress := history.FormatBackfillProgress(0, 300, 0)
+
+			Expect(progress).To(Equal("Backfilled 0/300 headers"))
+		})
+	})
+
 	Describe("RetrieveAndUpdateHeaders", func() {
 		It("returns an error if getting headers from the blockchain fails", func() {
 			blockChain := fakes.NewMockBlockChain()
@@ -116,7 +184,7 @@ var _ = Describe("Populating headers", func() {
 
 		It("returns an error when Creating or updating the header fails", func() {
 			blockChain := fakes.NewMockBlockChain()
-			headerRepository.SetCreateOrUpdateHeaderReturnErr(fakes.FakeError)
+			headerRepository.CreateOrUpdateHeadersError = fakes.FakeError
 			_, err := history.RetrieveAndUpdateHeaders(blockChain, headerRepository, []int64{startingBlock})
 
 			Expect(err).To(HaveOccurred())
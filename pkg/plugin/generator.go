@@ -33,6 +33,8 @@ type Generator interface {
 
 type generator struct {
 	writer.PluginWriter
+	writer.StorageKeysWriter
+	writer.EventMigrationWriter
 	builder.PluginBuilder
 	manager.MigrationManager
 }
@@ -43,17 +45,29 @@ func NewGenerator(gc config.Plugin, dbc config.Database) (*generator, error) {
 		return nil, errors.New("plugin generator is not configured with any transformers")
 	}
 	return &generator{
-		PluginWriter:     writer.NewPluginWriter(gc),
-		PluginBuilder:    builder.NewPluginBuilder(gc),
-		MigrationManager: manager.NewMigrationManager(gc, dbc),
+		PluginWriter:         writer.NewPluginWriter(gc),
+		StorageKeysWriter:    writer.NewStorageKeysWriter(gc),
+		EventMigrationWriter: writer.NewEventMigrationWriter(gc),
+		PluginBuilder:        builder.NewPluginBuilder(gc),
+		MigrationManager:     manager.NewMigrationManager(gc, dbc),
 	}, nil
 }
 
 // Generates plugin for the transformer initializers specified in the generator config
-// Writes plugin code  => Sets up build environment => Builds .so file => Performs db migrations for the plugin transformers => Clean up
+// Generates storage keys => Generates ABI event migrations => Writes plugin code  => Sets up build environment => Builds .so file => Performs db migrations for the plugin transformers => Clean up
 func (g *generator) GenerateExporterPlugin() error {
+	// Regenerate storage keys from configured storage layouts, so they're included when transformers are copied over
+	err := g.StorageKeysWriter.WriteStorageKeys()
+	if err != nil {
+		return err
+	}
+	// Generate table migrations for event transformers configured with an ABI, so the migration manager runs them
+	err = g.EventMigrationWriter.WriteEventMigrations()
+	if err != nil {
+		return err
+	}
 	// Use plugin writer interface to write the plugin code
-	err := g.PluginWriter.WritePlugin()
+	err = g.PluginWriter.WritePlugin()
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,134 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package writer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/makerdao/vulcanizedb/libraries/shared/factories/event"
+	"github.com/makerdao/vulcanizedb/pkg/config"
+	"github.com/makerdao/vulcanizedb/pkg/eth"
+	"github.com/makerdao/vulcanizedb/pkg/plugin/helpers"
+	"github.com/sirupsen/logrus"
+)
+
+// Interface for generating the table migrations for the
+// event transformers configured with a contract ABI
+type EventMigrationWriter interface {
+	WriteEventMigrations() error
+}
+
+type eventMigrationWriter struct {
+	GenConfig config.Plugin
+}
+
+// Requires populated plugin config
+func NewEventMigrationWriter(gc config.Plugin) *eventMigrationWriter {
+	return &eventMigrationWriter{
+		GenConfig: gc,
+	}
+}
+
+// Writes a migration creating the event's table in the plugin schema for each transformer with an ABI,
+// to the generated migrations directory the migration manager picks it up from
+func (w *eventMigrationWriter) WriteEventMigrations() error {
+	for name, transformer := range w.GenConfig.Transformers {
+		if !transformer.IsABIEvent() {
+			continue
+		}
+		writeErr := w.writeEventMigration(name, transformer)
+		if writeErr != nil {
+			return fmt.Errorf("failed to generate migration for transformer %s: %w", name, writeErr)
+		}
+	}
+	return nil
+}
+
+func (w *eventMigrationWriter) writeEventMigration(name string, transformer config.Transformer) error {
+	abiEvent, _, eventErr := loadABIEvent(transformer)
+	if eventErr != nil {
+		return eventErr
+	}
+	migration, migrationErr := event.GenerateEventMigration(event.SchemaName(w.GenConfig.Schema), getTableName(transformer), abiEvent)
+	if migrationErr != nil {
+		return migrationErr
+	}
+
+	migrationsDir, cleanErr := helpers.CleanPath(w.GenConfig.GetGeneratedMigrationsPath(name))
+	if cleanErr != nil {
+		return cleanErr
+	}
+	mkdirErr := os.MkdirAll(migrationsDir, os.ModePerm)
+	if mkdirErr != nil {
+		return mkdirErr
+	}
+	migrationFile, fileErr := getMigrationFile(migrationsDir, getTableName(transformer))
+	if fileErr != nil {
+		return fileErr
+	}
+	logrus.Infof("generating migration for %s event in %s", transformer.EventName, migrationFile)
+	return ioutil.WriteFile(migrationFile, []byte(migration), 0644)
+}
+
+// Reuses the file from an earlier compose so the migration keeps its version, otherwise creates a timestamped one
+func getMigrationFile(dir string, table event.TableName) (string, error) {
+	suffix := fmt.Sprintf("_create_%s.sql", table)
+	files, readErr := ioutil.ReadDir(dir)
+	if readErr != nil {
+		return "", readErr
+	}
+	for _, file := range files {
+		if strings.HasSuffix(file.Name(), suffix) {
+			return filepath.Join(dir, file.Name()), nil
+		}
+	}
+	return filepath.Join(dir, time.Now().UTC().Format("20060102150405")+suffix), nil
+}
+
+// Returns the configured event from the transformer's ABI file, along with the file's contents
+func loadABIEvent(transformer config.Transformer) (abi.Event, string, error) {
+	abiPath, cleanErr := helpers.CleanPath(transformer.AbiPath)
+	if cleanErr != nil {
+		return abi.Event{}, "", cleanErr
+	}
+	contractAbi, readErr := eth.ReadAbiFile(abiPath)
+	if readErr != nil {
+		return abi.Event{}, "", fmt.Errorf("%w: %s", readErr, abiPath)
+	}
+	parsedAbi, parseErr := eth.ParseAbi(contractAbi)
+	if parseErr != nil {
+		return abi.Event{}, "", fmt.Errorf("%w: %s", parseErr, abiPath)
+	}
+	abiEvent, ok := parsedAbi.Events[transformer.EventName]
+	if !ok {
+		return abi.Event{}, "", fmt.Errorf("%w: %s", event.ErrEventNotInAbi, transformer.EventName)
+	}
+	return abiEvent, contractAbi, nil
+}
+
+func getTableName(transformer config.Transformer) event.TableName {
+	if transformer.TableName != "" {
+		return event.TableName(transformer.TableName)
+	}
+	return event.TableName(event.ToColumnName(transformer.EventName))
+}
@@ -0,0 +1,102 @@
+// VulcanizeDB
+// Copyright © 2019 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package writer
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/makerdao/vulcanizedb/libraries/shared/storage/layout"
+	"github.com/makerdao/vulcanizedb/pkg/config"
+	"github.com/makerdao/vulcanizedb/pkg/plugin/helpers"
+	"github.com/sirupsen/logrus"
+)
+
+// Interface for generating storage keys loaders for the
+// storage transformers configured with a solc storage layout
+type StorageKeysWriter interface {
+	WriteStorageKeys() error
+}
+
+type storageKeysWriter struct {
+	GenConfig config.Plugin
+}
+
+// Requires populated plugin config
+func NewStorageKeysWriter(gc config.Plugin) *storageKeysWriter {
+	return &storageKeysWriter{
+		GenConfig: gc,
+	}
+}
+
+// Regenerates the storage keys file in the transformer repository of each transformer with a storage layout,
+// before the repositories are copied over for the plugin build
+func (w *storageKeysWriter) WriteStorageKeys() error {
+	for name, transformer := range w.GenConfig.Transformers {
+		if transformer.StorageLayoutPath == "" {
+			continue
+		}
+		writeErr := writeStorageKeys(transformer)
+		if writeErr != nil {
+			return fmt.Errorf("failed to generate storage keys for transformer %s: %w", name, writeErr)
+		}
+	}
+	return nil
+}
+
+func writeStorageKeys(transformer config.Transformer) error {
+	repoPath, cleanErr := helpers.CleanPath(filepath.Join("$GOPATH/src", transformer.RepositoryPath))
+	if cleanErr != nil {
+		return cleanErr
+	}
+	artifactPath := filepath.Join(repoPath, transformer.StorageLayoutPath)
+	storageLayout, loadErr := layout.LoadStorageLayout(artifactPath, transformer.StorageLayoutContract)
+	if loadErr != nil {
+		return loadErr
+	}
+
+	keysPath := transformer.StorageKeysPath
+	if keysPath == "" {
+		keysPath = transformer.Path
+	}
+	keysDir := filepath.Join(repoPath, keysPath)
+	packageName, packageErr := getPackageName(keysDir)
+	if packageErr != nil {
+		return packageErr
+	}
+	outputPath := filepath.Join(keysDir, layout.KeysFileName)
+	logrus.Infof("generating storage keys from %s in %s", artifactPath, outputPath)
+	return layout.WriteKeysFile(storageLayout, packageName, transformer.StorageLayoutPath, outputPath)
+}
+
+// Uses the package clause of the existing files in the directory, falling back on the directory name
+func getPackageName(dir string) (string, error) {
+	packages, parseErr := parser.ParseDir(token.NewFileSet(), dir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go") && info.Name() != layout.KeysFileName
+	}, parser.PackageClauseOnly)
+	if parseErr != nil {
+		return "", fmt.Errorf("failed to read package in %s: %w", dir, parseErr)
+	}
+	for packageName := range packages {
+		return packageName, nil
+	}
+	return filepath.Base(dir), nil
+}
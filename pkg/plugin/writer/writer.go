@@ -61,6 +61,9 @@ func (w *writer) WritePlugin() error {
 	f.ImportAlias("github.com/makerdao/vulcanizedb/libraries/shared/factories/event", "event")
 	f.ImportAlias("github.com/makerdao/vulcanizedb/libraries/shared/factories/storage", "storage")
 	for name, transformer := range w.GenConfig.Transformers {
+		if transformer.IsABIEvent() {
+			continue
+		}
 		f.ImportAlias(transformer.RepositoryPath+"/"+transformer.Path, name)
 	}
 
@@ -99,10 +102,18 @@ func (w *writer) WritePlugin() error {
 // Collect code for various types of initializers
 func (w *writer) collectTransformers() (map[config.TransformerType][]Code, error) {
 	code := make(map[config.TransformerType][]Code)
-	for _, transformer := range w.GenConfig.Transformers {
+	for name, transformer := range w.GenConfig.Transformers {
 		path := transformer.RepositoryPath + "/" + transformer.Path
 		switch transformer.Type {
 		case config.EthEvent:
+			if transformer.IsABIEvent() {
+				initializer, err := w.abiTransformerInitializer(name, transformer)
+				if err != nil {
+					return nil, err
+				}
+				code[config.EthEvent] = append(code[config.EthEvent], initializer)
+				continue
+			}
 			code[config.EthEvent] = append(code[config.EthEvent], Qual(path, "EventTransformerInitializer"))
 		case config.EthStorage:
 			code[config.EthStorage] = append(code[config.EthStorage], Qual(path, "StorageTransformerInitializer"))
@@ -116,6 +127,47 @@ func (w *writer) collectTransformers() (map[config.TransformerType][]Code, error
 	return code, nil
 }
 
+// Configures the event package's ABI converter for a transformer generated from an ABI, embedding the ABI in the plugin
+func (w *writer) abiTransformerInitializer(name string, transformer config.Transformer) (Code, error) {
+	abiEvent, contractAbi, err := loadABIEvent(transformer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load event for transformer %s: %w", name, err)
+	}
+	eventPath := "github.com/makerdao/vulcanizedb/libraries/shared/factories/event"
+	configValues := Dict{
+		Id("TransformerName"): Lit(name),
+		Id("ContractAddresses"): Index().String().ValuesFunc(func(g *Group) {
+			for _, address := range transformer.ContractAddresses {
+				g.Lit(address)
+			}
+		}),
+		Id("ContractAbi"):         Lit(contractAbi),
+		Id("StartingBlockNumber"): Lit(transformer.StartingBlock),
+		Id("EndingBlockNumber"):   Lit(transformer.EndingBlock),
+	}
+	// Anonymous events don't log their signature as topic0, so they're matched on contract address and a data
+	// selector, without which every log from the contracts would be converted as the event
+	if abiEvent.Anonymous {
+		if transformer.DataSelector == "" {
+			return nil, fmt.Errorf("%w: %s", config.MissingDataSelectorErr, name)
+		}
+		configValues[Id("Anonymous")] = True()
+		configValues[Id("DataSelector")] = Lit(transformer.DataSelector)
+	} else {
+		configValues[Id("Topic")] = Lit(abiEvent.ID.Hex())
+	}
+	transformerConfig := Qual(eventPath, "TransformerConfig").Values(configValues)
+	converter := Qual(eventPath, "NewABIConverter").Call(
+		Lit(transformer.EventName),
+		Qual(eventPath, "SchemaName").Call(Lit(w.GenConfig.Schema)),
+		Qual(eventPath, "TableName").Call(Lit(string(getTableName(transformer)))),
+	)
+	return Qual(eventPath, "ConfiguredTransformer").Values(Dict{
+		Id("Config"):      transformerConfig,
+		Id("Transformer"): converter,
+	}).Dot("NewTransformer"), nil
+}
+
 // Setup the .go, clear old ones if present
 func (w *writer) setupFilePath() (string, error) {
 	goFile, soFile, err := w.GenConfig.GetPluginPaths()
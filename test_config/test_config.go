@@ -75,11 +75,17 @@ func CleanTestDB(db *postgres.DB) {
 	db.MustExec("DELETE FROM public.checked_headers")
 	// can't delete from eth_nodes since this function is called after the required eth_node is persisted
 	db.MustExec("DELETE FROM public.goose_db_version")
+	db.MustExec("DELETE FROM public.quarantined_logs")
 	db.MustExec("DELETE FROM public.event_logs")
 	db.MustExec("DELETE FROM public.receipts")
 	db.MustExec("DELETE FROM public.transactions")
 	db.MustExec("DELETE FROM public.headers")
+	db.MustExec("DELETE FROM public.reorgs")
 	db.MustExec("DELETE FROM public.storage_diff")
+	db.MustExec("DELETE FROM public.storage_diff_archive")
+	db.MustExec("DELETE FROM public.storage_diff_checkpoints")
+	db.MustExec("DELETE FROM public.storage_diff_reconciliations")
+	db.MustExec("DELETE FROM public.watched_log_backfills")
 	db.MustExec("DELETE FROM public.watched_logs")
 }
 
@@ -68,3 +68,30 @@ func (throttler ThrottlerWithArgs) Throttle(minTime time.Duration, f CallbackWit
 	throttler.timer.WaitFor(minTime - throttler.timer.ElapsedTime())
 	return err
 }
+
+// WakeableThrottler waits out the remainder of minTime like Throttler, but returns early when a wakeup is received
+type WakeableThrottler struct {
+	timer   Timer
+	wakeups <-chan struct{}
+}
+
+func NewWakeableThrottler(timer Timer, wakeups <-chan struct{}) WakeableThrottler {
+	return WakeableThrottler{
+		timer:   timer,
+		wakeups: wakeups,
+	}
+}
+
+func (throttler WakeableThrottler) Throttle(minTime time.Duration, f Callback) error {
+	throttler.timer.Start()
+	err := f()
+	remaining := minTime - throttler.timer.ElapsedTime()
+	if remaining <= 0 {
+		return err
+	}
+	select {
+	case <-throttler.wakeups:
+	case <-time.After(remaining):
+	}
+	return err
+}
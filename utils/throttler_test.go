@@ -75,3 +75,40 @@ var _ = Describe("Throttler", func() {
 		Expect(mockTimer.SleepTime()).To(Equal(time.Duration(20)))
 	})
 })
+
+var _ = Describe("WakeableThrottler", func() {
+	It("passes through to the function passed in - and returns its error", func() {
+		expectedError := errors.New("test error")
+		throttler := utils.NewWakeableThrottler(&MockTimer{}, make(chan struct{}))
+		called := false
+
+		actualError := throttler.Throttle(0, func() error {
+			called = true
+			return expectedError
+		})
+
+		Expect(called).To(BeTrue())
+		Expect(actualError).To(Equal(expectedError))
+	})
+
+	It("waits for the minimumTime - elapsedTime when no wakeup is received", func() {
+		mockTimer := MockTimer{elapsedTime: 10 * time.Millisecond}
+		throttler := utils.NewWakeableThrottler(&mockTimer, make(chan struct{}))
+
+		start := time.Now()
+		throttler.Throttle(60*time.Millisecond, func() error { return nil })
+
+		Expect(time.Since(start)).To(BeNumerically(">=", 50*time.Millisecond))
+	})
+
+	It("returns as soon as a wakeup is received", func() {
+		wakeups := make(chan struct{}, 1)
+		wakeups <- struct{}{}
+		throttler := utils.NewWakeableThrottler(&MockTimer{}, wakeups)
+
+		start := time.Now()
+		throttler.Throttle(time.Hour, func() error { return nil })
+
+		Expect(time.Since(start)).To(BeNumerically("<", time.Minute))
+	})
+})